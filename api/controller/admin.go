@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// PendingDelayJobs 查看延时队列中等待执行的任务
+func PendingDelayJobs(c *gin.Context) {
+	pageInfo := resp.GetPageInfo(c)
+	jobs, err := service.NewTaskSvc(c).PendingDelayJobs(pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(jobs)
+}
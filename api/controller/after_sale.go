@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// ApplyAfterSale 申请售后
+func ApplyAfterSale(c *gin.Context) {
+	applyRequest := new(request.AfterSaleApply)
+	if err := c.ShouldBindJSON(applyRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	afterSaleReply, err := service.NewAfterSaleSvc(c).ApplyAfterSale(c.GetInt64("userId"), applyRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(afterSaleReply)
+}
+
+// AfterSaleInfo 售后单详情
+func AfterSaleInfo(c *gin.Context) {
+	afterSaleReply, err := service.NewAfterSaleSvc(c).AfterSaleInfo(c.GetInt64("userId"), c.Param("after_sale_no"))
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(afterSaleReply)
+}
+
+// AfterSaleList 用户售后单列表
+func AfterSaleList(c *gin.Context) {
+	listRequest := new(request.AfterSaleList)
+	if err := c.ShouldBindQuery(listRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	pageInfo := resp.GetPageInfo(c)
+	afterSales, err := service.NewAfterSaleSvc(c).AfterSaleList(c.GetInt64("userId"), listRequest, pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(afterSales)
+}
+
+// CancelAfterSale 撤销售后申请
+func CancelAfterSale(c *gin.Context) {
+	cancelRequest := new(request.AfterSaleCancel)
+	if err := c.ShouldBindJSON(cancelRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	err := service.NewAfterSaleSvc(c).CancelAfterSale(c.GetInt64("userId"), cancelRequest.AfterSaleNo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
+
+// SubmitAfterSaleReturn 填写退货物流
+func SubmitAfterSaleReturn(c *gin.Context) {
+	returnRequest := new(request.AfterSaleReturn)
+	if err := c.ShouldBindJSON(returnRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	err := service.NewAfterSaleSvc(c).SubmitReturn(c.GetInt64("userId"), returnRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
+
+// AdminAfterSaleList 管理后台售后单列表
+func AdminAfterSaleList(c *gin.Context) {
+	listRequest := new(request.AfterSaleList)
+	if err := c.ShouldBindQuery(listRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	pageInfo := resp.GetPageInfo(c)
+	afterSales, err := service.NewAfterSaleSvc(c).AdminAfterSaleList(c.GetInt64("merchantId"), listRequest, pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(afterSales)
+}
+
+// AdminAfterSaleInfo 管理后台售后单详情
+func AdminAfterSaleInfo(c *gin.Context) {
+	afterSaleReply, err := service.NewAfterSaleSvc(c).AdminAfterSaleInfo(c.GetInt64("merchantId"), c.Param("after_sale_no"))
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(afterSaleReply)
+}
+
+// ApproveAfterSale 同意售后申请
+func ApproveAfterSale(c *gin.Context) {
+	reviewAfterSale(c, (*service.AfterSaleSvc).ApproveAfterSale)
+}
+
+// RejectAfterSale 驳回售后申请或拒绝收货
+func RejectAfterSale(c *gin.Context) {
+	reviewAfterSale(c, (*service.AfterSaleSvc).RejectAfterSale)
+}
+
+// ConfirmAfterSaleReturn 确认收到退货
+func ConfirmAfterSaleReturn(c *gin.Context) {
+	reviewAfterSale(c, (*service.AfterSaleSvc).ConfirmReturnReceived)
+}
+
+// reviewAfterSale 商家处理售后单的几个接口参数相同, 统一绑定参数和返回结果
+func reviewAfterSale(c *gin.Context, handle func(svc *service.AfterSaleSvc, merchantId, operatorId int64, reviewRequest *request.AfterSaleReview) error) {
+	reviewRequest := new(request.AfterSaleReview)
+	if err := c.ShouldBindJSON(reviewRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	if err := handle(service.NewAfterSaleSvc(c), c.GetInt64("merchantId"), c.GetInt64("userId"), reviewRequest); err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// ClaimableCoupons 可领取的优惠券
+func ClaimableCoupons(c *gin.Context) {
+	templates, err := service.NewCouponSvc(c).ClaimableList()
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(templates)
+}
+
+// ClaimCoupon 领取优惠券
+func ClaimCoupon(c *gin.Context) {
+	claimRequest := new(request.CouponClaim)
+	if err := c.ShouldBindJSON(claimRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	coupon, err := service.NewCouponSvc(c).ClaimCoupon(c.GetInt64("userId"), claimRequest.TemplateId)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(coupon)
+}
+
+// UserCoupons 我的优惠券
+func UserCoupons(c *gin.Context) {
+	listRequest := new(request.UserCouponList)
+	if err := c.ShouldBindQuery(listRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	pageInfo := resp.GetPageInfo(c)
+	coupons, err := service.NewCouponSvc(c).UserCouponList(c.GetInt64("userId"), listRequest, pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(coupons)
+}
+
+// CouponCheckout 结算页可用优惠券和最优组合
+func CouponCheckout(c *gin.Context) {
+	checkoutRequest := new(request.CouponCheckout)
+	if err := c.ShouldBindJSON(checkoutRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	checkoutReply, err := service.NewCouponSvc(c).Checkout(c.GetInt64("userId"), checkoutRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(checkoutReply)
+}
+
+// CreateCouponTemplate 创建优惠券模板
+func CreateCouponTemplate(c *gin.Context) {
+	templateRequest := new(request.CouponTemplateCreate)
+	if err := c.ShouldBindJSON(templateRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	template, err := service.NewCouponSvc(c).CreateTemplate(templateRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(template)
+}
+
+// CouponTemplateList 优惠券模板列表
+func CouponTemplateList(c *gin.Context) {
+	pageInfo := resp.GetPageInfo(c)
+	templates, err := service.NewCouponSvc(c).TemplateList(pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(templates)
+}
+
+// IssueCoupons 给用户发放优惠券
+func IssueCoupons(c *gin.Context) {
+	issueRequest := new(request.CouponIssue)
+	if err := c.ShouldBindJSON(issueRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	resp.NewResponse(c).Success(service.NewCouponSvc(c).IssueCoupons(issueRequest))
+}
@@ -27,7 +27,7 @@ func TestPing(c *gin.Context) {
 
 // TestConfigRead 测试读取配置文件
 func TestConfigRead(c *gin.Context) {
-	database := config.Database
+	database := config.Database()
 	c.JSON(200, gin.H{
 		"type":          database.Master.Type,
 		"dsn":           database.Master.DSN,
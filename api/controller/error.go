@@ -0,0 +1,19 @@
+package controller
+
+import (
+	"errors"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// replyError 下层返回的项目预定义错误(订单不存在、库存不足等)直接响应给客户端,
+// 其他错误统一按服务内部错误响应, 原始错误作为cause记录到日志中
+func replyError(c *gin.Context, err error) {
+	var appErr *errcode.AppError
+	if errors.As(err, &appErr) && appErr.Code() > 0 {
+		resp.NewResponse(c).Error(appErr)
+		return
+	}
+	resp.NewResponse(c).Error(errcode.ErrServer.WithCause(err))
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"fmt"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/eventbus"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"time"
+)
+
+// EventStream 通过SSE实时推送订单状态变化和新消息
+// 断线重连时浏览器会自动带上Last-Event-ID请求头, 服务端先补发这个ID之后的事件再推送实时事件
+func EventStream(c *gin.Context) {
+	lastEventId := c.GetHeader("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = c.Query("last_event_id")
+	}
+	sub, missed, err := service.NewEventSvc(c).Subscribe(c.GetInt64("userId"), lastEventId)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	defer sub.Close()
+
+	// 推送连接会一直保持, 取消服务端的写超时
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭nginx的响应缓冲
+	c.Status(http.StatusOK)
+
+	realtimeConfig := config.AppConfig().Realtime
+	retryInterval, heartbeatInterval := realtimeConfig.RetryInterval, realtimeConfig.HeartbeatInterval
+	if retryInterval <= 0 {
+		retryInterval = 3 * time.Second
+	}
+	if heartbeatInterval <= 0 {
+		heartbeatInterval = 25 * time.Second
+	}
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", retryInterval.Milliseconds())
+	for _, event := range missed {
+		writeEvent(c.Writer, event)
+		lastEventId = event.Id
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-sub.Done():
+			return
+		case <-heartbeat.C:
+			// 注释行, 客户端会忽略, 只用来保持连接
+			if _, err := io.WriteString(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event := <-sub.Events():
+			// 补发期间到达的实时事件可能已经补发过
+			if !eventbus.After(event.Id, lastEventId) {
+				continue
+			}
+			if err := writeEvent(c.Writer, event); err != nil {
+				return
+			}
+			if event.Id != "" {
+				lastEventId = event.Id
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeEvent 按SSE格式写入一个事件, 事件数据是单行JSON
+func writeEvent(w io.Writer, event *eventbus.Event) error {
+	var err error
+	if event.Id != "" {
+		_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.Id, event.Type, event.Data)
+	} else {
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+	}
+	return err
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// AddFavorite 收藏商品
+func AddFavorite(c *gin.Context) {
+	favoriteRequest := new(request.FavoriteGoods)
+	if err := c.ShouldBindJSON(favoriteRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	if err := service.NewFavoriteSvc(c).AddFavorite(c.GetInt64("userId"), favoriteRequest); err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
+
+// RemoveFavorite 取消收藏
+func RemoveFavorite(c *gin.Context) {
+	favoriteRequest := new(request.FavoriteGoods)
+	if err := c.ShouldBindJSON(favoriteRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	if err := service.NewFavoriteSvc(c).RemoveFavorite(c.GetInt64("userId"), favoriteRequest); err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
+
+// FavoriteList 我的收藏夹
+func FavoriteList(c *gin.Context) {
+	pageInfo := resp.GetPageInfo(c)
+	favorites, err := service.NewFavoriteSvc(c).FavoriteList(c.GetInt64("userId"), pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(favorites)
+}
+
+// Subscribe 订阅到货或降价提醒
+func Subscribe(c *gin.Context) {
+	subscribeRequest := new(request.SubscriptionCreate)
+	if err := c.ShouldBindJSON(subscribeRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	subscription, err := service.NewFavoriteSvc(c).Subscribe(c.GetInt64("userId"), subscribeRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(subscription)
+}
+
+// CancelSubscription 退订提醒
+func CancelSubscription(c *gin.Context) {
+	cancelRequest := new(request.SubscriptionCancel)
+	if err := c.ShouldBindJSON(cancelRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	if err := service.NewFavoriteSvc(c).CancelSubscription(c.GetInt64("userId"), cancelRequest); err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
+
+// Unsubscribe 通知里的退订链接, 不需要登录
+func Unsubscribe(c *gin.Context) {
+	unsubscribeRequest := new(request.Unsubscribe)
+	if err := c.ShouldBindQuery(unsubscribeRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	if err := service.NewFavoriteSvc(c).Unsubscribe(unsubscribeRequest); err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
+
+// SubscriptionList 我订阅中的提醒
+func SubscriptionList(c *gin.Context) {
+	pageInfo := resp.GetPageInfo(c)
+	subscriptions, err := service.NewFavoriteSvc(c).SubscriptionList(c.GetInt64("userId"), pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(subscriptions)
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateFreightTemplate 创建运费模板
+func CreateFreightTemplate(c *gin.Context) {
+	templateRequest := new(request.FreightTemplateCreate)
+	if err := c.ShouldBindJSON(templateRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	template, err := service.NewFreightSvc(c).CreateTemplate(templateRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(template)
+}
+
+// FreightTemplateList 运费模板列表
+func FreightTemplateList(c *gin.Context) {
+	pageInfo := resp.GetPageInfo(c)
+	templates, err := service.NewFreightSvc(c).TemplateList(pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(templates)
+}
+
+// BindGoodsFreightTemplate 设置商品的运费模板
+func BindGoodsFreightTemplate(c *gin.Context) {
+	bindRequest := new(request.FreightTemplateBindGoods)
+	if err := c.ShouldBindJSON(bindRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	if err := service.NewFreightSvc(c).BindGoods(bindRequest); err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
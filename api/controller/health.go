@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/health"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+// Healthz 存活检查, 只检查进程内的后台任务, 失败时应该重启进程
+func Healthz(c *gin.Context) {
+	replyHealth(c, health.Live(c, healthCheckTimeout()))
+}
+
+// Readyz 就绪检查, 检查主从库、Redis和后台任务, 失败时负载均衡应该停止转发请求
+func Readyz(c *gin.Context) {
+	replyHealth(c, health.Ready(c, healthCheckTimeout()))
+}
+
+// replyHealth 探针只看状态码, 不使用统一的响应格式, 响应体给人排查问题用
+func replyHealth(c *gin.Context, report *health.Report) {
+	status := http.StatusOK
+	if !report.Up() {
+		status = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, report)
+}
+
+func healthCheckTimeout() time.Duration {
+	if timeout := config.AppConfig().Health.CheckTimeout; timeout > 0 {
+		return timeout
+	}
+	return time.Second
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// MemberInfo 我的会员信息和积分余额
+func MemberInfo(c *gin.Context) {
+	member, err := service.NewMemberSvc(c).MemberInfo(c.GetInt64("userId"))
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(member)
+}
+
+// MemberTiers 会员等级及权益
+func MemberTiers(c *gin.Context) {
+	tiers, err := service.NewMemberSvc(c).MemberTiers()
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(tiers)
+}
+
+// PointsLedgers 我的积分流水
+func PointsLedgers(c *gin.Context) {
+	pageInfo := resp.GetPageInfo(c)
+	ledgers, err := service.NewMemberSvc(c).PointsLedgers(c.GetInt64("userId"), pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(ledgers)
+}
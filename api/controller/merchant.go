@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateMerchant 管理员为用户开通店铺
+func CreateMerchant(c *gin.Context) {
+	createRequest := new(request.MerchantCreate)
+	if err := c.ShouldBindJSON(createRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	merchant, err := service.NewMerchantSvc(c).CreateMerchant(c.GetInt64("userId"), createRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(merchant)
+}
+
+// MerchantList 后台店铺列表
+func MerchantList(c *gin.Context) {
+	listRequest := new(request.MerchantList)
+	if err := c.ShouldBindQuery(listRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	pageInfo := resp.GetPageInfo(c)
+	merchants, err := service.NewMerchantSvc(c).MerchantList(listRequest, pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(merchants)
+}
+
+// SetMerchantState 管理员开启或关闭店铺
+func SetMerchantState(c *gin.Context) {
+	stateRequest := new(request.MerchantState)
+	if err := c.ShouldBindJSON(stateRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	if err := service.NewMerchantSvc(c).SetMerchantState(c.GetInt64("userId"), stateRequest); err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
+
+// MerchantInfo 商家后台查看本店铺信息
+func MerchantInfo(c *gin.Context) {
+	merchant, err := service.NewMerchantSvc(c).MerchantInfo(c.GetInt64("merchantId"))
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(merchant)
+}
+
+// MerchantGoodsList 商家后台商品列表
+func MerchantGoodsList(c *gin.Context) {
+	listRequest := new(request.MerchantGoodsList)
+	if err := c.ShouldBindQuery(listRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	pageInfo := resp.GetPageInfo(c)
+	goods, err := service.NewMerchantSvc(c).MerchantGoodsList(c.GetInt64("merchantId"), listRequest, pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(goods)
+}
+
+// SetMerchantGoodsState 商家批量上下架商品
+func SetMerchantGoodsState(c *gin.Context) {
+	stateRequest := new(request.MerchantGoodsState)
+	if err := c.ShouldBindJSON(stateRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	if err := service.NewMerchantSvc(c).SetGoodsState(c.GetInt64("merchantId"), c.GetInt64("userId"), stateRequest); err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
+
+// UpdateGoodsSku 修改SKU的售价或库存, 商家后台只能修改本店铺的商品
+func UpdateGoodsSku(c *gin.Context) {
+	updateRequest := new(request.GoodsSkuUpdate)
+	if err := c.ShouldBindJSON(updateRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	err := service.NewMerchantSvc(c).UpdateSku(c.GetInt64("merchantId"), c.GetInt64("userId"), updateRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
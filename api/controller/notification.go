@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationList 我的站内消息, 可按类型筛选
+func NotificationList(c *gin.Context) {
+	listRequest := new(request.NotificationList)
+	if err := c.ShouldBindQuery(listRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	pageInfo := resp.GetPageInfo(c)
+	messages, err := service.NewNotificationSvc(c).NotificationList(c.GetInt64("userId"), listRequest, pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(messages)
+}
+
+// ReadNotifications 把指定消息标记为已读
+func ReadNotifications(c *gin.Context) {
+	readRequest := new(request.NotificationRead)
+	if err := c.ShouldBindJSON(readRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	result, err := service.NewNotificationSvc(c).MarkRead(c.GetInt64("userId"), readRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(result)
+}
+
+// ReadAllNotifications 全部标记为已读
+func ReadAllNotifications(c *gin.Context) {
+	readRequest := new(request.NotificationReadAll)
+	if err := c.ShouldBindJSON(readRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	result, err := service.NewNotificationSvc(c).MarkAllRead(c.GetInt64("userId"), readRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(result)
+}
+
+// NotificationUnreadCount 未读消息数
+func NotificationUnreadCount(c *gin.Context) {
+	unread, err := service.NewNotificationSvc(c).UnreadCount(c.GetInt64("userId"))
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(unread)
+}
+
+// BroadcastNotification 后台发布全体用户的广播消息
+func BroadcastNotification(c *gin.Context) {
+	broadcastRequest := new(request.NotificationBroadcast)
+	if err := c.ShouldBindJSON(broadcastRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	broadcast, err := service.NewNotificationSvc(c).Broadcast(c.GetInt64("userId"), broadcastRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(broadcast)
+}
+
+// SendNotification 后台给指定用户发站内消息
+func SendNotification(c *gin.Context) {
+	sendRequest := new(request.NotificationSend)
+	if err := c.ShouldBindJSON(sendRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	result := service.NewNotificationSvc(c).SendToUsers(c.GetInt64("userId"), sendRequest)
+	resp.NewResponse(c).Success(result)
+}
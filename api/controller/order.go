@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateOrder 创建订单
+func CreateOrder(c *gin.Context) {
+	orderRequest := new(request.OrderCreate)
+	if err := c.ShouldBindJSON(orderRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	orderReply, err := service.NewOrderSvc(c).CreateOrder(c.GetInt64("userId"), orderRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(orderReply)
+}
+
+// OrderCheckout 结算页试算
+func OrderCheckout(c *gin.Context) {
+	checkoutRequest := new(request.OrderCheckout)
+	if err := c.ShouldBindJSON(checkoutRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	checkoutReply, err := service.NewOrderSvc(c).Checkout(c.GetInt64("userId"), checkoutRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(checkoutReply)
+}
+
+// OrderInfo 订单详情
+func OrderInfo(c *gin.Context) {
+	orderReply, err := service.NewOrderSvc(c).OrderInfo(c.GetInt64("userId"), c.Param("order_no"))
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(orderReply)
+}
+
+// OrderList 用户订单列表
+func OrderList(c *gin.Context) {
+	listRequest := new(request.OrderList)
+	if err := c.ShouldBindQuery(listRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	pageInfo := resp.GetPageInfo(c)
+	orders, err := service.NewOrderSvc(c).OrderList(c.GetInt64("userId"), listRequest, pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(orders)
+}
+
+// CancelOrder 取消订单
+func CancelOrder(c *gin.Context) {
+	cancelRequest := new(request.OrderCancel)
+	if err := c.ShouldBindJSON(cancelRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	err := service.NewOrderSvc(c).CancelOrder(c.GetInt64("userId"), cancelRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
+
+// ConfirmOrderReceipt 确认收货
+func ConfirmOrderReceipt(c *gin.Context) {
+	confirmRequest := new(request.OrderConfirmReceipt)
+	if err := c.ShouldBindJSON(confirmRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	err := service.NewOrderSvc(c).ConfirmReceipt(c.GetInt64("userId"), confirmRequest.OrderNo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
+
+// OrderStateLogs 订单状态流转记录
+func OrderStateLogs(c *gin.Context) {
+	logs, err := service.NewOrderSvc(c).OrderStateLogs(c.GetInt64("userId"), c.Param("order_no"))
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(logs)
+}
+
+// AdminOrderList 后台订单列表, 商家后台只能看到本店铺的订单
+func AdminOrderList(c *gin.Context) {
+	listRequest := new(request.OrderList)
+	if err := c.ShouldBindQuery(listRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	pageInfo := resp.GetPageInfo(c)
+	orders, err := service.NewOrderSvc(c).AdminOrderList(c.GetInt64("merchantId"), listRequest, pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(orders)
+}
+
+// AdminOrderInfo 后台订单详情
+func AdminOrderInfo(c *gin.Context) {
+	orderReply, err := service.NewOrderSvc(c).AdminOrderInfo(c.GetInt64("merchantId"), c.Param("order_no"))
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(orderReply)
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// CreatePayment 发起支付
+func CreatePayment(c *gin.Context) {
+	paymentRequest := new(request.PaymentCreate)
+	if err := c.ShouldBindJSON(paymentRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	paymentReply, err := service.NewPaymentSvc(c).CreatePayment(c.GetInt64("userId"), paymentRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(paymentReply)
+}
+
+// PaymentInfo 查询支付结果
+func PaymentInfo(c *gin.Context) {
+	paymentReply, err := service.NewPaymentSvc(c).PaymentInfo(c.GetInt64("userId"), c.Param("payment_no"))
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(paymentReply)
+}
+
+// PaymentNotify 支付渠道的异步通知, 响应非200时渠道会重发通知
+func PaymentNotify(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	err = service.NewPaymentSvc(c).HandleNotification(c.Param("provider"), c.Request.Header, body)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// GoodsReviews 商品评价列表
+func GoodsReviews(c *gin.Context) {
+	listRequest := new(request.GoodsReviewList)
+	if err := c.ShouldBindQuery(listRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	pageInfo := resp.GetPageInfo(c)
+	reviews, err := service.NewReviewSvc(c).GoodsReviews(listRequest, pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(reviews)
+}
+
+// ReviewSummary 商品评分汇总
+func ReviewSummary(c *gin.Context) {
+	summaryRequest := new(request.ReviewSummary)
+	if err := c.ShouldBindQuery(summaryRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	summary, err := service.NewReviewSvc(c).RatingSummary(summaryRequest.GoodsId)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(summary)
+}
+
+// CreateReview 评价商品
+func CreateReview(c *gin.Context) {
+	createRequest := new(request.ReviewCreate)
+	if err := c.ShouldBindJSON(createRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	review, err := service.NewReviewSvc(c).CreateReview(c.GetInt64("userId"), createRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(review)
+}
+
+// UserReviews 我的评价
+func UserReviews(c *gin.Context) {
+	pageInfo := resp.GetPageInfo(c)
+	reviews, err := service.NewReviewSvc(c).UserReviews(c.GetInt64("userId"), pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(reviews)
+}
+
+// AdminReviewList 后台评价列表
+func AdminReviewList(c *gin.Context) {
+	listRequest := new(request.AdminReviewList)
+	if err := c.ShouldBindQuery(listRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	pageInfo := resp.GetPageInfo(c)
+	reviews, err := service.NewReviewSvc(c).AdminReviews(c.GetInt64("merchantId"), listRequest, pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(reviews)
+}
+
+// ReplyReview 商家回复评价
+func ReplyReview(c *gin.Context) {
+	replyRequest := new(request.ReviewReply)
+	if err := c.ShouldBindJSON(replyRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	if err := service.NewReviewSvc(c).ReplyReview(c.GetInt64("merchantId"), c.GetInt64("userId"), replyRequest); err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
+
+// HideReview 隐藏或恢复展示评价
+func HideReview(c *gin.Context) {
+	hideRequest := new(request.ReviewHide)
+	if err := c.ShouldBindJSON(hideRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	if err := service.NewReviewSvc(c).HideReview(c.GetInt64("userId"), hideRequest); err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
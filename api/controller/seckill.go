@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// SeckillActivityList 秒杀活动列表
+func SeckillActivityList(c *gin.Context) {
+	pageInfo := resp.GetPageInfo(c)
+	activities, err := service.NewSeckillSvc(c).ActivityList(pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(activities)
+}
+
+// SeckillGrab 抢购
+func SeckillGrab(c *gin.Context) {
+	grabRequest := new(request.SeckillGrab)
+	if err := c.ShouldBindJSON(grabRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	grabReply, err := service.NewSeckillSvc(c).Grab(c.GetInt64("userId"), grabRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(grabReply)
+}
+
+// SeckillResult 轮询抢购结果
+func SeckillResult(c *gin.Context) {
+	resultReply, err := service.NewSeckillSvc(c).Result(c.GetInt64("userId"), c.Param("ticket"))
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(resultReply)
+}
+
+// CreateSeckillActivity 创建秒杀活动
+func CreateSeckillActivity(c *gin.Context) {
+	activityRequest := new(request.SeckillActivityCreate)
+	if err := c.ShouldBindJSON(activityRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	activityReply, err := service.NewSeckillSvc(c).CreateActivity(activityRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(activityReply)
+}
+
+// WarmUpSeckillActivity 手动预热秒杀库存
+func WarmUpSeckillActivity(c *gin.Context) {
+	warmupRequest := new(request.SeckillWarmup)
+	if err := c.ShouldBindJSON(warmupRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	if err := service.NewSeckillSvc(c).WarmUp(warmupRequest.ActivityId); err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SuccessOk()
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// OrderShipments 用户查看订单物流
+func OrderShipments(c *gin.Context) {
+	shipments, err := service.NewShipmentSvc(c).OrderShipments(c.GetInt64("userId"), c.Param("order_no"))
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(shipments)
+}
+
+// ShipOrder 订单发货
+func ShipOrder(c *gin.Context) {
+	shipRequest := new(request.OrderShip)
+	if err := c.ShouldBindJSON(shipRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	shipment, err := service.NewShipmentSvc(c).ShipOrder(c.GetInt64("merchantId"), c.GetInt64("userId"), shipRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(shipment)
+}
+
+// AdminOrderShipments 后台查看订单物流
+func AdminOrderShipments(c *gin.Context) {
+	shipments, err := service.NewShipmentSvc(c).AdminOrderShipments(c.GetInt64("merchantId"), c.Param("order_no"))
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(shipments)
+}
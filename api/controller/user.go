@@ -20,7 +20,7 @@ func RegisterUser(ctx *gin.Context) {
 	}
 	if !utils.PasswordComplexityVerify(userRequest.Password) {
 		// Validator验证通过后再应用 密码复杂度这样的特殊验证
-		logger.NewLogger(ctx).Warn("RegisterUserError", "err", "密码复杂度不满足", "password", userRequest.Password)
+		logger.NewLogger(ctx).Warn("RegisterUserError", "err", "密码复杂度不满足", "login_name", utils.MaskLoginName(userRequest.LoginName))
 		resp.NewResponse(ctx).Error(errcode.ErrParams)
 		return
 	}
@@ -132,7 +132,7 @@ func PasswordReset(c *gin.Context) {
 	}
 	if !utils.PasswordComplexityVerify(request.Password) {
 		// Validator验证通过后再应用 密码复杂度这样的特殊验证
-		logger.NewLogger(c).Warn("RegisterUserError", "err", "密码复杂度不满足", "password", request.Password)
+		logger.NewLogger(c).Warn("PasswordResetError", "err", "密码复杂度不满足")
 		resp.NewResponse(c).Error(errcode.ErrParams)
 		return
 	}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// WalletInfo 我的钱包余额
+func WalletInfo(c *gin.Context) {
+	wallet, err := service.NewWalletSvc(c).WalletInfo(c.GetInt64("userId"))
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(wallet)
+}
+
+// WalletEntries 我的钱包收支明细
+func WalletEntries(c *gin.Context) {
+	pageInfo := resp.GetPageInfo(c)
+	entries, err := service.NewWalletSvc(c).WalletEntries(c.GetInt64("userId"), pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(entries)
+}
+
+// RedeemGiftCard 兑换礼品卡到钱包
+func RedeemGiftCard(c *gin.Context) {
+	redeemRequest := new(request.GiftCardRedeem)
+	if err := c.ShouldBindJSON(redeemRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	result, err := service.NewWalletSvc(c).RedeemGiftCard(c.GetInt64("userId"), redeemRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(result)
+}
+
+// IssueGiftCards 管理员发行礼品卡
+func IssueGiftCards(c *gin.Context) {
+	issueRequest := new(request.GiftCardIssue)
+	if err := c.ShouldBindJSON(issueRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	cards, err := service.NewWalletSvc(c).IssueGiftCards(c.GetInt64("userId"), issueRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(cards)
+}
+
+// GiftCardList 后台礼品卡列表
+func GiftCardList(c *gin.Context) {
+	listRequest := new(request.GiftCardList)
+	if err := c.ShouldBindQuery(listRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	pageInfo := resp.GetPageInfo(c)
+	cards, err := service.NewWalletSvc(c).GiftCardList(listRequest, pageInfo)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).SetPageInfo(pageInfo).Success(cards)
+}
+
+// AdjustWallet 管理员人工调整用户钱包余额
+func AdjustWallet(c *gin.Context) {
+	adjustRequest := new(request.WalletAdjust)
+	if err := c.ShouldBindJSON(adjustRequest); err != nil {
+		resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+		return
+	}
+	result, err := service.NewWalletSvc(c).AdjustWallet(c.GetInt64("userId"), adjustRequest)
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(result)
+}
+
+// CheckLedger 手动执行一次钱包对账
+func CheckLedger(c *gin.Context) {
+	report, err := service.NewWalletSvc(c).CheckLedger()
+	if err != nil {
+		replyError(c, err)
+		return
+	}
+	resp.NewResponse(c).Success(report)
+}
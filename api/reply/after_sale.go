@@ -0,0 +1,33 @@
+package reply
+
+type AfterSale struct {
+	AfterSaleNo      string          `json:"after_sale_no"`
+	OrderNo          string          `json:"order_no"`
+	OrderItemId      int64           `json:"order_item_id"`
+	SkuId            int64           `json:"sku_id"`
+	UserId           int64           `json:"user_id"`
+	Type             int8            `json:"type"`
+	Quantity         int             `json:"quantity"`
+	RefundAmount     int64           `json:"refund_amount"`
+	RefundPoints     int64           `json:"refund_points"`
+	Reason           string          `json:"reason"`
+	Description      string          `json:"description"`
+	Images           []string        `json:"images"`
+	State            int8            `json:"state"`
+	StateName        string          `json:"state_name"`
+	ReturnCarrier    string          `json:"return_carrier"`
+	ReturnTrackingNo string          `json:"return_tracking_no"`
+	MerchantRemark   string          `json:"merchant_remark"`
+	RefundedAt       string          `json:"refunded_at"`
+	Logs             []*AfterSaleLog `json:"logs,omitempty"`
+	CreatedAt        string          `json:"created_at"`
+}
+
+type AfterSaleLog struct {
+	FromState    int8   `json:"from_state"`
+	ToState      int8   `json:"to_state"`
+	ToStateName  string `json:"to_state_name"`
+	OperatorType string `json:"operator_type"`
+	Remark       string `json:"remark"`
+	CreatedAt    string `json:"created_at"`
+}
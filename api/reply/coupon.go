@@ -0,0 +1,67 @@
+package reply
+
+type CouponTemplate struct {
+	ID           int64   `json:"id"`
+	Name         string  `json:"name"`
+	Type         int8    `json:"type"`
+	Threshold    int64   `json:"threshold"`
+	Amount       int64   `json:"amount"`
+	Discount     int     `json:"discount"`
+	MaxDiscount  int64   `json:"max_discount"`
+	ScopeType    int8    `json:"scope_type"`
+	ScopeIds     []int64 `json:"scope_ids"`
+	Stackable    bool    `json:"stackable"`
+	Claimable    bool    `json:"claimable"`
+	TotalCount   int     `json:"total_count"`
+	IssuedCount  int     `json:"issued_count"`
+	PerUserLimit int     `json:"per_user_limit"`
+	ClaimStartAt string  `json:"claim_start_at"`
+	ClaimEndAt   string  `json:"claim_end_at"`
+	ValidType    int8    `json:"valid_type"`
+	ValidStartAt string  `json:"valid_start_at"`
+	ValidEndAt   string  `json:"valid_end_at"`
+	ValidDays    int     `json:"valid_days"`
+}
+
+type UserCoupon struct {
+	ID           int64   `json:"id"`
+	TemplateId   int64   `json:"template_id"`
+	Name         string  `json:"name"`
+	Type         int8    `json:"type"`
+	Threshold    int64   `json:"threshold"`
+	Amount       int64   `json:"amount"`
+	Discount     int     `json:"discount"`
+	MaxDiscount  int64   `json:"max_discount"`
+	ScopeType    int8    `json:"scope_type"`
+	ScopeIds     []int64 `json:"scope_ids"`
+	Stackable    bool    `json:"stackable"`
+	State        int8    `json:"state"`
+	Expired      bool    `json:"expired"`
+	ValidStartAt string  `json:"valid_start_at"`
+	ValidEndAt   string  `json:"valid_end_at"`
+}
+
+// CheckoutCoupon 结算页可用的优惠券, DiscountAmount为单独使用时的优惠金额
+// 不能叫Discount, 会遮住UserCoupon中折扣券的折扣
+type CheckoutCoupon struct {
+	*UserCoupon
+	DiscountAmount int64 `json:"discount_amount"`
+}
+
+type CouponCheckout struct {
+	BillMoney     int64             `json:"bill_money"`
+	Coupons       []*CheckoutCoupon `json:"coupons"`
+	BestCouponIds []int64           `json:"best_coupon_ids"`
+	BestDiscount  int64             `json:"best_discount"`
+	PayMoney      int64             `json:"pay_money"`
+}
+
+type CouponIssueFailure struct {
+	UserId int64  `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+type CouponIssue struct {
+	Issued   int                   `json:"issued"`
+	Failures []*CouponIssueFailure `json:"failures"`
+}
@@ -0,0 +1,22 @@
+package reply
+
+type Favorite struct {
+	Id        int64  `json:"id"`
+	GoodsId   int64  `json:"goods_id"`
+	Title     string `json:"title"`
+	Image     string `json:"image"`
+	State     int    `json:"state"`
+	MinPrice  int64  `json:"min_price"`
+	InStock   bool   `json:"in_stock"`
+	CreatedAt string `json:"created_at"`
+}
+
+type GoodsSubscription struct {
+	Id            int64  `json:"id"`
+	SkuId         int64  `json:"sku_id"`
+	GoodsId       int64  `json:"goods_id"`
+	Type          int8   `json:"type"`
+	BaselinePrice int64  `json:"baseline_price"` // 降价提醒的比较基准
+	TargetPrice   int64  `json:"target_price"`
+	CreatedAt     string `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package reply
+
+type FreightTemplate struct {
+	ID             int64                `json:"id"`
+	Name           string               `json:"name"`
+	ChargeType     int8                 `json:"charge_type"`
+	FirstUnit      int64                `json:"first_unit"`
+	FirstFee       int64                `json:"first_fee"`
+	AdditionalUnit int64                `json:"additional_unit"`
+	AdditionalFee  int64                `json:"additional_fee"`
+	FreeThreshold  int64                `json:"free_threshold"`
+	IsDefault      bool                 `json:"is_default"`
+	Rules          []*FreightRegionRule `json:"rules"`
+	CreatedAt      string               `json:"created_at"`
+}
+
+type FreightRegionRule struct {
+	Regions        []string `json:"regions"`
+	Undeliverable  bool     `json:"undeliverable"`
+	FirstUnit      int64    `json:"first_unit"`
+	FirstFee       int64    `json:"first_fee"`
+	AdditionalUnit int64    `json:"additional_unit"`
+	AdditionalFee  int64    `json:"additional_fee"`
+	FreeThreshold  int64    `json:"free_threshold"`
+}
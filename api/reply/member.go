@@ -0,0 +1,30 @@
+package reply
+
+type Member struct {
+	Balance      int64       `json:"balance"`
+	GrowthValue  int64       `json:"growth_value"`
+	Tier         *MemberTier `json:"tier"`
+	NextTier     *MemberTier `json:"next_tier"`      // 已经是最高等级时为null
+	GrowthToNext int64       `json:"growth_to_next"` // 距离下一等级还差的成长值
+}
+
+type MemberTier struct {
+	Level        int8   `json:"level"`
+	Name         string `json:"name"`
+	MinGrowth    int64  `json:"min_growth"`
+	DiscountBps  int64  `json:"discount_bps"`
+	FreeShipping bool   `json:"free_shipping"`
+}
+
+type PointsLedger struct {
+	Id        int64  `json:"id"`
+	Type      int8   `json:"type"`
+	TypeName  string `json:"type_name"`
+	Points    int64  `json:"points"`
+	Growth    int64  `json:"growth"`
+	Balance   int64  `json:"balance"`
+	OrderNo   string `json:"order_no"`
+	Remark    string `json:"remark"`
+	ExpireAt  string `json:"expire_at"` // 增加积分的流水才有过期时间
+	CreatedAt string `json:"created_at"`
+}
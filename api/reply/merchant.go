@@ -0,0 +1,31 @@
+package reply
+
+type Merchant struct {
+	Id          int64  `json:"id"`
+	Name        string `json:"name"`
+	OwnerUserId int64  `json:"owner_user_id"`
+	Logo        string `json:"logo"`
+	Description string `json:"description"`
+	State       int8   `json:"state"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type MerchantGoods struct {
+	Id                int64       `json:"id"`
+	CategoryId        int64       `json:"category_id"`
+	Title             string      `json:"title"`
+	Image             string      `json:"image"`
+	State             int         `json:"state"`
+	FreightTemplateId int64       `json:"freight_template_id"`
+	Skus              []*GoodsSku `json:"skus"`
+	CreatedAt         string      `json:"created_at"`
+}
+
+type GoodsSku struct {
+	Id     int64  `json:"id"`
+	Spec   string `json:"spec"`
+	Image  string `json:"image"`
+	Price  int64  `json:"price"`
+	Stock  int    `json:"stock"`
+	Weight int64  `json:"weight"`
+}
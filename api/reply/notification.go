@@ -0,0 +1,40 @@
+package reply
+
+type NotificationMessage struct {
+	Id        int64  `json:"id"`
+	Type      int8   `json:"type"`
+	TypeName  string `json:"type_name"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	Link      string `json:"link"`
+	IsRead    bool   `json:"is_read"`
+	ReadAt    string `json:"read_at"`
+	CreatedAt string `json:"created_at"`
+}
+
+// NotificationUnread 未读消息数
+type NotificationUnread struct {
+	Total     int64 `json:"total"`
+	System    int64 `json:"system"`
+	Order     int64 `json:"order"`
+	Promotion int64 `json:"promotion"`
+}
+
+// NotificationReadResult 标记已读的条数
+type NotificationReadResult struct {
+	Updated int64 `json:"updated"`
+}
+
+type NotificationBroadcast struct {
+	Id        int64  `json:"id"`
+	Type      int8   `json:"type"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	Link      string `json:"link"`
+	CreatedAt string `json:"created_at"`
+}
+
+// NotificationSendResult 实际发送的用户数
+type NotificationSendResult struct {
+	Sent int `json:"sent"`
+}
@@ -0,0 +1,121 @@
+package reply
+
+type Order struct {
+	OrderNo         string       `json:"order_no"`
+	ParentOrderNo   string       `json:"parent_order_no"`
+	MerchantId      int64        `json:"merchant_id"`
+	UserId          int64        `json:"user_id"`
+	BillMoney       int64        `json:"bill_money"`
+	PromotionAmount int64        `json:"promotion_amount"`
+	CouponAmount    int64        `json:"coupon_amount"`
+	PointsAmount    int64        `json:"points_amount"`
+	PointsUsed      int64        `json:"points_used"`
+	ShippingFee     int64        `json:"shipping_fee"`
+	TaxAmount       int64        `json:"tax_amount"`
+	PayMoney        int64        `json:"pay_money"`
+	ReceiverName    string       `json:"receiver_name"`
+	ReceiverPhone   string       `json:"receiver_phone"`
+	Province        string       `json:"province"`
+	City            string       `json:"city"`
+	District        string       `json:"district"`
+	Address         string       `json:"address"`
+	State           int8         `json:"state"`
+	StateName       string       `json:"state_name"`
+	Remark          string       `json:"remark"`
+	Items           []*OrderItem `json:"items,omitempty"`
+	PaidAt          string       `json:"paid_at"`
+	ShippedAt       string       `json:"shipped_at"`
+	DeliveredAt     string       `json:"delivered_at"`
+	CompletedAt     string       `json:"completed_at"`
+	CancelledAt     string       `json:"cancelled_at"`
+	CreatedAt       string       `json:"created_at"`
+}
+
+// OrderCreate 下单结果, 按店铺拆分的子订单共用一个父订单号, 用父订单号发起支付
+type OrderCreate struct {
+	ParentOrderNo string   `json:"parent_order_no"`
+	PayMoney      int64    `json:"pay_money"` // 各子订单实付金额之和
+	Orders        []*Order `json:"orders"`
+}
+
+// OrderItem 订单明细, 商品信息是下单时的快照
+type OrderItem struct {
+	Id              int64                 `json:"id"`
+	GoodsId         int64                 `json:"goods_id"`
+	SkuId           int64                 `json:"sku_id"`
+	GoodsTitle      string                `json:"goods_title"`
+	SkuSpec         string                `json:"sku_spec"`
+	GoodsImage      string                `json:"goods_image"`
+	Quantity        int                   `json:"quantity"`
+	UnitPrice       int64                 `json:"unit_price"`
+	Amount          int64                 `json:"amount"`
+	PromotionAmount int64                 `json:"promotion_amount"`
+	CouponAmount    int64                 `json:"coupon_amount"`
+	PointsAmount    int64                 `json:"points_amount"`
+	PointsUsed      int64                 `json:"points_used"`
+	TaxAmount       int64                 `json:"tax_amount"`
+	PayAmount       int64                 `json:"pay_amount"`
+	Promotions      []*OrderItemPromotion `json:"promotions"`
+}
+
+// OrderItemPromotion 订单明细享受的营销活动或优惠券, 金额是分摊到该明细的部分
+type OrderItemPromotion struct {
+	Name     string `json:"name"`
+	Discount int64  `json:"discount"`
+	CouponId int64  `json:"coupon_id,omitempty"`
+}
+
+// OrderCheckout 结算页试算结果, 金额单位为分
+type OrderCheckout struct {
+	Lines             []*CheckoutLine      `json:"lines"`
+	Shops             []*CheckoutShop      `json:"shops"` // 按店铺汇总, 下单时每个店铺拆成一个子订单
+	Subtotal          int64                `json:"subtotal"`
+	PromotionDiscount int64                `json:"promotion_discount"`
+	CouponDiscount    int64                `json:"coupon_discount"`
+	PointsDiscount    int64                `json:"points_discount"`
+	PointsUsed        int64                `json:"points_used"` // 实际抵扣使用的积分, 受抵扣比例上限限制时可能少于请求的积分
+	ShippingFee       int64                `json:"shipping_fee"`
+	Tax               int64                `json:"tax"`
+	Payable           int64                `json:"payable"`
+	Promotions        []*CheckoutPromotion `json:"promotions"`
+	CouponIds         []int64              `json:"coupon_ids"` // 实际使用的优惠券
+}
+
+type CheckoutLine struct {
+	SkuId             int64 `json:"sku_id"`
+	GoodsId           int64 `json:"goods_id"`
+	MerchantId        int64 `json:"merchant_id"`
+	Quantity          int   `json:"quantity"`
+	UnitPrice         int64 `json:"unit_price"`
+	Subtotal          int64 `json:"subtotal"`
+	PromotionDiscount int64 `json:"promotion_discount"`
+	CouponDiscount    int64 `json:"coupon_discount"`
+	PointsDiscount    int64 `json:"points_discount"`
+	Tax               int64 `json:"tax"`
+	Payable           int64 `json:"payable"`
+}
+
+type CheckoutShop struct {
+	MerchantId        int64 `json:"merchant_id"`
+	Subtotal          int64 `json:"subtotal"`
+	PromotionDiscount int64 `json:"promotion_discount"`
+	CouponDiscount    int64 `json:"coupon_discount"`
+	PointsDiscount    int64 `json:"points_discount"`
+	ShippingFee       int64 `json:"shipping_fee"`
+	Tax               int64 `json:"tax"`
+	Payable           int64 `json:"payable"`
+}
+
+type CheckoutPromotion struct {
+	Name     string `json:"name"`
+	Discount int64  `json:"discount"`
+}
+
+type OrderStateLog struct {
+	FromState    int8   `json:"from_state"`
+	ToState      int8   `json:"to_state"`
+	ToStateName  string `json:"to_state_name"`
+	OperatorType string `json:"operator_type"`
+	Remark       string `json:"remark"`
+	CreatedAt    string `json:"created_at"`
+}
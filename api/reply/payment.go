@@ -0,0 +1,12 @@
+package reply
+
+type Payment struct {
+	PaymentNo     string `json:"payment_no"`
+	ParentOrderNo string `json:"parent_order_no"`
+	Provider      string `json:"provider"`
+	Amount        int64  `json:"amount"`
+	State         int8   `json:"state"`
+	PayUrl        string `json:"pay_url,omitempty"`
+	PaidAt        string `json:"paid_at"`
+	CreatedAt     string `json:"created_at"`
+}
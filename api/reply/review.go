@@ -0,0 +1,34 @@
+package reply
+
+type Review struct {
+	ID           int64    `json:"id"`
+	GoodsId      int64    `json:"goods_id"`
+	SkuId        int64    `json:"sku_id"`
+	Rating       int8     `json:"rating"`
+	Content      string   `json:"content"`
+	Images       []string `json:"images"`
+	Anonymous    bool     `json:"anonymous"`
+	Nickname     string   `json:"nickname"`
+	Avatar       string   `json:"avatar"`
+	ReplyContent string   `json:"reply_content"`
+	RepliedAt    string   `json:"replied_at"`
+	CreatedAt    string   `json:"created_at"`
+}
+
+// AdminReview 后台看到的评价, 包括隐藏状态和真实的评价人
+type AdminReview struct {
+	Review
+	UserId       int64  `json:"user_id"`
+	OrderId      int64  `json:"order_id"`
+	State        int8   `json:"state"`
+	HiddenReason string `json:"hidden_reason"`
+}
+
+type RatingSummary struct {
+	GoodsId      int64    `json:"goods_id"`
+	TotalCount   int64    `json:"total_count"`
+	AverageScore float64  `json:"average_score"`
+	GoodRate     int      `json:"good_rate"`
+	ImageCount   int64    `json:"image_count"`
+	RatingCounts [5]int64 `json:"rating_counts"`
+}
@@ -0,0 +1,25 @@
+package reply
+
+type SeckillActivity struct {
+	ID             int64  `json:"id"`
+	Title          string `json:"title"`
+	GoodsId        int64  `json:"goods_id"`
+	SkuId          int64  `json:"sku_id"`
+	SeckillPrice   int64  `json:"seckill_price"`
+	TotalStock     int    `json:"total_stock"`
+	RemainingStock int    `json:"remaining_stock"`
+	PerUserLimit   int    `json:"per_user_limit"`
+	StartAt        string `json:"start_at"`
+	EndAt          string `json:"end_at"`
+}
+
+type SeckillGrab struct {
+	Ticket string `json:"ticket"`
+}
+
+type SeckillResult struct {
+	Ticket  string `json:"ticket"`
+	State   string `json:"state"`
+	OrderNo string `json:"order_no"`
+	Reason  string `json:"reason"`
+}
@@ -0,0 +1,20 @@
+package reply
+
+type Shipment struct {
+	OrderNo     string           `json:"order_no"`
+	Carrier     string           `json:"carrier"`
+	CarrierName string           `json:"carrier_name"`
+	TrackingNo  string           `json:"tracking_no"`
+	State       int8             `json:"state"`
+	StateName   string           `json:"state_name"`
+	ShippedAt   string           `json:"shipped_at"`
+	DeliveredAt string           `json:"delivered_at"`
+	Events      []*ShipmentEvent `json:"events"`
+}
+
+type ShipmentEvent struct {
+	EventTime   string `json:"event_time"`
+	Status      string `json:"status"`
+	Location    string `json:"location"`
+	Description string `json:"description"`
+}
@@ -0,0 +1,15 @@
+package reply
+
+type DelayQueueJob struct {
+	Id       string `json:"id"`
+	Topic    string `json:"topic"`
+	Payload  string `json:"payload"`
+	Attempts int    `json:"attempts"`
+	RunAt    string `json:"run_at"`
+	LastErr  string `json:"last_err"`
+}
+
+type DelayQueueJobs struct {
+	DeadCount int64            `json:"dead_count"` // 超过最大重试次数的任务数
+	Jobs      []*DelayQueueJob `json:"jobs"`
+}
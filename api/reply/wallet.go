@@ -0,0 +1,63 @@
+package reply
+
+type Wallet struct {
+	Balance int64 `json:"balance"`
+}
+
+type WalletEntry struct {
+	Id        int64  `json:"id"`
+	TxNo      string `json:"tx_no"`
+	Type      int8   `json:"type"`
+	TypeName  string `json:"type_name"`
+	BizNo     string `json:"biz_no"`
+	Amount    int64  `json:"amount"`  // 入账为正数, 出账为负数
+	Balance   int64  `json:"balance"` // 变动后的余额
+	Remark    string `json:"remark"`
+	CreatedAt string `json:"created_at"`
+}
+
+type GiftCard struct {
+	Id         int64  `json:"id"`
+	CardNo     string `json:"card_no"`
+	Code       string `json:"code,omitempty"` // 卡密只在发行时返回一次
+	BatchNo    string `json:"batch_no"`
+	Amount     int64  `json:"amount"`
+	State      int8   `json:"state"`
+	RedeemedBy int64  `json:"redeemed_by"`
+	RedeemedAt string `json:"redeemed_at"`
+	ExpireAt   string `json:"expire_at"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// GiftCardRedeem 兑换结果
+type GiftCardRedeem struct {
+	CardNo  string `json:"card_no"`
+	Amount  int64  `json:"amount"`
+	Balance int64  `json:"balance"` // 兑换后的钱包余额
+}
+
+type WalletAdjust struct {
+	TxNo    string `json:"tx_no"`
+	Balance int64  `json:"balance"` // 调账后的钱包余额
+}
+
+type LedgerCheckReport struct {
+	CheckedAt              string                   `json:"checked_at"`
+	Consistent             bool                     `json:"consistent"`
+	Accounts               int64                    `json:"accounts"`
+	TotalBalance           int64                    `json:"total_balance"`
+	AccountMismatches      []*LedgerAccountMismatch `json:"account_mismatches"`
+	UnbalancedTransactions []*LedgerUnbalancedTx    `json:"unbalanced_transactions"`
+}
+
+type LedgerAccountMismatch struct {
+	AccountId int64  `json:"account_id"`
+	AccountNo string `json:"account_no"`
+	Balance   int64  `json:"balance"`
+	EntrySum  int64  `json:"entry_sum"`
+}
+
+type LedgerUnbalancedTx struct {
+	TransactionId int64 `json:"transaction_id"`
+	EntrySum      int64 `json:"entry_sum"`
+}
@@ -0,0 +1,35 @@
+package request
+
+// AfterSaleApply 申请售后请求
+type AfterSaleApply struct {
+	OrderNo     string   `json:"order_no" binding:"required"`
+	OrderItemId int64    `json:"order_item_id" binding:"required,gt=0"`
+	Type        int8     `json:"type" binding:"required,oneof=1 2"`
+	Quantity    int      `json:"quantity" binding:"required,gt=0"`
+	Reason      string   `json:"reason" binding:"required,max=64"`
+	Description string   `json:"description" binding:"max=512"`
+	Images      []string `json:"images" binding:"max=9,dive,url"`
+}
+
+// AfterSaleList 售后单列表查询, state不传时查询全部
+type AfterSaleList struct {
+	State int8 `form:"state" binding:"omitempty,min=1,max=7"`
+}
+
+// AfterSaleCancel 撤销售后申请请求
+type AfterSaleCancel struct {
+	AfterSaleNo string `json:"after_sale_no" binding:"required"`
+}
+
+// AfterSaleReturn 填写退货物流请求
+type AfterSaleReturn struct {
+	AfterSaleNo string `json:"after_sale_no" binding:"required"`
+	Carrier     string `json:"carrier" binding:"required,max=32"`
+	TrackingNo  string `json:"tracking_no" binding:"required,max=64"`
+}
+
+// AfterSaleReview 商家处理售后请求, 同意、驳回、确认收货共用
+type AfterSaleReview struct {
+	AfterSaleNo string `json:"after_sale_no" binding:"required"`
+	Remark      string `json:"remark" binding:"max=255"`
+}
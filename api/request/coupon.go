@@ -0,0 +1,44 @@
+package request
+
+// CouponTemplateCreate 创建优惠券模板请求, 时间格式为 2006-01-02 15:04:05
+type CouponTemplateCreate struct {
+	Name         string  `json:"name" binding:"required,max=64"`
+	Type         int8    `json:"type" binding:"required,oneof=1 2 3"`
+	Threshold    int64   `json:"threshold" binding:"gte=0"`
+	Amount       int64   `json:"amount" binding:"gte=0"`
+	Discount     int     `json:"discount" binding:"gte=0,lt=100"`
+	MaxDiscount  int64   `json:"max_discount" binding:"gte=0"`
+	ScopeType    int8    `json:"scope_type" binding:"oneof=0 1 2"`
+	ScopeIds     []int64 `json:"scope_ids" binding:"max=200,dive,gt=0"`
+	Stackable    bool    `json:"stackable"`
+	Claimable    bool    `json:"claimable"`
+	TotalCount   int     `json:"total_count" binding:"gte=0"`
+	PerUserLimit int     `json:"per_user_limit" binding:"gte=0"`
+	ClaimStartAt string  `json:"claim_start_at" binding:"required,datetime=2006-01-02 15:04:05"`
+	ClaimEndAt   string  `json:"claim_end_at" binding:"required,datetime=2006-01-02 15:04:05"`
+	ValidType    int8    `json:"valid_type" binding:"required,oneof=1 2"`
+	ValidStartAt string  `json:"valid_start_at" binding:"omitempty,datetime=2006-01-02 15:04:05"`
+	ValidEndAt   string  `json:"valid_end_at" binding:"omitempty,datetime=2006-01-02 15:04:05"`
+	ValidDays    int     `json:"valid_days" binding:"gte=0"`
+}
+
+// CouponClaim 领取优惠券请求
+type CouponClaim struct {
+	TemplateId int64 `json:"template_id" binding:"required,gt=0"`
+}
+
+// CouponIssue 后台发放优惠券请求
+type CouponIssue struct {
+	TemplateId int64   `json:"template_id" binding:"required,gt=0"`
+	UserIds    []int64 `json:"user_ids" binding:"required,min=1,max=1000,dive,gt=0"`
+}
+
+// UserCouponList 我的优惠券列表查询, state不传时查询全部
+type UserCouponList struct {
+	State int8 `form:"state" binding:"omitempty,oneof=1 2 3"`
+}
+
+// CouponCheckout 结算页查询可用优惠券和最优组合
+type CouponCheckout struct {
+	Items []*OrderCreateItem `json:"items" binding:"required,min=1,max=50,dive"`
+}
@@ -0,0 +1,23 @@
+package request
+
+// FavoriteGoods 收藏或取消收藏商品
+type FavoriteGoods struct {
+	GoodsId int64 `json:"goods_id" binding:"required,gt=0"`
+}
+
+// SubscriptionCreate 订阅SKU的到货或降价提醒
+type SubscriptionCreate struct {
+	SkuId       int64 `json:"sku_id" binding:"required,gt=0"`
+	Type        int8  `json:"type" binding:"required,oneof=1 2"` // 1-到货提醒 2-降价提醒
+	TargetPrice int64 `json:"target_price" binding:"min=0"`      // 降价提醒的目标价, 单位分, 0表示只要降价就提醒
+}
+
+// SubscriptionCancel 退订提醒
+type SubscriptionCancel struct {
+	SubscriptionId int64 `json:"subscription_id" binding:"required,gt=0"`
+}
+
+// Unsubscribe 通过通知里的退订链接退订
+type Unsubscribe struct {
+	Token string `form:"token" binding:"required,max=64"`
+}
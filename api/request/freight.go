@@ -0,0 +1,31 @@
+package request
+
+// FreightTemplateCreate 创建运费模板请求, 金额单位为分, 重量单位为克
+type FreightTemplateCreate struct {
+	Name           string                     `json:"name" binding:"required,max=64"`
+	ChargeType     int8                       `json:"charge_type" binding:"required,oneof=1 2 3"`
+	FirstUnit      int64                      `json:"first_unit" binding:"gte=0"`
+	FirstFee       int64                      `json:"first_fee" binding:"gte=0"`
+	AdditionalUnit int64                      `json:"additional_unit" binding:"gte=0"`
+	AdditionalFee  int64                      `json:"additional_fee" binding:"gte=0"`
+	FreeThreshold  int64                      `json:"free_threshold" binding:"gte=0"`
+	IsDefault      bool                       `json:"is_default"`
+	Rules          []*FreightRegionRuleCreate `json:"rules" binding:"max=100,dive"`
+}
+
+// FreightRegionRuleCreate 运费模板的地区规则, regions的元素为"省"或"省/市"
+type FreightRegionRuleCreate struct {
+	Regions        []string `json:"regions" binding:"required,min=1,max=500,dive,required,max=64"`
+	Undeliverable  bool     `json:"undeliverable"`
+	FirstUnit      int64    `json:"first_unit" binding:"gte=0"`
+	FirstFee       int64    `json:"first_fee" binding:"gte=0"`
+	AdditionalUnit int64    `json:"additional_unit" binding:"gte=0"`
+	AdditionalFee  int64    `json:"additional_fee" binding:"gte=0"`
+	FreeThreshold  int64    `json:"free_threshold" binding:"gte=0"`
+}
+
+// FreightTemplateBindGoods 设置商品的运费模板, template_id为0时恢复使用默认模板
+type FreightTemplateBindGoods struct {
+	TemplateId int64   `json:"template_id" binding:"gte=0"`
+	GoodsIds   []int64 `json:"goods_ids" binding:"required,min=1,max=500,dive,gt=0"`
+}
@@ -0,0 +1,38 @@
+package request
+
+// MerchantCreate 平台为用户开通店铺
+type MerchantCreate struct {
+	OwnerUserId int64  `json:"owner_user_id" binding:"required,gt=0"`
+	Name        string `json:"name" binding:"required,max=64"`
+	Logo        string `json:"logo" binding:"omitempty,url,max=255"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// MerchantList 店铺列表查询, state不传时查询全部
+type MerchantList struct {
+	State int8 `form:"state" binding:"omitempty,oneof=1 2"`
+}
+
+// MerchantState 平台开启或关闭店铺
+type MerchantState struct {
+	MerchantId int64 `json:"merchant_id" binding:"required,gt=0"`
+	State      int8  `json:"state" binding:"required,oneof=1 2"`
+}
+
+// MerchantGoodsList 店铺商品列表查询, state不传时查询全部
+type MerchantGoodsList struct {
+	State *int `form:"state" binding:"omitempty,oneof=0 1"`
+}
+
+// MerchantGoodsState 店铺批量上下架商品
+type MerchantGoodsState struct {
+	GoodsIds []int64 `json:"goods_ids" binding:"required,min=1,max=100,dive,gt=0"`
+	State    *int    `json:"state" binding:"required,oneof=0 1"`
+}
+
+// GoodsSkuUpdate 修改SKU的售价或库存, 不传的字段不修改
+type GoodsSkuUpdate struct {
+	SkuId int64  `json:"sku_id" binding:"required,gt=0"`
+	Price *int64 `json:"price" binding:"omitempty,min=1"` // 售价, 单位分
+	Stock *int   `json:"stock" binding:"omitempty,min=0"`
+}
@@ -0,0 +1,33 @@
+package request
+
+// NotificationList 站内消息列表
+type NotificationList struct {
+	Type int8 `form:"type" binding:"omitempty,oneof=1 2 3"` // 为空时查询全部类型
+}
+
+// NotificationRead 把指定消息标记为已读
+type NotificationRead struct {
+	Ids []int64 `json:"ids" binding:"required,min=1,max=100,dive,gt=0"`
+}
+
+// NotificationReadAll 全部标记为已读
+type NotificationReadAll struct {
+	Type int8 `json:"type" binding:"omitempty,oneof=1 2 3"` // 为空时不限类型
+}
+
+// NotificationBroadcast 发布全体用户的广播消息
+type NotificationBroadcast struct {
+	Type    int8   `json:"type" binding:"required,oneof=1 2 3"` // 1-系统消息 2-订单消息 3-促销消息
+	Title   string `json:"title" binding:"required,max=128"`
+	Content string `json:"content" binding:"required,max=1024"`
+	Link    string `json:"link" binding:"max=255"`
+}
+
+// NotificationSend 给指定用户发站内消息
+type NotificationSend struct {
+	UserIds []int64 `json:"user_ids" binding:"required,min=1,max=1000,dive,gt=0"`
+	Type    int8    `json:"type" binding:"required,oneof=1 2 3"`
+	Title   string  `json:"title" binding:"required,max=128"`
+	Content string  `json:"content" binding:"required,max=1024"`
+	Link    string  `json:"link" binding:"max=255"`
+}
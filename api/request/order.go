@@ -0,0 +1,52 @@
+package request
+
+// OrderCreate 创建订单请求
+type OrderCreate struct {
+	Items       []*OrderCreateItem `json:"items" binding:"required,min=1,max=50,dive"`
+	Address     *ShippingAddress   `json:"address" binding:"required"`
+	CouponIds   []int64            `json:"coupon_ids" binding:"max=3,dive,gt=0"` // 使用的优惠券, best_coupons为true时忽略
+	BestCoupons bool               `json:"best_coupons"`                         // 由系统选择优惠最多的优惠券组合
+	Points      int64              `json:"points" binding:"min=0"`               // 使用多少积分抵扣, 不超过积分余额
+	Remark      string             `json:"remark" binding:"max=255"`
+}
+
+// OrderCreateItem 下单的商品和数量
+type OrderCreateItem struct {
+	SkuId    int64 `json:"sku_id" binding:"required,gt=0"`
+	Quantity int   `json:"quantity" binding:"required,gt=0,lte=999"`
+}
+
+// OrderCheckout 结算页试算请求, 和创建订单使用同样的计价规则
+type OrderCheckout struct {
+	Items       []*OrderCreateItem `json:"items" binding:"required,min=1,max=50,dive"`
+	Address     *ShippingAddress   `json:"address" binding:"omitempty"` // 未选择地址时按默认运费规则试算
+	CouponIds   []int64            `json:"coupon_ids" binding:"max=3,dive,gt=0"`
+	BestCoupons bool               `json:"best_coupons"`
+	Points      int64              `json:"points" binding:"min=0"`
+}
+
+// ShippingAddress 收货地址
+type ShippingAddress struct {
+	ReceiverName  string `json:"receiver_name" binding:"required,max=32"`
+	ReceiverPhone string `json:"receiver_phone" binding:"required,max=20"`
+	Province      string `json:"province" binding:"required,max=32"`
+	City          string `json:"city" binding:"required,max=32"`
+	District      string `json:"district" binding:"max=32"`
+	Detail        string `json:"detail" binding:"required,max=255"`
+}
+
+// OrderList 订单列表查询, state不传时查询全部
+type OrderList struct {
+	State int8 `form:"state" binding:"omitempty,min=1,max=8"`
+}
+
+// OrderCancel 取消订单请求
+type OrderCancel struct {
+	OrderNo string `json:"order_no" binding:"required"`
+	Reason  string `json:"reason" binding:"max=100"`
+}
+
+// OrderConfirmReceipt 确认收货请求
+type OrderConfirmReceipt struct {
+	OrderNo string `json:"order_no" binding:"required"`
+}
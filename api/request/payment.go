@@ -0,0 +1,7 @@
+package request
+
+// PaymentCreate 发起支付请求
+type PaymentCreate struct {
+	OrderNo  string `json:"order_no" binding:"required"`                   // 任一子订单号, 支付的是整个父订单
+	Provider string `json:"provider" binding:"required,oneof=mock wallet"` // 支付渠道, wallet为钱包余额支付
+}
@@ -0,0 +1,42 @@
+package request
+
+// ReviewCreate 评价订单中的商品
+type ReviewCreate struct {
+	OrderNo     string   `json:"order_no" binding:"required"`
+	OrderItemId int64    `json:"order_item_id" binding:"required,gt=0"`
+	Rating      int8     `json:"rating" binding:"required,min=1,max=5"`
+	Content     string   `json:"content" binding:"max=500"`
+	Images      []string `json:"images" binding:"max=9,dive,required,url,max=255"`
+	Anonymous   bool     `json:"anonymous"`
+}
+
+// GoodsReviewList 商品评价列表查询, rating不传时查询全部评分
+type GoodsReviewList struct {
+	GoodsId    int64 `form:"goods_id" binding:"required,gt=0"`
+	Rating     int8  `form:"rating" binding:"omitempty,min=1,max=5"`
+	WithImages bool  `form:"with_images"`
+}
+
+// ReviewSummary 商品评分汇总查询
+type ReviewSummary struct {
+	GoodsId int64 `form:"goods_id" binding:"required,gt=0"`
+}
+
+// AdminReviewList 后台评价列表查询, goods_id和state不传时查询全部
+type AdminReviewList struct {
+	GoodsId int64 `form:"goods_id" binding:"omitempty,gt=0"`
+	State   int8  `form:"state" binding:"omitempty,oneof=1 2"`
+}
+
+// ReviewReply 商家回复评价
+type ReviewReply struct {
+	ReviewId int64  `json:"review_id" binding:"required,gt=0"`
+	Content  string `json:"content" binding:"required,max=500"`
+}
+
+// ReviewHide 管理员隐藏或恢复展示评价
+type ReviewHide struct {
+	ReviewId int64  `json:"review_id" binding:"required,gt=0"`
+	Hidden   bool   `json:"hidden"`
+	Reason   string `json:"reason" binding:"max=100"`
+}
@@ -0,0 +1,24 @@
+package request
+
+// SeckillActivityCreate 创建秒杀活动请求, 时间格式为 2006-01-02 15:04:05
+type SeckillActivityCreate struct {
+	Title        string `json:"title" binding:"required,max=64"`
+	SkuId        int64  `json:"sku_id" binding:"required,gt=0"`
+	SeckillPrice int64  `json:"seckill_price" binding:"required,gt=0"`
+	TotalStock   int    `json:"total_stock" binding:"required,gt=0"`
+	PerUserLimit int    `json:"per_user_limit" binding:"required,gt=0,lte=99"`
+	StartAt      string `json:"start_at" binding:"required,datetime=2006-01-02 15:04:05"`
+	EndAt        string `json:"end_at" binding:"required,datetime=2006-01-02 15:04:05"`
+}
+
+// SeckillWarmup 手动预热秒杀库存请求
+type SeckillWarmup struct {
+	ActivityId int64 `json:"activity_id" binding:"required,gt=0"`
+}
+
+// SeckillGrab 抢购请求
+type SeckillGrab struct {
+	ActivityId int64            `json:"activity_id" binding:"required,gt=0"`
+	Quantity   int              `json:"quantity" binding:"required,gt=0,lte=99"`
+	Address    *ShippingAddress `json:"address" binding:"required"`
+}
@@ -0,0 +1,8 @@
+package request
+
+// OrderShip 订单发货请求
+type OrderShip struct {
+	OrderNo    string `json:"order_no" binding:"required"`
+	Carrier    string `json:"carrier" binding:"required,max=32"`
+	TrackingNo string `json:"tracking_no" binding:"required,max=64"`
+}
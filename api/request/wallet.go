@@ -0,0 +1,28 @@
+package request
+
+// GiftCardRedeem 兑换礼品卡
+type GiftCardRedeem struct {
+	Code string `json:"code" binding:"required,max=32"`
+}
+
+// GiftCardIssue 平台发行礼品卡, 批次号作为幂等键, 重复提交同一批次不会重复发行
+type GiftCardIssue struct {
+	BatchNo    string `json:"batch_no" binding:"required,max=64"`
+	Count      int    `json:"count" binding:"required,min=1,max=1000"`
+	Amount     int64  `json:"amount" binding:"required,min=1"`      // 面值, 单位分
+	ExpireDays int    `json:"expire_days" binding:"required,min=1"` // 发行后多少天内可以兑换
+}
+
+// GiftCardList 礼品卡列表查询, 不传时查询全部
+type GiftCardList struct {
+	BatchNo string `form:"batch_no" binding:"max=64"`
+	State   int8   `form:"state" binding:"omitempty,oneof=1 2"`
+}
+
+// WalletAdjust 人工调整用户钱包余额, 幂等键防止重复调账
+type WalletAdjust struct {
+	UserId         int64  `json:"user_id" binding:"required,gt=0"`
+	Amount         int64  `json:"amount" binding:"required"` // 正数加款, 负数扣款, 单位分
+	Remark         string `json:"remark" binding:"required,max=255"`
+	IdempotencyKey string `json:"idempotency_key" binding:"required,max=64"`
+}
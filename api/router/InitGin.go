@@ -2,10 +2,16 @@ package router
 
 import (
 	"errors"
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/external/carrier"
+	"github.com/Cospk/go-mall/external/payment"
+	"github.com/Cospk/go-mall/pkg/config"
 	"github.com/Cospk/go-mall/pkg/errcode"
 	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/metrics"
 	"github.com/Cospk/go-mall/pkg/middleware"
 	"github.com/gin-gonic/gin"
+	"net/http"
 )
 
 func InitWebRouter() *gin.Engine {
@@ -15,9 +21,14 @@ func InitWebRouter() *gin.Engine {
 			"message": "pong",
 		})
 	})
+	// 健康检查探针请求频繁, 在挂载中间件之前注册, 不生成链路ID也不记录访问日志
+	Router.GET("/healthz", controller.Healthz)
+	Router.GET("/readyz", controller.Readyz)
+	// 监控指标, 生产环境应该在网关上限制只允许内网的Prometheus访问
+	Router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// 使用中间件
-	Router.Use(middleware.TraceMiddleware(), middleware.LoggerMiddleware(), middleware.RecoveryMiddleware())
+	Router.Use(middleware.TraceMiddleware(), middleware.MetricsMiddleware(), middleware.LoggerMiddleware(), middleware.RecoveryMiddleware())
 
 	Router.GET("/customized-error-test", func(c *gin.Context) {
 
@@ -39,10 +50,32 @@ func InitWebRouter() *gin.Engine {
 
 	})
 
+	// 开发测试环境挂载模拟支付网关和模拟物流网关, 不依赖真实的支付渠道和物流公司走通支付、发货流程
+	if config.AppConfig().Env != "prod" {
+		mockGateway := payment.NewMockGateway(config.Payment().Mock.Secret, config.Payment().Mock.NotifyDelay)
+		Router.Any("/mock-gateway/*path", gin.WrapH(http.StripPrefix("/mock-gateway", mockGateway)))
+		mockCarrier := carrier.NewMockGateway(config.Carrier().Mock.StepInterval)
+		Router.Any("/mock-carrier/*path", gin.WrapH(http.StripPrefix("/mock-carrier", mockCarrier)))
+	}
+
 	router := Router.Group("api/v1")
+	router.Use(middleware.RateLimitMiddleware("default"))
 	// 注册路由
 	RegisterUserRouter(router)
 	RegisterDemoRouter(router)
+	RegisterOrderRouter(router)
+	RegisterPaymentRouter(router)
+	RegisterAfterSaleRouter(router)
+	RegisterCouponRouter(router)
+	RegisterSeckillRouter(router)
+	RegisterReviewRouter(router)
+	RegisterMemberRouter(router)
+	RegisterWalletRouter(router)
+	RegisterFavoriteRouter(router)
+	RegisterNotificationRouter(router)
+	RegisterEventRouter(router)
+	RegisterMerchantRouter(router)
+	RegisterAdminRouter(router)
 
 	return Router
 }
@@ -0,0 +1,76 @@
+package router
+
+import (
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminRouter(router *gin.RouterGroup) {
+	AdminRouter := router.Group("/admin/")
+	AdminRouter.Use(middleware.AuthMiddleware(), middleware.AdminMiddleware())
+	{
+		// 延时队列中等待执行的任务
+		AdminRouter.GET("delay-queue/jobs", controller.PendingDelayJobs)
+		// 开通店铺
+		AdminRouter.POST("merchant/create", controller.CreateMerchant)
+		// 店铺列表
+		AdminRouter.GET("merchant/list", controller.MerchantList)
+		// 开启或关闭店铺
+		AdminRouter.POST("merchant/state", controller.SetMerchantState)
+		// 修改SKU售价和库存
+		AdminRouter.POST("goods/sku/update", controller.UpdateGoodsSku)
+		// 订单列表
+		AdminRouter.GET("order/list", controller.AdminOrderList)
+		// 订单详情
+		AdminRouter.GET("order/info/:order_no", controller.AdminOrderInfo)
+		// 订单发货
+		AdminRouter.POST("order/ship", controller.ShipOrder)
+		// 订单物流
+		AdminRouter.GET("order/shipments/:order_no", controller.AdminOrderShipments)
+		// 创建运费模板
+		AdminRouter.POST("freight/template/create", controller.CreateFreightTemplate)
+		// 运费模板列表
+		AdminRouter.GET("freight/template/list", controller.FreightTemplateList)
+		// 设置商品的运费模板
+		AdminRouter.POST("freight/template/bind-goods", controller.BindGoodsFreightTemplate)
+		// 售后单列表
+		AdminRouter.GET("after-sale/list", controller.AdminAfterSaleList)
+		// 售后单详情
+		AdminRouter.GET("after-sale/info/:after_sale_no", controller.AdminAfterSaleInfo)
+		// 同意售后申请
+		AdminRouter.POST("after-sale/approve", controller.ApproveAfterSale)
+		// 驳回售后申请
+		AdminRouter.POST("after-sale/reject", controller.RejectAfterSale)
+		// 确认收到退货
+		AdminRouter.POST("after-sale/confirm-return", controller.ConfirmAfterSaleReturn)
+		// 创建优惠券模板
+		AdminRouter.POST("coupon/template/create", controller.CreateCouponTemplate)
+		// 优惠券模板列表
+		AdminRouter.GET("coupon/template/list", controller.CouponTemplateList)
+		// 发放优惠券
+		AdminRouter.POST("coupon/issue", controller.IssueCoupons)
+		// 评价列表
+		AdminRouter.GET("review/list", controller.AdminReviewList)
+		// 回复评价
+		AdminRouter.POST("review/reply", controller.ReplyReview)
+		// 隐藏或恢复展示评价
+		AdminRouter.POST("review/hide", controller.HideReview)
+		// 发行礼品卡
+		AdminRouter.POST("gift-card/issue", controller.IssueGiftCards)
+		// 礼品卡列表
+		AdminRouter.GET("gift-card/list", controller.GiftCardList)
+		// 人工调整钱包余额
+		AdminRouter.POST("wallet/adjust", controller.AdjustWallet)
+		// 手动执行钱包对账
+		AdminRouter.GET("wallet/ledger-check", controller.CheckLedger)
+		// 发布全体用户的广播消息
+		AdminRouter.POST("notification/broadcast", controller.BroadcastNotification)
+		// 给指定用户发站内消息
+		AdminRouter.POST("notification/send", controller.SendNotification)
+		// 创建秒杀活动
+		AdminRouter.POST("seckill/activity/create", controller.CreateSeckillActivity)
+		// 手动预热秒杀库存
+		AdminRouter.POST("seckill/activity/warmup", controller.WarmUpSeckillActivity)
+	}
+}
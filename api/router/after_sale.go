@@ -0,0 +1,24 @@
+package router
+
+import (
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAfterSaleRouter(router *gin.RouterGroup) {
+	AfterSaleRouter := router.Group("/after-sale/")
+	AfterSaleRouter.Use(middleware.AuthMiddleware())
+	{
+		// 申请售后
+		AfterSaleRouter.POST("apply", middleware.IdempotencyMiddleware(), controller.ApplyAfterSale)
+		// 售后单列表
+		AfterSaleRouter.GET("list", controller.AfterSaleList)
+		// 售后单详情, 包含处理记录
+		AfterSaleRouter.GET("info/:after_sale_no", controller.AfterSaleInfo)
+		// 撤销售后申请
+		AfterSaleRouter.POST("cancel", controller.CancelAfterSale)
+		// 填写退货物流
+		AfterSaleRouter.POST("return", controller.SubmitAfterSaleReturn)
+	}
+}
@@ -0,0 +1,22 @@
+package router
+
+import (
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCouponRouter(router *gin.RouterGroup) {
+	CouponRouter := router.Group("/coupon/")
+	CouponRouter.Use(middleware.AuthMiddleware())
+	{
+		// 可领取的优惠券
+		CouponRouter.GET("claimable", controller.ClaimableCoupons)
+		// 领取优惠券
+		CouponRouter.POST("claim", controller.ClaimCoupon)
+		// 我的优惠券
+		CouponRouter.GET("mine", controller.UserCoupons)
+		// 结算页可用优惠券和最优组合
+		CouponRouter.POST("checkout", controller.CouponCheckout)
+	}
+}
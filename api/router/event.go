@@ -0,0 +1,16 @@
+package router
+
+import (
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterEventRouter(router *gin.RouterGroup) {
+	EventRouter := router.Group("/event/")
+	EventRouter.Use(middleware.StreamAuthMiddleware())
+	{
+		// SSE实时推送订单状态变化和新消息
+		EventRouter.GET("stream", controller.EventStream)
+	}
+}
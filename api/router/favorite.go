@@ -0,0 +1,35 @@
+package router
+
+import (
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterFavoriteRouter(router *gin.RouterGroup) {
+	FavoriteRouter := router.Group("/favorite/")
+	FavoriteRouter.Use(middleware.AuthMiddleware())
+	{
+		// 收藏商品
+		FavoriteRouter.POST("add", controller.AddFavorite)
+		// 取消收藏
+		FavoriteRouter.POST("remove", controller.RemoveFavorite)
+		// 我的收藏夹
+		FavoriteRouter.GET("list", controller.FavoriteList)
+	}
+
+	SubscriptionRouter := router.Group("/subscription/")
+	{
+		// 通知里的退订链接
+		SubscriptionRouter.GET("unsubscribe", controller.Unsubscribe)
+	}
+	SubscriptionRouter.Use(middleware.AuthMiddleware())
+	{
+		// 订阅到货或降价提醒
+		SubscriptionRouter.POST("create", controller.Subscribe)
+		// 退订提醒
+		SubscriptionRouter.POST("cancel", controller.CancelSubscription)
+		// 我订阅中的提醒
+		SubscriptionRouter.GET("list", controller.SubscriptionList)
+	}
+}
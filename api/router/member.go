@@ -0,0 +1,22 @@
+package router
+
+import (
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterMemberRouter(router *gin.RouterGroup) {
+	MemberRouter := router.Group("/member/")
+	{
+		// 会员等级及权益
+		MemberRouter.GET("tiers", controller.MemberTiers)
+	}
+	MemberRouter.Use(middleware.AuthMiddleware())
+	{
+		// 我的会员信息和积分余额
+		MemberRouter.GET("info", controller.MemberInfo)
+		// 我的积分流水
+		MemberRouter.GET("points/ledger", controller.PointsLedgers)
+	}
+}
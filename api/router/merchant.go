@@ -0,0 +1,45 @@
+package router
+
+import (
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterMerchantRouter 商家后台, 与管理后台共用处理函数, 由MerchantMiddleware把数据范围限定在本店铺
+func RegisterMerchantRouter(router *gin.RouterGroup) {
+	MerchantRouter := router.Group("/merchant/")
+	MerchantRouter.Use(middleware.AuthMiddleware(), middleware.MerchantMiddleware())
+	{
+		// 店铺信息
+		MerchantRouter.GET("info", controller.MerchantInfo)
+		// 店铺商品列表
+		MerchantRouter.GET("goods/list", controller.MerchantGoodsList)
+		// 批量上下架商品
+		MerchantRouter.POST("goods/state", controller.SetMerchantGoodsState)
+		// 修改SKU售价和库存
+		MerchantRouter.POST("goods/sku/update", controller.UpdateGoodsSku)
+		// 店铺订单列表
+		MerchantRouter.GET("order/list", controller.AdminOrderList)
+		// 店铺订单详情
+		MerchantRouter.GET("order/info/:order_no", controller.AdminOrderInfo)
+		// 订单发货
+		MerchantRouter.POST("order/ship", controller.ShipOrder)
+		// 订单物流
+		MerchantRouter.GET("order/shipments/:order_no", controller.AdminOrderShipments)
+		// 售后单列表
+		MerchantRouter.GET("after-sale/list", controller.AdminAfterSaleList)
+		// 售后单详情
+		MerchantRouter.GET("after-sale/info/:after_sale_no", controller.AdminAfterSaleInfo)
+		// 同意售后申请
+		MerchantRouter.POST("after-sale/approve", controller.ApproveAfterSale)
+		// 驳回售后申请
+		MerchantRouter.POST("after-sale/reject", controller.RejectAfterSale)
+		// 确认收到退货
+		MerchantRouter.POST("after-sale/confirm-return", controller.ConfirmAfterSaleReturn)
+		// 评价列表
+		MerchantRouter.GET("review/list", controller.AdminReviewList)
+		// 回复评价
+		MerchantRouter.POST("review/reply", controller.ReplyReview)
+	}
+}
@@ -0,0 +1,22 @@
+package router
+
+import (
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterNotificationRouter(router *gin.RouterGroup) {
+	NotificationRouter := router.Group("/notification/")
+	NotificationRouter.Use(middleware.AuthMiddleware())
+	{
+		// 我的站内消息
+		NotificationRouter.GET("list", controller.NotificationList)
+		// 标记已读
+		NotificationRouter.POST("read", controller.ReadNotifications)
+		// 全部标记为已读
+		NotificationRouter.POST("read-all", controller.ReadAllNotifications)
+		// 未读消息数
+		NotificationRouter.GET("unread-count", controller.NotificationUnreadCount)
+	}
+}
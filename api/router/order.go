@@ -0,0 +1,30 @@
+package router
+
+import (
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterOrderRouter(router *gin.RouterGroup) {
+	OrderRouter := router.Group("/order/")
+	OrderRouter.Use(middleware.AuthMiddleware(), middleware.RateLimitMiddleware("order"))
+	{
+		// 结算页试算
+		OrderRouter.POST("checkout", controller.OrderCheckout)
+		// 创建订单
+		OrderRouter.POST("create", middleware.IdempotencyMiddleware(), controller.CreateOrder)
+		// 订单列表
+		OrderRouter.GET("list", controller.OrderList)
+		// 订单详情
+		OrderRouter.GET("info/:order_no", controller.OrderInfo)
+		// 订单物流
+		OrderRouter.GET("shipments/:order_no", controller.OrderShipments)
+		// 订单状态流转记录
+		OrderRouter.GET("state-logs/:order_no", controller.OrderStateLogs)
+		// 取消订单
+		OrderRouter.POST("cancel", controller.CancelOrder)
+		// 确认收货
+		OrderRouter.POST("confirm-receipt", controller.ConfirmOrderReceipt)
+	}
+}
@@ -0,0 +1,22 @@
+package router
+
+import (
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterPaymentRouter(router *gin.RouterGroup) {
+	PaymentRouter := router.Group("/payment/")
+	{
+		// 支付渠道异步通知, 由渠道调用, 通过签名校验
+		PaymentRouter.POST("notify/:provider", controller.PaymentNotify)
+	}
+	PaymentRouter.Use(middleware.AuthMiddleware())
+	{
+		// 发起支付
+		PaymentRouter.POST("create", middleware.IdempotencyMiddleware(), controller.CreatePayment)
+		// 查询支付结果
+		PaymentRouter.GET("info/:payment_no", controller.PaymentInfo)
+	}
+}
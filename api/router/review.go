@@ -0,0 +1,24 @@
+package router
+
+import (
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterReviewRouter(router *gin.RouterGroup) {
+	ReviewRouter := router.Group("/review/")
+	{
+		// 商品评价列表
+		ReviewRouter.GET("goods", controller.GoodsReviews)
+		// 商品评分汇总
+		ReviewRouter.GET("summary", controller.ReviewSummary)
+	}
+	ReviewRouter.Use(middleware.AuthMiddleware())
+	{
+		// 评价商品
+		ReviewRouter.POST("create", controller.CreateReview)
+		// 我的评价
+		ReviewRouter.GET("mine", controller.UserReviews)
+	}
+}
@@ -0,0 +1,22 @@
+package router
+
+import (
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSeckillRouter(router *gin.RouterGroup) {
+	SeckillRouter := router.Group("/seckill/")
+	{
+		// 秒杀活动列表
+		SeckillRouter.GET("activity/list", controller.SeckillActivityList)
+	}
+	SeckillRouter.Use(middleware.AuthMiddleware(), middleware.RateLimitMiddleware("seckill"))
+	{
+		// 抢购
+		SeckillRouter.POST("grab", middleware.IdempotencyMiddleware(), controller.SeckillGrab)
+		// 轮询抢购结果
+		SeckillRouter.GET("result/:ticket", controller.SeckillResult)
+	}
+}
@@ -10,9 +10,9 @@ func RegisterUserRouter(router *gin.RouterGroup) {
 	UserRouter := router.Group("/user/")
 	{
 		// 注册
-		UserRouter.POST("register", controller.RegisterUser)
+		UserRouter.POST("register", middleware.RateLimitMiddleware("auth"), controller.RegisterUser)
 		// 登录
-		UserRouter.POST("login", controller.LoginUser)
+		UserRouter.POST("login", middleware.RateLimitMiddleware("auth"), controller.LoginUser)
 	}
 	UserRouter.Use(middleware.AuthMiddleware())
 	{
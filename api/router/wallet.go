@@ -0,0 +1,20 @@
+package router
+
+import (
+	"github.com/Cospk/go-mall/api/controller"
+	"github.com/Cospk/go-mall/pkg/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterWalletRouter(router *gin.RouterGroup) {
+	WalletRouter := router.Group("/wallet/")
+	WalletRouter.Use(middleware.AuthMiddleware())
+	{
+		// 我的钱包余额
+		WalletRouter.GET("info", controller.WalletInfo)
+		// 我的钱包收支明细
+		WalletRouter.GET("entries", controller.WalletEntries)
+		// 兑换礼品卡
+		WalletRouter.POST("gift-card/redeem", middleware.IdempotencyMiddleware(), controller.RedeemGiftCard)
+	}
+}
@@ -1,15 +1,31 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/Cospk/go-mall/api/router"
+	"github.com/Cospk/go-mall/external/carrier"
+	"github.com/Cospk/go-mall/external/notify"
+	"github.com/Cospk/go-mall/external/payment"
 	"github.com/Cospk/go-mall/internal/dal/cache"
 	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/task"
 	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/health"
 	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/tracing"
 	"go.uber.org/zap"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
+	flag.Parse()
 
 	// 初始化配置
 	config.InitConfig()
@@ -17,19 +33,178 @@ func main() {
 	// 初始化日志
 	logger.InitLogger()
 
-	//初始化数据库
-	dao.InitGorm()
+	// 初始化链路追踪
+	tracing.InitTracer()
 
-	// 初始化缓存
-	cache.InitRedis()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// 初始化数据库和缓存
+	if err := initDependencies(ctx); err != nil {
+		logger.Logger.Error("依赖初始化失败, 服务退出", zap.Error(err))
+		_ = dao.CloseGorm()
+		_ = cache.CloseRedis()
+		_ = logger.Logger.Sync()
+		os.Exit(1)
+	}
+
+	// 接口限流和幂等
+	cache.InitRateLimiter()
+	cache.InitIdempotency()
+
+	// 启动实时推送的事件总线
+	cache.InitEventBus()
+	cache.EventBus().Start(context.Background())
+
+	// 启动后台任务
+	task.InitDelayQueue()
+	task.StartWorkers(context.Background())
+
+	// 注册支付渠道
+	payment.InitProviders()
+
+	// 注册物流公司
+	carrier.InitCarriers()
+
+	// 注册消息触达渠道
+	notify.InitSenders()
+
+	// 注册健康检查项
+	registerHealthChecks()
 
 	// 初始化路由
 	Router := router.InitWebRouter()
 
-	err := Router.Run("127.0.0.1:8080")
+	serverConfig := config.AppConfig().Server
+	addr := serverConfig.Addr
+	if addr == "" {
+		addr = "127.0.0.1:8080"
+	}
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           Router,
+		ReadTimeout:       serverConfig.ReadTimeout,
+		ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+		WriteTimeout:      serverConfig.WriteTimeout,
+		IdleTimeout:       serverConfig.IdleTimeout,
+	}
+	// SSE长连接不会自己结束, 开始关闭时先停掉事件总线, 让推送连接退出
+	server.RegisterOnShutdown(cache.EventBus().Stop)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Logger.Info("服务启动", zap.String("addr", addr))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	var startErr error
+	select {
+	case startErr = <-serverErr:
+		if startErr != nil {
+			logger.Logger.Error("服务启动失败", zap.Error(startErr))
+		}
+	case <-ctx.Done():
+		logger.Logger.Info("收到退出信号, 开始关闭服务")
+	}
+	// 再次收到信号时按默认行为直接退出
+	stop()
+
+	shutdown(server)
+	if startErr != nil {
+		os.Exit(1)
+	}
+}
+
+// initDependencies 连接数据库和Redis
+// 开启app.startup.wait_dependencies时依赖不可用会按退避间隔重试, 直到超过等待时间或者收到退出信号
+func initDependencies(ctx context.Context) error {
+	if err := waitFor(ctx, "mysql", dao.InitGorm); err != nil {
+		return err
+	}
+	return waitFor(ctx, "redis", cache.InitRedis)
+}
+
+func waitFor(ctx context.Context, name string, init func() error) error {
+	startup := config.AppConfig().Startup
+	if !startup.WaitDependencies {
+		return init()
+	}
+	if startup.WaitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, startup.WaitTimeout)
+		defer cancel()
+	}
+	interval := startup.RetryInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	for attempt := 1; ; attempt++ {
+		err := init()
+		if err == nil {
+			return nil
+		}
+		logger.Logger.Warn("依赖暂不可用, 等待重试", zap.String("dependency", name),
+			zap.Int("attempt", attempt), zap.Duration("retryAfter", interval), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("等待%s可用失败(%v): %w", name, ctx.Err(), err)
+		case <-time.After(interval):
+		}
+		interval = min(interval*2, maxRetryInterval)
+	}
+}
+
+const maxRetryInterval = 30 * time.Second
 
-	if err != nil {
-		logger.Logger.Info("服务启动失败", zap.Error(err))
+// registerHealthChecks 后台协程放在存活检查里, 外部依赖放在就绪检查里
+func registerHealthChecks() {
+	health.AddLiveness("delay_queue", task.DelayQueue().Check)
+	health.AddLiveness("event_bus", cache.EventBus().Check)
+	health.AddReadiness("mysql_master", dao.PingMaster)
+	health.AddReadiness("mysql_slave", dao.PingSlave)
+	health.AddReadiness("redis", cache.PingRedis)
+}
+
+// shutdown 按顺序关闭服务: 停止接收新请求并等待处理中的请求完成, 停止后台任务, 最后关闭数据库和Redis连接
+func shutdown(server *http.Server) {
+	timeout := config.AppConfig().Server.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	// 就绪检查先返回未就绪, 等负载均衡摘掉这个实例后再停止接收请求, 等待期间请求照常处理
+	health.SetDraining()
+	if delay := config.AppConfig().Server.DrainDelay; delay > 0 {
+		logger.Logger.Info("等待负载均衡摘除实例", zap.Duration("drainDelay", delay))
+		time.Sleep(delay)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Logger.Error("等待请求处理完成超时, 强制关闭连接", zap.Error(err))
+		_ = server.Close()
 	}
 
+	// 等待已领取的延时任务处理完成, 和等待请求共用同一个超时时间
+	// 未处理的任务和超时时还没执行完的任务, 租约到期后由其他实例重新领取
+	if err := task.StopWorkers(ctx); err != nil {
+		logger.Logger.Error("等待延时任务处理完成超时", zap.Error(err))
+	}
+
+	if err := dao.CloseGorm(); err != nil {
+		logger.Logger.Error("关闭数据库连接失败", zap.Error(err))
+	}
+	if err := cache.CloseRedis(); err != nil {
+		logger.Logger.Error("关闭Redis连接失败", zap.Error(err))
+	}
+	// 所有请求和后台任务都结束后再导出剩余的链路数据
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer flushCancel()
+	if err := tracing.Shutdown(flushCtx); err != nil {
+		logger.Logger.Error("导出链路数据失败", zap.Error(err))
+	}
+	logger.Logger.Info("服务已关闭")
+	_ = logger.Logger.Sync()
 }
@@ -0,0 +1,56 @@
+package carrier
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/pkg/config"
+	"time"
+)
+
+// carrier 对接物流公司的轨迹查询, 每家物流公司实现一份Carrier, 上层只依赖Carrier接口
+
+// Carrier 物流公司
+type Carrier interface {
+	// Code 物流公司编码, 发货时填写的carrier与它对应
+	Code() string
+	// Name 物流公司名称
+	Name() string
+	// Track 查询物流单号的完整轨迹, 轨迹按发生时间升序
+	Track(ctx context.Context, trackingNo string) ([]*TrackEvent, error)
+}
+
+// TrackStatus 物流轨迹节点的状态
+type TrackStatus string
+
+const (
+	TrackStatusPickedUp       TrackStatus = "PICKED_UP"        // 已揽收
+	TrackStatusInTransit      TrackStatus = "IN_TRANSIT"       // 运输中
+	TrackStatusOutForDelivery TrackStatus = "OUT_FOR_DELIVERY" // 派送中
+	TrackStatusDelivered      TrackStatus = "DELIVERED"        // 已签收
+	TrackStatusException      TrackStatus = "EXCEPTION"        // 异常
+)
+
+// TrackEvent 物流轨迹节点
+type TrackEvent struct {
+	Time        time.Time
+	Status      TrackStatus
+	Location    string
+	Description string
+}
+
+var carriers = map[string]Carrier{}
+
+// Register 注册物流公司
+func Register(carrier Carrier) {
+	carriers[carrier.Code()] = carrier
+}
+
+// GetCarrier 按编码获取物流公司
+func GetCarrier(code string) (Carrier, bool) {
+	carrier, ok := carriers[code]
+	return carrier, ok
+}
+
+// InitCarriers 根据配置注册项目支持的物流公司
+func InitCarriers() {
+	Register(NewMockCarrier(config.Carrier().Mock.GatewayUrl))
+}
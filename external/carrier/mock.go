@@ -0,0 +1,71 @@
+package carrier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/utils/httptool"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const CarrierMock = "mock"
+
+// MockCarrier 对接本地模拟物流网关(MockGateway)的物流公司, 开发测试环境走通发货和签收流程用
+type MockCarrier struct {
+	gatewayUrl string
+}
+
+func NewMockCarrier(gatewayUrl string) *MockCarrier {
+	return &MockCarrier{gatewayUrl: gatewayUrl}
+}
+
+func (c *MockCarrier) Code() string {
+	return CarrierMock
+}
+
+func (c *MockCarrier) Name() string {
+	return "模拟快递"
+}
+
+// mockTrackEvent 模拟网关返回的轨迹节点
+type mockTrackEvent struct {
+	Time        int64       `json:"time"` // 发生时间的Unix时间戳
+	Status      TrackStatus `json:"status"`
+	Location    string      `json:"location"`
+	Description string      `json:"description"`
+}
+
+func (c *MockCarrier) Track(ctx context.Context, trackingNo string) ([]*TrackEvent, error) {
+	httpCode, respBody, err := httptool.Get(ctx, c.gatewayUrl+"/track?tracking_no="+url.QueryEscape(trackingNo))
+	if err != nil {
+		return nil, errcode.Wrap("请求模拟物流网关失败", err)
+	}
+	if httpCode != http.StatusOK {
+		return nil, errcode.Wrap("请求模拟物流网关失败", fmt.Errorf("http status %d", httpCode))
+	}
+	reply := new(struct {
+		Code int               `json:"code"`
+		Msg  string            `json:"msg"`
+		Data []*mockTrackEvent `json:"data"`
+	})
+	if err = json.Unmarshal(respBody, reply); err != nil {
+		return nil, errcode.Wrap("解析模拟物流网关响应失败", err)
+	}
+	if reply.Code != 0 {
+		return nil, errcode.Wrap("模拟物流网关返回错误", errors.New(reply.Msg))
+	}
+	events := make([]*TrackEvent, 0, len(reply.Data))
+	for _, event := range reply.Data {
+		events = append(events, &TrackEvent{
+			Time:        time.Unix(event.Time, 0),
+			Status:      event.Status,
+			Location:    event.Location,
+			Description: event.Description,
+		})
+	}
+	return events, nil
+}
@@ -0,0 +1,87 @@
+package carrier
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MockGateway 本地模拟的物流公司轨迹查询接口, 只在开发测试环境挂载
+// 物流单号第一次被查询时视为揽收, 之后每隔stepInterval前进一个节点, 直到签收
+type MockGateway struct {
+	stepInterval time.Duration
+	mux          *http.ServeMux
+
+	mu       sync.Mutex
+	pickedAt map[string]time.Time // tracking_no -> 揽收时间
+}
+
+// mockRoute 模拟的轨迹节点, 按顺序逐个出现
+var mockRoute = []struct {
+	status      TrackStatus
+	location    string
+	description string
+}{
+	{TrackStatusPickedUp, "深圳市", "快递员已揽收"},
+	{TrackStatusInTransit, "深圳转运中心", "快件已到达深圳转运中心"},
+	{TrackStatusInTransit, "上海转运中心", "快件已到达上海转运中心"},
+	{TrackStatusOutForDelivery, "上海市浦东新区", "快递员正在派送"},
+	{TrackStatusDelivered, "上海市浦东新区", "快件已签收, 签收人: 本人"},
+}
+
+func NewMockGateway(stepInterval time.Duration) *MockGateway {
+	if stepInterval <= 0 {
+		stepInterval = time.Minute
+	}
+	gateway := &MockGateway{
+		stepInterval: stepInterval,
+		mux:          http.NewServeMux(),
+		pickedAt:     make(map[string]time.Time),
+	}
+	gateway.mux.HandleFunc("/track", gateway.track)
+	return gateway
+}
+
+func (g *MockGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+func (g *MockGateway) track(w http.ResponseWriter, r *http.Request) {
+	trackingNo := r.URL.Query().Get("tracking_no")
+	if trackingNo == "" {
+		g.reply(w, 1, "invalid params", nil)
+		return
+	}
+	g.mu.Lock()
+	pickedAt, ok := g.pickedAt[trackingNo]
+	if !ok {
+		pickedAt = time.Now()
+		g.pickedAt[trackingNo] = pickedAt
+	}
+	g.mu.Unlock()
+
+	steps := int(time.Since(pickedAt)/g.stepInterval) + 1
+	if steps > len(mockRoute) {
+		steps = len(mockRoute)
+	}
+	events := make([]*mockTrackEvent, 0, steps)
+	for i := 0; i < steps; i++ {
+		events = append(events, &mockTrackEvent{
+			Time:        pickedAt.Add(time.Duration(i) * g.stepInterval).Unix(),
+			Status:      mockRoute[i].status,
+			Location:    mockRoute[i].location,
+			Description: mockRoute[i].description,
+		})
+	}
+	g.reply(w, 0, "ok", events)
+}
+
+func (g *MockGateway) reply(w http.ResponseWriter, code int, msg string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": code,
+		"msg":  msg,
+		"data": data,
+	})
+}
@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/pkg/logger"
+)
+
+const ChannelLog = "log"
+
+// LogSender 只把通知写到日志的渠道, 开发测试环境在没有短信、推送通道时用来确认通知内容
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Channel() string {
+	return ChannelLog
+}
+
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	logger.NewLogger(ctx).Info("NotificationSent", "channel", ChannelLog, "userId", msg.UserId, "title", msg.Title,
+		"content", msg.Content, "link", msg.Link, "unsubscribeUrl", msg.UnsubscribeUrl)
+	return nil
+}
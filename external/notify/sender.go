@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"context"
+)
+
+// notify 对接站外的触达渠道, 如短信、邮件、App推送, 每个渠道实现一份Sender, 上层只依赖Sender接口
+
+// Message 发给用户的一条通知
+type Message struct {
+	UserId         int64
+	Title          string
+	Content        string
+	Link           string // 点击通知打开的地址
+	UnsubscribeUrl string // 退订地址, 营销类通知必须携带
+}
+
+// Sender 触达渠道
+type Sender interface {
+	// Channel 渠道编码
+	Channel() string
+	// Send 发送通知, 渠道内部按用户ID查找手机号、邮箱或设备
+	Send(ctx context.Context, msg *Message) error
+}
+
+var senders []Sender
+
+// Register 注册触达渠道
+func Register(sender Sender) {
+	senders = append(senders, sender)
+}
+
+// Senders 已注册的全部触达渠道, 一条通知会发到每个渠道
+func Senders() []Sender {
+	return senders
+}
+
+// InitSenders 注册项目支持的触达渠道
+func InitSenders() {
+	Register(NewLogSender())
+}
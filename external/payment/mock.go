@@ -0,0 +1,155 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/utils/httptool"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	ProviderMock = "mock"
+
+	mockSignatureHeader = "X-Mock-Signature"
+)
+
+// MockProvider 对接本地模拟支付网关(MockGateway)的支付渠道, 开发测试环境走通支付流程用
+type MockProvider struct {
+	gatewayUrl string
+	secret     string
+}
+
+func NewMockProvider(gatewayUrl, secret string) *MockProvider {
+	return &MockProvider{gatewayUrl: gatewayUrl, secret: secret}
+}
+
+func (p *MockProvider) Name() string {
+	return ProviderMock
+}
+
+// mockGatewayReply 模拟网关统一的响应格式
+type mockGatewayReply struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// mockTrade 模拟网关的交易数据, 查询接口和异步通知共用
+type mockTrade struct {
+	PaymentNo string     `json:"payment_no"`
+	TradeNo   string     `json:"trade_no"`
+	Amount    int64      `json:"amount"`
+	State     TradeState `json:"state"`
+	PaidAt    int64      `json:"paid_at"` // 支付时间的Unix时间戳
+}
+
+func (p *MockProvider) CreatePayment(ctx context.Context, req *CreatePaymentRequest) (*CreatePaymentResult, error) {
+	reqBody, _ := json.Marshal(map[string]interface{}{
+		"payment_no": req.PaymentNo,
+		"amount":     req.Amount,
+		"subject":    req.Subject,
+		"notify_url": req.NotifyUrl,
+		"expire_at":  req.ExpireAt.Unix(),
+	})
+	result := new(CreatePaymentResult)
+	err := p.post(ctx, "/pay/create", reqBody, &struct {
+		TradeNo *string `json:"trade_no"`
+		PayUrl  *string `json:"pay_url"`
+	}{&result.TradeNo, &result.PayUrl})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (p *MockProvider) QueryPayment(ctx context.Context, paymentNo string) (*QueryPaymentResult, error) {
+	httpCode, respBody, err := httptool.Get(ctx, p.gatewayUrl+"/pay/query?payment_no="+url.QueryEscape(paymentNo))
+	trade := new(mockTrade)
+	if err = p.parseReply(httpCode, respBody, err, trade); err != nil {
+		return nil, err
+	}
+	return &QueryPaymentResult{
+		PaymentNo: trade.PaymentNo,
+		TradeNo:   trade.TradeNo,
+		State:     trade.State,
+		Amount:    trade.Amount,
+		PaidAt:    time.Unix(trade.PaidAt, 0),
+	}, nil
+}
+
+func (p *MockProvider) Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error) {
+	reqBody, _ := json.Marshal(map[string]interface{}{
+		"payment_no": req.PaymentNo,
+		"refund_no":  req.RefundNo,
+		"amount":     req.Amount,
+		"reason":     req.Reason,
+	})
+	result := &RefundResult{RefundNo: req.RefundNo}
+	err := p.post(ctx, "/pay/refund", reqBody, &struct {
+		RefundTradeNo *string `json:"refund_trade_no"`
+		Success       *bool   `json:"success"`
+	}{&result.RefundTradeNo, &result.Success})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (p *MockProvider) VerifyNotification(ctx context.Context, header http.Header, body []byte) (*Notification, error) {
+	signature := header.Get(mockSignatureHeader)
+	if !hmac.Equal([]byte(signature), []byte(mockSign(p.secret, body))) {
+		return nil, ErrInvalidSignature
+	}
+	trade := new(mockTrade)
+	if err := json.Unmarshal(body, trade); err != nil {
+		return nil, errcode.Wrap("解析模拟支付通知失败", err)
+	}
+	return &Notification{
+		PaymentNo: trade.PaymentNo,
+		TradeNo:   trade.TradeNo,
+		State:     trade.State,
+		Amount:    trade.Amount,
+		PaidAt:    time.Unix(trade.PaidAt, 0),
+	}, nil
+}
+
+func (p *MockProvider) post(ctx context.Context, path string, reqBody []byte, data interface{}) error {
+	httpCode, respBody, err := httptool.Post(ctx, p.gatewayUrl+path, reqBody)
+	return p.parseReply(httpCode, respBody, err, data)
+}
+
+// parseReply 解析网关响应, 网关返回的业务错误也转换成error
+func (p *MockProvider) parseReply(httpCode int, respBody []byte, err error, data interface{}) error {
+	if err != nil {
+		return errcode.Wrap("请求模拟支付网关失败", err)
+	}
+	if httpCode != http.StatusOK {
+		return errcode.Wrap("请求模拟支付网关失败", fmt.Errorf("http status %d", httpCode))
+	}
+	reply := new(mockGatewayReply)
+	if err = json.Unmarshal(respBody, reply); err != nil {
+		return errcode.Wrap("解析模拟支付网关响应失败", err)
+	}
+	if reply.Code != 0 {
+		return errcode.Wrap("模拟支付网关返回错误", errors.New(reply.Msg))
+	}
+	if err = json.Unmarshal(reply.Data, data); err != nil {
+		return errcode.Wrap("解析模拟支付网关响应失败", err)
+	}
+	return nil
+}
+
+// mockSign 对通知内容做HMAC-SHA256签名
+func mockSign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
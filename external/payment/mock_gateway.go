@@ -0,0 +1,162 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"github.com/Cospk/go-mall/pkg/utils/httptool"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MockGateway 本地模拟的第三方支付网关, 只在开发测试环境挂载
+// 创建支付单后等待notifyDelay模拟用户完成支付, 然后像真实网关一样向notify_url发送签名的异步通知,
+// 商户未确认时按退避时间重发, 确认后还会再补发一次重复通知, 用来验证回调处理的幂等性
+type MockGateway struct {
+	secret      string
+	notifyDelay time.Duration
+	mux         *http.ServeMux
+
+	mu       sync.Mutex
+	trades   map[string]*mockTrade // payment_no -> 交易
+	refunds  map[string]string     // refund_no -> 退款交易号
+	refunded map[string]int64      // payment_no -> 已退款总金额
+}
+
+func NewMockGateway(secret string, notifyDelay time.Duration) *MockGateway {
+	gateway := &MockGateway{
+		secret:      secret,
+		notifyDelay: notifyDelay,
+		mux:         http.NewServeMux(),
+		trades:      make(map[string]*mockTrade),
+		refunds:     make(map[string]string),
+		refunded:    make(map[string]int64),
+	}
+	gateway.mux.HandleFunc("/pay/create", gateway.create)
+	gateway.mux.HandleFunc("/pay/query", gateway.query)
+	gateway.mux.HandleFunc("/pay/refund", gateway.refund)
+	return gateway
+}
+
+func (g *MockGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+func (g *MockGateway) create(w http.ResponseWriter, r *http.Request) {
+	req := new(struct {
+		PaymentNo string `json:"payment_no"`
+		Amount    int64  `json:"amount"`
+		NotifyUrl string `json:"notify_url"`
+	})
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil || req.PaymentNo == "" || req.Amount <= 0 {
+		g.reply(w, 1, "invalid params", nil)
+		return
+	}
+
+	g.mu.Lock()
+	trade, existed := g.trades[req.PaymentNo]
+	if !existed {
+		trade = &mockTrade{
+			PaymentNo: req.PaymentNo,
+			TradeNo:   "MOCK" + utils.RandNumStr(20),
+			Amount:    req.Amount,
+			State:     TradeStatePending,
+		}
+		g.trades[req.PaymentNo] = trade
+	}
+	tradeNo := trade.TradeNo
+	g.mu.Unlock()
+
+	if !existed {
+		go g.payAndNotify(req.PaymentNo, req.NotifyUrl)
+	}
+	g.reply(w, 0, "ok", map[string]string{
+		"trade_no": tradeNo,
+		"pay_url":  "mockpay://pay?trade_no=" + tradeNo,
+	})
+}
+
+func (g *MockGateway) query(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	trade, ok := g.trades[r.URL.Query().Get("payment_no")]
+	var data mockTrade
+	if ok {
+		data = *trade
+	}
+	g.mu.Unlock()
+	if !ok {
+		g.reply(w, 1, "trade not found", nil)
+		return
+	}
+	g.reply(w, 0, "ok", data)
+}
+
+func (g *MockGateway) refund(w http.ResponseWriter, r *http.Request) {
+	req := new(struct {
+		PaymentNo string `json:"payment_no"`
+		RefundNo  string `json:"refund_no"`
+		Amount    int64  `json:"amount"`
+	})
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil || req.RefundNo == "" {
+		g.reply(w, 1, "invalid params", nil)
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	trade, ok := g.trades[req.PaymentNo]
+	if !ok || trade.State != TradeStateSuccess || req.Amount <= 0 || req.Amount > trade.Amount {
+		g.reply(w, 1, "refund not allowed", nil)
+		return
+	}
+	// 同一个退款单号重复请求返回同一笔退款, 新的退款累计金额不能超过支付金额
+	refundTradeNo, existed := g.refunds[req.RefundNo]
+	if !existed {
+		if g.refunded[req.PaymentNo]+req.Amount > trade.Amount {
+			g.reply(w, 1, "refund amount exceeds paid amount", nil)
+			return
+		}
+		refundTradeNo = "MOCKRF" + utils.RandNumStr(18)
+		g.refunds[req.RefundNo] = refundTradeNo
+		g.refunded[req.PaymentNo] += req.Amount
+	}
+	g.reply(w, 0, "ok", map[string]interface{}{
+		"refund_trade_no": refundTradeNo,
+		"success":         true,
+	})
+}
+
+// payAndNotify 模拟用户完成支付后网关发送异步通知
+func (g *MockGateway) payAndNotify(paymentNo, notifyUrl string) {
+	time.Sleep(g.notifyDelay)
+
+	g.mu.Lock()
+	trade := g.trades[paymentNo]
+	trade.State = TradeStateSuccess
+	trade.PaidAt = time.Now().Unix()
+	body, _ := json.Marshal(trade)
+	g.mu.Unlock()
+
+	ctx := context.Background()
+	headers := map[string]string{mockSignatureHeader: mockSign(g.secret, body)}
+	for attempt := 1; attempt <= 5; attempt++ {
+		httpCode, _, err := httptool.Post(ctx, notifyUrl, body, httptool.WithHeaders(headers))
+		if err == nil && httpCode == http.StatusOK {
+			// 真实网关偶尔会重复通知, 这里固定补发一次
+			httptool.Post(ctx, notifyUrl, body, httptool.WithHeaders(headers))
+			return
+		}
+		logger.NewLogger(ctx).Warn("MockGatewayNotifyFailed", "paymentNo", paymentNo, "attempt", attempt, "httpCode", httpCode, "err", err)
+		time.Sleep(time.Duration(attempt) * time.Second)
+	}
+}
+
+func (g *MockGateway) reply(w http.ResponseWriter, code int, msg string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": code,
+		"msg":  msg,
+		"data": data,
+	})
+}
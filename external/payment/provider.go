@@ -0,0 +1,99 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/pkg/config"
+	"net/http"
+	"time"
+)
+
+// payment 对接第三方支付渠道, 每个渠道实现一份Provider, 上层只依赖Provider接口
+
+// Provider 支付渠道
+type Provider interface {
+	// Name 渠道名称, 与回调地址中的{provider}对应
+	Name() string
+	// CreatePayment 在渠道创建支付单, 返回拉起支付用的地址
+	CreatePayment(ctx context.Context, req *CreatePaymentRequest) (*CreatePaymentResult, error)
+	// QueryPayment 主动查询支付单状态, 用于回调丢失时补偿
+	QueryPayment(ctx context.Context, paymentNo string) (*QueryPaymentResult, error)
+	// Refund 发起退款
+	Refund(ctx context.Context, req *RefundRequest) (*RefundResult, error)
+	// VerifyNotification 校验异步通知的签名并解析通知内容, 签名不正确时返回ErrInvalidSignature
+	VerifyNotification(ctx context.Context, header http.Header, body []byte) (*Notification, error)
+}
+
+// TradeState 渠道侧的交易状态
+type TradeState string
+
+const (
+	TradeStatePending TradeState = "PENDING" // 待支付
+	TradeStateSuccess TradeState = "SUCCESS" // 支付成功
+	TradeStateClosed  TradeState = "CLOSED"  // 已关闭
+)
+
+var ErrInvalidSignature = errors.New("invalid notification signature")
+
+type CreatePaymentRequest struct {
+	PaymentNo string    // 商户侧支付单号
+	Amount    int64     // 支付金额(分)
+	Subject   string    // 支付标题
+	NotifyUrl string    // 异步通知地址
+	ExpireAt  time.Time // 支付单过期时间
+}
+
+type CreatePaymentResult struct {
+	TradeNo string // 渠道侧交易号
+	PayUrl  string // 拉起支付的地址
+}
+
+type QueryPaymentResult struct {
+	PaymentNo string
+	TradeNo   string
+	State     TradeState
+	Amount    int64
+	PaidAt    time.Time
+}
+
+type RefundRequest struct {
+	PaymentNo   string // 原支付单号
+	RefundNo    string // 商户侧退款单号, 渠道按退款单号做幂等
+	Amount      int64  // 本次退款金额(分)
+	TotalAmount int64  // 原支付金额(分)
+	Reason      string
+}
+
+type RefundResult struct {
+	RefundNo      string
+	RefundTradeNo string // 渠道侧退款交易号
+	Success       bool
+}
+
+// Notification 支付结果异步通知
+type Notification struct {
+	PaymentNo string
+	TradeNo   string
+	State     TradeState
+	Amount    int64
+	PaidAt    time.Time
+}
+
+var providers = map[string]Provider{}
+
+// Register 注册支付渠道
+func Register(provider Provider) {
+	providers[provider.Name()] = provider
+}
+
+// GetProvider 按名称获取支付渠道
+func GetProvider(name string) (Provider, bool) {
+	provider, ok := providers[name]
+	return provider, ok
+}
+
+// InitProviders 根据配置注册项目支持的支付渠道
+func InitProviders() {
+	mockConfig := config.Payment().Mock
+	Register(NewMockProvider(mockConfig.GatewayUrl, mockConfig.Secret))
+}
@@ -2,7 +2,10 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/metrics"
+	"github.com/Cospk/go-mall/pkg/tracing"
 	"github.com/redis/go-redis/v9"
 	"time"
 )
@@ -13,22 +16,43 @@ func Redis() *redis.Client {
 	return RedisClient
 }
 
-func InitRedis() {
-	RedisClient = redis.NewClient(&redis.Options{
-		Addr:         config.Redis.Address,
-		Password:     config.Redis.Password,
-		PoolSize:     config.Redis.PoolSize, // 连接池大小
-		DB:           config.Redis.DB,       // use default DB
-		DialTimeout:  10 * time.Second,      // 连接超时
-		ReadTimeout:  30 * time.Second,      // 读取超时
-		WriteTimeout: 30 * time.Second,      // 写入超时
-		PoolTimeout:  30 * time.Second,      // 当所有连接都处在繁忙状态时，客户端等待可用连接的最大等待时长，默认为读超时+1秒
-	})
+// InitRedis 创建Redis客户端并检查连接, 连接失败时返回错误由调用方决定重试还是退出
+// 客户端自带连接池和断线重连, 重试时只重新Ping, 不重复创建客户端
+func InitRedis() error {
+	if RedisClient == nil {
+		redisConfig := config.Redis()
+		RedisClient = redis.NewClient(&redis.Options{
+			Addr:         redisConfig.Address,
+			Password:     redisConfig.Password,
+			PoolSize:     redisConfig.PoolSize, // 连接池大小
+			DB:           redisConfig.DB,       // use default DB
+			DialTimeout:  10 * time.Second,     // 连接超时
+			ReadTimeout:  30 * time.Second,     // 读取超时
+			WriteTimeout: 30 * time.Second,     // 写入超时
+			PoolTimeout:  30 * time.Second,     // 当所有连接都处在繁忙状态时，客户端等待可用连接的最大等待时长，默认为读超时+1秒
+		})
+		// 记录命令耗时、连接池状态和链路
+		RedisClient.AddHook(metrics.RedisHook{})
+		RedisClient.AddHook(tracing.RedisHook{})
+		metrics.RegisterRedisPoolStats(RedisClient)
+	}
+	return RedisClient.Ping(context.Background()).Err()
+}
+
+// PingRedis 检查Redis是否可用, 用于就绪检查
+func PingRedis(ctx context.Context) error {
+	if RedisClient == nil {
+		return errors.New("Redis未初始化")
+	}
+	return RedisClient.Ping(ctx).Err()
+}
 
-	if err := RedisClient.Ping(context.Background()).Err(); err != nil {
-		// 连接失败停止程序，若是缓存无影响可注释掉
-		panic(err)
+// CloseRedis 关闭Redis连接池, 服务退出时最后调用
+func CloseRedis() error {
+	if RedisClient == nil {
+		return nil
 	}
+	return RedisClient.Close()
 }
 
 // 注：
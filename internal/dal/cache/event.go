@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/eventbus"
+)
+
+var eventBus *eventbus.Bus
+
+// EventBus 推送订单状态、站内消息等用户事件的总线
+func EventBus() *eventbus.Bus {
+	return eventBus
+}
+
+// InitEventBus 初始化事件总线, 需要在InitRedis之后调用
+func InitEventBus() {
+	realtimeConfig := config.AppConfig().Realtime
+	eventBus = eventbus.New(Redis(), enum.REDIS_KEY_EVENT_BUS,
+		eventbus.WithMaxLen(realtimeConfig.StreamMaxLen),
+		eventbus.WithRetention(realtimeConfig.StreamRetention),
+		eventbus.WithBufferSize(realtimeConfig.BufferSize),
+	)
+}
@@ -0,0 +1,23 @@
+package cache
+
+import (
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/idempotency"
+)
+
+var idempotencyStore *idempotency.Store
+
+// Idempotency 保存Idempotency-Key对应响应的存储
+func Idempotency() *idempotency.Store {
+	return idempotencyStore
+}
+
+// InitIdempotency 初始化幂等键存储, 需要在InitRedis之后调用
+func InitIdempotency() {
+	idempotencyConfig := config.AppConfig().Idempotency
+	idempotencyStore = idempotency.New(Redis(), enum.REDIS_KEY_IDEMPOTENCY,
+		idempotency.WithTTL(idempotencyConfig.TTL),
+		idempotency.WithLockTimeout(idempotencyConfig.LockTimeout),
+	)
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// incrUnreadScript 未读数已经缓存时才累加, 没有缓存时等下次查询从数据库统计, 避免只有增量的缓存
+var incrUnreadScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HINCRBY', KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
+
+// GetUnreadCounts 读取缓存的各类型未读数, 没有缓存时返回 nil
+func GetUnreadCounts(ctx context.Context, userId int64) (map[int8]int64, error) {
+	values, err := Redis().HGetAll(ctx, fmt.Sprintf(enum.REDIS_KEY_NOTIFY_UNREAD, userId)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	counts := make(map[int8]int64, len(values))
+	for field, value := range values {
+		messageType, err := strconv.ParseInt(field, 10, 8)
+		if err != nil {
+			continue
+		}
+		count, _ := strconv.ParseInt(value, 10, 64)
+		counts[int8(messageType)] = max(count, 0)
+	}
+	return counts, nil
+}
+
+// SetUnreadCounts 缓存从数据库统计的各类型未读数, 每个类型都写一个字段, 没有未读的写0, 保证缓存存在
+func SetUnreadCounts(ctx context.Context, userId int64, counts map[int8]int64, ttl time.Duration) error {
+	key := fmt.Sprintf(enum.REDIS_KEY_NOTIFY_UNREAD, userId)
+	values := make(map[string]interface{}, len(enum.NotificationTypes))
+	for _, messageType := range enum.NotificationTypes {
+		values[strconv.Itoa(int(messageType))] = counts[messageType]
+	}
+	pipe := Redis().TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, values)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// IncrUnreadCount 收到新消息后累加未读数
+func IncrUnreadCount(ctx context.Context, userId int64, messageType int8, delta int64) error {
+	key := fmt.Sprintf(enum.REDIS_KEY_NOTIFY_UNREAD, userId)
+	return incrUnreadScript.Run(ctx, Redis(), []string{key}, messageType, delta).Err()
+}
+
+// DelUnreadCounts 标记已读后删除未读数缓存, 下次查询时重新统计
+func DelUnreadCounts(ctx context.Context, userId int64) error {
+	return Redis().Del(ctx, fmt.Sprintf(enum.REDIS_KEY_NOTIFY_UNREAD, userId)).Err()
+}
@@ -0,0 +1,18 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"time"
+)
+
+// SetNotifyDedup 占用通知的去重键, 返回false表示有效期内已经发送过
+func SetNotifyDedup(ctx context.Context, dedupKey string, ttl time.Duration) (bool, error) {
+	return Redis().SetNX(ctx, fmt.Sprintf(enum.REDIS_KEY_NOTIFY_DEDUP, dedupKey), 1, ttl).Result()
+}
+
+// DelNotifyDedup 通知发送失败时释放去重键, 允许重试
+func DelNotifyDedup(ctx context.Context, dedupKey string) error {
+	return Redis().Del(ctx, fmt.Sprintf(enum.REDIS_KEY_NOTIFY_DEDUP, dedupKey)).Err()
+}
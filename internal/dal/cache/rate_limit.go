@@ -0,0 +1,21 @@
+package cache
+
+import (
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/ratelimit"
+)
+
+var rateLimiter *ratelimit.Limiter
+
+// RateLimiter 接口限流器, 规则由限流中间件按路由传入
+func RateLimiter() *ratelimit.Limiter {
+	return rateLimiter
+}
+
+// InitRateLimiter 初始化限流器, 需要在InitRedis之后调用
+func InitRateLimiter() {
+	rateLimiter = ratelimit.New(Redis(), enum.REDIS_KEY_RATE_LIMIT,
+		ratelimit.WithFallbackCooldown(config.AppConfig().RateLimit.FallbackCooldown),
+	)
+}
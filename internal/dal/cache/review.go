@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// SetRatingSummary 缓存商品的评分汇总
+func SetRatingSummary(ctx context.Context, summary *do.RatingSummary, ttl time.Duration) error {
+	data, _ := json.Marshal(summary)
+	return Redis().Set(ctx, fmt.Sprintf(enum.REDIS_KEY_REVIEW_SUMMARY, summary.GoodsId), data, ttl).Err()
+}
+
+// GetRatingSummary 读取缓存的评分汇总, 没有缓存时返回 nil
+func GetRatingSummary(ctx context.Context, goodsId int64) (*do.RatingSummary, error) {
+	data, err := Redis().Get(ctx, fmt.Sprintf(enum.REDIS_KEY_REVIEW_SUMMARY, goodsId)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	summary := new(do.RatingSummary)
+	if err = json.Unmarshal(data, summary); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// DelRatingSummary 评价有变化时删除评分汇总缓存, 下次查询时重新统计
+func DelRatingSummary(ctx context.Context, goodsId int64) error {
+	return Redis().Del(ctx, fmt.Sprintf(enum.REDIS_KEY_REVIEW_SUMMARY, goodsId)).Err()
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// 秒杀库存扣减脚本的返回值
+const (
+	SeckillDeductOk          = 0 // 扣减成功
+	SeckillDeductSoldOut     = 1 // 库存不足
+	SeckillDeductLimit       = 2 // 超过每人限购数量
+	SeckillDeductNotWarmedUp = 3 // 库存还没有预热
+)
+
+// seckillDeductScript 原子地校验库存和用户限购并扣减
+// KEYS[1] 库存key KEYS[2] 用户已购数量key; ARGV[1] 用户ID ARGV[2] 数量 ARGV[3] 每人限购数量
+var seckillDeductScript = redis.NewScript(`
+local stock = redis.call('GET', KEYS[1])
+if not stock then
+	return 3
+end
+local quantity = tonumber(ARGV[2])
+if tonumber(stock) < quantity then
+	return 1
+end
+local bought = tonumber(redis.call('HGET', KEYS[2], ARGV[1]) or '0')
+if bought + quantity > tonumber(ARGV[3]) then
+	return 2
+end
+redis.call('DECRBY', KEYS[1], quantity)
+redis.call('HINCRBY', KEYS[2], ARGV[1], quantity)
+local ttl = redis.call('TTL', KEYS[1])
+if ttl > 0 then
+	redis.call('EXPIRE', KEYS[2], ttl)
+end
+return 0
+`)
+
+// seckillRestoreScript 创建订单失败时退回库存和用户已购数量, 库存key已过期时不再退回
+var seckillRestoreScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('INCRBY', KEYS[1], ARGV[2])
+local bought = redis.call('HINCRBY', KEYS[2], ARGV[1], -tonumber(ARGV[2]))
+if bought <= 0 then
+	redis.call('HDEL', KEYS[2], ARGV[1])
+end
+return 1
+`)
+
+// SetSeckillActivity 缓存秒杀活动信息, 抢购时不再查库
+func SetSeckillActivity(ctx context.Context, activity *do.SeckillActivity, ttl time.Duration) error {
+	data, _ := json.Marshal(activity)
+	return Redis().Set(ctx, fmt.Sprintf(enum.REDIS_KEY_SECKILL_ACTIVITY, activity.ID), data, ttl).Err()
+}
+
+// GetSeckillActivity 读取缓存的秒杀活动信息, 还没有预热时返回 nil
+func GetSeckillActivity(ctx context.Context, activityId int64) (*do.SeckillActivity, error) {
+	data, err := Redis().Get(ctx, fmt.Sprintf(enum.REDIS_KEY_SECKILL_ACTIVITY, activityId)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	activity := new(do.SeckillActivity)
+	if err = json.Unmarshal(data, activity); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// InitSeckillStock 预热库存, 库存已经存在时不覆盖, 避免活动进行中重复预热把已扣减的库存加回去
+func InitSeckillStock(ctx context.Context, activityId int64, stock int, ttl time.Duration) (bool, error) {
+	return Redis().SetNX(ctx, fmt.Sprintf(enum.REDIS_KEY_SECKILL_STOCK, activityId), stock, ttl).Result()
+}
+
+// GetSeckillStock 查询剩余库存, 还没有预热时返回-1
+func GetSeckillStock(ctx context.Context, activityId int64) (int, error) {
+	stock, err := Redis().Get(ctx, fmt.Sprintf(enum.REDIS_KEY_SECKILL_STOCK, activityId)).Int()
+	if errors.Is(err, redis.Nil) {
+		return -1, nil
+	}
+	return stock, err
+}
+
+// DeductSeckillStock 扣减秒杀库存, 返回值见SeckillDeductXXX
+func DeductSeckillStock(ctx context.Context, activityId, userId int64, quantity, perUserLimit int) (int, error) {
+	keys := []string{
+		fmt.Sprintf(enum.REDIS_KEY_SECKILL_STOCK, activityId),
+		fmt.Sprintf(enum.REDIS_KEY_SECKILL_BOUGHT, activityId),
+	}
+	return seckillDeductScript.Run(ctx, Redis(), keys, userId, quantity, perUserLimit).Int()
+}
+
+// RestoreSeckillStock 退回秒杀库存和用户已购数量
+func RestoreSeckillStock(ctx context.Context, activityId, userId int64, quantity int) error {
+	keys := []string{
+		fmt.Sprintf(enum.REDIS_KEY_SECKILL_STOCK, activityId),
+		fmt.Sprintf(enum.REDIS_KEY_SECKILL_BOUGHT, activityId),
+	}
+	return seckillRestoreScript.Run(ctx, Redis(), keys, userId, quantity).Err()
+}
+
+// AdmitSeckillRequest 按秒计数的准入控制, 当前这一秒放行的请求超过qps时返回false
+func AdmitSeckillRequest(ctx context.Context, activityId int64, qps int) (bool, error) {
+	key := fmt.Sprintf(enum.REDIS_KEY_SECKILL_GATE, activityId, time.Now().Unix())
+	pipe := Redis().TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, 2*time.Second)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return incr.Val() <= int64(qps), nil
+}
+
+// SetSeckillResult 保存抢购结果
+func SetSeckillResult(ctx context.Context, result *do.SeckillResult, ttl time.Duration) error {
+	data, _ := json.Marshal(result)
+	return Redis().Set(ctx, fmt.Sprintf(enum.REDIS_KEY_SECKILL_RESULT, result.Ticket), data, ttl).Err()
+}
+
+// GetSeckillResult 查询抢购结果, 不存在或已过期时返回 nil
+func GetSeckillResult(ctx context.Context, ticket string) (*do.SeckillResult, error) {
+	data, err := Redis().Get(ctx, fmt.Sprintf(enum.REDIS_KEY_SECKILL_RESULT, ticket)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := new(do.SeckillResult)
+	if err = json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -3,25 +3,41 @@ package dao
 import (
 	"context"
 	"errors"
+	"github.com/Cospk/go-mall/pkg/config"
 	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/metrics"
+	"github.com/Cospk/go-mall/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
+	"strings"
 	"time"
 )
 
 // GormLogger 实现 gormLogger.Interface，并使用自定义 Logger 记录日志
 type GormLogger struct {
-	SlowThreshold time.Duration
+	SlowThreshold time.Duration // 为0时使用配置文件中的database.slow_threshold, 配置热加载后立即生效
 }
 
 func NewGormLogger() *GormLogger {
-	return &GormLogger{
-		SlowThreshold: 500 * time.Millisecond, // 超过500毫秒算慢查询, 如果需要按环境定制化, 这个放到配置文件中
-	}
+	return &GormLogger{}
 }
 
 func (l *GormLogger) LogMode(lev gormLogger.LogLevel) gormLogger.Interface {
-	return &GormLogger{}
+	return &GormLogger{SlowThreshold: l.SlowThreshold}
+}
+
+// slowThreshold 慢查询阈值, 没有配置时为500毫秒
+func (l *GormLogger) slowThreshold() time.Duration {
+	if l.SlowThreshold > 0 {
+		return l.SlowThreshold
+	}
+	if threshold := config.Database().SlowThreshold; threshold > 0 {
+		return threshold
+	}
+	return 500 * time.Millisecond
 }
 func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
 	logger.NewLogger(ctx).Info(msg, "data", data)
@@ -34,17 +50,63 @@ func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{})
 }
 func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
 	// 获取运行时间
-	duration := time.Since(begin).Milliseconds()
+	elapsed := time.Since(begin)
+	duration := elapsed.Milliseconds()
 	// 获取 SQL 语句和返回条数
 	sql, rows := fc()
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	operation := sqlOperation(sql)
+	metrics.ObserveSQL(operation, failed, elapsed)
+	traceSQL(ctx, operation, sql, rows, err, failed, begin)
 	// Gorm 错误时记录错误日志
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+	if failed {
 		logger.NewLogger(ctx).Error("SQL ERROR", "sql", sql, "rows", rows, "dur(ms)", duration)
 	}
 	// 慢查询日志
-	if duration > l.SlowThreshold.Milliseconds() {
+	if duration > l.slowThreshold().Milliseconds() {
 		logger.NewLogger(ctx).Warn("SQL SLOW", "sql", sql, "rows", rows, "dur(ms)", duration)
 	} else {
 		logger.NewLogger(ctx).Debug("SQL DEBUG", "sql", sql, "rows", rows, "dur(ms)", duration)
 	}
 }
+
+// sqlOperations 按语句的第一个关键字区分SQL类型, 其他语句统一记为other
+var sqlOperations = map[string]struct{}{
+	"select": {}, "insert": {}, "update": {}, "delete": {},
+}
+
+func sqlOperation(sql string) string {
+	keyword, _, _ := strings.Cut(strings.TrimSpace(sql), " ")
+	keyword = strings.ToLower(keyword)
+	if _, ok := sqlOperations[keyword]; ok {
+		return keyword
+	}
+	return "other"
+}
+
+// sqlSpanLimit span中记录的SQL最大长度, 批量插入的语句可能很长
+const sqlSpanLimit = 2048
+
+// traceSQL 按SQL的开始时间补记一个span, 只记录处于请求链路中的SQL
+func traceSQL(ctx context.Context, operation, sql string, rows int64, err error, failed bool, begin time.Time) {
+	_, span := tracing.StartChild(ctx, "SQL "+strings.ToUpper(operation),
+		trace.WithTimestamp(begin),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "mysql"),
+			attribute.String("db.operation", operation),
+		),
+	)
+	if !span.IsRecording() {
+		return
+	}
+	if len(sql) > sqlSpanLimit {
+		sql = sql[:sqlSpanLimit]
+	}
+	span.SetAttributes(attribute.String("db.statement", sql), attribute.Int64("db.rows_affected", rows))
+	if failed {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
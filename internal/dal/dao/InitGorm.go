@@ -1,8 +1,11 @@
 package dao
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/metrics"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -18,15 +21,87 @@ func DBMaster() *gorm.DB {
 	return _DBMaster
 }
 
-func InitGorm() {
-	_DBMaster = initDB(config.Database.Master)
-	_DBSlave = initDB(config.Database.Slave)
+type afterCommitKey struct{}
+
+// Transaction 在主库上开启事务, fn返回error时回滚
+// 需要在同一个事务里执行的Dao方法统一接收tx参数
+func Transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	hooks := make([]func(), 0)
+	ctx = context.WithValue(ctx, afterCommitKey{}, &hooks)
+	if err := DBMaster().WithContext(ctx).Transaction(fn); err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit 注册事务提交后执行的操作, 事务回滚时不执行
+// 用于推送事件等不能回滚的副作用, tx不是Transaction开启的事务时立即执行
+func AfterCommit(tx *gorm.DB, hook func()) {
+	hooks, ok := tx.Statement.Context.Value(afterCommitKey{}).(*[]func())
+	if !ok {
+		hook()
+		return
+	}
+	*hooks = append(*hooks, hook)
+}
+
+// InitGorm 连接主库和从库, 连接失败时返回错误由调用方决定重试还是退出
+// 重试时已经连上的库不会重复创建连接池
+func InitGorm() error {
+	var err error
+	if _DBMaster == nil {
+		if _DBMaster, err = initDB(config.Database().Master); err != nil {
+			return fmt.Errorf("连接主库失败: %w", err)
+		}
+		registerDBStats("master", _DBMaster)
+	}
+	if _DBSlave == nil {
+		if _DBSlave, err = initDB(config.Database().Slave); err != nil {
+			return fmt.Errorf("连接从库失败: %w", err)
+		}
+		registerDBStats("slave", _DBSlave)
+	}
+	return nil
+}
+
+// CloseGorm 关闭主库和从库的连接池, 服务退出时在所有请求和后台任务结束后调用
+func CloseGorm() error {
+	var errs []error
+	for _, db := range []*gorm.DB{_DBMaster, _DBSlave} {
+		if err := closeDB(db); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PingMaster 检查主库是否可用, 用于就绪检查
+func PingMaster(ctx context.Context) error {
+	return pingDB(ctx, _DBMaster)
+}
+
+// PingSlave 检查从库是否可用, 用于就绪检查
+func PingSlave(ctx context.Context) error {
+	return pingDB(ctx, _DBSlave)
+}
+
+func pingDB(ctx context.Context, db *gorm.DB) error {
+	if db == nil {
+		return errors.New("数据库未初始化")
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
 }
 
 // initDB 初始化数据库
-func initDB(option config.DbConnectOption) *gorm.DB {
-	fmt.Println(option)
-	// 创建数据库连接（参数：驱动和日志记录器）
+func initDB(option config.DbConnectOption) (*gorm.DB, error) {
+	// 创建数据库连接（参数：驱动和日志记录器）, 创建时会Ping一次数据库
 	db, err := gorm.Open(
 		getDialector(option.Type, option.DSN),
 		&gorm.Config{
@@ -34,18 +109,41 @@ func initDB(option config.DbConnectOption) *gorm.DB {
 		},
 	)
 	if err != nil {
-		panic(err)
+		// Ping失败时gorm仍然会返回创建好的连接池, 关掉避免重试时泄漏
+		_ = closeDB(db)
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
 	}
-	sqlDB, _ := db.DB()
 	// 设置连接池参数
 	sqlDB.SetMaxOpenConns(option.MaxOpenConn)
 	sqlDB.SetMaxIdleConns(option.MaxIdleConn)
 	sqlDB.SetConnMaxLifetime(option.MaxLifeTime)
 	if err = sqlDB.Ping(); err != nil {
-		// 测试连接，若失败了直接panic
-		panic(err)
+		_ = sqlDB.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// registerDBStats 采集连接池状态, 用于监控连接数是否够用
+func registerDBStats(name string, db *gorm.DB) {
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDBStats(name, sqlDB)
+	}
+}
+
+func closeDB(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
 	}
-	return db
+	return sqlDB.Close()
 }
 
 func getDialector(t, dsn string) gorm.Dialector {
@@ -0,0 +1,107 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"gorm.io/gorm"
+)
+
+type AfterSaleDao struct {
+	ctx context.Context
+}
+
+func NewAfterSaleDao(ctx context.Context) *AfterSaleDao {
+	return &AfterSaleDao{ctx: ctx}
+}
+
+// afterSaleClosedStates 已结束的售后单状态
+var afterSaleClosedStates = []int8{enum.AfterSaleStateRejected, enum.AfterSaleStateRefunded, enum.AfterSaleStateCancelled}
+
+func (dao *AfterSaleDao) CreateAfterSale(tx *gorm.DB, afterSale *model.AfterSale) error {
+	return tx.Create(afterSale).Error
+}
+
+// FindAfterSaleByNo 根据售后单号查询, 不存在时返回 nil
+func (dao *AfterSaleDao) FindAfterSaleByNo(afterSaleNo string) (*model.AfterSale, error) {
+	afterSale := new(model.AfterSale)
+	err := DBMaster().WithContext(dao.ctx).Where("after_sale_no = ?", afterSaleNo).First(afterSale).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return afterSale, nil
+}
+
+// FindOrderAfterSales 查询订单下的所有售后单
+func (dao *AfterSaleDao) FindOrderAfterSales(tx *gorm.DB, orderId int64) ([]*model.AfterSale, error) {
+	afterSales := make([]*model.AfterSale, 0)
+	err := tx.Where("order_id = ?", orderId).Find(&afterSales).Error
+	return afterSales, err
+}
+
+// FindAfterSales 分页查询售后单, userId、merchantId、state为0时不作为查询条件
+func (dao *AfterSaleDao) FindAfterSales(userId, merchantId int64, state int8, offset, limit int) ([]*model.AfterSale, int64, error) {
+	afterSales := make([]*model.AfterSale, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.AfterSale{})
+	if userId != 0 {
+		query = query.Where("user_id = ?", userId)
+	}
+	if merchantId != 0 {
+		query = query.Where("merchant_id = ?", merchantId)
+	}
+	if state != 0 {
+		query = query.Where("state = ?", state)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&afterSales).Error
+	return afterSales, total, err
+}
+
+// CountOrderOpenAfterSales 统计订单下还没有结束的售后单数量
+func (dao *AfterSaleDao) CountOrderOpenAfterSales(tx *gorm.DB, orderId int64) (int64, error) {
+	var count int64
+	err := tx.Model(&model.AfterSale{}).
+		Where("order_id = ? AND state NOT IN ?", orderId, afterSaleClosedStates).
+		Count(&count).Error
+	return count, err
+}
+
+// UpdateAfterSaleState 基于版本号的乐观锁更新售后单状态, fields为需要同时更新的字段
+func (dao *AfterSaleDao) UpdateAfterSaleState(tx *gorm.DB, afterSaleId int64, fromState, toState int8, version int, fields map[string]interface{}) error {
+	updates := map[string]interface{}{
+		"state":   toState,
+		"version": gorm.Expr("version + 1"),
+	}
+	for column, value := range fields {
+		updates[column] = value
+	}
+	result := tx.Model(&model.AfterSale{}).
+		Where("id = ? AND state = ? AND version = ?", afterSaleId, fromState, version).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errcode.ErrAfterSaleConcurrent
+	}
+	return nil
+}
+
+func (dao *AfterSaleDao) CreateAfterSaleLog(tx *gorm.DB, afterSaleLog *model.AfterSaleLog) error {
+	return tx.Create(afterSaleLog).Error
+}
+
+// FindAfterSaleLogs 查询售后单的处理记录
+func (dao *AfterSaleDao) FindAfterSaleLogs(afterSaleId int64) ([]*model.AfterSaleLog, error) {
+	logs := make([]*model.AfterSaleLog, 0)
+	err := DB().WithContext(dao.ctx).Where("after_sale_id = ?", afterSaleId).Order("id ASC").Find(&logs).Error
+	return logs, err
+}
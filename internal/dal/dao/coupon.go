@@ -0,0 +1,168 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type CouponDao struct {
+	ctx context.Context
+}
+
+func NewCouponDao(ctx context.Context) *CouponDao {
+	return &CouponDao{ctx: ctx}
+}
+
+func (dao *CouponDao) CreateTemplate(template *model.CouponTemplate) error {
+	return DBMaster().WithContext(dao.ctx).Create(template).Error
+}
+
+// FindTemplateById 查询优惠券模板, 不存在时返回 nil
+func (dao *CouponDao) FindTemplateById(templateId int64) (*model.CouponTemplate, error) {
+	template := new(model.CouponTemplate)
+	err := DB().WithContext(dao.ctx).Where("id = ?", templateId).First(template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// LockTemplate 在事务中对模板加行锁, 串行化同一模板的领取, 保证每人限领数量的校验不被并发绕过
+func (dao *CouponDao) LockTemplate(tx *gorm.DB, templateId int64) (*model.CouponTemplate, error) {
+	template := new(model.CouponTemplate)
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", templateId).First(template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (dao *CouponDao) FindTemplatesByIds(templateIds []int64) ([]*model.CouponTemplate, error) {
+	templates := make([]*model.CouponTemplate, 0, len(templateIds))
+	err := DB().WithContext(dao.ctx).Where("id IN ?", templateIds).Find(&templates).Error
+	return templates, err
+}
+
+// FindClaimableTemplates 查询当前在领取时间内且可以主动领取的模板
+func (dao *CouponDao) FindClaimableTemplates(now time.Time) ([]*model.CouponTemplate, error) {
+	templates := make([]*model.CouponTemplate, 0)
+	err := DB().WithContext(dao.ctx).
+		Where("claimable = ? AND claim_start_at <= ? AND claim_end_at > ?", true, now, now).
+		Where("total_count = 0 OR issued_count < total_count").
+		Order("id DESC").Find(&templates).Error
+	return templates, err
+}
+
+// FindTemplates 分页查询所有模板
+func (dao *CouponDao) FindTemplates(offset, limit int) ([]*model.CouponTemplate, int64, error) {
+	templates := make([]*model.CouponTemplate, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.CouponTemplate{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&templates).Error
+	return templates, total, err
+}
+
+// IncrIssuedCount 增加模板的已发放数量, 超过发放总量时返回ErrCouponSoldOut
+func (dao *CouponDao) IncrIssuedCount(tx *gorm.DB, templateId int64) error {
+	result := tx.Model(&model.CouponTemplate{}).
+		Where("id = ? AND (total_count = 0 OR issued_count < total_count)", templateId).
+		Update("issued_count", gorm.Expr("issued_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errcode.ErrCouponSoldOut
+	}
+	return nil
+}
+
+// CountUserTemplateCoupons 统计用户持有某个模板的优惠券张数, 已使用的也计算在内
+func (dao *CouponDao) CountUserTemplateCoupons(tx *gorm.DB, userId, templateId int64) (int64, error) {
+	var count int64
+	err := tx.Model(&model.UserCoupon{}).
+		Where("user_id = ? AND template_id = ?", userId, templateId).
+		Count(&count).Error
+	return count, err
+}
+
+func (dao *CouponDao) CreateUserCoupon(tx *gorm.DB, userCoupon *model.UserCoupon) error {
+	return tx.Create(userCoupon).Error
+}
+
+// FindUserCoupons 分页查询用户的优惠券, state为0时查询全部
+func (dao *CouponDao) FindUserCoupons(userId int64, state int8, offset, limit int) ([]*model.UserCoupon, int64, error) {
+	coupons := make([]*model.UserCoupon, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.UserCoupon{}).Where("user_id = ?", userId)
+	if state != 0 {
+		query = query.Where("state = ?", state)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&coupons).Error
+	return coupons, total, err
+}
+
+// FindUsableCoupons 查询用户当前可以使用的优惠券
+func (dao *CouponDao) FindUsableCoupons(userId int64, now time.Time) ([]*model.UserCoupon, error) {
+	coupons := make([]*model.UserCoupon, 0)
+	err := DBMaster().WithContext(dao.ctx).
+		Where("user_id = ? AND state = ? AND valid_start_at <= ? AND valid_end_at > ?", userId, enum.UserCouponStateUnused, now, now).
+		Order("valid_end_at ASC, id ASC").Find(&coupons).Error
+	return coupons, err
+}
+
+// LockCoupons 下单时锁定优惠券, 只有全部是用户自己未使用且在有效期内的券才能锁定成功, 否则返回ErrCouponNotAvailable
+func (dao *CouponDao) LockCoupons(tx *gorm.DB, userId, orderId int64, couponIds []int64, now time.Time) error {
+	result := tx.Model(&model.UserCoupon{}).
+		Where("id IN ? AND user_id = ? AND state = ?", couponIds, userId, enum.UserCouponStateUnused).
+		Where("valid_start_at <= ? AND valid_end_at > ?", now, now).
+		Updates(map[string]interface{}{
+			"state":     enum.UserCouponStateLocked,
+			"order_id":  orderId,
+			"locked_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected != int64(len(couponIds)) {
+		return errcode.ErrCouponNotAvailable
+	}
+	return nil
+}
+
+// ConsumeOrderCoupons 订单支付成功后核销订单锁定的优惠券
+func (dao *CouponDao) ConsumeOrderCoupons(tx *gorm.DB, orderId int64, now time.Time) error {
+	return tx.Model(&model.UserCoupon{}).
+		Where("order_id = ? AND state = ?", orderId, enum.UserCouponStateLocked).
+		Updates(map[string]interface{}{
+			"state":   enum.UserCouponStateUsed,
+			"used_at": now,
+		}).Error
+}
+
+// ReleaseOrderCoupons 订单取消后把锁定的优惠券退回给用户
+func (dao *CouponDao) ReleaseOrderCoupons(tx *gorm.DB, orderId int64) error {
+	return tx.Model(&model.UserCoupon{}).
+		Where("order_id = ? AND state = ?", orderId, enum.UserCouponStateLocked).
+		Updates(map[string]interface{}{
+			"state":    enum.UserCouponStateUnused,
+			"order_id": 0,
+		}).Error
+}
@@ -0,0 +1,123 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type FavoriteDao struct {
+	ctx context.Context
+}
+
+func NewFavoriteDao(ctx context.Context) *FavoriteDao {
+	return &FavoriteDao{ctx: ctx}
+}
+
+// CreateFavorite 收藏商品, 已经收藏过时不做处理
+func (dao *FavoriteDao) CreateFavorite(favorite *model.Favorite) error {
+	return DBMaster().WithContext(dao.ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(favorite).Error
+}
+
+func (dao *FavoriteDao) DeleteFavorite(userId, goodsId int64) error {
+	return DBMaster().WithContext(dao.ctx).Where("user_id = ? AND goods_id = ?", userId, goodsId).Delete(&model.Favorite{}).Error
+}
+
+// FindUserFavorites 分页查询用户的收藏, 按收藏时间倒序
+func (dao *FavoriteDao) FindUserFavorites(userId int64, offset, limit int) ([]*model.Favorite, int64, error) {
+	favorites := make([]*model.Favorite, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.Favorite{}).Where("user_id = ?", userId)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&favorites).Error
+	return favorites, total, err
+}
+
+// UpsertSubscription 创建订阅, 同一用户对同一SKU的同类订阅已存在时重新激活并更新比较基准
+func (dao *FavoriteDao) UpsertSubscription(subscription *model.GoodsSubscription) error {
+	db := DBMaster().WithContext(dao.ctx)
+	err := db.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"state", "baseline_price", "target_price", "updated_at"}),
+	}).Create(subscription).Error
+	if err != nil {
+		return err
+	}
+	// 冲突更新时MySQL不会回填已有记录的ID, 重新查询一次
+	return db.Where("user_id = ? AND sku_id = ? AND type = ?", subscription.UserId, subscription.SkuId, subscription.Type).
+		First(subscription).Error
+}
+
+// FindSubscription 查询订阅, 不存在时返回 nil
+func (dao *FavoriteDao) FindSubscription(subscriptionId int64) (*model.GoodsSubscription, error) {
+	subscription := new(model.GoodsSubscription)
+	err := DBMaster().WithContext(dao.ctx).Where("id = ?", subscriptionId).First(subscription).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// FindUserSubscriptions 分页查询用户订阅中的提醒
+func (dao *FavoriteDao) FindUserSubscriptions(userId int64, offset, limit int) ([]*model.GoodsSubscription, int64, error) {
+	subscriptions := make([]*model.GoodsSubscription, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.GoodsSubscription{}).
+		Where("user_id = ? AND state = ?", userId, enum.SubscriptionStateActive)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&subscriptions).Error
+	return subscriptions, total, err
+}
+
+// CancelSubscription 退订, 返回是否更新成功
+func (dao *FavoriteDao) CancelSubscription(subscriptionId int64) (bool, error) {
+	result := DBMaster().WithContext(dao.ctx).Model(&model.GoodsSubscription{}).
+		Where("id = ? AND state <> ?", subscriptionId, enum.SubscriptionStateCancelled).
+		Update("state", enum.SubscriptionStateCancelled)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindBackInStockSubscriptions 按ID升序分批查询SKU订阅中的到货提醒
+func (dao *FavoriteDao) FindBackInStockSubscriptions(skuId, afterId int64, limit int) ([]*model.GoodsSubscription, error) {
+	subscriptions := make([]*model.GoodsSubscription, 0, limit)
+	err := DBMaster().WithContext(dao.ctx).
+		Where("sku_id = ? AND type = ? AND state = ? AND id > ?", skuId, enum.SubscriptionTypeBackInStock, enum.SubscriptionStateActive, afterId).
+		Order("id ASC").Limit(limit).Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// FindPriceDropSubscriptions 按ID升序分批查询当前价格满足提醒条件的降价提醒
+func (dao *FavoriteDao) FindPriceDropSubscriptions(skuId, price, afterId int64, limit int) ([]*model.GoodsSubscription, error) {
+	subscriptions := make([]*model.GoodsSubscription, 0, limit)
+	err := DBMaster().WithContext(dao.ctx).
+		Where("sku_id = ? AND type = ? AND state = ? AND id > ?", skuId, enum.SubscriptionTypePriceDrop, enum.SubscriptionStateActive, afterId).
+		Where("baseline_price > ? AND (target_price = 0 OR target_price >= ?)", price, price).
+		Order("id ASC").Limit(limit).Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// ClaimBackInStock 到货提醒 订阅中->已通知, 返回是否抢到通知权, 并发检查时同一订阅只通知一次
+func (dao *FavoriteDao) ClaimBackInStock(subscriptionId int64, notifiedAt time.Time) (bool, error) {
+	result := DBMaster().WithContext(dao.ctx).Model(&model.GoodsSubscription{}).
+		Where("id = ? AND state = ?", subscriptionId, enum.SubscriptionStateActive).
+		Updates(map[string]interface{}{"state": enum.SubscriptionStateNotified, "notified_at": notifiedAt})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ClaimPriceDrop 把降价提醒的比较基准从baseline更新为当前价格, 返回是否抢到通知权, 同一次降价只通知一次
+func (dao *FavoriteDao) ClaimPriceDrop(subscriptionId, baseline, price int64, notifiedAt time.Time) (bool, error) {
+	result := DBMaster().WithContext(dao.ctx).Model(&model.GoodsSubscription{}).
+		Where("id = ? AND state = ? AND baseline_price = ?", subscriptionId, enum.SubscriptionStateActive, baseline).
+		Updates(map[string]interface{}{"baseline_price": price, "notified_at": notifiedAt})
+	return result.RowsAffected > 0, result.Error
+}
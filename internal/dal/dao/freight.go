@@ -0,0 +1,96 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"gorm.io/gorm"
+)
+
+type FreightDao struct {
+	ctx context.Context
+}
+
+func NewFreightDao(ctx context.Context) *FreightDao {
+	return &FreightDao{ctx: ctx}
+}
+
+// CreateTemplate 在事务中创建运费模板和地区规则, 新模板是默认模板时取消其他模板的默认标记
+func (dao *FreightDao) CreateTemplate(template *model.FreightTemplate, rules []*model.FreightRegionRule) error {
+	return DBMaster().WithContext(dao.ctx).Transaction(func(tx *gorm.DB) error {
+		if template.IsDefault {
+			err := tx.Model(&model.FreightTemplate{}).Where("is_default = ?", true).
+				Update("is_default", false).Error
+			if err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(template).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		for _, rule := range rules {
+			rule.TemplateId = template.ID
+		}
+		return tx.Create(&rules).Error
+	})
+}
+
+// FindTemplateById 查询运费模板, 不存在时返回 nil
+func (dao *FreightDao) FindTemplateById(templateId int64) (*model.FreightTemplate, error) {
+	template := new(model.FreightTemplate)
+	err := DB().WithContext(dao.ctx).Where("id = ?", templateId).First(template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (dao *FreightDao) FindTemplatesByIds(templateIds []int64) ([]*model.FreightTemplate, error) {
+	templates := make([]*model.FreightTemplate, 0, len(templateIds))
+	err := DB().WithContext(dao.ctx).Where("id IN ?", templateIds).Find(&templates).Error
+	return templates, err
+}
+
+// FindDefaultTemplate 查询默认运费模板, 没有配置时返回 nil
+func (dao *FreightDao) FindDefaultTemplate() (*model.FreightTemplate, error) {
+	template := new(model.FreightTemplate)
+	err := DB().WithContext(dao.ctx).Where("is_default = ?", true).Order("id DESC").First(template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// FindTemplates 分页查询所有运费模板
+func (dao *FreightDao) FindTemplates(offset, limit int) ([]*model.FreightTemplate, int64, error) {
+	templates := make([]*model.FreightTemplate, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.FreightTemplate{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&templates).Error
+	return templates, total, err
+}
+
+// FindRegionRules 批量查询运费模板的地区规则
+func (dao *FreightDao) FindRegionRules(templateIds []int64) ([]*model.FreightRegionRule, error) {
+	rules := make([]*model.FreightRegionRule, 0)
+	err := DB().WithContext(dao.ctx).Where("template_id IN ?", templateIds).Order("id ASC").Find(&rules).Error
+	return rules, err
+}
+
+// BindGoods 设置商品使用的运费模板, templateId为0时恢复使用默认模板
+func (dao *FreightDao) BindGoods(goodsIds []int64, templateId int64) error {
+	return DBMaster().WithContext(dao.ctx).Model(&model.Goods{}).
+		Where("id IN ?", goodsIds).Update("freight_template_id", templateId).Error
+}
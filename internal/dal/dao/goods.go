@@ -0,0 +1,86 @@
+package dao
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"gorm.io/gorm"
+)
+
+type GoodsDao struct {
+	ctx context.Context
+}
+
+func NewGoodsDao(ctx context.Context) *GoodsDao {
+	return &GoodsDao{ctx: ctx}
+}
+
+// FindGoodsByIds 批量查询商品
+func (dao *GoodsDao) FindGoodsByIds(ids []int64) ([]*model.Goods, error) {
+	goods := make([]*model.Goods, 0, len(ids))
+	err := DB().WithContext(dao.ctx).Where("id IN ?", ids).Find(&goods).Error
+	return goods, err
+}
+
+// FindSkusByIds 批量查询SKU
+func (dao *GoodsDao) FindSkusByIds(ids []int64) ([]*model.GoodsSku, error) {
+	skus := make([]*model.GoodsSku, 0, len(ids))
+	err := DB().WithContext(dao.ctx).Where("id IN ?", ids).Find(&skus).Error
+	return skus, err
+}
+
+// FindMerchantGoods 分页查询店铺的商品, state小于0时查询全部状态
+func (dao *GoodsDao) FindMerchantGoods(merchantId int64, state int, offset, limit int) ([]*model.Goods, int64, error) {
+	goods := make([]*model.Goods, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.Goods{}).Where("merchant_id = ?", merchantId)
+	if state >= 0 {
+		query = query.Where("state = ?", state)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&goods).Error
+	return goods, total, err
+}
+
+// FindSkusByGoodsIds 查询商品下的所有SKU
+func (dao *GoodsDao) FindSkusByGoodsIds(goodsIds []int64) ([]*model.GoodsSku, error) {
+	skus := make([]*model.GoodsSku, 0, len(goodsIds))
+	err := DB().WithContext(dao.ctx).Where("goods_id IN ?", goodsIds).Order("id ASC").Find(&skus).Error
+	return skus, err
+}
+
+// UpdateMerchantGoodsState 修改店铺商品的上下架状态, 不属于该店铺的商品不会被修改, 返回实际修改的数量
+func (dao *GoodsDao) UpdateMerchantGoodsState(merchantId int64, goodsIds []int64, state int) (int64, error) {
+	result := DBMaster().WithContext(dao.ctx).Model(&model.Goods{}).
+		Where("id IN ? AND merchant_id = ?", goodsIds, merchantId).
+		Update("state", state)
+	return result.RowsAffected, result.Error
+}
+
+// UpdateSku 修改SKU的售价或库存, fields的键为列名
+func (dao *GoodsDao) UpdateSku(skuId int64, fields map[string]interface{}) error {
+	return DBMaster().WithContext(dao.ctx).Model(&model.GoodsSku{}).Where("id = ?", skuId).Updates(fields).Error
+}
+
+// DeductSkuStock 扣减SKU库存, 库存不足时返回ErrGoodsStockNotEnough
+func (dao *GoodsDao) DeductSkuStock(tx *gorm.DB, skuId int64, quantity int) error {
+	result := tx.Model(&model.GoodsSku{}).
+		Where("id = ? AND stock >= ?", skuId, quantity).
+		Update("stock", gorm.Expr("stock - ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errcode.ErrGoodsStockNotEnough
+	}
+	return nil
+}
+
+// RestoreSkuStock 归还SKU库存, 取消订单、退货时使用
+func (dao *GoodsDao) RestoreSkuStock(tx *gorm.DB, skuId int64, quantity int) error {
+	return tx.Model(&model.GoodsSku{}).
+		Where("id = ?", skuId).
+		Update("stock", gorm.Expr("stock + ?", quantity)).Error
+}
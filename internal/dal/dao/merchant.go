@@ -0,0 +1,88 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"gorm.io/gorm"
+)
+
+type MerchantDao struct {
+	ctx context.Context
+}
+
+func NewMerchantDao(ctx context.Context) *MerchantDao {
+	return &MerchantDao{ctx: ctx}
+}
+
+func (dao *MerchantDao) CreateMerchant(merchant *model.Merchant) error {
+	return DBMaster().WithContext(dao.ctx).Create(merchant).Error
+}
+
+// FindMerchantById 查询店铺, 不存在时返回 nil
+func (dao *MerchantDao) FindMerchantById(merchantId int64) (*model.Merchant, error) {
+	merchant := new(model.Merchant)
+	err := DB().WithContext(dao.ctx).Where("id = ?", merchantId).First(merchant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return merchant, nil
+}
+
+// FindMerchantByOwner 查询用户开通的店铺, 不存在时返回 nil
+func (dao *MerchantDao) FindMerchantByOwner(ownerUserId int64) (*model.Merchant, error) {
+	merchant := new(model.Merchant)
+	// 商家后台每个请求都要校验店铺状态, 读主库保证店铺被关闭后立即生效
+	err := DBMaster().WithContext(dao.ctx).Where("owner_user_id = ?", ownerUserId).First(merchant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return merchant, nil
+}
+
+// FindMerchantByName 根据店铺名称查询, 不存在时返回 nil
+func (dao *MerchantDao) FindMerchantByName(name string) (*model.Merchant, error) {
+	merchant := new(model.Merchant)
+	err := DBMaster().WithContext(dao.ctx).Where("name = ?", name).First(merchant).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return merchant, nil
+}
+
+// FindMerchantsByIds 批量查询店铺
+func (dao *MerchantDao) FindMerchantsByIds(ids []int64) ([]*model.Merchant, error) {
+	merchants := make([]*model.Merchant, 0, len(ids))
+	err := DB().WithContext(dao.ctx).Where("id IN ?", ids).Find(&merchants).Error
+	return merchants, err
+}
+
+// FindMerchants 分页查询店铺, state为0时查询全部状态
+func (dao *MerchantDao) FindMerchants(state int8, offset, limit int) ([]*model.Merchant, int64, error) {
+	merchants := make([]*model.Merchant, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.Merchant{})
+	if state != 0 {
+		query = query.Where("state = ?", state)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&merchants).Error
+	return merchants, total, err
+}
+
+// UpdateMerchantState 修改店铺状态
+func (dao *MerchantDao) UpdateMerchantState(merchantId int64, state int8) error {
+	return DBMaster().WithContext(dao.ctx).Model(&model.Merchant{}).
+		Where("id = ?", merchantId).Update("state", state).Error
+}
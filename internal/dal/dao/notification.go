@@ -0,0 +1,126 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type NotificationDao struct {
+	ctx context.Context
+}
+
+func NewNotificationDao(ctx context.Context) *NotificationDao {
+	return &NotificationDao{ctx: ctx}
+}
+
+func (dao *NotificationDao) CreateMessage(message *model.NotificationMessage) error {
+	return DBMaster().WithContext(dao.ctx).Create(message).Error
+}
+
+// FindUserMessages 分页查询用户收件箱, messageType为0时查询全部类型, 按时间倒序
+func (dao *NotificationDao) FindUserMessages(userId int64, messageType int8, offset, limit int) ([]*model.NotificationMessage, int64, error) {
+	messages := make([]*model.NotificationMessage, 0, limit)
+	var total int64
+	// 刚拉取的广播要立即能查到, 读主库
+	query := DBMaster().WithContext(dao.ctx).Model(&model.NotificationMessage{}).Where("user_id = ?", userId)
+	if messageType > 0 {
+		query = query.Where("type = ?", messageType)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	// 拉取的广播保留发布时间, 按创建时间排序才能和个人消息交错展示
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&messages).Error
+	return messages, total, err
+}
+
+// CountUnread 统计用户各类型的未读消息数
+func (dao *NotificationDao) CountUnread(userId int64) (map[int8]int64, error) {
+	var rows []struct {
+		Type  int8
+		Count int64
+	}
+	err := DBMaster().WithContext(dao.ctx).Model(&model.NotificationMessage{}).Select("type, COUNT(*) AS count").
+		Where("user_id = ? AND is_read = ?", userId, false).Group("type").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[int8]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Type] = row.Count
+	}
+	return counts, nil
+}
+
+// MarkRead 把用户的指定消息标记为已读, 返回实际更新的条数
+func (dao *NotificationDao) MarkRead(userId int64, messageIds []int64, readAt time.Time) (int64, error) {
+	result := DBMaster().WithContext(dao.ctx).Model(&model.NotificationMessage{}).
+		Where("user_id = ? AND id IN ? AND is_read = ?", userId, messageIds, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": readAt})
+	return result.RowsAffected, result.Error
+}
+
+// MarkAllRead 把用户的全部未读消息标记为已读, messageType为0时不限类型, 返回实际更新的条数
+func (dao *NotificationDao) MarkAllRead(userId int64, messageType int8, readAt time.Time) (int64, error) {
+	query := DBMaster().WithContext(dao.ctx).Model(&model.NotificationMessage{}).
+		Where("user_id = ? AND is_read = ?", userId, false)
+	if messageType > 0 {
+		query = query.Where("type = ?", messageType)
+	}
+	result := query.Updates(map[string]interface{}{"is_read": true, "read_at": readAt})
+	return result.RowsAffected, result.Error
+}
+
+func (dao *NotificationDao) CreateBroadcast(broadcast *model.NotificationBroadcast) error {
+	return DBMaster().WithContext(dao.ctx).Create(broadcast).Error
+}
+
+// FindLatestBroadcastId 查询最新的广播ID, 没有广播时返回0
+func (dao *NotificationDao) FindLatestBroadcastId() (int64, error) {
+	var latestId int64
+	err := DBMaster().WithContext(dao.ctx).Model(&model.NotificationBroadcast{}).Select("COALESCE(MAX(id), 0)").Scan(&latestId).Error
+	return latestId, err
+}
+
+// FindCursor 查询用户的广播拉取进度, 还没有拉取过时返回 nil
+func (dao *NotificationDao) FindCursor(userId int64) (*model.NotificationCursor, error) {
+	cursor := new(model.NotificationCursor)
+	err := DBMaster().WithContext(dao.ctx).Where("user_id = ?", userId).First(cursor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// LockCursor 在事务中锁定用户的广播拉取进度, 不存在时先创建, 串行化同一用户的并发拉取
+func (dao *NotificationDao) LockCursor(tx *gorm.DB, userId int64) (*model.NotificationCursor, error) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.NotificationCursor{UserId: userId}).Error
+	if err != nil {
+		return nil, err
+	}
+	cursor := new(model.NotificationCursor)
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userId).First(cursor).Error
+	return cursor, err
+}
+
+func (dao *NotificationDao) UpdateCursor(tx *gorm.DB, userId, lastBroadcastId int64) error {
+	return tx.Model(&model.NotificationCursor{}).Where("user_id = ?", userId).Update("last_broadcast_id", lastBroadcastId).Error
+}
+
+// FindBroadcastsAfter 按ID升序查询afterId之后、since之后发布的广播
+func (dao *NotificationDao) FindBroadcastsAfter(tx *gorm.DB, afterId int64, since time.Time, limit int) ([]*model.NotificationBroadcast, error) {
+	broadcasts := make([]*model.NotificationBroadcast, 0, limit)
+	err := tx.Where("id > ? AND created_at >= ?", afterId, since).Order("id ASC").Limit(limit).Find(&broadcasts).Error
+	return broadcasts, err
+}
+
+func (dao *NotificationDao) CreateMessagesInTx(tx *gorm.DB, messages []*model.NotificationMessage) error {
+	return tx.Create(messages).Error
+}
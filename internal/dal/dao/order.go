@@ -0,0 +1,161 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type OrderDao struct {
+	ctx context.Context
+}
+
+func NewOrderDao(ctx context.Context) *OrderDao {
+	return &OrderDao{ctx: ctx}
+}
+
+// orderStateTimeColumns 状态变更时需要同时记录时间的字段
+var orderStateTimeColumns = map[int8]string{
+	enum.OrderStatePaid:      "paid_at",
+	enum.OrderStateShipped:   "shipped_at",
+	enum.OrderStateDelivered: "delivered_at",
+	enum.OrderStateCompleted: "completed_at",
+	enum.OrderStateCancelled: "cancelled_at",
+}
+
+// CreateOrder 创建订单和订单明细
+func (dao *OrderDao) CreateOrder(tx *gorm.DB, order *model.Order, items []*model.OrderItem) error {
+	if err := tx.Create(order).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		item.OrderId = order.ID
+	}
+	return tx.Create(&items).Error
+}
+
+// FindOrderByOrderNo 根据订单号查询订单, 订单不存在时返回 nil
+func (dao *OrderDao) FindOrderByOrderNo(orderNo string) (*model.Order, error) {
+	order := new(model.Order)
+	// 订单状态变更前都要先读一遍订单, 读主库避免主从延迟拿到旧的版本号
+	err := DBMaster().WithContext(dao.ctx).Where("order_no = ?", orderNo).First(order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// LockOrder 在事务中对订单加行锁并返回最新数据, 用于串行化同一订单上的售后等并发操作
+func (dao *OrderDao) LockOrder(tx *gorm.DB, orderId int64) (*model.Order, error) {
+	order := new(model.Order)
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", orderId).First(order).Error
+	return order, err
+}
+
+// FindOrderItems 查询订单明细
+func (dao *OrderDao) FindOrderItems(orderId int64) ([]*model.OrderItem, error) {
+	return dao.FindOrderItemsInTx(DB().WithContext(dao.ctx), orderId)
+}
+
+// FindOrderItemsInTx 在事务中查询订单明细, 和锁定的订单读到同一份数据
+func (dao *OrderDao) FindOrderItemsInTx(tx *gorm.DB, orderId int64) ([]*model.OrderItem, error) {
+	items := make([]*model.OrderItem, 0)
+	err := tx.Where("order_id = ?", orderId).Find(&items).Error
+	return items, err
+}
+
+// FindUserOrders 分页查询用户订单, state为0时查询全部状态
+func (dao *OrderDao) FindUserOrders(userId int64, state int8, offset, limit int) ([]*model.Order, int64, error) {
+	orders := make([]*model.Order, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.Order{}).Where("user_id = ?", userId)
+	if state != 0 {
+		query = query.Where("state = ?", state)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&orders).Error
+	return orders, total, err
+}
+
+// FindOrders 管理后台分页查询订单, merchantId为0时查询所有店铺, state为0时查询全部状态
+func (dao *OrderDao) FindOrders(merchantId int64, state int8, offset, limit int) ([]*model.Order, int64, error) {
+	orders := make([]*model.Order, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.Order{})
+	if merchantId != 0 {
+		query = query.Where("merchant_id = ?", merchantId)
+	}
+	if state != 0 {
+		query = query.Where("state = ?", state)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&orders).Error
+	return orders, total, err
+}
+
+// FindOrdersByParentNo 查询父订单下的所有子订单
+func (dao *OrderDao) FindOrdersByParentNo(parentOrderNo string) ([]*model.Order, error) {
+	orders := make([]*model.Order, 0)
+	// 支付和取消都依赖子订单的最新状态, 读主库
+	err := DBMaster().WithContext(dao.ctx).Where("parent_order_no = ?", parentOrderNo).Order("id ASC").Find(&orders).Error
+	return orders, err
+}
+
+// UpdateOrderState 基于版本号的乐观锁更新订单状态
+// 订单已被其他请求修改(状态或者版本号对不上)时返回ErrOrderConcurrentUpdate
+func (dao *OrderDao) UpdateOrderState(tx *gorm.DB, orderId int64, fromState, toState int8, version int) error {
+	updates := map[string]interface{}{
+		"state":   toState,
+		"version": gorm.Expr("version + 1"),
+	}
+	if column, ok := orderStateTimeColumns[toState]; ok {
+		updates[column] = time.Now()
+	}
+	result := tx.Model(&model.Order{}).
+		Where("id = ? AND state = ? AND version = ?", orderId, fromState, version).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errcode.ErrOrderConcurrentUpdate
+	}
+	return nil
+}
+
+// CreateOrderStateLog 写入订单状态流转记录
+func (dao *OrderDao) CreateOrderStateLog(tx *gorm.DB, stateLog *model.OrderStateLog) error {
+	return tx.Create(stateLog).Error
+}
+
+// FindLastStateLogTo 查询订单最近一次流转到指定状态的记录, 不存在时返回 nil
+func (dao *OrderDao) FindLastStateLogTo(tx *gorm.DB, orderId int64, toState int8) (*model.OrderStateLog, error) {
+	stateLog := new(model.OrderStateLog)
+	err := tx.Where("order_id = ? AND to_state = ?", orderId, toState).Order("id DESC").First(stateLog).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stateLog, nil
+}
+
+// FindOrderStateLogs 查询订单的状态流转记录
+func (dao *OrderDao) FindOrderStateLogs(orderId int64) ([]*model.OrderStateLog, error) {
+	logs := make([]*model.OrderStateLog, 0)
+	err := DB().WithContext(dao.ctx).Where("order_id = ?", orderId).Order("id ASC").Find(&logs).Error
+	return logs, err
+}
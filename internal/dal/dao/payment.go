@@ -0,0 +1,93 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"gorm.io/gorm"
+	"time"
+)
+
+type PaymentDao struct {
+	ctx context.Context
+}
+
+func NewPaymentDao(ctx context.Context) *PaymentDao {
+	return &PaymentDao{ctx: ctx}
+}
+
+func (dao *PaymentDao) CreatePayment(payment *model.Payment) error {
+	return DBMaster().WithContext(dao.ctx).Create(payment).Error
+}
+
+// FindPaymentByPaymentNo 根据支付单号查询, 不存在时返回 nil
+func (dao *PaymentDao) FindPaymentByPaymentNo(paymentNo string) (*model.Payment, error) {
+	payment := new(model.Payment)
+	// 回调处理依赖支付单的最新状态, 读主库
+	err := DBMaster().WithContext(dao.ctx).Where("payment_no = ?", paymentNo).First(payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// FindParentOrderPayment 查询父订单在指定渠道、指定状态的支付单, provider为空时不限渠道, 不存在时返回 nil
+func (dao *PaymentDao) FindParentOrderPayment(parentOrderNo string, provider string, state int8) (*model.Payment, error) {
+	payment := new(model.Payment)
+	query := DBMaster().WithContext(dao.ctx).Where("parent_order_no = ? AND state = ?", parentOrderNo, state)
+	if provider != "" {
+		query = query.Where("provider = ?", provider)
+	}
+	err := query.Order("id DESC").First(payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// MarkPaymentSuccess 把待支付的支付单标记为支付成功
+// 返回false表示支付单已经不是待支付状态(重复通知已处理过), 调用方据此保证只处理一次
+func (dao *PaymentDao) MarkPaymentSuccess(tx *gorm.DB, paymentId int64, tradeNo string, paidAt time.Time) (bool, error) {
+	result := tx.Model(&model.Payment{}).
+		Where("id = ? AND state = ?", paymentId, enum.PaymentStateCreated).
+		Updates(map[string]interface{}{
+			"state":    enum.PaymentStateSuccess,
+			"trade_no": tradeNo,
+			"paid_at":  paidAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkClosedRefund 记录支付成功时已取消的子订单需要退回的金额, 同时计入已退款金额, 避免售后再退这部分钱
+func (dao *PaymentDao) MarkClosedRefund(tx *gorm.DB, paymentId int64, amount int64) (bool, error) {
+	result := tx.Model(&model.Payment{}).
+		Where("id = ? AND closed_refund_amount = 0 AND refunded_amount + ? <= amount", paymentId, amount).
+		Updates(map[string]interface{}{
+			"closed_refund_amount": amount,
+			"refunded_amount":      gorm.Expr("refunded_amount + ?", amount),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// MarkClosedRefundDone 记录已取消子订单的退款已经完成, 返回false表示已经处理过
+func (dao *PaymentDao) MarkClosedRefundDone(tx *gorm.DB, paymentId int64, refundTradeNo string) (bool, error) {
+	result := tx.Model(&model.Payment{}).
+		Where("id = ? AND closed_refund_amount > 0 AND closed_refund_trade_no = ''", paymentId).
+		Update("closed_refund_trade_no", refundTradeNo)
+	return result.RowsAffected > 0, result.Error
+}
+
+// AddRefundedAmount 累加支付单的已退款金额, 累计退款超过支付金额时不更新并返回false
+func (dao *PaymentDao) AddRefundedAmount(tx *gorm.DB, paymentId int64, amount int64) (bool, error) {
+	result := tx.Model(&model.Payment{}).
+		Where("id = ? AND refunded_amount + ? <= amount", paymentId, amount).
+		Update("refunded_amount", gorm.Expr("refunded_amount + ?", amount))
+	return result.RowsAffected > 0, result.Error
+}
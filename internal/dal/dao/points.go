@@ -0,0 +1,115 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type PointsDao struct {
+	ctx context.Context
+}
+
+func NewPointsDao(ctx context.Context) *PointsDao {
+	return &PointsDao{ctx: ctx}
+}
+
+// FindAccount 查询用户的积分账户, 还没有账户时返回 nil
+func (dao *PointsDao) FindAccount(userId int64) (*model.PointsAccount, error) {
+	account := new(model.PointsAccount)
+	err := DB().WithContext(dao.ctx).Where("user_id = ?", userId).First(account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// LockAccount 在事务中锁定用户的积分账户, 账户不存在时先创建, 串行化同一用户的积分变动
+func (dao *PointsDao) LockAccount(tx *gorm.DB, userId int64) (*model.PointsAccount, error) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.PointsAccount{UserId: userId}).Error
+	if err != nil {
+		return nil, err
+	}
+	account := new(model.PointsAccount)
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userId).First(account).Error
+	return account, err
+}
+
+// UpdateAccount 更新积分余额和成长值, 需要先用LockAccount锁定账户
+func (dao *PointsDao) UpdateAccount(tx *gorm.DB, account *model.PointsAccount) error {
+	return tx.Model(&model.PointsAccount{}).Where("id = ?", account.ID).Updates(map[string]interface{}{
+		"balance":      account.Balance,
+		"growth_value": account.GrowthValue,
+	}).Error
+}
+
+// FindLedger 查询用户某个业务单号的某类流水, 不存在时返回 nil
+func (dao *PointsDao) FindLedger(tx *gorm.DB, userId int64, pointsType int8, bizNo string) (*model.PointsLedger, error) {
+	ledger := new(model.PointsLedger)
+	err := tx.Where("user_id = ? AND type = ? AND biz_no = ?", userId, pointsType, bizNo).First(ledger).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ledger, nil
+}
+
+func (dao *PointsDao) CreateLedger(tx *gorm.DB, ledger *model.PointsLedger) error {
+	return tx.Create(ledger).Error
+}
+
+// FindUserLedgers 分页查询用户的积分流水, 按时间倒序
+func (dao *PointsDao) FindUserLedgers(userId int64, offset, limit int) ([]*model.PointsLedger, int64, error) {
+	ledgers := make([]*model.PointsLedger, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.PointsLedger{}).Where("user_id = ?", userId)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&ledgers).Error
+	return ledgers, total, err
+}
+
+// SumOrderLedgers 汇总订单上某类流水的积分和成长值
+func (dao *PointsDao) SumOrderLedgers(tx *gorm.DB, userId int64, orderNo string, pointsType int8) (points, growth int64, err error) {
+	var sum struct {
+		Points int64
+		Growth int64
+	}
+	err = tx.Model(&model.PointsLedger{}).Select("COALESCE(SUM(points), 0) AS points, COALESCE(SUM(growth), 0) AS growth").
+		Where("user_id = ? AND order_no = ? AND type = ?", userId, orderNo, pointsType).Scan(&sum).Error
+	return sum.Points, sum.Growth, err
+}
+
+// SumExpiredCredits 汇总用户在before之前到期的所有增加积分的流水
+func (dao *PointsDao) SumExpiredCredits(tx *gorm.DB, userId int64, before time.Time) (int64, error) {
+	var sum int64
+	err := tx.Model(&model.PointsLedger{}).Select("COALESCE(SUM(points), 0)").
+		Where("user_id = ? AND points > 0 AND expire_at <= ?", userId, before).Scan(&sum).Error
+	return sum, err
+}
+
+// SumDebits 汇总用户所有减少积分的流水, 返回正数
+func (dao *PointsDao) SumDebits(tx *gorm.DB, userId int64) (int64, error) {
+	var sum int64
+	err := tx.Model(&model.PointsLedger{}).Select("COALESCE(SUM(-points), 0)").
+		Where("user_id = ? AND points < 0", userId).Scan(&sum).Error
+	return sum, err
+}
+
+// FindExpiringUserIds 按用户ID升序分批查询在(from, to]之间有积分到期的用户
+func (dao *PointsDao) FindExpiringUserIds(from, to time.Time, afterUserId int64, limit int) ([]int64, error) {
+	userIds := make([]int64, 0, limit)
+	err := DB().WithContext(dao.ctx).Model(&model.PointsLedger{}).Distinct("user_id").
+		Where("points > 0 AND expire_at > ? AND expire_at <= ? AND user_id > ?", from, to, afterUserId).
+		Order("user_id ASC").Limit(limit).Pluck("user_id", &userIds).Error
+	return userIds, err
+}
@@ -0,0 +1,124 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ReviewDao struct {
+	ctx context.Context
+}
+
+func NewReviewDao(ctx context.Context) *ReviewDao {
+	return &ReviewDao{ctx: ctx}
+}
+
+func (dao *ReviewDao) CreateReview(review *model.Review) error {
+	return DBMaster().WithContext(dao.ctx).Create(review).Error
+}
+
+// FindReviewById 查询评价, 不存在时返回 nil
+func (dao *ReviewDao) FindReviewById(reviewId int64) (*model.Review, error) {
+	review := new(model.Review)
+	err := DBMaster().WithContext(dao.ctx).Where("id = ?", reviewId).First(review).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// FindReviewByOrderItemId 查询订单明细的评价, 不存在时返回 nil
+func (dao *ReviewDao) FindReviewByOrderItemId(orderItemId int64) (*model.Review, error) {
+	review := new(model.Review)
+	err := DBMaster().WithContext(dao.ctx).Where("order_item_id = ?", orderItemId).First(review).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// FindGoodsReviews 分页查询商品展示中的评价, rating为0时不按评分筛选
+func (dao *ReviewDao) FindGoodsReviews(goodsId int64, rating int8, withImages bool, offset, limit int) ([]*model.Review, int64, error) {
+	reviews := make([]*model.Review, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.Review{}).
+		Where("goods_id = ? AND state = ?", goodsId, enum.ReviewStateVisible)
+	if rating > 0 {
+		query = query.Where("rating = ?", rating)
+	}
+	if withImages {
+		query = query.Where("has_images = ?", true)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&reviews).Error
+	return reviews, total, err
+}
+
+// FindUserReviews 分页查询用户发表的评价, 包括被隐藏的
+func (dao *ReviewDao) FindUserReviews(userId int64, offset, limit int) ([]*model.Review, int64, error) {
+	reviews := make([]*model.Review, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.Review{}).Where("user_id = ?", userId)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&reviews).Error
+	return reviews, total, err
+}
+
+// FindReviews 后台分页查询评价, merchantId、goodsId和state为0时不筛选
+func (dao *ReviewDao) FindReviews(merchantId, goodsId int64, state int8, offset, limit int) ([]*model.Review, int64, error) {
+	reviews := make([]*model.Review, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.Review{})
+	if merchantId > 0 {
+		query = query.Where("merchant_id = ?", merchantId)
+	}
+	if goodsId > 0 {
+		query = query.Where("goods_id = ?", goodsId)
+	}
+	if state > 0 {
+		query = query.Where("state = ?", state)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&reviews).Error
+	return reviews, total, err
+}
+
+// UpdateReply 保存商家回复, 只能回复一次, 已经回复过时返回false
+func (dao *ReviewDao) UpdateReply(reviewId int64, content string, repliedAt time.Time) (bool, error) {
+	result := DBMaster().WithContext(dao.ctx).Model(&model.Review{}).
+		Where("id = ? AND reply_content = ?", reviewId, "").
+		Updates(map[string]interface{}{"reply_content": content, "replied_at": repliedAt})
+	return result.RowsAffected > 0, result.Error
+}
+
+// UpdateState 修改评价的展示状态
+func (dao *ReviewDao) UpdateState(reviewId int64, state int8, hiddenReason string) error {
+	return DBMaster().WithContext(dao.ctx).Model(&model.Review{}).Where("id = ?", reviewId).
+		Updates(map[string]interface{}{"state": state, "hidden_reason": hiddenReason}).Error
+}
+
+// CountGoodsRatings 按评分统计商品展示中的评价数量和有图评价数量
+func (dao *ReviewDao) CountGoodsRatings(goodsId int64) ([]*model.ReviewRatingStat, error) {
+	stats := make([]*model.ReviewRatingStat, 0, 5)
+	err := DB().WithContext(dao.ctx).Model(&model.Review{}).
+		Select("rating, COUNT(*) AS count, SUM(has_images) AS image_count").
+		Where("goods_id = ? AND state = ?", goodsId, enum.ReviewStateVisible).
+		Group("rating").Scan(&stats).Error
+	return stats, err
+}
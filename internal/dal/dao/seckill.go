@@ -0,0 +1,97 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"gorm.io/gorm"
+)
+
+type SeckillDao struct {
+	ctx context.Context
+}
+
+func NewSeckillDao(ctx context.Context) *SeckillDao {
+	return &SeckillDao{ctx: ctx}
+}
+
+func (dao *SeckillDao) CreateActivity(activity *model.SeckillActivity) error {
+	return DBMaster().WithContext(dao.ctx).Create(activity).Error
+}
+
+// FindActivityById 查询秒杀活动, 不存在时返回 nil
+func (dao *SeckillDao) FindActivityById(activityId int64) (*model.SeckillActivity, error) {
+	activity := new(model.SeckillActivity)
+	err := DBMaster().WithContext(dao.ctx).Where("id = ?", activityId).First(activity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// FindActivities 分页查询秒杀活动, 按开始时间倒序
+func (dao *SeckillDao) FindActivities(offset, limit int) ([]*model.SeckillActivity, int64, error) {
+	activities := make([]*model.SeckillActivity, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.SeckillActivity{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("start_at DESC, id DESC").Offset(offset).Limit(limit).Find(&activities).Error
+	return activities, total, err
+}
+
+// IncrSoldCount 增加活动已售数量, 超过活动库存时返回ErrSeckillSoldOut, 是Redis库存之外的兜底校验
+func (dao *SeckillDao) IncrSoldCount(tx *gorm.DB, activityId int64, quantity int) error {
+	result := tx.Model(&model.SeckillActivity{}).
+		Where("id = ? AND sold_count + ? <= total_stock", activityId, quantity).
+		Update("sold_count", gorm.Expr("sold_count + ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errcode.ErrSeckillSoldOut
+	}
+	return nil
+}
+
+// DecrSoldCount 秒杀订单取消后退回活动已售数量
+func (dao *SeckillDao) DecrSoldCount(tx *gorm.DB, activityId int64, quantity int) error {
+	return tx.Model(&model.SeckillActivity{}).
+		Where("id = ? AND sold_count >= ?", activityId, quantity).
+		Update("sold_count", gorm.Expr("sold_count - ?", quantity)).Error
+}
+
+func (dao *SeckillDao) CreateSeckillOrder(tx *gorm.DB, seckillOrder *model.SeckillOrder) error {
+	return tx.Create(seckillOrder).Error
+}
+
+// FindSeckillOrderByTicket 根据抢购凭证查询已生成的订单, 不存在时返回 nil
+func (dao *SeckillDao) FindSeckillOrderByTicket(ticket string) (*model.SeckillOrder, error) {
+	seckillOrder := new(model.SeckillOrder)
+	err := DBMaster().WithContext(dao.ctx).Where("ticket = ?", ticket).First(seckillOrder).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return seckillOrder, nil
+}
+
+// FindSeckillOrderByOrderId 在事务中查询订单对应的秒杀记录, 不是秒杀订单时返回 nil
+func (dao *SeckillDao) FindSeckillOrderByOrderId(tx *gorm.DB, orderId int64) (*model.SeckillOrder, error) {
+	seckillOrder := new(model.SeckillOrder)
+	err := tx.Where("order_id = ?", orderId).First(seckillOrder).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return seckillOrder, nil
+}
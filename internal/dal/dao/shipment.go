@@ -0,0 +1,66 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ShipmentDao struct {
+	ctx context.Context
+}
+
+func NewShipmentDao(ctx context.Context) *ShipmentDao {
+	return &ShipmentDao{ctx: ctx}
+}
+
+func (dao *ShipmentDao) CreateShipment(tx *gorm.DB, shipment *model.Shipment) error {
+	return tx.Create(shipment).Error
+}
+
+// FindShipmentById 查询物流单, 不存在时返回 nil
+func (dao *ShipmentDao) FindShipmentById(shipmentId int64) (*model.Shipment, error) {
+	shipment := new(model.Shipment)
+	err := DBMaster().WithContext(dao.ctx).Where("id = ?", shipmentId).First(shipment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return shipment, nil
+}
+
+// FindShipmentsByOrderId 查询订单的物流单, 按发货先后排序
+func (dao *ShipmentDao) FindShipmentsByOrderId(orderId int64) ([]*model.Shipment, error) {
+	shipments := make([]*model.Shipment, 0, 1)
+	err := DB().WithContext(dao.ctx).Where("order_id = ?", orderId).Order("id ASC").Find(&shipments).Error
+	return shipments, err
+}
+
+// FindShipmentEvents 查询物流单的轨迹, 按发生时间倒序
+func (dao *ShipmentDao) FindShipmentEvents(shipmentIds []int64) ([]*model.ShipmentEvent, error) {
+	events := make([]*model.ShipmentEvent, 0)
+	err := DB().WithContext(dao.ctx).Where("shipment_id IN ?", shipmentIds).
+		Order("event_time DESC, id DESC").Find(&events).Error
+	return events, err
+}
+
+// SaveShipmentEvents 保存同步到的物流轨迹, 已经保存过的轨迹按唯一键忽略
+func (dao *ShipmentDao) SaveShipmentEvents(tx *gorm.DB, events []*model.ShipmentEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&events).Error
+}
+
+// UpdateShipmentTrack 更新物流单的状态和同步时间
+func (dao *ShipmentDao) UpdateShipmentTrack(tx *gorm.DB, shipment *model.Shipment) error {
+	return tx.Model(&model.Shipment{}).Where("id = ?", shipment.ID).Updates(map[string]interface{}{
+		"state":           shipment.State,
+		"delivered_at":    shipment.DeliveredAt,
+		"last_tracked_at": shipment.LastTrackedAt,
+	}).Error
+}
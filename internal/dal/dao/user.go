@@ -22,6 +22,13 @@ func (dao *UserDao) FindUserById(id int64) (*model.User, error) {
 	return &model.User{}, nil
 }
 
+// FindUsersByIds 批量查询用户
+func (dao *UserDao) FindUsersByIds(ids []int64) ([]*model.User, error) {
+	users := make([]*model.User, 0, len(ids))
+	err := DB().WithContext(dao.ctx).Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
 func (dao *UserDao) FindUserByName(name string) (user model.User, err error) {
 	// TODO 执行sql查询数据库的数据
 	result := DB().WithContext(dao.ctx).Where("name = ?", name).First(&user)
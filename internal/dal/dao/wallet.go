@@ -0,0 +1,194 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type WalletDao struct {
+	ctx context.Context
+}
+
+func NewWalletDao(ctx context.Context) *WalletDao {
+	return &WalletDao{ctx: ctx}
+}
+
+// FindAccountByNo 按账户号查询账户, 账户不存在时返回 nil
+func (dao *WalletDao) FindAccountByNo(accountNo string) (*model.LedgerAccount, error) {
+	account := new(model.LedgerAccount)
+	err := DB().WithContext(dao.ctx).Where("account_no = ?", accountNo).First(account).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// LockAccount 在事务中锁定账户, 账户不存在时先创建, 串行化同一账户的记账
+func (dao *WalletDao) LockAccount(tx *gorm.DB, accountNo string, accountType int8, ownerId int64) (*model.LedgerAccount, error) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.LedgerAccount{
+		AccountNo: accountNo,
+		Type:      accountType,
+		OwnerId:   ownerId,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	account := new(model.LedgerAccount)
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("account_no = ?", accountNo).First(account).Error
+	return account, err
+}
+
+// UpdateAccountBalance 更新账户余额, 需要先用LockAccount锁定账户
+func (dao *WalletDao) UpdateAccountBalance(tx *gorm.DB, accountId, balance int64) error {
+	return tx.Model(&model.LedgerAccount{}).Where("id = ?", accountId).Update("balance", balance).Error
+}
+
+// FindTransactionByKey 按幂等键查询记账交易, 不存在时返回 nil
+func (dao *WalletDao) FindTransactionByKey(tx *gorm.DB, idempotencyKey string) (*model.LedgerTransaction, error) {
+	transaction := new(model.LedgerTransaction)
+	err := tx.Where("idempotency_key = ?", idempotencyKey).First(transaction).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+func (dao *WalletDao) CreateTransaction(tx *gorm.DB, transaction *model.LedgerTransaction) error {
+	return tx.Create(transaction).Error
+}
+
+func (dao *WalletDao) CreateEntries(tx *gorm.DB, entries []*model.LedgerEntry) error {
+	return tx.Create(entries).Error
+}
+
+// FindAccountEntries 分页查询账户的分录, 按时间倒序
+func (dao *WalletDao) FindAccountEntries(accountId int64, offset, limit int) ([]*model.LedgerEntry, int64, error) {
+	entries := make([]*model.LedgerEntry, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.LedgerEntry{}).Where("account_id = ?", accountId)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&entries).Error
+	return entries, total, err
+}
+
+func (dao *WalletDao) FindTransactionsByIds(transactionIds []int64) ([]*model.LedgerTransaction, error) {
+	transactions := make([]*model.LedgerTransaction, 0, len(transactionIds))
+	err := DB().WithContext(dao.ctx).Where("id IN ?", transactionIds).Find(&transactions).Error
+	return transactions, err
+}
+
+// FindAccounts 按ID升序分批查询账户, 对账使用
+func (dao *WalletDao) FindAccounts(tx *gorm.DB, afterId int64, limit int) ([]*model.LedgerAccount, error) {
+	accounts := make([]*model.LedgerAccount, 0, limit)
+	err := tx.Where("id > ?", afterId).Order("id ASC").Limit(limit).Find(&accounts).Error
+	return accounts, err
+}
+
+// SumAccountEntries 汇总每个账户的分录金额, 没有分录的账户不在结果中
+func (dao *WalletDao) SumAccountEntries(tx *gorm.DB, accountIds []int64) (map[int64]int64, error) {
+	var rows []struct {
+		AccountId int64
+		Amount    int64
+	}
+	err := tx.Model(&model.LedgerEntry{}).Select("account_id, SUM(amount) AS amount").
+		Where("account_id IN ?", accountIds).Group("account_id").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	sums := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		sums[row.AccountId] = row.Amount
+	}
+	return sums, nil
+}
+
+// FindUnbalancedTransactions 查询分录金额之和不为0的记账交易, 返回交易ID和分录金额之和
+func (dao *WalletDao) FindUnbalancedTransactions(limit int) (map[int64]int64, error) {
+	var rows []struct {
+		TransactionId int64
+		Amount        int64
+	}
+	err := DBMaster().WithContext(dao.ctx).Model(&model.LedgerEntry{}).Select("transaction_id, SUM(amount) AS amount").
+		Group("transaction_id").Having("SUM(amount) <> 0").Limit(limit).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	sums := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		sums[row.TransactionId] = row.Amount
+	}
+	return sums, nil
+}
+
+// SumAccountBalances 汇总全部账户的余额, 借贷平衡时应为0
+func (dao *WalletDao) SumAccountBalances() (int64, error) {
+	var sum int64
+	err := DBMaster().WithContext(dao.ctx).Model(&model.LedgerAccount{}).Select("COALESCE(SUM(balance), 0)").Scan(&sum).Error
+	return sum, err
+}
+
+func (dao *WalletDao) CreateGiftCards(tx *gorm.DB, cards []*model.GiftCard) error {
+	return tx.Create(cards).Error
+}
+
+// FindGiftCardsByBatch 查询批次下的全部礼品卡
+func (dao *WalletDao) FindGiftCardsByBatch(tx *gorm.DB, batchNo string) ([]*model.GiftCard, error) {
+	cards := make([]*model.GiftCard, 0)
+	err := tx.Where("batch_no = ?", batchNo).Order("id ASC").Find(&cards).Error
+	return cards, err
+}
+
+// LockGiftCardByCode 在事务中按卡密哈希锁定礼品卡, 不存在时返回 nil
+func (dao *WalletDao) LockGiftCardByCode(tx *gorm.DB, codeHash string) (*model.GiftCard, error) {
+	card := new(model.GiftCard)
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("code_hash = ?", codeHash).First(card).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// MarkGiftCardRedeemed 礼品卡 未兑换->已兑换, 返回是否更新成功
+func (dao *WalletDao) MarkGiftCardRedeemed(tx *gorm.DB, cardId, userId int64, redeemedAt time.Time) (bool, error) {
+	result := tx.Model(&model.GiftCard{}).Where("id = ? AND state = ?", cardId, enum.GiftCardStateActive).
+		Updates(map[string]interface{}{
+			"state":       enum.GiftCardStateRedeemed,
+			"redeemed_by": userId,
+			"redeemed_at": redeemedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindGiftCards 分页查询礼品卡, batchNo为空查询全部批次, state为0查询全部状态
+func (dao *WalletDao) FindGiftCards(batchNo string, state int8, offset, limit int) ([]*model.GiftCard, int64, error) {
+	cards := make([]*model.GiftCard, 0, limit)
+	var total int64
+	query := DB().WithContext(dao.ctx).Model(&model.GiftCard{})
+	if batchNo != "" {
+		query = query.Where("batch_no = ?", batchNo)
+	}
+	if state > 0 {
+		query = query.Where("state = ?", state)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&cards).Error
+	return cards, total, err
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+type AfterSale struct {
+	ID               int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                       // 售后单ID
+	AfterSaleNo      string    `gorm:"column:after_sale_no;type:varchar(32);uniqueIndex;NOT NULL"` // 售后单号, 同时作为退款单号
+	OrderId          int64     `gorm:"column:order_id;index;NOT NULL"`                             // 订单ID
+	OrderNo          string    `gorm:"column:order_no;type:varchar(32);NOT NULL"`                  // 订单号
+	OrderItemId      int64     `gorm:"column:order_item_id;NOT NULL"`                              // 订单明细ID
+	SkuId            int64     `gorm:"column:sku_id;NOT NULL"`                                     // SKU ID
+	UserId           int64     `gorm:"column:user_id;index;NOT NULL"`                              // 用户ID
+	MerchantId       int64     `gorm:"column:merchant_id;index;default:0;NOT NULL"`                // 订单所属店铺ID
+	Type             int8      `gorm:"column:type;NOT NULL"`                                       // 售后类型 1-仅退款 2-退货退款
+	Quantity         int       `gorm:"column:quantity;NOT NULL"`                                   // 售后商品数量
+	RefundAmount     int64     `gorm:"column:refund_amount;NOT NULL"`                              // 退款金额(分)
+	RefundPoints     int64     `gorm:"column:refund_points;default:0;NOT NULL"`                    // 退回的抵扣积分, 按数量比例计算
+	Reason           string    `gorm:"column:reason;type:varchar(64);NOT NULL"`                    // 售后原因
+	Description      string    `gorm:"column:description;type:varchar(512);NOT NULL"`              // 问题描述
+	Images           string    `gorm:"column:images;type:text"`                                    // 凭证图片, JSON数组
+	State            int8      `gorm:"column:state;default:1;NOT NULL"`                            // 售后单状态, 见enum.AfterSaleStateXXX
+	Version          int       `gorm:"column:version;default:0;NOT NULL"`                          // 乐观锁版本号
+	Restock          bool      `gorm:"column:restock;default:0;NOT NULL"`                          // 退款后是否归还库存
+	ReturnCarrier    string    `gorm:"column:return_carrier;type:varchar(32);NOT NULL"`            // 退货物流公司
+	ReturnTrackingNo string    `gorm:"column:return_tracking_no;type:varchar(64);NOT NULL"`        // 退货物流单号
+	MerchantRemark   string    `gorm:"column:merchant_remark;type:varchar(255);NOT NULL"`          // 商家处理意见
+	RefundNo         string    `gorm:"column:refund_no;type:varchar(32);NOT NULL"`                 // 退款单号, 调用渠道退款之前写入, 不为空表示退款已经发起
+	RefundTradeNo    string    `gorm:"column:refund_trade_no;type:varchar(64);NOT NULL"`           // 渠道侧退款交易号
+	RefundedAt       time.Time `gorm:"column:refunded_at;default:\"1970-01-01 00:00:00\""`         // 退款完成时间
+	CreatedAt        time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"`       // 创建时间
+	UpdatedAt        time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"`       // 更新时间
+}
+
+func (AfterSale) TableName() string {
+	return "after_sale"
+}
+
+// AfterSaleLog 售后单处理记录, 只追加不修改
+type AfterSaleLog struct {
+	ID           int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // 自增ID
+	AfterSaleId  int64     `gorm:"column:after_sale_id;index;NOT NULL"`                  // 售后单ID
+	FromState    int8      `gorm:"column:from_state;NOT NULL"`                           // 变更前状态
+	ToState      int8      `gorm:"column:to_state;NOT NULL"`                             // 变更后状态
+	OperatorType string    `gorm:"column:operator_type;type:varchar(16);NOT NULL"`       // 操作人类型 user merchant admin system
+	OperatorId   int64     `gorm:"column:operator_id;NOT NULL"`                          // 操作人ID, 系统操作时为0
+	Remark       string    `gorm:"column:remark;type:varchar(255);NOT NULL"`             // 处理说明
+	CreatedAt    time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 创建时间
+}
+
+func (AfterSaleLog) TableName() string {
+	return "after_sale_log"
+}
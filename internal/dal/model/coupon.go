@@ -0,0 +1,57 @@
+package model
+
+import (
+	"gorm.io/plugin/soft_delete"
+	"time"
+)
+
+// CouponTemplate 优惠券模板, 定义优惠规则、发放数量和有效期, 用户领到的是模板的一张实例
+type CouponTemplate struct {
+	ID           int64                 `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // 模板ID
+	Name         string                `gorm:"column:name;type:varchar(64);NOT NULL"`                // 优惠券名称
+	Type         int8                  `gorm:"column:type;NOT NULL"`                                 // 优惠券类型, 见enum.CouponTypeXXX
+	Threshold    int64                 `gorm:"column:threshold;default:0;NOT NULL"`                  // 使用门槛(分), 适用范围内商品金额满多少可用
+	Amount       int64                 `gorm:"column:amount;default:0;NOT NULL"`                     // 立减券、满减券的优惠金额(分)
+	Discount     int                   `gorm:"column:discount;default:0;NOT NULL"`                   // 折扣券的折扣率, 85表示打85折
+	MaxDiscount  int64                 `gorm:"column:max_discount;default:0;NOT NULL"`               // 折扣券的最高优惠金额(分), 0表示不限
+	ScopeType    int8                  `gorm:"column:scope_type;default:0;NOT NULL"`                 // 适用范围, 见enum.CouponScopeXXX
+	ScopeIds     string                `gorm:"column:scope_ids;type:text"`                           // 适用的分类ID或SKU ID, JSON数组
+	Stackable    bool                  `gorm:"column:stackable;default:0;NOT NULL"`                  // 能否与其他可叠加的券一起使用
+	Claimable    bool                  `gorm:"column:claimable;default:0;NOT NULL"`                  // 用户能否主动领取, 否则只能后台发放
+	TotalCount   int                   `gorm:"column:total_count;default:0;NOT NULL"`                // 发放总量, 0表示不限
+	IssuedCount  int                   `gorm:"column:issued_count;default:0;NOT NULL"`               // 已发放数量
+	PerUserLimit int                   `gorm:"column:per_user_limit;default:0;NOT NULL"`             // 每个用户最多领取的张数, 0表示不限
+	ClaimStartAt time.Time             `gorm:"column:claim_start_at;NOT NULL"`                       // 领取开始时间
+	ClaimEndAt   time.Time             `gorm:"column:claim_end_at;NOT NULL"`                         // 领取结束时间
+	ValidType    int8                  `gorm:"column:valid_type;NOT NULL"`                           // 有效期类型, 见enum.CouponValidXXX
+	ValidStartAt time.Time             `gorm:"column:valid_start_at;NOT NULL"`                       // 固定有效期的开始时间
+	ValidEndAt   time.Time             `gorm:"column:valid_end_at;NOT NULL"`                         // 固定有效期的结束时间
+	ValidDays    int                   `gorm:"column:valid_days;default:0;NOT NULL"`                 // 领取后有效天数
+	IsDel        soft_delete.DeletedAt `gorm:"softDelete:flag"`                                      // 删除状态 0-未删除 1-已删除
+	CreatedAt    time.Time             `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 创建时间
+	UpdatedAt    time.Time             `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 更新时间
+}
+
+func (CouponTemplate) TableName() string {
+	return "coupon_template"
+}
+
+// UserCoupon 用户持有的优惠券
+type UserCoupon struct {
+	ID           int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // 用户优惠券ID
+	TemplateId   int64     `gorm:"column:template_id;index;NOT NULL"`                    // 优惠券模板ID
+	UserId       int64     `gorm:"column:user_id;index;NOT NULL"`                        // 用户ID
+	Source       int8      `gorm:"column:source;NOT NULL"`                               // 来源, 见enum.CouponSourceXXX
+	State        int8      `gorm:"column:state;default:1;NOT NULL"`                      // 状态, 见enum.UserCouponStateXXX
+	ValidStartAt time.Time `gorm:"column:valid_start_at;NOT NULL"`                       // 生效时间
+	ValidEndAt   time.Time `gorm:"column:valid_end_at;NOT NULL"`                         // 过期时间
+	OrderId      int64     `gorm:"column:order_id;index;default:0;NOT NULL"`             // 锁定或使用该券的订单ID
+	LockedAt     time.Time `gorm:"column:locked_at;default:\"1970-01-01 00:00:00\""`     // 锁定时间
+	UsedAt       time.Time `gorm:"column:used_at;default:\"1970-01-01 00:00:00\""`       // 使用时间
+	CreatedAt    time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 领取时间
+	UpdatedAt    time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 更新时间
+}
+
+func (UserCoupon) TableName() string {
+	return "user_coupon"
+}
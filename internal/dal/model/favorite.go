@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// Favorite 用户收藏的商品, 取消收藏时直接删除
+type Favorite struct {
+	ID        int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                     // 自增ID
+	UserId    int64     `gorm:"column:user_id;uniqueIndex:uk_user_goods;NOT NULL"`        // 用户ID
+	GoodsId   int64     `gorm:"column:goods_id;uniqueIndex:uk_user_goods;index;NOT NULL"` // 商品ID
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"`     // 收藏时间
+}
+
+func (Favorite) TableName() string {
+	return "favorite"
+}
+
+// GoodsSubscription 用户对SKU的到货或降价提醒, 同一用户对同一SKU的每种提醒只有一条, 重新订阅时复用
+type GoodsSubscription struct {
+	ID            int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                                         // 自增ID
+	UserId        int64     `gorm:"column:user_id;uniqueIndex:uk_user_sku_type;NOT NULL"`                         // 用户ID
+	SkuId         int64     `gorm:"column:sku_id;uniqueIndex:uk_user_sku_type;index:idx_sku_type_state;NOT NULL"` // SKU ID
+	Type          int8      `gorm:"column:type;uniqueIndex:uk_user_sku_type;index:idx_sku_type_state;NOT NULL"`   // 订阅类型, 见enum.SubscriptionTypeXXX
+	State         int8      `gorm:"column:state;index:idx_sku_type_state;NOT NULL"`                               // 订阅状态, 见enum.SubscriptionStateXXX
+	GoodsId       int64     `gorm:"column:goods_id;NOT NULL"`                                                     // 商品ID
+	BaselinePrice int64     `gorm:"column:baseline_price;NOT NULL"`                                               // 降价提醒的比较基准: 订阅时的价格, 每次通知后更新为通知时的价格
+	TargetPrice   int64     `gorm:"column:target_price;default:0;NOT NULL"`                                       // 降到多少才提醒, 0表示只要降价就提醒
+	NotifiedAt    time.Time `gorm:"column:notified_at;default:\"1970-01-01 00:00:00\""`                           // 最近一次通知时间
+	CreatedAt     time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"`                         // 创建时间
+	UpdatedAt     time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"`                         // 更新时间
+}
+
+func (GoodsSubscription) TableName() string {
+	return "goods_subscription"
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"gorm.io/plugin/soft_delete"
+	"time"
+)
+
+// FreightTemplate 运费模板, 没有命中地区规则时按模板上的默认规则计费
+type FreightTemplate struct {
+	ID             int64                 `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // 运费模板ID
+	Name           string                `gorm:"column:name;type:varchar(64);NOT NULL"`                // 模板名称
+	ChargeType     int8                  `gorm:"column:charge_type;NOT NULL"`                          // 计费方式, 见enum.FreightChargeXXX
+	FirstUnit      int64                 `gorm:"column:first_unit;default:0;NOT NULL"`                 // 首件数或首重(克)
+	FirstFee       int64                 `gorm:"column:first_fee;default:0;NOT NULL"`                  // 首件/首重运费, 固定运费时为运费(分)
+	AdditionalUnit int64                 `gorm:"column:additional_unit;default:0;NOT NULL"`            // 续件数或续重(克)
+	AdditionalFee  int64                 `gorm:"column:additional_fee;default:0;NOT NULL"`             // 每个续件/续重单位的运费(分)
+	FreeThreshold  int64                 `gorm:"column:free_threshold;default:0;NOT NULL"`             // 优惠后商品金额满多少包邮(分), 0表示不包邮
+	IsDefault      bool                  `gorm:"column:is_default;default:0;NOT NULL"`                 // 是否默认模板, 没有指定模板的商品使用默认模板
+	IsDel          soft_delete.DeletedAt `gorm:"softDelete:flag"`                                      // 删除状态 0-未删除 1-已删除
+	CreatedAt      time.Time             `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 创建时间
+	UpdatedAt      time.Time             `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 更新时间
+}
+
+func (FreightTemplate) TableName() string {
+	return "freight_template"
+}
+
+// FreightRegionRule 运费模板的地区规则, 计费方式和模板一致
+type FreightRegionRule struct {
+	ID             int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // 自增ID
+	TemplateId     int64     `gorm:"column:template_id;index;NOT NULL"`                    // 运费模板ID
+	Regions        string    `gorm:"column:regions;type:text;NOT NULL"`                    // 适用地区, JSON数组, 元素为"省"或"省/市"
+	Undeliverable  bool      `gorm:"column:undeliverable;default:0;NOT NULL"`              // 是否不配送, 不配送的地区不能下单
+	FirstUnit      int64     `gorm:"column:first_unit;default:0;NOT NULL"`                 // 首件数或首重(克)
+	FirstFee       int64     `gorm:"column:first_fee;default:0;NOT NULL"`                  // 首件/首重运费(分)
+	AdditionalUnit int64     `gorm:"column:additional_unit;default:0;NOT NULL"`            // 续件数或续重(克)
+	AdditionalFee  int64     `gorm:"column:additional_fee;default:0;NOT NULL"`             // 每个续件/续重单位的运费(分)
+	FreeThreshold  int64     `gorm:"column:free_threshold;default:0;NOT NULL"`             // 优惠后商品金额满多少包邮(分), 0表示不包邮
+	CreatedAt      time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 创建时间
+}
+
+func (FreightRegionRule) TableName() string {
+	return "freight_region_rule"
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"gorm.io/plugin/soft_delete"
+	"time"
+)
+
+type Goods struct {
+	ID                int64                 `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // 商品ID
+	MerchantId        int64                 `gorm:"column:merchant_id;index;default:0;NOT NULL"`          // 所属店铺ID, 0表示平台自营
+	CategoryId        int64                 `gorm:"column:category_id;NOT NULL"`                          // 商品分类ID
+	Title             string                `gorm:"column:title;type:varchar(128);NOT NULL"`              // 商品标题
+	Image             string                `gorm:"column:image;type:varchar(255);NOT NULL"`              // 商品主图
+	State             int                   `gorm:"column:state;default:0;NOT NULL"`                      // 上架状态 0-下架 1-上架
+	FreightTemplateId int64                 `gorm:"column:freight_template_id;default:0;NOT NULL"`        // 运费模板ID, 0表示使用默认模板
+	IsDel             soft_delete.DeletedAt `gorm:"softDelete:flag"`                                      // 删除状态 0-未删除 1-已删除
+	CreatedAt         time.Time             `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 创建时间
+	UpdatedAt         time.Time             `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 更新时间
+}
+
+func (Goods) TableName() string {
+	return "goods"
+}
+
+type GoodsSku struct {
+	ID        int64                 `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // SKU ID
+	GoodsId   int64                 `gorm:"column:goods_id;NOT NULL"`                             // 商品ID
+	Spec      string                `gorm:"column:spec;type:varchar(255);NOT NULL"`               // 规格描述, 如: 颜色:红;尺码:L
+	Image     string                `gorm:"column:image;type:varchar(255);NOT NULL"`              // SKU图片
+	Price     int64                 `gorm:"column:price;NOT NULL"`                                // 售价(分)
+	Stock     int                   `gorm:"column:stock;default:0;NOT NULL"`                      // 库存
+	Weight    int64                 `gorm:"column:weight;default:0;NOT NULL"`                     // 重量(克), 按重量计算运费时使用
+	IsDel     soft_delete.DeletedAt `gorm:"softDelete:flag"`                                      // 删除状态 0-未删除 1-已删除
+	CreatedAt time.Time             `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 创建时间
+	UpdatedAt time.Time             `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 更新时间
+}
+
+func (GoodsSku) TableName() string {
+	return "goods_sku"
+}
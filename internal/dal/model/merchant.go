@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Merchant 店铺, 一个用户只能开通一个店铺, 店主登录后通过商家后台管理自己店铺的商品、订单和售后
+type Merchant struct {
+	ID          int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // 店铺ID
+	Name        string    `gorm:"column:name;type:varchar(64);uniqueIndex;NOT NULL"`    // 店铺名称
+	OwnerUserId int64     `gorm:"column:owner_user_id;uniqueIndex;NOT NULL"`            // 店主的用户ID
+	Logo        string    `gorm:"column:logo;type:varchar(255);NOT NULL"`               // 店铺Logo
+	Description string    `gorm:"column:description;type:varchar(512);NOT NULL"`        // 店铺简介
+	State       int8      `gorm:"column:state;default:1;NOT NULL"`                      // 店铺状态, 见enum.MerchantStateXXX
+	CreatedAt   time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 创建时间
+	UpdatedAt   time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 更新时间
+}
+
+func (Merchant) TableName() string {
+	return "merchant"
+}
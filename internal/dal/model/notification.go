@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// NotificationMessage 用户收件箱里的站内消息
+// 广播消息不在发布时给每个用户写一条, 用户打开消息中心时才把还没拉取的广播复制到自己的收件箱
+type NotificationMessage struct {
+	ID          int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                       // 自增ID
+	UserId      int64     `gorm:"column:user_id;index:idx_user_read_type;NOT NULL"`           // 用户ID
+	IsRead      bool      `gorm:"column:is_read;index:idx_user_read_type;default:0;NOT NULL"` // 是否已读
+	Type        int8      `gorm:"column:type;index:idx_user_read_type;NOT NULL"`              // 消息类型, 见enum.NotificationTypeXXX
+	BroadcastId int64     `gorm:"column:broadcast_id;default:0;NOT NULL"`                     // 从哪条广播复制来的, 0表示发给个人的消息
+	Title       string    `gorm:"column:title;type:varchar(128);NOT NULL"`                    // 标题
+	Content     string    `gorm:"column:content;type:varchar(1024);NOT NULL"`                 // 内容
+	Link        string    `gorm:"column:link;type:varchar(255);NOT NULL"`                     // 点击消息打开的地址
+	ReadAt      time.Time `gorm:"column:read_at;default:\"1970-01-01 00:00:00\""`             // 阅读时间
+	CreatedAt   time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"`       // 创建时间
+}
+
+func (NotificationMessage) TableName() string {
+	return "notification_message"
+}
+
+// NotificationBroadcast 发给全体用户的广播消息
+type NotificationBroadcast struct {
+	ID        int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                       // 自增ID
+	Type      int8      `gorm:"column:type;NOT NULL"`                                       // 消息类型, 见enum.NotificationTypeXXX
+	Title     string    `gorm:"column:title;type:varchar(128);NOT NULL"`                    // 标题
+	Content   string    `gorm:"column:content;type:varchar(1024);NOT NULL"`                 // 内容
+	Link      string    `gorm:"column:link;type:varchar(255);NOT NULL"`                     // 点击消息打开的地址
+	CreatedBy int64     `gorm:"column:created_by;NOT NULL"`                                 // 发布的管理员ID
+	CreatedAt time.Time `gorm:"column:created_at;index;default:CURRENT_TIMESTAMP;NOT NULL"` // 发布时间
+}
+
+func (NotificationBroadcast) TableName() string {
+	return "notification_broadcast"
+}
+
+// NotificationCursor 用户已经拉取到的最后一条广播消息
+type NotificationCursor struct {
+	UserId          int64     `gorm:"column:user_id;primary_key"`                           // 用户ID
+	LastBroadcastId int64     `gorm:"column:last_broadcast_id;default:0;NOT NULL"`          // 已拉取的最大广播ID
+	UpdatedAt       time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 更新时间
+}
+
+func (NotificationCursor) TableName() string {
+	return "notification_cursor"
+}
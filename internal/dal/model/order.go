@@ -0,0 +1,87 @@
+package model
+
+import (
+	"gorm.io/plugin/soft_delete"
+	"time"
+)
+
+type Order struct {
+	ID              int64                 `gorm:"column:id;primary_key;AUTO_INCREMENT"`                   // 订单ID
+	OrderNo         string                `gorm:"column:order_no;type:varchar(32);uniqueIndex;NOT NULL"`  // 订单号
+	ParentOrderNo   string                `gorm:"column:parent_order_no;type:varchar(32);index;NOT NULL"` // 父订单号, 一次结算按店铺拆分出的子订单共用一个父订单号并合并支付
+	MerchantId      int64                 `gorm:"column:merchant_id;index;default:0;NOT NULL"`            // 所属店铺ID, 0表示平台自营
+	UserId          int64                 `gorm:"column:user_id;index;NOT NULL"`                          // 用户ID
+	BillMoney       int64                 `gorm:"column:bill_money;NOT NULL"`                             // 商品金额(分) = 各明细小计之和
+	PromotionAmount int64                 `gorm:"column:promotion_amount;default:0;NOT NULL"`             // 营销活动优惠金额(分)
+	CouponAmount    int64                 `gorm:"column:coupon_amount;default:0;NOT NULL"`                // 优惠券抵扣金额(分)
+	PointsAmount    int64                 `gorm:"column:points_amount;default:0;NOT NULL"`                // 积分抵扣金额(分)
+	PointsUsed      int64                 `gorm:"column:points_used;default:0;NOT NULL"`                  // 抵扣使用的积分数
+	ShippingFee     int64                 `gorm:"column:shipping_fee;default:0;NOT NULL"`                 // 运费(分)
+	TaxAmount       int64                 `gorm:"column:tax_amount;default:0;NOT NULL"`                   // 税费(分)
+	PayMoney        int64                 `gorm:"column:pay_money;NOT NULL"`                              // 实付金额(分) = 商品金额 - 优惠 + 税费 + 运费
+	ReceiverName    string                `gorm:"column:receiver_name;type:varchar(32);NOT NULL"`         // 收货人
+	ReceiverPhone   string                `gorm:"column:receiver_phone;type:varchar(20);NOT NULL"`        // 收货人手机号
+	Province        string                `gorm:"column:province;type:varchar(32);NOT NULL"`              // 省
+	City            string                `gorm:"column:city;type:varchar(32);NOT NULL"`                  // 市
+	District        string                `gorm:"column:district;type:varchar(32);NOT NULL"`              // 区县
+	Address         string                `gorm:"column:address;type:varchar(255);NOT NULL"`              // 详细地址
+	State           int8                  `gorm:"column:state;default:1;NOT NULL"`                        // 订单状态, 见enum.OrderStateXXX
+	Version         int                   `gorm:"column:version;default:0;NOT NULL"`                      // 乐观锁版本号, 每次状态变更+1
+	Remark          string                `gorm:"column:remark;type:varchar(255);NOT NULL"`               // 买家备注
+	PaidAt          time.Time             `gorm:"column:paid_at;default:\"1970-01-01 00:00:00\""`         // 支付时间
+	ShippedAt       time.Time             `gorm:"column:shipped_at;default:\"1970-01-01 00:00:00\""`      // 发货时间
+	DeliveredAt     time.Time             `gorm:"column:delivered_at;default:\"1970-01-01 00:00:00\""`    // 签收时间
+	CompletedAt     time.Time             `gorm:"column:completed_at;default:\"1970-01-01 00:00:00\""`    // 完成时间
+	CancelledAt     time.Time             `gorm:"column:cancelled_at;default:\"1970-01-01 00:00:00\""`    // 取消时间
+	IsDel           soft_delete.DeletedAt `gorm:"softDelete:flag"`                                        // 删除状态 0-未删除 1-已删除
+	CreatedAt       time.Time             `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"`   // 创建时间
+	UpdatedAt       time.Time             `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"`   // 更新时间
+}
+
+func (Order) TableName() string {
+	return "orders"
+}
+
+// OrderItem 订单明细, 商品信息和金额都是下单时的快照, 写入后不再修改,
+// 订单详情、售后退款都以快照为准, 不受商品后续改价、改标题、下架的影响
+type OrderItem struct {
+	ID              int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // 订单明细ID
+	OrderId         int64     `gorm:"column:order_id;index;NOT NULL"`                       // 订单ID
+	GoodsId         int64     `gorm:"column:goods_id;NOT NULL"`                             // 商品ID
+	SkuId           int64     `gorm:"column:sku_id;NOT NULL"`                               // SKU ID
+	GoodsTitle      string    `gorm:"column:goods_title;type:varchar(128);NOT NULL"`        // 下单时的商品标题
+	SkuSpec         string    `gorm:"column:sku_spec;type:varchar(255);NOT NULL"`           // 下单时的SKU规格描述
+	GoodsImage      string    `gorm:"column:goods_image;type:varchar(255);NOT NULL"`        // 下单时的商品图片, SKU有图时取SKU图片
+	Quantity        int       `gorm:"column:quantity;NOT NULL"`                             // 购买数量
+	UnitPrice       int64     `gorm:"column:unit_price;NOT NULL"`                           // 下单时的单价(分)
+	Amount          int64     `gorm:"column:amount;NOT NULL"`                               // 明细金额(分) = 单价 * 数量
+	PromotionAmount int64     `gorm:"column:promotion_amount;default:0;NOT NULL"`           // 分摊到该明细的营销活动优惠金额(分)
+	CouponAmount    int64     `gorm:"column:coupon_amount;default:0;NOT NULL"`              // 分摊到该明细的优惠券抵扣金额(分)
+	PointsAmount    int64     `gorm:"column:points_amount;default:0;NOT NULL"`              // 分摊到该明细的积分抵扣金额(分)
+	PointsUsed      int64     `gorm:"column:points_used;default:0;NOT NULL"`                // 分摊到该明细的积分数, 退款时按数量比例退回
+	TaxAmount       int64     `gorm:"column:tax_amount;default:0;NOT NULL"`                 // 分摊到该明细的税费(分)
+	PayAmount       int64     `gorm:"column:pay_amount;default:0;NOT NULL"`                 // 明细实付金额(分) = 小计 - 优惠 + 税费, 退款按它计算
+	Promotions      string    `gorm:"column:promotions;type:text"`                          // 下单时该明细享受的优惠, JSON数组
+	CreatedAt       time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 创建时间
+}
+
+func (OrderItem) TableName() string {
+	return "order_item"
+}
+
+// OrderStateLog 订单状态流转记录, 只追加不修改
+type OrderStateLog struct {
+	ID           int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // 自增ID
+	OrderId      int64     `gorm:"column:order_id;index;NOT NULL"`                       // 订单ID
+	OrderNo      string    `gorm:"column:order_no;type:varchar(32);NOT NULL"`            // 订单号
+	FromState    int8      `gorm:"column:from_state;NOT NULL"`                           // 变更前状态
+	ToState      int8      `gorm:"column:to_state;NOT NULL"`                             // 变更后状态
+	OperatorType string    `gorm:"column:operator_type;type:varchar(16);NOT NULL"`       // 操作人类型 user merchant admin system
+	OperatorId   int64     `gorm:"column:operator_id;NOT NULL"`                          // 操作人ID, 系统操作时为0
+	Remark       string    `gorm:"column:remark;type:varchar(255);NOT NULL"`             // 变更原因
+	CreatedAt    time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 创建时间
+}
+
+func (OrderStateLog) TableName() string {
+	return "order_state_log"
+}
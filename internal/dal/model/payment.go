@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type Payment struct {
+	ID             int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                    // 支付单ID
+	PaymentNo      string    `gorm:"column:payment_no;type:varchar(32);uniqueIndex;NOT NULL"` // 支付单号
+	ParentOrderNo  string    `gorm:"column:parent_order_no;type:varchar(32);index;NOT NULL"`  // 父订单号, 一次支付父订单下所有待支付的子订单
+	UserId         int64     `gorm:"column:user_id;NOT NULL"`                                 // 用户ID
+	Provider       string    `gorm:"column:provider;type:varchar(16);NOT NULL"`               // 支付渠道
+	Amount         int64     `gorm:"column:amount;NOT NULL"`                                  // 支付金额(分) = 各子订单实付金额之和
+	RefundedAmount int64     `gorm:"column:refunded_amount;default:0;NOT NULL"`               // 已退款金额(分), 包含已经发起还没有完成的退款
+	State          int8      `gorm:"column:state;default:1;NOT NULL"`                         // 状态 1-待支付 2-支付成功 3-已关闭
+	TradeNo        string    `gorm:"column:trade_no;type:varchar(64);NOT NULL"`               // 渠道侧交易号
+	PaidAt         time.Time `gorm:"column:paid_at;default:\"1970-01-01 00:00:00\""`          // 支付时间
+	CreatedAt      time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"`    // 创建时间
+	UpdatedAt      time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"`    // 更新时间
+
+	// 收到支付成功时子订单已经超时取消, 这部分金额需要原路退回
+	ClosedRefundAmount  int64  `gorm:"column:closed_refund_amount;default:0;NOT NULL"`          // 已取消子订单需要退回的金额(分)
+	ClosedRefundTradeNo string `gorm:"column:closed_refund_trade_no;type:varchar(64);NOT NULL"` // 已取消子订单的渠道退款交易号, 为空表示还没有退款成功
+}
+
+func (Payment) TableName() string {
+	return "payment"
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// PointsAccount 用户的积分账户, 是积分流水的汇总, 每次写流水时在同一个事务里更新
+type PointsAccount struct {
+	ID          int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // 自增ID
+	UserId      int64     `gorm:"column:user_id;uniqueIndex;NOT NULL"`                  // 用户ID
+	Balance     int64     `gorm:"column:balance;default:0;NOT NULL"`                    // 可用积分 = 全部流水的积分之和
+	GrowthValue int64     `gorm:"column:growth_value;default:0;NOT NULL"`               // 成长值, 决定会员等级, 不会过期
+	CreatedAt   time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 创建时间
+	UpdatedAt   time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 更新时间
+}
+
+func (PointsAccount) TableName() string {
+	return "points_account"
+}
+
+// PointsLedger 积分流水, 只追加不修改
+// 同一用户、同一类型、同一业务单号只能有一条流水, 重复执行的任务和回调不会重复加减积分
+type PointsLedger struct {
+	ID        int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                                 // 自增ID
+	UserId    int64     `gorm:"column:user_id;uniqueIndex:uk_user_type_biz;NOT NULL"`                 // 用户ID
+	Type      int8      `gorm:"column:type;uniqueIndex:uk_user_type_biz;NOT NULL"`                    // 流水类型, 见enum.PointsTypeXXX
+	BizNo     string    `gorm:"column:biz_no;type:varchar(64);uniqueIndex:uk_user_type_biz;NOT NULL"` // 业务单号: 订单号、售后单号或过期批次号
+	OrderNo   string    `gorm:"column:order_no;type:varchar(32);index;NOT NULL"`                      // 关联的订单号, 过期流水为空
+	Points    int64     `gorm:"column:points;NOT NULL"`                                               // 积分变动, 增加为正数, 减少为负数
+	Growth    int64     `gorm:"column:growth;default:0;NOT NULL"`                                     // 成长值变动
+	Balance   int64     `gorm:"column:balance;NOT NULL"`                                              // 变动后的积分余额
+	ExpireAt  time.Time `gorm:"column:expire_at;index;default:\"1970-01-01 00:00:00\""`               // 增加的积分的过期时间, 减少积分的流水不使用
+	Remark    string    `gorm:"column:remark;type:varchar(255);NOT NULL"`                             // 备注
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"`                 // 创建时间
+}
+
+func (PointsLedger) TableName() string {
+	return "points_ledger"
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Review 商品评价, 每个订单明细只能评价一次
+type Review struct {
+	ID           int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                  // 评价ID
+	OrderId      int64     `gorm:"column:order_id;NOT NULL"`                              // 订单ID
+	OrderItemId  int64     `gorm:"column:order_item_id;uniqueIndex;NOT NULL"`             // 订单明细ID
+	UserId       int64     `gorm:"column:user_id;index;NOT NULL"`                         // 评价人用户ID
+	GoodsId      int64     `gorm:"column:goods_id;index:idx_goods_state;NOT NULL"`        // 商品ID
+	SkuId        int64     `gorm:"column:sku_id;NOT NULL"`                                // SKU ID
+	MerchantId   int64     `gorm:"column:merchant_id;index;default:0;NOT NULL"`           // 商品所属店铺ID
+	Rating       int8      `gorm:"column:rating;NOT NULL"`                                // 评分 1~5
+	Content      string    `gorm:"column:content;type:varchar(1024);NOT NULL"`            // 评价内容, 已经过敏感词过滤
+	Images       string    `gorm:"column:images;type:text;NOT NULL"`                      // 评价图片, JSON数组
+	HasImages    bool      `gorm:"column:has_images;default:0;NOT NULL"`                  // 是否有图, 用于筛选有图评价
+	Anonymous    bool      `gorm:"column:anonymous;default:0;NOT NULL"`                   // 是否匿名评价
+	State        int8      `gorm:"column:state;index:idx_goods_state;default:1;NOT NULL"` // 展示状态, 见enum.ReviewStateXXX
+	HiddenReason string    `gorm:"column:hidden_reason;type:varchar(255);NOT NULL"`       // 隐藏原因
+	ReplyContent string    `gorm:"column:reply_content;type:varchar(1024);NOT NULL"`      // 商家回复
+	RepliedAt    time.Time `gorm:"column:replied_at;default:\"1970-01-01 00:00:00\""`     // 商家回复时间
+	CreatedAt    time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"`  // 创建时间
+	UpdatedAt    time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"`  // 更新时间
+}
+
+func (Review) TableName() string {
+	return "review"
+}
+
+// ReviewRatingStat 按评分分组统计的评价数量
+type ReviewRatingStat struct {
+	Rating     int8
+	Count      int64
+	ImageCount int64
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"gorm.io/plugin/soft_delete"
+	"time"
+)
+
+// SeckillActivity 秒杀活动, 一个活动对应一个SKU
+type SeckillActivity struct {
+	ID           int64                 `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // 活动ID
+	Title        string                `gorm:"column:title;type:varchar(64);NOT NULL"`               // 活动标题
+	GoodsId      int64                 `gorm:"column:goods_id;NOT NULL"`                             // 商品ID
+	SkuId        int64                 `gorm:"column:sku_id;index;NOT NULL"`                         // SKU ID
+	SeckillPrice int64                 `gorm:"column:seckill_price;NOT NULL"`                        // 秒杀价(分)
+	TotalStock   int                   `gorm:"column:total_stock;NOT NULL"`                          // 活动库存
+	SoldCount    int                   `gorm:"column:sold_count;default:0;NOT NULL"`                 // 已生成订单的数量
+	PerUserLimit int                   `gorm:"column:per_user_limit;default:1;NOT NULL"`             // 每人限购数量
+	StartAt      time.Time             `gorm:"column:start_at;NOT NULL"`                             // 开始时间
+	EndAt        time.Time             `gorm:"column:end_at;NOT NULL"`                               // 结束时间
+	IsDel        soft_delete.DeletedAt `gorm:"softDelete:flag"`                                      // 删除状态 0-未删除 1-已删除
+	CreatedAt    time.Time             `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 创建时间
+	UpdatedAt    time.Time             `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 更新时间
+}
+
+func (SeckillActivity) TableName() string {
+	return "seckill_activity"
+}
+
+// SeckillOrder 抢购凭证和订单的对应关系, 凭证唯一保证重复消费同一个抢购请求只生成一个订单
+type SeckillOrder struct {
+	ID         int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // 自增ID
+	Ticket     string    `gorm:"column:ticket;type:varchar(32);uniqueIndex;NOT NULL"`  // 抢购凭证
+	ActivityId int64     `gorm:"column:activity_id;index;NOT NULL"`                    // 活动ID
+	UserId     int64     `gorm:"column:user_id;index;NOT NULL"`                        // 用户ID
+	Quantity   int       `gorm:"column:quantity;NOT NULL"`                             // 抢购数量
+	OrderId    int64     `gorm:"column:order_id;index;NOT NULL"`                       // 订单ID
+	OrderNo    string    `gorm:"column:order_no;type:varchar(32);NOT NULL"`            // 订单号
+	CreatedAt  time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 创建时间
+}
+
+func (SeckillOrder) TableName() string {
+	return "seckill_order"
+}
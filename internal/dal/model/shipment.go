@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// Shipment 订单的物流单
+type Shipment struct {
+	ID            int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                                         // 物流单ID
+	OrderId       int64     `gorm:"column:order_id;index;NOT NULL"`                                               // 订单ID
+	OrderNo       string    `gorm:"column:order_no;type:varchar(32);NOT NULL"`                                    // 订单号
+	UserId        int64     `gorm:"column:user_id;NOT NULL"`                                                      // 买家用户ID
+	Carrier       string    `gorm:"column:carrier;type:varchar(32);uniqueIndex:uk_carrier_tracking;NOT NULL"`     // 物流公司编码
+	TrackingNo    string    `gorm:"column:tracking_no;type:varchar(64);uniqueIndex:uk_carrier_tracking;NOT NULL"` // 物流单号
+	State         int8      `gorm:"column:state;default:1;NOT NULL"`                                              // 物流状态, 见enum.ShipmentStateXXX
+	ShippedAt     time.Time `gorm:"column:shipped_at;default:CURRENT_TIMESTAMP;NOT NULL"`                         // 发货时间
+	DeliveredAt   time.Time `gorm:"column:delivered_at;default:\"1970-01-01 00:00:00\""`                          // 签收时间
+	LastTrackedAt time.Time `gorm:"column:last_tracked_at;default:\"1970-01-01 00:00:00\""`                       // 最近一次同步物流轨迹的时间
+	CreatedAt     time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"`                         // 创建时间
+	UpdatedAt     time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"`                         // 更新时间
+}
+
+func (Shipment) TableName() string {
+	return "shipment"
+}
+
+// ShipmentEvent 物流轨迹, 同一个物流单的同一时间同一状态只保存一条, 重复同步时忽略
+type ShipmentEvent struct {
+	ID          int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                                  // 自增ID
+	ShipmentId  int64     `gorm:"column:shipment_id;uniqueIndex:uk_shipment_event;NOT NULL"`             // 物流单ID
+	EventTime   time.Time `gorm:"column:event_time;uniqueIndex:uk_shipment_event;NOT NULL"`              // 轨迹发生时间
+	Status      string    `gorm:"column:status;type:varchar(32);uniqueIndex:uk_shipment_event;NOT NULL"` // 物流公司返回的轨迹状态
+	Location    string    `gorm:"column:location;type:varchar(64);NOT NULL"`                             // 所在地
+	Description string    `gorm:"column:description;type:varchar(255);NOT NULL"`                         // 轨迹描述
+	CreatedAt   time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"`                  // 创建时间
+}
+
+func (ShipmentEvent) TableName() string {
+	return "shipment_event"
+}
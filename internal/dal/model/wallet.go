@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// LedgerAccount 复式记账的账户, 用户钱包、礼品卡和系统账户都是账户
+// 余额是账户全部分录金额之和, 每次记账时在同一个事务里更新, 每日对账任务校验两者一致
+type LedgerAccount struct {
+	ID        int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                    // 自增ID
+	AccountNo string    `gorm:"column:account_no;type:varchar(64);uniqueIndex;NOT NULL"` // 账户号, 如 wallet:用户ID、gift_card:卡号、system:xxx
+	Type      int8      `gorm:"column:type;NOT NULL"`                                    // 账户类型, 见enum.LedgerAccountTypeXXX
+	OwnerId   int64     `gorm:"column:owner_id;index;default:0;NOT NULL"`                // 钱包所属的用户ID, 其他账户为0
+	Balance   int64     `gorm:"column:balance;default:0;NOT NULL"`                       // 余额, 单位分
+	CreatedAt time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"`    // 创建时间
+	UpdatedAt time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"`    // 更新时间
+}
+
+func (LedgerAccount) TableName() string {
+	return "ledger_account"
+}
+
+// LedgerTransaction 记账交易, 一笔交易包含多条分录且分录金额之和为0
+// 幂等键唯一, 同一个业务动作重复记账时直接返回已有的交易
+type LedgerTransaction struct {
+	ID             int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                          // 自增ID
+	TxNo           string    `gorm:"column:tx_no;type:varchar(32);uniqueIndex;NOT NULL"`            // 交易号
+	IdempotencyKey string    `gorm:"column:idempotency_key;type:varchar(128);uniqueIndex;NOT NULL"` // 幂等键
+	Type           int8      `gorm:"column:type;NOT NULL"`                                          // 交易类型, 见enum.LedgerTxTypeXXX
+	BizNo          string    `gorm:"column:biz_no;type:varchar(64);index;NOT NULL"`                 // 业务单号: 支付单号、售后单号、礼品卡号或批次号
+	Amount         int64     `gorm:"column:amount;NOT NULL"`                                        // 交易金额 = 全部出账分录的金额之和
+	Remark         string    `gorm:"column:remark;type:varchar(255);NOT NULL"`                      // 备注
+	CreatedAt      time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"`          // 创建时间
+}
+
+func (LedgerTransaction) TableName() string {
+	return "ledger_transaction"
+}
+
+// LedgerEntry 记账分录, 只追加不修改, 入账为正数, 出账为负数
+type LedgerEntry struct {
+	ID            int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                 // 自增ID
+	TransactionId int64     `gorm:"column:transaction_id;index;NOT NULL"`                 // 记账交易ID
+	AccountId     int64     `gorm:"column:account_id;index;NOT NULL"`                     // 账户ID
+	Amount        int64     `gorm:"column:amount;NOT NULL"`                               // 金额, 入账为正数, 出账为负数
+	Balance       int64     `gorm:"column:balance;NOT NULL"`                              // 记账后的账户余额
+	CreatedAt     time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"` // 创建时间
+}
+
+func (LedgerEntry) TableName() string {
+	return "ledger_entry"
+}
+
+// GiftCard 礼品卡, 发行时从礼品卡发行账户入账到礼品卡账户, 兑换时整张卡的余额转入用户钱包
+// 卡密只保存哈希, 明文只在发行时返回一次
+type GiftCard struct {
+	ID         int64     `gorm:"column:id;primary_key;AUTO_INCREMENT"`                   // 自增ID
+	CardNo     string    `gorm:"column:card_no;type:varchar(32);uniqueIndex;NOT NULL"`   // 卡号
+	CodeHash   string    `gorm:"column:code_hash;type:varchar(64);uniqueIndex;NOT NULL"` // 卡密的SHA256
+	BatchNo    string    `gorm:"column:batch_no;type:varchar(64);index;NOT NULL"`        // 发行批次号, 即发行请求的幂等键
+	Amount     int64     `gorm:"column:amount;NOT NULL"`                                 // 面值, 单位分
+	State      int8      `gorm:"column:state;NOT NULL"`                                  // 状态, 见enum.GiftCardStateXXX
+	RedeemedBy int64     `gorm:"column:redeemed_by;default:0;NOT NULL"`                  // 兑换的用户ID
+	RedeemedAt time.Time `gorm:"column:redeemed_at;default:\"1970-01-01 00:00:00\""`     // 兑换时间
+	ExpireAt   time.Time `gorm:"column:expire_at;NOT NULL"`                              // 兑换截止时间
+	CreatedBy  int64     `gorm:"column:created_by;NOT NULL"`                             // 发行的管理员ID
+	CreatedAt  time.Time `gorm:"column:created_at;default:CURRENT_TIMESTAMP;NOT NULL"`   // 创建时间
+	UpdatedAt  time.Time `gorm:"column:updated_at;default:CURRENT_TIMESTAMP;NOT NULL"`   // 更新时间
+}
+
+func (GiftCard) TableName() string {
+	return "gift_card"
+}
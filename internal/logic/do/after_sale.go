@@ -0,0 +1,44 @@
+package do
+
+import "time"
+
+type AfterSale struct {
+	ID               int64           `json:"id"`
+	AfterSaleNo      string          `json:"after_sale_no"`
+	OrderId          int64           `json:"order_id"`
+	OrderNo          string          `json:"order_no"`
+	OrderItemId      int64           `json:"order_item_id"`
+	SkuId            int64           `json:"sku_id"`
+	UserId           int64           `json:"user_id"`
+	MerchantId       int64           `json:"merchant_id"`
+	Type             int8            `json:"type"`
+	Quantity         int             `json:"quantity"`
+	RefundAmount     int64           `json:"refund_amount"`
+	RefundPoints     int64           `json:"refund_points"`
+	Reason           string          `json:"reason"`
+	Description      string          `json:"description"`
+	Images           []string        `json:"images"`
+	State            int8            `json:"state"`
+	Version          int             `json:"version"`
+	Restock          bool            `json:"restock"`
+	ReturnCarrier    string          `json:"return_carrier"`
+	ReturnTrackingNo string          `json:"return_tracking_no"`
+	MerchantRemark   string          `json:"merchant_remark"`
+	RefundNo         string          `json:"refund_no"`
+	RefundTradeNo    string          `json:"refund_trade_no"`
+	RefundedAt       time.Time       `json:"refunded_at"`
+	Logs             []*AfterSaleLog `json:"logs"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+type AfterSaleLog struct {
+	ID           int64     `json:"id"`
+	AfterSaleId  int64     `json:"after_sale_id"`
+	FromState    int8      `json:"from_state"`
+	ToState      int8      `json:"to_state"`
+	OperatorType string    `json:"operator_type"`
+	OperatorId   int64     `json:"operator_id"`
+	Remark       string    `json:"remark"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,63 @@
+package do
+
+import "time"
+
+type CouponTemplate struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	Type         int8      `json:"type"`
+	Threshold    int64     `json:"threshold"`
+	Amount       int64     `json:"amount"`
+	Discount     int       `json:"discount"`
+	MaxDiscount  int64     `json:"max_discount"`
+	ScopeType    int8      `json:"scope_type"`
+	ScopeIds     []int64   `json:"scope_ids"`
+	Stackable    bool      `json:"stackable"`
+	Claimable    bool      `json:"claimable"`
+	TotalCount   int       `json:"total_count"`
+	IssuedCount  int       `json:"issued_count"`
+	PerUserLimit int       `json:"per_user_limit"`
+	ClaimStartAt time.Time `json:"claim_start_at"`
+	ClaimEndAt   time.Time `json:"claim_end_at"`
+	ValidType    int8      `json:"valid_type"`
+	ValidStartAt time.Time `json:"valid_start_at"`
+	ValidEndAt   time.Time `json:"valid_end_at"`
+	ValidDays    int       `json:"valid_days"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type UserCoupon struct {
+	ID           int64           `json:"id"`
+	TemplateId   int64           `json:"template_id"`
+	UserId       int64           `json:"user_id"`
+	Source       int8            `json:"source"`
+	State        int8            `json:"state"`
+	ValidStartAt time.Time       `json:"valid_start_at"`
+	ValidEndAt   time.Time       `json:"valid_end_at"`
+	OrderId      int64           `json:"order_id"`
+	UsedAt       time.Time       `json:"used_at"`
+	Template     *CouponTemplate `json:"template"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// CouponLine 参与优惠计算的一行商品
+type CouponLine struct {
+	SkuId      int64 `json:"sku_id"`
+	CategoryId int64 `json:"category_id"`
+	Amount     int64 `json:"amount"` // 商品金额(分)
+}
+
+// CouponPlan 一组优惠券的使用方案和计算结果
+type CouponPlan struct {
+	Coupons         []*UserCoupon `json:"coupons"`          // 按实际计算顺序排列的优惠券
+	CouponDiscounts []int64       `json:"coupon_discounts"` // 每张券的优惠金额, 和Coupons一一对应
+	LineDiscounts   []int64       `json:"line_discounts"`   // 分摊到每行商品上的优惠金额, 和计算时传入的lines一一对应
+	CouponShares    [][]int64     `json:"coupon_shares"`    // 每张券分摊到每行商品上的金额, 外层和Coupons对应, 内层和lines对应
+	Discount        int64         `json:"discount"`         // 优惠总金额
+}
+
+// CouponSelection 下单时优惠券的选择方式
+type CouponSelection struct {
+	UserCouponIds []int64 // 用户指定使用的优惠券
+	Best          bool    // 由系统选出优惠最多的组合, 为true时忽略UserCouponIds
+}
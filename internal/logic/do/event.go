@@ -0,0 +1,10 @@
+package do
+
+// OrderStateEvent 推送给用户的订单状态变化
+type OrderStateEvent struct {
+	OrderNo       string `json:"order_no"`
+	ParentOrderNo string `json:"parent_order_no"`
+	FromState     int8   `json:"from_state"`
+	State         int8   `json:"state"`
+	StateName     string `json:"state_name"`
+}
@@ -0,0 +1,28 @@
+package do
+
+import "time"
+
+// Favorite 收藏的商品及其当前的价格和库存
+type Favorite struct {
+	ID        int64     `json:"id"`
+	GoodsId   int64     `json:"goods_id"`
+	Title     string    `json:"title"`
+	Image     string    `json:"image"`
+	State     int       `json:"state"`     // 商品上架状态, 商品已删除时为0
+	MinPrice  int64     `json:"min_price"` // 各SKU的最低售价
+	InStock   bool      `json:"in_stock"`  // 是否有SKU有库存
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GoodsSubscription struct {
+	ID            int64     `json:"id"`
+	UserId        int64     `json:"user_id"`
+	SkuId         int64     `json:"sku_id"`
+	GoodsId       int64     `json:"goods_id"`
+	Type          int8      `json:"type"`
+	State         int8      `json:"state"`
+	BaselinePrice int64     `json:"baseline_price"`
+	TargetPrice   int64     `json:"target_price"`
+	NotifiedAt    time.Time `json:"notified_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
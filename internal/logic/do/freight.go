@@ -0,0 +1,29 @@
+package do
+
+import "time"
+
+type FreightTemplate struct {
+	ID             int64                `json:"id"`
+	Name           string               `json:"name"`
+	ChargeType     int8                 `json:"charge_type"`
+	FirstUnit      int64                `json:"first_unit"`
+	FirstFee       int64                `json:"first_fee"`
+	AdditionalUnit int64                `json:"additional_unit"`
+	AdditionalFee  int64                `json:"additional_fee"`
+	FreeThreshold  int64                `json:"free_threshold"`
+	IsDefault      bool                 `json:"is_default"`
+	Rules          []*FreightRegionRule `json:"rules"`
+	CreatedAt      time.Time            `json:"created_at"`
+}
+
+type FreightRegionRule struct {
+	ID             int64    `json:"id"`
+	TemplateId     int64    `json:"template_id"`
+	Regions        []string `json:"regions"` // 元素为"省"或"省/市", 市级规则优先于省级规则
+	Undeliverable  bool     `json:"undeliverable"`
+	FirstUnit      int64    `json:"first_unit"`
+	FirstFee       int64    `json:"first_fee"`
+	AdditionalUnit int64    `json:"additional_unit"`
+	AdditionalFee  int64    `json:"additional_fee"`
+	FreeThreshold  int64    `json:"free_threshold"`
+}
@@ -0,0 +1,35 @@
+package do
+
+import "time"
+
+// Member 用户的会员信息, 等级由成长值计算得出
+type Member struct {
+	UserId      int64       `json:"user_id"`
+	Balance     int64       `json:"balance"`
+	GrowthValue int64       `json:"growth_value"`
+	Tier        *MemberTier `json:"tier"`
+	NextTier    *MemberTier `json:"next_tier"` // 已经是最高等级时为nil
+}
+
+// MemberTier 会员等级及权益
+type MemberTier struct {
+	Level        int8   `json:"level"`
+	Name         string `json:"name"`
+	MinGrowth    int64  `json:"min_growth"`
+	DiscountBps  int64  `json:"discount_bps"`
+	FreeShipping bool   `json:"free_shipping"`
+}
+
+type PointsLedger struct {
+	ID        int64     `json:"id"`
+	UserId    int64     `json:"user_id"`
+	Type      int8      `json:"type"`
+	BizNo     string    `json:"biz_no"`
+	OrderNo   string    `json:"order_no"`
+	Points    int64     `json:"points"`
+	Growth    int64     `json:"growth"`
+	Balance   int64     `json:"balance"`
+	ExpireAt  time.Time `json:"expire_at"`
+	Remark    string    `json:"remark"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,36 @@
+package do
+
+import "time"
+
+type Merchant struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	OwnerUserId int64     `json:"owner_user_id"`
+	Logo        string    `json:"logo"`
+	Description string    `json:"description"`
+	State       int8      `json:"state"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// MerchantGoods 商家后台的商品, 包含全部SKU
+type MerchantGoods struct {
+	ID                int64       `json:"id"`
+	MerchantId        int64       `json:"merchant_id"`
+	CategoryId        int64       `json:"category_id"`
+	Title             string      `json:"title"`
+	Image             string      `json:"image"`
+	State             int         `json:"state"`
+	FreightTemplateId int64       `json:"freight_template_id"`
+	Skus              []*GoodsSku `json:"skus"`
+	CreatedAt         time.Time   `json:"created_at"`
+}
+
+type GoodsSku struct {
+	ID      int64  `json:"id"`
+	GoodsId int64  `json:"goods_id"`
+	Spec    string `json:"spec"`
+	Image   string `json:"image"`
+	Price   int64  `json:"price"`
+	Stock   int    `json:"stock"`
+	Weight  int64  `json:"weight"`
+}
@@ -0,0 +1,45 @@
+package do
+
+import "time"
+
+// Notification 发给用户的一条通知
+type Notification struct {
+	UserId         int64  `json:"user_id"`
+	Type           int8   `json:"type"` // 站内消息类型, 为0时不写入消息中心, 只发到触达渠道
+	Title          string `json:"title"`
+	Content        string `json:"content"`
+	Link           string `json:"link"`
+	UnsubscribeUrl string `json:"unsubscribe_url"`
+	DedupKey       string `json:"dedup_key"` // 去重键, 为空时不去重
+}
+
+// NotificationMessage 消息中心的一条站内消息
+type NotificationMessage struct {
+	ID          int64     `json:"id"`
+	UserId      int64     `json:"user_id"`
+	Type        int8      `json:"type"`
+	BroadcastId int64     `json:"broadcast_id"`
+	Title       string    `json:"title"`
+	Content     string    `json:"content"`
+	Link        string    `json:"link"`
+	IsRead      bool      `json:"is_read"`
+	ReadAt      time.Time `json:"read_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NotificationBroadcast 发给全体用户的广播消息
+type NotificationBroadcast struct {
+	ID        int64     `json:"id"`
+	Type      int8      `json:"type"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	Link      string    `json:"link"`
+	CreatedBy int64     `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UnreadCount 各类型的未读消息数
+type UnreadCount struct {
+	Total  int64          `json:"total"`
+	ByType map[int8]int64 `json:"by_type"`
+}
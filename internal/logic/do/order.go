@@ -0,0 +1,76 @@
+package do
+
+import "time"
+
+type Order struct {
+	ID              int64        `json:"id"`
+	OrderNo         string       `json:"order_no"`
+	ParentOrderNo   string       `json:"parent_order_no"`
+	MerchantId      int64        `json:"merchant_id"`
+	UserId          int64        `json:"user_id"`
+	BillMoney       int64        `json:"bill_money"`
+	PromotionAmount int64        `json:"promotion_amount"`
+	CouponAmount    int64        `json:"coupon_amount"`
+	PointsAmount    int64        `json:"points_amount"`
+	PointsUsed      int64        `json:"points_used"`
+	ShippingFee     int64        `json:"shipping_fee"`
+	TaxAmount       int64        `json:"tax_amount"`
+	PayMoney        int64        `json:"pay_money"`
+	ReceiverName    string       `json:"receiver_name"`
+	ReceiverPhone   string       `json:"receiver_phone"`
+	Province        string       `json:"province"`
+	City            string       `json:"city"`
+	District        string       `json:"district"`
+	Address         string       `json:"address"`
+	State           int8         `json:"state"`
+	Version         int          `json:"version"`
+	Remark          string       `json:"remark"`
+	Items           []*OrderItem `json:"items"`
+	PaidAt          time.Time    `json:"paid_at"`
+	ShippedAt       time.Time    `json:"shipped_at"`
+	DeliveredAt     time.Time    `json:"delivered_at"`
+	CompletedAt     time.Time    `json:"completed_at"`
+	CancelledAt     time.Time    `json:"cancelled_at"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+type OrderItem struct {
+	ID              int64               `json:"id"`
+	OrderId         int64               `json:"order_id"`
+	GoodsId         int64               `json:"goods_id"`
+	SkuId           int64               `json:"sku_id"`
+	GoodsTitle      string              `json:"goods_title"`
+	SkuSpec         string              `json:"sku_spec"`
+	GoodsImage      string              `json:"goods_image"`
+	Quantity        int                 `json:"quantity"`
+	UnitPrice       int64               `json:"unit_price"`
+	Amount          int64               `json:"amount"`
+	PromotionAmount int64               `json:"promotion_amount"`
+	CouponAmount    int64               `json:"coupon_amount"`
+	PointsAmount    int64               `json:"points_amount"`
+	PointsUsed      int64               `json:"points_used"`
+	TaxAmount       int64               `json:"tax_amount"`
+	PayAmount       int64               `json:"pay_amount"`
+	Promotions      []*AppliedPromotion `json:"promotions"`  // 该明细享受的优惠
+	CategoryId      int64               `json:"category_id"` // 商品分类ID, 只在下单计算优惠时使用, 不落库
+	MerchantId      int64               `json:"merchant_id"` // 商品所属店铺ID, 下单时按它拆分子订单, 落在订单上
+}
+
+type OrderStateLog struct {
+	ID           int64     `json:"id"`
+	OrderId      int64     `json:"order_id"`
+	OrderNo      string    `json:"order_no"`
+	FromState    int8      `json:"from_state"`
+	ToState      int8      `json:"to_state"`
+	OperatorType string    `json:"operator_type"`
+	OperatorId   int64     `json:"operator_id"`
+	Remark       string    `json:"remark"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// OrderOperator 订单状态变更的操作人
+type OrderOperator struct {
+	Type string // 操作人类型 enum.OrderOperatorXXX
+	Id   int64  // 操作人ID, 系统操作时为0
+}
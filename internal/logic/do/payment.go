@@ -0,0 +1,22 @@
+package do
+
+import "time"
+
+type Payment struct {
+	ID             int64     `json:"id"`
+	PaymentNo      string    `json:"payment_no"`
+	ParentOrderNo  string    `json:"parent_order_no"`
+	UserId         int64     `json:"user_id"`
+	Provider       string    `json:"provider"`
+	Amount         int64     `json:"amount"`
+	RefundedAmount int64     `json:"refunded_amount"`
+	State          int8      `json:"state"`
+	TradeNo        string    `json:"trade_no"`
+	PayUrl         string    `json:"pay_url"` // 拉起支付的地址, 不落库
+	PaidAt         time.Time `json:"paid_at"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// 收到支付成功时子订单已经取消, 需要原路退回的金额和渠道退款交易号
+	ClosedRefundAmount  int64  `json:"closed_refund_amount"`
+	ClosedRefundTradeNo string `json:"closed_refund_trade_no"`
+}
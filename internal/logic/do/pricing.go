@@ -0,0 +1,76 @@
+package do
+
+// ShippingAddress 收货地址
+type ShippingAddress struct {
+	ReceiverName  string `json:"receiver_name"`
+	ReceiverPhone string `json:"receiver_phone"`
+	Province      string `json:"province"`
+	City          string `json:"city"`
+	District      string `json:"district"`
+	Detail        string `json:"detail"`
+}
+
+// PricingRequest 结算计价的输入
+type PricingRequest struct {
+	UserId      int64
+	Items       []*OrderItem     // 已经补全价格和分类的订单明细
+	Address     *ShippingAddress // 收货地址, 用于计算运费
+	Coupons     *CouponSelection // 优惠券的选择方式, nil表示不使用优惠券
+	Points      int64            // 用户要求使用的抵扣积分, 实际使用量受抵扣比例上限限制
+	NoPromotion bool             // 活动价商品不再参加其他营销活动
+}
+
+// PriceLine 一行商品的计价结果, 金额单位为分
+type PriceLine struct {
+	SkuId             int64               `json:"sku_id"`
+	GoodsId           int64               `json:"goods_id"`
+	CategoryId        int64               `json:"category_id"`
+	MerchantId        int64               `json:"merchant_id"`
+	Quantity          int                 `json:"quantity"`
+	UnitPrice         int64               `json:"unit_price"`
+	Subtotal          int64               `json:"subtotal"`           // 单价 * 数量
+	PromotionDiscount int64               `json:"promotion_discount"` // 分摊到该行的营销活动优惠
+	CouponDiscount    int64               `json:"coupon_discount"`    // 分摊到该行的优惠券优惠
+	PointsDiscount    int64               `json:"points_discount"`    // 分摊到该行的积分抵扣金额
+	PointsUsed        int64               `json:"points_used"`        // 分摊到该行的积分数
+	Tax               int64               `json:"tax"`                // 分摊到该行的税费
+	Payable           int64               `json:"payable"`            // 该行实付 = 小计 - 优惠 - 积分抵扣 + 税费, 部分退款时按它计算可退金额
+	Promotions        []*AppliedPromotion `json:"promotions"`         // 该行享受的营销活动和优惠券, 金额为分摊到该行的部分
+}
+
+// AppliedPromotion 命中的营销活动或优惠券
+type AppliedPromotion struct {
+	Name     string `json:"name"`
+	Discount int64  `json:"discount"`
+	CouponId int64  `json:"coupon_id,omitempty"` // 用户优惠券ID, 营销活动时为0
+}
+
+// ShopPrice 一个店铺的计价汇总, 下单时每个店铺拆成一个子订单
+type ShopPrice struct {
+	MerchantId        int64 `json:"merchant_id"`
+	LineIndexes       []int `json:"-"` // 属于该店铺的计价行在Lines中的下标
+	Subtotal          int64 `json:"subtotal"`
+	PromotionDiscount int64 `json:"promotion_discount"`
+	CouponDiscount    int64 `json:"coupon_discount"`
+	PointsDiscount    int64 `json:"points_discount"`
+	PointsUsed        int64 `json:"points_used"`
+	ShippingFee       int64 `json:"shipping_fee"`
+	Tax               int64 `json:"tax"`
+	Payable           int64 `json:"payable"` // 店铺各行实付之和 + 店铺运费
+}
+
+// PriceBreakdown 订单的计价明细, 各项金额都等于各行对应金额之和(运费除外), 运费等于各店铺运费之和
+type PriceBreakdown struct {
+	Lines             []*PriceLine        `json:"lines"`
+	Shops             []*ShopPrice        `json:"shops"` // 按店铺汇总, 顺序为店铺在Lines中第一次出现的顺序
+	Subtotal          int64               `json:"subtotal"`
+	PromotionDiscount int64               `json:"promotion_discount"`
+	CouponDiscount    int64               `json:"coupon_discount"`
+	PointsDiscount    int64               `json:"points_discount"`
+	PointsUsed        int64               `json:"points_used"`
+	ShippingFee       int64               `json:"shipping_fee"`
+	Tax               int64               `json:"tax"`
+	Payable           int64               `json:"payable"` // 各行实付之和 + 运费
+	Promotions        []*AppliedPromotion `json:"promotions"`
+	CouponPlan        *CouponPlan         `json:"coupon_plan"`
+}
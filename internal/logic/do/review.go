@@ -0,0 +1,34 @@
+package do
+
+import "time"
+
+type Review struct {
+	ID           int64     `json:"id"`
+	OrderId      int64     `json:"order_id"`
+	OrderItemId  int64     `json:"order_item_id"`
+	UserId       int64     `json:"user_id"`
+	GoodsId      int64     `json:"goods_id"`
+	SkuId        int64     `json:"sku_id"`
+	MerchantId   int64     `json:"merchant_id"`
+	Rating       int8      `json:"rating"`
+	Content      string    `json:"content"`
+	Images       []string  `json:"images"`
+	Anonymous    bool      `json:"anonymous"`
+	State        int8      `json:"state"`
+	HiddenReason string    `json:"hidden_reason"`
+	ReplyContent string    `json:"reply_content"`
+	RepliedAt    time.Time `json:"replied_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	Nickname     string    `json:"nickname"` // 评价人昵称, 匿名评价时已脱敏
+	Avatar       string    `json:"avatar"`   // 评价人头像, 匿名评价时为空
+}
+
+// RatingSummary 商品的评分汇总, 只统计展示中的评价
+type RatingSummary struct {
+	GoodsId      int64    `json:"goods_id"`
+	TotalCount   int64    `json:"total_count"`
+	AverageScore float64  `json:"average_score"` // 平均分, 保留一位小数
+	GoodRate     int      `json:"good_rate"`     // 好评率(4星及以上)百分比
+	ImageCount   int64    `json:"image_count"`   // 有图评价数量
+	RatingCounts [5]int64 `json:"rating_counts"` // 1~5星各自的评价数量
+}
@@ -0,0 +1,36 @@
+package do
+
+import "time"
+
+type SeckillActivity struct {
+	ID           int64     `json:"id"`
+	Title        string    `json:"title"`
+	GoodsId      int64     `json:"goods_id"`
+	SkuId        int64     `json:"sku_id"`
+	SeckillPrice int64     `json:"seckill_price"`
+	TotalStock   int       `json:"total_stock"`
+	SoldCount    int       `json:"sold_count"`
+	PerUserLimit int       `json:"per_user_limit"`
+	StartAt      time.Time `json:"start_at"`
+	EndAt        time.Time `json:"end_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// SeckillGrab 一次抢到库存的抢购请求, 作为异步创建订单任务的内容
+type SeckillGrab struct {
+	Ticket     string           `json:"ticket"`
+	ActivityId int64            `json:"activity_id"`
+	UserId     int64            `json:"user_id"`
+	Quantity   int              `json:"quantity"`
+	Address    *ShippingAddress `json:"address"`
+}
+
+// SeckillResult 抢购结果, 客户端按凭证轮询
+type SeckillResult struct {
+	Ticket     string `json:"ticket"`
+	ActivityId int64  `json:"activity_id"`
+	UserId     int64  `json:"user_id"`
+	State      string `json:"state"` // 见enum.SeckillResultXXX
+	OrderNo    string `json:"order_no"`
+	Reason     string `json:"reason"`
+}
@@ -0,0 +1,24 @@
+package do
+
+import "time"
+
+type Shipment struct {
+	ID            int64            `json:"id"`
+	OrderId       int64            `json:"order_id"`
+	OrderNo       string           `json:"order_no"`
+	UserId        int64            `json:"user_id"`
+	Carrier       string           `json:"carrier"`
+	TrackingNo    string           `json:"tracking_no"`
+	State         int8             `json:"state"`
+	ShippedAt     time.Time        `json:"shipped_at"`
+	DeliveredAt   time.Time        `json:"delivered_at"`
+	LastTrackedAt time.Time        `json:"last_tracked_at"`
+	Events        []*ShipmentEvent `json:"events"`
+}
+
+type ShipmentEvent struct {
+	EventTime   time.Time `json:"event_time"`
+	Status      string    `json:"status"`
+	Location    string    `json:"location"`
+	Description string    `json:"description"`
+}
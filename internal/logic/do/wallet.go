@@ -0,0 +1,87 @@
+package do
+
+import "time"
+
+// Wallet 用户钱包
+type Wallet struct {
+	UserId  int64 `json:"user_id"`
+	Balance int64 `json:"balance"`
+}
+
+// LedgerPosting 一次记账请求, 分录金额之和必须为0
+type LedgerPosting struct {
+	IdempotencyKey string
+	Type           int8
+	BizNo          string
+	Remark         string
+	Entries        []*LedgerPostingEntry
+}
+
+// LedgerPostingEntry 记账请求的一条分录, 账户不存在时按账户号、类型和所属用户创建
+type LedgerPostingEntry struct {
+	AccountNo   string
+	AccountType int8
+	OwnerId     int64
+	Amount      int64 // 入账为正数, 出账为负数
+}
+
+type LedgerTransaction struct {
+	ID             int64     `json:"id"`
+	TxNo           string    `json:"tx_no"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	Type           int8      `json:"type"`
+	BizNo          string    `json:"biz_no"`
+	Amount         int64     `json:"amount"`
+	Remark         string    `json:"remark"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// WalletEntry 钱包的一条分录及其所属的记账交易
+type WalletEntry struct {
+	ID        int64     `json:"id"`
+	TxNo      string    `json:"tx_no"`
+	Type      int8      `json:"type"`
+	BizNo     string    `json:"biz_no"`
+	Amount    int64     `json:"amount"`
+	Balance   int64     `json:"balance"`
+	Remark    string    `json:"remark"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GiftCard struct {
+	ID         int64     `json:"id"`
+	CardNo     string    `json:"card_no"`
+	Code       string    `json:"code"` // 卡密明文, 只在发行时有值
+	BatchNo    string    `json:"batch_no"`
+	Amount     int64     `json:"amount"`
+	State      int8      `json:"state"`
+	RedeemedBy int64     `json:"redeemed_by"`
+	RedeemedAt time.Time `json:"redeemed_at"`
+	ExpireAt   time.Time `json:"expire_at"`
+	CreatedBy  int64     `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// LedgerCheckReport 对账结果
+type LedgerCheckReport struct {
+	CheckedAt              time.Time                `json:"checked_at"`
+	Consistent             bool                     `json:"consistent"`    // 账户余额等于分录之和、每笔交易借贷平衡、全部余额之和为0时对账通过
+	Accounts               int64                    `json:"accounts"`      // 检查的账户数
+	TotalBalance           int64                    `json:"total_balance"` // 全部账户余额之和, 借贷平衡时为0
+	AccountMismatches      []*LedgerAccountMismatch `json:"account_mismatches"`
+	UnbalancedTransactions []*LedgerUnbalancedTx    `json:"unbalanced_transactions"`
+}
+
+// LedgerAccountMismatch 余额和分录金额之和不一致的账户
+type LedgerAccountMismatch struct {
+	AccountId int64  `json:"account_id"`
+	AccountNo string `json:"account_no"`
+	Balance   int64  `json:"balance"`
+	EntrySum  int64  `json:"entry_sum"`
+}
+
+// LedgerUnbalancedTx 分录金额之和不为0的记账交易
+type LedgerUnbalancedTx struct {
+	TransactionId int64 `json:"transaction_id"`
+	EntrySum      int64 `json:"entry_sum"`
+}
@@ -0,0 +1,500 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Cospk/go-mall/external/payment"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"gorm.io/gorm"
+	"time"
+)
+
+type AfterSaleDomain struct {
+	ctx          context.Context
+	afterSaleDao *dao.AfterSaleDao
+	paymentDao   *dao.PaymentDao
+	goodsDao     *dao.GoodsDao
+	orderDomain  *OrderDomain
+	memberDomain *MemberDomain
+	walletDomain *WalletDomain
+}
+
+func NewAfterSaleDomain(ctx context.Context) *AfterSaleDomain {
+	return &AfterSaleDomain{
+		ctx:          ctx,
+		afterSaleDao: dao.NewAfterSaleDao(ctx),
+		paymentDao:   dao.NewPaymentDao(ctx),
+		goodsDao:     dao.NewGoodsDao(ctx),
+		orderDomain:  NewOrderDomain(ctx),
+		memberDomain: NewMemberDomain(ctx),
+		walletDomain: NewWalletDomain(ctx),
+	}
+}
+
+// afterSaleOrderStates 可以申请售后的订单状态
+var afterSaleOrderStates = map[int8]bool{
+	enum.OrderStatePaid:      true,
+	enum.OrderStateShipped:   true,
+	enum.OrderStateDelivered: true,
+	enum.OrderStateCompleted: true,
+	enum.OrderStateRefunding: true,
+}
+
+// ApplyAfterSale 用户针对订单中的一个商品申请售后, 订单同时进入退款中
+// 同一个商品同时只能有一个进行中的售后单, 累计申请数量不能超过购买数量
+func (domain *AfterSaleDomain) ApplyAfterSale(orderNo string, apply *do.AfterSale) (*do.AfterSale, error) {
+	order, err := domain.orderDomain.GetUserOrder(apply.UserId, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	images, _ := json.Marshal(apply.Images)
+	afterSaleModel := &model.AfterSale{
+		AfterSaleNo: utils.GenAfterSaleNo(apply.UserId),
+		OrderId:     order.ID,
+		OrderNo:     order.OrderNo,
+		OrderItemId: apply.OrderItemId,
+		UserId:      apply.UserId,
+		MerchantId:  order.MerchantId,
+		Type:        apply.Type,
+		Quantity:    apply.Quantity,
+		Reason:      apply.Reason,
+		Description: apply.Description,
+		Images:      string(images),
+		State:       enum.AfterSaleStateApplied,
+	}
+	operator := &do.OrderOperator{Type: enum.OrderOperatorUser, Id: apply.UserId}
+
+	err = dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+		// 锁住订单串行化同一订单上的售后操作, 保证累计数量和金额的校验不被并发申请绕过
+		order, err := domain.orderDomain.LockOrderInTx(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		if !afterSaleOrderStates[order.State] {
+			return errcode.ErrAfterSaleNotAllowed
+		}
+		if apply.Type == enum.AfterSaleTypeReturnRefund && !isOrderShipped(order) {
+			// 还没发货的订单没有东西可退, 只能申请仅退款
+			return errcode.ErrAfterSaleNotAllowed
+		}
+		var item *do.OrderItem
+		for _, orderItem := range order.Items {
+			if orderItem.ID == apply.OrderItemId {
+				item = orderItem
+			}
+		}
+		if item == nil {
+			return errcode.ErrAfterSaleNotAllowed
+		}
+		afterSales, err := domain.afterSaleDao.FindOrderAfterSales(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		appliedQuantity, appliedAmount, appliedPoints := 0, int64(0), int64(0)
+		for _, afterSale := range afterSales {
+			if afterSale.OrderItemId != item.ID {
+				continue
+			}
+			if isAfterSaleOpen(afterSale.State) {
+				return errcode.ErrAfterSaleNotAllowed
+			}
+			if afterSale.State == enum.AfterSaleStateRefunded {
+				appliedQuantity += afterSale.Quantity
+				appliedAmount += afterSale.RefundAmount
+				appliedPoints += afterSale.RefundPoints
+			}
+		}
+		if apply.Quantity <= 0 || appliedQuantity+apply.Quantity > item.Quantity {
+			return errcode.ErrAfterSaleQuantity
+		}
+		// 按数量比例计算退款金额和退回的抵扣积分, 最后一次把剩余的全部退掉, 保证累计退款和商品实付金额、抵扣积分一致
+		paidAmount := item.PayAmount
+		if appliedQuantity+apply.Quantity == item.Quantity {
+			afterSaleModel.RefundAmount = paidAmount - appliedAmount
+			afterSaleModel.RefundPoints = item.PointsUsed - appliedPoints
+		} else {
+			afterSaleModel.RefundAmount = paidAmount * int64(apply.Quantity) / int64(item.Quantity)
+			afterSaleModel.RefundPoints = item.PointsUsed * int64(apply.Quantity) / int64(item.Quantity)
+		}
+		afterSaleModel.SkuId = item.SkuId
+		// 退货的商品和没发货的商品退款后可以重新销售, 仅退款的已发货商品不归还库存
+		afterSaleModel.Restock = apply.Type == enum.AfterSaleTypeReturnRefund || !isOrderShipped(order)
+
+		if err = domain.afterSaleDao.CreateAfterSale(tx, afterSaleModel); err != nil {
+			return err
+		}
+		err = domain.afterSaleDao.CreateAfterSaleLog(tx, &model.AfterSaleLog{
+			AfterSaleId:  afterSaleModel.ID,
+			ToState:      enum.AfterSaleStateApplied,
+			OperatorType: operator.Type,
+			OperatorId:   operator.Id,
+			Remark:       apply.Reason,
+		})
+		if err != nil {
+			return err
+		}
+		return domain.orderDomain.EnterRefundingInTx(tx, order, operator, "申请售后, 售后单号"+afterSaleModel.AfterSaleNo)
+	})
+	if err != nil {
+		return nil, wrapAfterSaleError(err)
+	}
+	return domain.toAfterSaleDo(afterSaleModel), nil
+}
+
+// GetAfterSale 查询售后单
+func (domain *AfterSaleDomain) GetAfterSale(afterSaleNo string) (*do.AfterSale, error) {
+	afterSaleModel, err := domain.afterSaleDao.FindAfterSaleByNo(afterSaleNo)
+	if err != nil {
+		return nil, errcode.Wrap("查询售后单失败", err)
+	}
+	if afterSaleModel == nil {
+		return nil, errcode.ErrAfterSaleNotFound
+	}
+	return domain.toAfterSaleDo(afterSaleModel), nil
+}
+
+// GetUserAfterSale 查询用户自己的售后单, 不属于该用户时按不存在处理
+func (domain *AfterSaleDomain) GetUserAfterSale(userId int64, afterSaleNo string) (*do.AfterSale, error) {
+	afterSale, err := domain.GetAfterSale(afterSaleNo)
+	if err != nil {
+		return nil, err
+	}
+	if afterSale.UserId != userId {
+		return nil, errcode.ErrAfterSaleNotFound
+	}
+	return afterSale, nil
+}
+
+// GetMerchantAfterSale 查询店铺的售后单, 不属于该店铺时按不存在处理, merchantId为0表示平台管理员, 不限制店铺
+func (domain *AfterSaleDomain) GetMerchantAfterSale(merchantId int64, afterSaleNo string) (*do.AfterSale, error) {
+	afterSale, err := domain.GetAfterSale(afterSaleNo)
+	if err != nil {
+		return nil, err
+	}
+	if merchantId != 0 && afterSale.MerchantId != merchantId {
+		return nil, errcode.ErrAfterSaleNotFound
+	}
+	return afterSale, nil
+}
+
+// GetAfterSales 分页查询售后单, userId、merchantId为0时不限制用户、店铺
+func (domain *AfterSaleDomain) GetAfterSales(userId, merchantId int64, state int8, pageNum, pageSize int) ([]*do.AfterSale, int64, error) {
+	afterSales, total, err := domain.afterSaleDao.FindAfterSales(userId, merchantId, state, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询售后单列表失败", err)
+	}
+	afterSaleDos := make([]*do.AfterSale, 0, len(afterSales))
+	for _, afterSale := range afterSales {
+		afterSaleDos = append(afterSaleDos, domain.toAfterSaleDo(afterSale))
+	}
+	return afterSaleDos, total, nil
+}
+
+// GetAfterSaleLogs 查询售后单的处理记录
+func (domain *AfterSaleDomain) GetAfterSaleLogs(afterSaleId int64) ([]*do.AfterSaleLog, error) {
+	logs, err := domain.afterSaleDao.FindAfterSaleLogs(afterSaleId)
+	if err != nil {
+		return nil, errcode.Wrap("查询售后处理记录失败", err)
+	}
+	logDos := make([]*do.AfterSaleLog, 0, len(logs))
+	for _, afterSaleLog := range logs {
+		logDo := new(do.AfterSaleLog)
+		_ = utils.CopyStruct(logDo, afterSaleLog)
+		logDos = append(logDos, logDo)
+	}
+	return logDos, nil
+}
+
+// Approve 商家同意售后申请, 仅退款直接进入退款中, 退货退款等待买家寄回商品
+func (domain *AfterSaleDomain) Approve(afterSale *do.AfterSale, operator *do.OrderOperator, remark string) error {
+	toState := enum.AfterSaleStateApproved
+	if afterSale.Type == enum.AfterSaleTypeRefund {
+		toState = enum.AfterSaleStateRefunding
+	}
+	return domain.transitInTx(afterSale, func(tx *gorm.DB) error {
+		return domain.transit(tx, afterSale, toState, operator, remark, map[string]interface{}{"merchant_remark": remark})
+	})
+}
+
+// Reject 商家驳回售后申请或拒绝收货, 订单上没有其他进行中的售后时离开退款中
+func (domain *AfterSaleDomain) Reject(afterSale *do.AfterSale, operator *do.OrderOperator, remark string) error {
+	return domain.closeInTx(afterSale, enum.AfterSaleStateRejected, operator, remark, map[string]interface{}{"merchant_remark": remark})
+}
+
+// Cancel 用户撤销售后申请
+func (domain *AfterSaleDomain) Cancel(afterSale *do.AfterSale, operator *do.OrderOperator) error {
+	return domain.closeInTx(afterSale, enum.AfterSaleStateCancelled, operator, "用户撤销售后申请", nil)
+}
+
+// SubmitReturn 用户寄回商品后填写退货物流
+func (domain *AfterSaleDomain) SubmitReturn(afterSale *do.AfterSale, carrier, trackingNo string, operator *do.OrderOperator) error {
+	fields := map[string]interface{}{
+		"return_carrier":     carrier,
+		"return_tracking_no": trackingNo,
+	}
+	err := domain.transitInTx(afterSale, func(tx *gorm.DB) error {
+		return domain.transit(tx, afterSale, enum.AfterSaleStateReturned, operator, carrier+" "+trackingNo, fields)
+	})
+	if err != nil {
+		return err
+	}
+	afterSale.ReturnCarrier, afterSale.ReturnTrackingNo = carrier, trackingNo
+	return nil
+}
+
+// ConfirmReturnReceived 商家确认收到退货, 进入退款中
+func (domain *AfterSaleDomain) ConfirmReturnReceived(afterSale *do.AfterSale, operator *do.OrderOperator, remark string) error {
+	return domain.transitInTx(afterSale, func(tx *gorm.DB) error {
+		return domain.transit(tx, afterSale, enum.AfterSaleStateRefunding, operator, remark, nil)
+	})
+}
+
+// ExecuteRefund 原路退款, 分三步保证钱退出去之后一定有记录:
+//  1. 事务中写入退款单号并把退款金额计入支付单的已退款金额, 累计退款超过支付金额时在调用渠道之前失败
+//  2. 调用支付渠道退款, 渠道按退款单号做幂等, 任务重试时重复调用不会多退钱
+//  3. 事务中完成 售后单->已退款、归还库存、积分退回和扣回、订单状态流转, 钱包余额支付的订单在这个事务里退回钱包
+//
+// 第2、3步失败时由退款任务重试, 已经写入退款单号的售后单跳过第1步
+func (domain *AfterSaleDomain) ExecuteRefund(afterSale *do.AfterSale) error {
+	log := logger.NewLogger(domain.ctx)
+	if afterSale.State != enum.AfterSaleStateRefunding {
+		return errcode.ErrAfterSaleStateTransition
+	}
+	order, err := domain.orderDomain.GetOrder(afterSale.OrderNo)
+	if err != nil {
+		return err
+	}
+	// 子订单合并支付, 按父订单找到支付单原路退款
+	parentOrderNo := order.ParentOrderNo
+	if parentOrderNo == "" {
+		parentOrderNo = order.OrderNo
+	}
+	paymentModel, err := domain.paymentDao.FindParentOrderPayment(parentOrderNo, "", enum.PaymentStateSuccess)
+	if err != nil {
+		return errcode.Wrap("查询支付单失败", err)
+	}
+	if paymentModel == nil {
+		return errcode.ErrPaymentNotFound
+	}
+	if afterSale.RefundNo == "" {
+		if err = domain.submitRefund(afterSale, paymentModel); err != nil {
+			return err
+		}
+	}
+
+	// 钱包余额支付的退款在下面的事务里直接退回钱包, 其他渠道先调用渠道退款
+	var refundTradeNo string
+	if paymentModel.Provider != enum.PaymentProviderWallet {
+		provider, ok := payment.GetProvider(paymentModel.Provider)
+		if !ok {
+			return errcode.ErrPaymentProviderNotSupported
+		}
+		result, err := provider.Refund(domain.ctx, &payment.RefundRequest{
+			PaymentNo:   paymentModel.PaymentNo,
+			RefundNo:    afterSale.RefundNo,
+			Amount:      afterSale.RefundAmount,
+			TotalAmount: paymentModel.Amount,
+			Reason:      afterSale.Reason,
+		})
+		if err != nil {
+			log.Error("AfterSaleRefundError", "afterSaleNo", afterSale.AfterSaleNo, "err", err)
+			return errcode.ErrPaymentProviderFailed
+		}
+		if !result.Success {
+			log.Error("AfterSaleRefundFailed", "afterSaleNo", afterSale.AfterSaleNo, "paymentNo", paymentModel.PaymentNo)
+			return errcode.ErrPaymentProviderFailed
+		}
+		refundTradeNo = result.RefundTradeNo
+	}
+
+	refundedAt := time.Now()
+	operator := &do.OrderOperator{Type: enum.OrderOperatorSystem}
+	err = domain.transitInTx(afterSale, func(tx *gorm.DB) error {
+		order, err := domain.orderDomain.LockOrderInTx(tx, afterSale.OrderId)
+		if err != nil {
+			return err
+		}
+		if paymentModel.Provider == enum.PaymentProviderWallet {
+			transaction, err := domain.walletDomain.RefundOrderInTx(tx, paymentModel.UserId, afterSale.RefundNo, afterSale.RefundAmount)
+			if err != nil {
+				return err
+			}
+			refundTradeNo = transaction.TxNo
+		}
+		fields := map[string]interface{}{
+			"refund_trade_no": refundTradeNo,
+			"refunded_at":     refundedAt,
+		}
+		err = domain.transit(tx, afterSale, enum.AfterSaleStateRefunded, operator, "退款成功, 渠道退款单号"+refundTradeNo, fields)
+		if err != nil {
+			return err
+		}
+		if afterSale.Restock {
+			if err = domain.goodsDao.RestoreSkuStock(tx, afterSale.SkuId, afterSale.Quantity); err != nil {
+				return err
+			}
+		}
+		if err = domain.memberDomain.RefundPointsInTx(tx, order, afterSale); err != nil {
+			return err
+		}
+		return domain.leaveOrderRefunding(tx, order, operator)
+	})
+	if err != nil {
+		// 渠道已经退款成功, 售后单停在退款中并带着退款单号, 由任务重试完成
+		log.Error("AfterSaleRefundConfirmError", "afterSaleNo", afterSale.AfterSaleNo, "refundNo", afterSale.RefundNo, "refundTradeNo", refundTradeNo, "err", err)
+		return err
+	}
+	afterSale.RefundTradeNo, afterSale.RefundedAt = refundTradeNo, refundedAt
+	log.Info("AfterSaleRefunded", "afterSaleNo", afterSale.AfterSaleNo, "orderNo", afterSale.OrderNo, "amount", afterSale.RefundAmount)
+	return nil
+}
+
+// submitRefund 退款第1步: 预留支付单的退款金额并写入退款单号, 售后单号同时作为退款单号
+// 售后单的乐观锁保证并发执行的退款任务只有一个能发起退款
+func (domain *AfterSaleDomain) submitRefund(afterSale *do.AfterSale, paymentModel *model.Payment) error {
+	refundNo := afterSale.AfterSaleNo
+	err := domain.transitInTx(afterSale, func(tx *gorm.DB) error {
+		updated, err := domain.paymentDao.AddRefundedAmount(tx, paymentModel.ID, afterSale.RefundAmount)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			logger.NewLogger(domain.ctx).Error("RefundAmountExceeded", "afterSaleNo", afterSale.AfterSaleNo, "paymentNo", paymentModel.PaymentNo)
+			return errcode.ErrAfterSaleQuantity
+		}
+		err = domain.afterSaleDao.UpdateAfterSaleState(tx, afterSale.ID, afterSale.State, afterSale.State, afterSale.Version,
+			map[string]interface{}{"refund_no": refundNo})
+		if err != nil {
+			return err
+		}
+		err = domain.afterSaleDao.CreateAfterSaleLog(tx, &model.AfterSaleLog{
+			AfterSaleId:  afterSale.ID,
+			FromState:    afterSale.State,
+			ToState:      afterSale.State,
+			OperatorType: enum.OrderOperatorSystem,
+			Remark:       "发起退款, 退款单号" + refundNo,
+		})
+		if err != nil {
+			return err
+		}
+		afterSale.Version++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	afterSale.RefundNo = refundNo
+	return nil
+}
+
+// closeInTx 把售后单流转到驳回或撤销, 并检查订单是否可以离开退款中
+func (domain *AfterSaleDomain) closeInTx(afterSale *do.AfterSale, toState int8, operator *do.OrderOperator, remark string, fields map[string]interface{}) error {
+	return domain.transitInTx(afterSale, func(tx *gorm.DB) error {
+		order, err := domain.orderDomain.LockOrderInTx(tx, afterSale.OrderId)
+		if err != nil {
+			return err
+		}
+		if err = domain.transit(tx, afterSale, toState, operator, remark, fields); err != nil {
+			return err
+		}
+		return domain.leaveOrderRefunding(tx, order, operator)
+	})
+}
+
+// leaveOrderRefunding 订单上的售后单全部结束后让订单离开退款中, 所有商品都已全部退款时订单变为已退款
+func (domain *AfterSaleDomain) leaveOrderRefunding(tx *gorm.DB, order *do.Order, operator *do.OrderOperator) error {
+	openCount, err := domain.afterSaleDao.CountOrderOpenAfterSales(tx, order.ID)
+	if err != nil {
+		return err
+	}
+	if openCount > 0 {
+		return nil
+	}
+	afterSales, err := domain.afterSaleDao.FindOrderAfterSales(tx, order.ID)
+	if err != nil {
+		return err
+	}
+	refundedQuantity := make(map[int64]int)
+	for _, afterSale := range afterSales {
+		if afterSale.State == enum.AfterSaleStateRefunded {
+			refundedQuantity[afterSale.OrderItemId] += afterSale.Quantity
+		}
+	}
+	allRefunded := true
+	for _, item := range order.Items {
+		if refundedQuantity[item.ID] < item.Quantity {
+			allRefunded = false
+		}
+	}
+	return domain.orderDomain.LeaveRefundingInTx(tx, order, allRefunded, operator, "售后处理完成")
+}
+
+// transit 在事务中校验并执行一次售后单状态流转, 成功后同步更新afterSale上的状态和版本号
+func (domain *AfterSaleDomain) transit(tx *gorm.DB, afterSale *do.AfterSale, toState int8, operator *do.OrderOperator, remark string, fields map[string]interface{}) error {
+	if !canTransitAfterSaleState(afterSale.State, toState) {
+		return errcode.ErrAfterSaleStateTransition
+	}
+	err := domain.afterSaleDao.UpdateAfterSaleState(tx, afterSale.ID, afterSale.State, toState, afterSale.Version, fields)
+	if err != nil {
+		return err
+	}
+	err = domain.afterSaleDao.CreateAfterSaleLog(tx, &model.AfterSaleLog{
+		AfterSaleId:  afterSale.ID,
+		FromState:    afterSale.State,
+		ToState:      toState,
+		OperatorType: operator.Type,
+		OperatorId:   operator.Id,
+		Remark:       remark,
+	})
+	if err != nil {
+		return err
+	}
+	afterSale.State = toState
+	afterSale.Version++
+	return nil
+}
+
+// transitInTx 在事务中执行售后单状态流转, 事务回滚时把afterSale上的状态和版本号一起恢复
+func (domain *AfterSaleDomain) transitInTx(afterSale *do.AfterSale, fn func(tx *gorm.DB) error) error {
+	state, version := afterSale.State, afterSale.Version
+	err := dao.Transaction(domain.ctx, fn)
+	if err == nil {
+		return nil
+	}
+	afterSale.State, afterSale.Version = state, version
+	return wrapAfterSaleError(err)
+}
+
+func (domain *AfterSaleDomain) toAfterSaleDo(afterSaleModel *model.AfterSale) *do.AfterSale {
+	afterSale := new(do.AfterSale)
+	_ = utils.CopyStruct(afterSale, afterSaleModel)
+	afterSale.Images = make([]string, 0)
+	if afterSaleModel.Images != "" {
+		_ = json.Unmarshal([]byte(afterSaleModel.Images), &afterSale.Images)
+	}
+	return afterSale
+}
+
+// wrapAfterSaleError 项目预定义的业务错误原样返回给上层判断, 其他错误包装后返回
+func wrapAfterSaleError(err error) error {
+	if appErr, ok := err.(*errcode.AppError); ok && appErr.Code() > 0 {
+		return err
+	}
+	return errcode.Wrap("售后单处理失败", err)
+}
+
+// isAfterSaleOpen 售后单是否还在处理中
+func isAfterSaleOpen(state int8) bool {
+	return state != enum.AfterSaleStateRejected && state != enum.AfterSaleStateCancelled && state != enum.AfterSaleStateRefunded
+}
+
+// isOrderShipped 订单是否已经发过货, 发货时间为默认值1970年表示还没有发货
+func isOrderShipped(order *do.Order) bool {
+	return order.ShippedAt.Year() > 1970
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "github.com/Cospk/go-mall/pkg/enum"
+
+// 售后单状态机
+//
+//	仅退款:   待审核 --同意--> 退款中 --退款成功--> 已退款
+//	退货退款: 待审核 --同意--> 待退货 --买家寄回--> 待收货 --商家收货--> 退款中 --退款成功--> 已退款
+//
+// 待审核、待收货可以被商家驳回, 待审核、待退货可以被买家撤销
+// 已驳回、已撤销、已退款是终态
+
+// afterSaleStateTransitions key为当前状态, value为允许流转到的状态
+var afterSaleStateTransitions = map[int8][]int8{
+	enum.AfterSaleStateApplied:   {enum.AfterSaleStateRefunding, enum.AfterSaleStateApproved, enum.AfterSaleStateRejected, enum.AfterSaleStateCancelled},
+	enum.AfterSaleStateApproved:  {enum.AfterSaleStateReturned, enum.AfterSaleStateCancelled},
+	enum.AfterSaleStateReturned:  {enum.AfterSaleStateRefunding, enum.AfterSaleStateRejected},
+	enum.AfterSaleStateRefunding: {enum.AfterSaleStateRefunded},
+}
+
+// canTransitAfterSaleState 判断售后单能否从from状态流转到to状态
+func canTransitAfterSaleState(from, to int8) bool {
+	for _, state := range afterSaleStateTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"github.com/Cospk/go-mall/pkg/enum"
+	"testing"
+)
+
+func TestCanTransitAfterSaleState(t *testing.T) {
+	tests := []struct {
+		name string
+		from int8
+		to   int8
+		want bool
+	}{
+		{"仅退款同意", enum.AfterSaleStateApplied, enum.AfterSaleStateRefunding, true},
+		{"退货退款同意", enum.AfterSaleStateApplied, enum.AfterSaleStateApproved, true},
+		{"待审核驳回", enum.AfterSaleStateApplied, enum.AfterSaleStateRejected, true},
+		{"待审核撤销", enum.AfterSaleStateApplied, enum.AfterSaleStateCancelled, true},
+		{"待审核不能直接退款成功", enum.AfterSaleStateApplied, enum.AfterSaleStateRefunded, false},
+		{"买家寄回", enum.AfterSaleStateApproved, enum.AfterSaleStateReturned, true},
+		{"待退货撤销", enum.AfterSaleStateApproved, enum.AfterSaleStateCancelled, true},
+		{"待退货不能驳回", enum.AfterSaleStateApproved, enum.AfterSaleStateRejected, false},
+		{"待退货不能退款", enum.AfterSaleStateApproved, enum.AfterSaleStateRefunding, false},
+		{"商家收货", enum.AfterSaleStateReturned, enum.AfterSaleStateRefunding, true},
+		{"待收货驳回", enum.AfterSaleStateReturned, enum.AfterSaleStateRejected, true},
+		{"待收货不能撤销", enum.AfterSaleStateReturned, enum.AfterSaleStateCancelled, false},
+		{"退款成功", enum.AfterSaleStateRefunding, enum.AfterSaleStateRefunded, true},
+		{"退款中不能撤销", enum.AfterSaleStateRefunding, enum.AfterSaleStateCancelled, false},
+		{"退款中不能原地流转", enum.AfterSaleStateRefunding, enum.AfterSaleStateRefunding, false},
+		{"已驳回是终态", enum.AfterSaleStateRejected, enum.AfterSaleStateApplied, false},
+		{"已撤销是终态", enum.AfterSaleStateCancelled, enum.AfterSaleStateApplied, false},
+		{"已退款是终态", enum.AfterSaleStateRefunded, enum.AfterSaleStateRefunding, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canTransitAfterSaleState(tt.from, tt.to); got != tt.want {
+				t.Errorf("canTransitAfterSaleState(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
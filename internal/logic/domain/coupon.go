@@ -0,0 +1,296 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"gorm.io/gorm"
+	"time"
+)
+
+type CouponDomain struct {
+	ctx       context.Context
+	couponDao *dao.CouponDao
+}
+
+func NewCouponDomain(ctx context.Context) *CouponDomain {
+	return &CouponDomain{
+		ctx:       ctx,
+		couponDao: dao.NewCouponDao(ctx),
+	}
+}
+
+// CreateTemplate 创建优惠券模板
+func (domain *CouponDomain) CreateTemplate(template *do.CouponTemplate) error {
+	if err := checkCouponTemplate(template); err != nil {
+		return err
+	}
+	templateModel := new(model.CouponTemplate)
+	_ = utils.CopyStruct(templateModel, template)
+	scopeIds, _ := json.Marshal(template.ScopeIds)
+	templateModel.ScopeIds = string(scopeIds)
+	if err := domain.couponDao.CreateTemplate(templateModel); err != nil {
+		return errcode.Wrap("创建优惠券模板失败", err)
+	}
+	template.ID = templateModel.ID
+	return nil
+}
+
+// GetTemplates 分页查询优惠券模板
+func (domain *CouponDomain) GetTemplates(pageNum, pageSize int) ([]*do.CouponTemplate, int64, error) {
+	templates, total, err := domain.couponDao.FindTemplates((pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询优惠券模板失败", err)
+	}
+	return domain.toTemplateDos(templates), total, nil
+}
+
+// GetClaimableTemplates 查询当前可以领取的优惠券
+func (domain *CouponDomain) GetClaimableTemplates() ([]*do.CouponTemplate, error) {
+	templates, err := domain.couponDao.FindClaimableTemplates(time.Now())
+	if err != nil {
+		return nil, errcode.Wrap("查询可领取优惠券失败", err)
+	}
+	return domain.toTemplateDos(templates), nil
+}
+
+// ClaimCoupon 用户领取优惠券
+func (domain *CouponDomain) ClaimCoupon(userId, templateId int64) (*do.UserCoupon, error) {
+	return domain.grantCoupon(userId, templateId, enum.CouponSourceClaim)
+}
+
+// IssueCoupons 后台给一批用户发放优惠券, 不要求模板可领取, 仍然受发放总量和每人限领数量的限制
+// 返回发放成功的用户优惠券和每个失败用户的原因
+func (domain *CouponDomain) IssueCoupons(templateId int64, userIds []int64) ([]*do.UserCoupon, map[int64]error) {
+	coupons := make([]*do.UserCoupon, 0, len(userIds))
+	failures := make(map[int64]error)
+	for _, userId := range userIds {
+		coupon, err := domain.grantCoupon(userId, templateId, enum.CouponSourceIssue)
+		if err != nil {
+			failures[userId] = err
+			continue
+		}
+		coupons = append(coupons, coupon)
+	}
+	return coupons, failures
+}
+
+// GetUserCoupons 分页查询用户的优惠券
+func (domain *CouponDomain) GetUserCoupons(userId int64, state int8, pageNum, pageSize int) ([]*do.UserCoupon, int64, error) {
+	coupons, total, err := domain.couponDao.FindUserCoupons(userId, state, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询用户优惠券失败", err)
+	}
+	couponDos, err := domain.toUserCouponDos(coupons)
+	if err != nil {
+		return nil, 0, err
+	}
+	return couponDos, total, nil
+}
+
+// GetUsableCoupons 查询用户当前可以使用的优惠券, 带上模板信息供规则引擎计算
+func (domain *CouponDomain) GetUsableCoupons(userId int64) ([]*do.UserCoupon, error) {
+	coupons, err := domain.couponDao.FindUsableCoupons(userId, time.Now())
+	if err != nil {
+		return nil, errcode.Wrap("查询可用优惠券失败", err)
+	}
+	return domain.toUserCouponDos(coupons)
+}
+
+// PlanCoupons 按下单时的选择计算优惠券方案, 指定的券必须都是用户当前可用的
+func (domain *CouponDomain) PlanCoupons(userId int64, lines []*do.CouponLine, selection *do.CouponSelection) (*do.CouponPlan, error) {
+	if selection == nil || (!selection.Best && len(selection.UserCouponIds) == 0) {
+		return emptyCouponPlan(lines), nil
+	}
+	usable, err := domain.GetUsableCoupons(userId)
+	if err != nil {
+		return nil, err
+	}
+	if selection.Best {
+		return BestCouponPlan(lines, usable), nil
+	}
+	usableMap := make(map[int64]*do.UserCoupon, len(usable))
+	for _, coupon := range usable {
+		usableMap[coupon.ID] = coupon
+	}
+	selected := make([]*do.UserCoupon, 0, len(selection.UserCouponIds))
+	for _, couponId := range selection.UserCouponIds {
+		coupon, ok := usableMap[couponId]
+		if !ok {
+			return nil, errcode.ErrCouponNotAvailable
+		}
+		selected = append(selected, coupon)
+	}
+	return EvaluateCouponPlan(lines, selected)
+}
+
+// LockCouponsInTx 在创建订单的事务里锁定方案中的优惠券, 有券已被使用时返回ErrCouponNotAvailable让整个下单回滚
+func (domain *CouponDomain) LockCouponsInTx(tx *gorm.DB, userId, orderId int64, plan *do.CouponPlan) error {
+	if plan == nil || len(plan.Coupons) == 0 {
+		return nil
+	}
+	couponIds := make([]int64, 0, len(plan.Coupons))
+	for _, coupon := range plan.Coupons {
+		couponIds = append(couponIds, coupon.ID)
+	}
+	return domain.couponDao.LockCoupons(tx, userId, orderId, couponIds, time.Now())
+}
+
+// ConsumeOrderCouponsInTx 订单支付成功后核销锁定的优惠券
+func (domain *CouponDomain) ConsumeOrderCouponsInTx(tx *gorm.DB, orderId int64) error {
+	return domain.couponDao.ConsumeOrderCoupons(tx, orderId, time.Now())
+}
+
+// ReleaseOrderCouponsInTx 订单取消后退回锁定的优惠券, 退回时已过期的券不会再被查询为可用
+func (domain *CouponDomain) ReleaseOrderCouponsInTx(tx *gorm.DB, orderId int64) error {
+	return domain.couponDao.ReleaseOrderCoupons(tx, orderId)
+}
+
+// grantCoupon 给用户发一张券, 锁住模板后校验每人限领数量并扣减发放数量
+func (domain *CouponDomain) grantCoupon(userId, templateId int64, source int8) (*do.UserCoupon, error) {
+	now := time.Now()
+	userCoupon := &model.UserCoupon{
+		TemplateId: templateId,
+		UserId:     userId,
+		Source:     source,
+		State:      enum.UserCouponStateUnused,
+	}
+	var templateModel *model.CouponTemplate
+	err := dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+		var err error
+		templateModel, err = domain.couponDao.LockTemplate(tx, templateId)
+		if err != nil {
+			return err
+		}
+		if templateModel == nil {
+			return errcode.ErrCouponTemplateNotFound
+		}
+		if source == enum.CouponSourceClaim &&
+			(!templateModel.Claimable || now.Before(templateModel.ClaimStartAt) || !now.Before(templateModel.ClaimEndAt)) {
+			return errcode.ErrCouponNotClaimable
+		}
+		count, err := domain.couponDao.CountUserTemplateCoupons(tx, userId, templateId)
+		if err != nil {
+			return err
+		}
+		if templateModel.PerUserLimit > 0 && count >= int64(templateModel.PerUserLimit) {
+			return errcode.ErrCouponLimitExceeded
+		}
+		if err = domain.couponDao.IncrIssuedCount(tx, templateId); err != nil {
+			return err
+		}
+		if templateModel.ValidType == enum.CouponValidRelative {
+			userCoupon.ValidStartAt = now
+			userCoupon.ValidEndAt = now.AddDate(0, 0, templateModel.ValidDays)
+		} else {
+			userCoupon.ValidStartAt = templateModel.ValidStartAt
+			userCoupon.ValidEndAt = templateModel.ValidEndAt
+		}
+		if !now.Before(userCoupon.ValidEndAt) {
+			// 固定有效期已经结束的券发出去也用不了
+			return errcode.ErrCouponNotClaimable
+		}
+		return domain.couponDao.CreateUserCoupon(tx, userCoupon)
+	})
+	if err != nil {
+		if appErr, ok := err.(*errcode.AppError); ok && appErr.Code() > 0 {
+			return nil, err
+		}
+		return nil, errcode.Wrap("发放优惠券失败", err)
+	}
+	couponDo := new(do.UserCoupon)
+	_ = utils.CopyStruct(couponDo, userCoupon)
+	couponDo.Template = domain.toTemplateDo(templateModel)
+	return couponDo, nil
+}
+
+func (domain *CouponDomain) toUserCouponDos(coupons []*model.UserCoupon) ([]*do.UserCoupon, error) {
+	couponDos := make([]*do.UserCoupon, 0, len(coupons))
+	if len(coupons) == 0 {
+		return couponDos, nil
+	}
+	templateIds := make([]int64, 0, len(coupons))
+	for _, coupon := range coupons {
+		templateIds = append(templateIds, coupon.TemplateId)
+	}
+	templates, err := domain.couponDao.FindTemplatesByIds(templateIds)
+	if err != nil {
+		return nil, errcode.Wrap("查询优惠券模板失败", err)
+	}
+	templateMap := make(map[int64]*do.CouponTemplate, len(templates))
+	for _, template := range templates {
+		templateMap[template.ID] = domain.toTemplateDo(template)
+	}
+	for _, coupon := range coupons {
+		template, ok := templateMap[coupon.TemplateId]
+		if !ok {
+			// 模板被删除的券不再展示也不能使用
+			continue
+		}
+		couponDo := new(do.UserCoupon)
+		_ = utils.CopyStruct(couponDo, coupon)
+		couponDo.Template = template
+		couponDos = append(couponDos, couponDo)
+	}
+	return couponDos, nil
+}
+
+func (domain *CouponDomain) toTemplateDos(templates []*model.CouponTemplate) []*do.CouponTemplate {
+	templateDos := make([]*do.CouponTemplate, 0, len(templates))
+	for _, template := range templates {
+		templateDos = append(templateDos, domain.toTemplateDo(template))
+	}
+	return templateDos
+}
+
+func (domain *CouponDomain) toTemplateDo(template *model.CouponTemplate) *do.CouponTemplate {
+	templateDo := new(do.CouponTemplate)
+	_ = utils.CopyStruct(templateDo, template)
+	templateDo.ScopeIds = make([]int64, 0)
+	if template.ScopeIds != "" {
+		_ = json.Unmarshal([]byte(template.ScopeIds), &templateDo.ScopeIds)
+	}
+	return templateDo
+}
+
+// checkCouponTemplate 校验模板的优惠规则是否完整
+func checkCouponTemplate(template *do.CouponTemplate) error {
+	switch template.Type {
+	case enum.CouponTypeFixed, enum.CouponTypeThreshold:
+		if template.Amount <= 0 {
+			return errcode.ErrCouponParams
+		}
+	case enum.CouponTypePercentage:
+		if template.Discount <= 0 || template.Discount >= 100 {
+			return errcode.ErrCouponParams
+		}
+	default:
+		return errcode.ErrCouponParams
+	}
+	if template.ScopeType != enum.CouponScopeAll && len(template.ScopeIds) == 0 {
+		return errcode.ErrCouponParams
+	}
+	if !template.ClaimStartAt.Before(template.ClaimEndAt) {
+		return errcode.ErrCouponParams
+	}
+	switch template.ValidType {
+	case enum.CouponValidFixed:
+		if !template.ValidStartAt.Before(template.ValidEndAt) {
+			return errcode.ErrCouponParams
+		}
+	case enum.CouponValidRelative:
+		if template.ValidDays <= 0 {
+			return errcode.ErrCouponParams
+		}
+		// 领取后N天有效的券没有固定的起止时间, 存成领取时间段方便按时间查询
+		template.ValidStartAt, template.ValidEndAt = template.ClaimStartAt, template.ClaimEndAt.AddDate(0, 0, template.ValidDays)
+	default:
+		return errcode.ErrCouponParams
+	}
+	return nil
+}
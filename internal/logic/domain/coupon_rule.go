@@ -0,0 +1,247 @@
+package domain
+
+import (
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"sort"
+)
+
+// 优惠券规则引擎, 纯计算不读写数据, 金额全部以分为单位
+//
+// 组合规则:
+//   - 不可叠加的券只能单独使用, 可叠加的券之间可以组合, 一笔订单最多用maxCouponsPerOrder张
+//   - 同一个模板的券一笔订单只能用一张
+//   - 先算立减券、满减券, 再算折扣券, 同类按模板ID排序, 每张券的门槛按前面的券优惠之后的金额计算
+//   - 每张券的优惠按适用范围内商品的剩余金额比例分摊到各行商品, 用于部分退款时计算可退金额
+
+const (
+	maxCouponsPerOrder  = 3  // 一笔订单最多同时使用的优惠券张数
+	maxCouponCandidates = 10 // 参与组合枚举的候选券数量上限, 限制枚举的组合数量
+)
+
+// EvaluateCouponPlan 按规则计算指定的一组优惠券, 组合不合法或者有券不满足使用条件时返回ErrCouponNotApplicable
+func EvaluateCouponPlan(lines []*do.CouponLine, coupons []*do.UserCoupon) (*do.CouponPlan, error) {
+	if !isValidCouponCombination(coupons) {
+		return nil, errcode.ErrCouponNotApplicable
+	}
+	plan, ok := calcCouponPlan(lines, coupons)
+	if !ok {
+		return nil, errcode.ErrCouponNotApplicable
+	}
+	return plan, nil
+}
+
+// BestCouponPlan 从用户可用的优惠券里选出优惠金额最大的组合
+// 优惠金额相同时依次选用券更少的、更早过期的, 保证同样的输入总是得到同样的结果; 没有可用的券时返回空方案
+func BestCouponPlan(lines []*do.CouponLine, coupons []*do.UserCoupon) *do.CouponPlan {
+	candidates := couponCandidates(lines, coupons)
+	best := emptyCouponPlan(lines)
+	for _, coupon := range candidates {
+		plan, _ := calcCouponPlan(lines, []*do.UserCoupon{coupon})
+		if isBetterCouponPlan(plan, best) {
+			best = plan
+		}
+	}
+
+	stackable := make([]*do.UserCoupon, 0, len(candidates))
+	for _, coupon := range candidates {
+		if coupon.Template.Stackable {
+			stackable = append(stackable, coupon)
+		}
+	}
+	var enumerate func(start int, chosen []*do.UserCoupon)
+	enumerate = func(start int, chosen []*do.UserCoupon) {
+		if len(chosen) >= 2 {
+			if plan, ok := calcCouponPlan(lines, chosen); ok && isBetterCouponPlan(plan, best) {
+				best = plan
+			}
+		}
+		if len(chosen) == maxCouponsPerOrder {
+			return
+		}
+		for i := start; i < len(stackable); i++ {
+			enumerate(i+1, append(chosen[:len(chosen):len(chosen)], stackable[i]))
+		}
+	}
+	enumerate(0, nil)
+	return best
+}
+
+// ApplicableCoupons 返回单独使用时能产生优惠的券和各自的优惠金额, 用于结算页展示可用券
+func ApplicableCoupons(lines []*do.CouponLine, coupons []*do.UserCoupon) ([]*do.UserCoupon, []int64) {
+	applicable := make([]*do.UserCoupon, 0, len(coupons))
+	discounts := make([]int64, 0, len(coupons))
+	for _, coupon := range coupons {
+		if plan, ok := calcCouponPlan(lines, []*do.UserCoupon{coupon}); ok {
+			applicable = append(applicable, coupon)
+			discounts = append(discounts, plan.Discount)
+		}
+	}
+	return applicable, discounts
+}
+
+// couponCandidates 选出参与组合的候选券: 单独可用, 同一模板只保留最早过期的一张, 按单券优惠从大到小取前若干张
+func couponCandidates(lines []*do.CouponLine, coupons []*do.UserCoupon) []*do.UserCoupon {
+	sorted := append([]*do.UserCoupon(nil), coupons...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].ValidEndAt.Equal(sorted[j].ValidEndAt) {
+			return sorted[i].ValidEndAt.Before(sorted[j].ValidEndAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	applicable, discounts := ApplicableCoupons(lines, sorted)
+	seen := make(map[int64]bool, len(applicable))
+	candidates := make([]*do.UserCoupon, 0, len(applicable))
+	candidateDiscounts := make(map[int64]int64, len(applicable))
+	for i, coupon := range applicable {
+		if seen[coupon.TemplateId] {
+			continue
+		}
+		seen[coupon.TemplateId] = true
+		candidates = append(candidates, coupon)
+		candidateDiscounts[coupon.ID] = discounts[i]
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidateDiscounts[candidates[i].ID] > candidateDiscounts[candidates[j].ID]
+	})
+	if len(candidates) > maxCouponCandidates {
+		candidates = candidates[:maxCouponCandidates]
+	}
+	return candidates
+}
+
+// isBetterCouponPlan 判断方案a是否优于方案b
+func isBetterCouponPlan(a, b *do.CouponPlan) bool {
+	if a.Discount != b.Discount {
+		return a.Discount > b.Discount
+	}
+	if len(a.Coupons) != len(b.Coupons) {
+		return len(a.Coupons) < len(b.Coupons)
+	}
+	for i := range a.Coupons {
+		if !a.Coupons[i].ValidEndAt.Equal(b.Coupons[i].ValidEndAt) {
+			return a.Coupons[i].ValidEndAt.Before(b.Coupons[i].ValidEndAt)
+		}
+		if a.Coupons[i].ID != b.Coupons[i].ID {
+			return a.Coupons[i].ID < b.Coupons[i].ID
+		}
+	}
+	return false
+}
+
+// isValidCouponCombination 校验一组券能否同时使用
+func isValidCouponCombination(coupons []*do.UserCoupon) bool {
+	if len(coupons) > maxCouponsPerOrder {
+		return false
+	}
+	templates := make(map[int64]bool, len(coupons))
+	for _, coupon := range coupons {
+		if coupon.Template == nil || templates[coupon.TemplateId] {
+			return false
+		}
+		templates[coupon.TemplateId] = true
+		if len(coupons) > 1 && !coupon.Template.Stackable {
+			return false
+		}
+	}
+	return true
+}
+
+// calcCouponPlan 按计算顺序依次应用每张券, 有券不满足门槛或者优惠为0时返回false
+func calcCouponPlan(lines []*do.CouponLine, coupons []*do.UserCoupon) (*do.CouponPlan, bool) {
+	ordered := append([]*do.UserCoupon(nil), coupons...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		pi := ordered[i].Template.Type == enum.CouponTypePercentage
+		pj := ordered[j].Template.Type == enum.CouponTypePercentage
+		if pi != pj {
+			return !pi
+		}
+		if ordered[i].TemplateId != ordered[j].TemplateId {
+			return ordered[i].TemplateId < ordered[j].TemplateId
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	plan := emptyCouponPlan(lines)
+	remaining := make([]int64, len(lines))
+	for i, line := range lines {
+		remaining[i] = line.Amount
+	}
+	for _, coupon := range ordered {
+		discount, shares := applyCoupon(coupon.Template, lines, remaining)
+		if discount <= 0 {
+			return nil, false
+		}
+		for i, share := range shares {
+			remaining[i] -= share
+			plan.LineDiscounts[i] += share
+		}
+		plan.Coupons = append(plan.Coupons, coupon)
+		plan.CouponDiscounts = append(plan.CouponDiscounts, discount)
+		plan.CouponShares = append(plan.CouponShares, shares)
+		plan.Discount += discount
+	}
+	return plan, true
+}
+
+// applyCoupon 计算一张券在当前剩余金额上的优惠金额, 以及分摊到每行商品上的金额, 不满足门槛时优惠为0
+func applyCoupon(template *do.CouponTemplate, lines []*do.CouponLine, remaining []int64) (int64, []int64) {
+	weights := make([]int64, len(lines))
+	var base int64
+	for i, line := range lines {
+		if matchCouponScope(template, line) {
+			weights[i] = remaining[i]
+			base += remaining[i]
+		}
+	}
+	if base <= 0 || base < template.Threshold {
+		return 0, nil
+	}
+	var discount int64
+	switch template.Type {
+	case enum.CouponTypeFixed, enum.CouponTypeThreshold:
+		discount = template.Amount
+	case enum.CouponTypePercentage:
+		// 优惠金额向下取整, 零头算给用户实付, 保证计算结果确定
+		discount = base * int64(100-template.Discount) / 100
+		if template.MaxDiscount > 0 && discount > template.MaxDiscount {
+			discount = template.MaxDiscount
+		}
+	}
+	if discount > base {
+		discount = base
+	}
+	return discount, utils.AllocateByWeight(discount, weights)
+}
+
+// matchCouponScope 判断商品是否在券的适用范围内
+func matchCouponScope(template *do.CouponTemplate, line *do.CouponLine) bool {
+	var target int64
+	switch template.ScopeType {
+	case enum.CouponScopeAll:
+		return true
+	case enum.CouponScopeCategory:
+		target = line.CategoryId
+	case enum.CouponScopeSku:
+		target = line.SkuId
+	default:
+		return false
+	}
+	for _, id := range template.ScopeIds {
+		if id == target {
+			return true
+		}
+	}
+	return false
+}
+
+func emptyCouponPlan(lines []*do.CouponLine) *do.CouponPlan {
+	return &do.CouponPlan{
+		Coupons:         make([]*do.UserCoupon, 0),
+		CouponDiscounts: make([]int64, 0),
+		LineDiscounts:   make([]int64, len(lines)),
+		CouponShares:    make([][]int64, 0),
+	}
+}
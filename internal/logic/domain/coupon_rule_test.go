@@ -0,0 +1,228 @@
+package domain
+
+import (
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// 两行商品共100元: SKU1(分类10)60元, SKU2(分类20)40元
+var testCouponLines = []*do.CouponLine{
+	{SkuId: 1, CategoryId: 10, Amount: 6000},
+	{SkuId: 2, CategoryId: 20, Amount: 4000},
+}
+
+var (
+	// 无门槛立减5元, 可叠加
+	fixedTemplate = &do.CouponTemplate{ID: 1, Type: enum.CouponTypeFixed, Amount: 500, Stackable: true}
+	// 满80减10, 可叠加
+	thresholdTemplate = &do.CouponTemplate{ID: 2, Type: enum.CouponTypeThreshold, Threshold: 8000, Amount: 1000, Stackable: true}
+	// 9折, 可叠加
+	percentageTemplate = &do.CouponTemplate{ID: 3, Type: enum.CouponTypePercentage, Discount: 90, Stackable: true}
+	// 满50减15, 不可叠加
+	soloTemplate = &do.CouponTemplate{ID: 4, Type: enum.CouponTypeThreshold, Threshold: 5000, Amount: 1500}
+	// 分类20立减3元, 可叠加
+	categoryTemplate = &do.CouponTemplate{ID: 5, Type: enum.CouponTypeFixed, Amount: 300, ScopeType: enum.CouponScopeCategory, ScopeIds: []int64{20}, Stackable: true}
+	// 满200减50, 订单金额不够
+	unreachableTemplate = &do.CouponTemplate{ID: 6, Type: enum.CouponTypeThreshold, Threshold: 20000, Amount: 5000, Stackable: true}
+	// 满96减10, 可叠加, 和立减券一起用时门槛按立减之后的金额算
+	highThresholdTemplate = &do.CouponTemplate{ID: 7, Type: enum.CouponTypeThreshold, Threshold: 9600, Amount: 1000, Stackable: true}
+	// 5折最多减20元, 可叠加
+	cappedTemplate = &do.CouponTemplate{ID: 8, Type: enum.CouponTypePercentage, Discount: 50, MaxDiscount: 2000, Stackable: true}
+	// 满50减30, 不可叠加
+	bigSoloTemplate = &do.CouponTemplate{ID: 9, Type: enum.CouponTypeThreshold, Threshold: 5000, Amount: 3000}
+)
+
+func testUserCoupon(id int64, template *do.CouponTemplate, validDays int) *do.UserCoupon {
+	return &do.UserCoupon{
+		ID:         id,
+		TemplateId: template.ID,
+		ValidEndAt: time.Date(2030, 1, 1, 0, 0, 0, 0, time.Local).AddDate(0, 0, validDays),
+		Template:   template,
+	}
+}
+
+func couponIds(coupons []*do.UserCoupon) []int64 {
+	ids := make([]int64, 0, len(coupons))
+	for _, coupon := range coupons {
+		ids = append(ids, coupon.ID)
+	}
+	return ids
+}
+
+func TestEvaluateCouponPlan(t *testing.T) {
+	fixed := testUserCoupon(101, fixedTemplate, 1)
+	threshold := testUserCoupon(102, thresholdTemplate, 1)
+	percentage := testUserCoupon(103, percentageTemplate, 1)
+	solo := testUserCoupon(104, soloTemplate, 1)
+	category := testUserCoupon(105, categoryTemplate, 1)
+	highThreshold := testUserCoupon(107, highThresholdTemplate, 1)
+	capped := testUserCoupon(108, cappedTemplate, 1)
+	fixedAgain := testUserCoupon(111, fixedTemplate, 2)
+
+	tests := []struct {
+		name              string
+		coupons           []*do.UserCoupon
+		wantErr           error
+		wantCouponIds     []int64
+		wantDiscount      int64
+		wantLineDiscounts []int64
+	}{
+		{
+			name:              "立减券按金额比例分摊",
+			coupons:           []*do.UserCoupon{fixed},
+			wantCouponIds:     []int64{101},
+			wantDiscount:      500,
+			wantLineDiscounts: []int64{300, 200},
+		},
+		{
+			name:              "立减后仍满足满减门槛",
+			coupons:           []*do.UserCoupon{threshold, fixed},
+			wantCouponIds:     []int64{101, 102},
+			wantDiscount:      1500,
+			wantLineDiscounts: []int64{900, 600},
+		},
+		{
+			name:              "折扣券最后计算",
+			coupons:           []*do.UserCoupon{percentage, threshold, fixed},
+			wantCouponIds:     []int64{101, 102, 103},
+			wantDiscount:      2350,
+			wantLineDiscounts: []int64{1410, 940},
+		},
+		{
+			name:              "折扣券不超过最高优惠",
+			coupons:           []*do.UserCoupon{capped},
+			wantCouponIds:     []int64{108},
+			wantDiscount:      2000,
+			wantLineDiscounts: []int64{1200, 800},
+		},
+		{
+			name:              "指定分类的券只分摊到范围内的商品",
+			coupons:           []*do.UserCoupon{category},
+			wantCouponIds:     []int64{105},
+			wantDiscount:      300,
+			wantLineDiscounts: []int64{0, 300},
+		},
+		{
+			name:    "立减后不满足满减门槛",
+			coupons: []*do.UserCoupon{fixed, highThreshold},
+			wantErr: errcode.ErrCouponNotApplicable,
+		},
+		{
+			name:    "不可叠加的券不能组合",
+			coupons: []*do.UserCoupon{solo, fixed},
+			wantErr: errcode.ErrCouponNotApplicable,
+		},
+		{
+			name:    "同一模板只能用一张",
+			coupons: []*do.UserCoupon{fixed, fixedAgain},
+			wantErr: errcode.ErrCouponNotApplicable,
+		},
+		{
+			name:    "超过每单张数上限",
+			coupons: []*do.UserCoupon{fixed, threshold, percentage, category},
+			wantErr: errcode.ErrCouponNotApplicable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := EvaluateCouponPlan(testCouponLines, tt.coupons)
+			if tt.wantErr != nil {
+				if err != tt.wantErr {
+					t.Fatalf("EvaluateCouponPlan() err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EvaluateCouponPlan() unexpected err = %v", err)
+			}
+			if got := couponIds(plan.Coupons); !reflect.DeepEqual(got, tt.wantCouponIds) {
+				t.Errorf("coupons = %v, want %v", got, tt.wantCouponIds)
+			}
+			if plan.Discount != tt.wantDiscount {
+				t.Errorf("discount = %d, want %d", plan.Discount, tt.wantDiscount)
+			}
+			if !reflect.DeepEqual(plan.LineDiscounts, tt.wantLineDiscounts) {
+				t.Errorf("line discounts = %v, want %v", plan.LineDiscounts, tt.wantLineDiscounts)
+			}
+			var sum int64
+			for _, discount := range plan.CouponDiscounts {
+				sum += discount
+			}
+			if sum != plan.Discount {
+				t.Errorf("coupon discounts sum = %d, want %d", sum, plan.Discount)
+			}
+		})
+	}
+}
+
+func TestBestCouponPlan(t *testing.T) {
+	fixed := testUserCoupon(101, fixedTemplate, 1)
+	threshold := testUserCoupon(102, thresholdTemplate, 1)
+	percentage := testUserCoupon(103, percentageTemplate, 1)
+	solo := testUserCoupon(104, soloTemplate, 1)
+	unreachable := testUserCoupon(106, unreachableTemplate, 1)
+	bigSolo := testUserCoupon(109, bigSoloTemplate, 1)
+	fixedLater := testUserCoupon(110, fixedTemplate, 5)
+	fixedEarlier := testUserCoupon(111, fixedTemplate, 3)
+
+	tests := []struct {
+		name          string
+		coupons       []*do.UserCoupon
+		wantCouponIds []int64
+		wantDiscount  int64
+	}{
+		{
+			name:          "没有可用的券",
+			coupons:       []*do.UserCoupon{unreachable},
+			wantCouponIds: []int64{},
+			wantDiscount:  0,
+		},
+		{
+			name:          "叠加优于单独使用",
+			coupons:       []*do.UserCoupon{solo, unreachable, percentage, threshold, fixed},
+			wantCouponIds: []int64{101, 102, 103},
+			wantDiscount:  2350,
+		},
+		{
+			name:          "单独使用优于叠加",
+			coupons:       []*do.UserCoupon{bigSolo, percentage, threshold, fixed},
+			wantCouponIds: []int64{109},
+			wantDiscount:  3000,
+		},
+		{
+			name:          "同一模板选更早过期的",
+			coupons:       []*do.UserCoupon{fixedLater, fixedEarlier},
+			wantCouponIds: []int64{111},
+			wantDiscount:  500,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := BestCouponPlan(testCouponLines, tt.coupons)
+			if got := couponIds(plan.Coupons); !reflect.DeepEqual(got, tt.wantCouponIds) {
+				t.Errorf("coupons = %v, want %v", got, tt.wantCouponIds)
+			}
+			if plan.Discount != tt.wantDiscount {
+				t.Errorf("discount = %d, want %d", plan.Discount, tt.wantDiscount)
+			}
+		})
+	}
+}
+
+func TestApplicableCoupons(t *testing.T) {
+	fixed := testUserCoupon(101, fixedTemplate, 1)
+	category := testUserCoupon(105, categoryTemplate, 1)
+	unreachable := testUserCoupon(106, unreachableTemplate, 1)
+
+	applicable, discounts := ApplicableCoupons(testCouponLines, []*do.UserCoupon{fixed, unreachable, category})
+	if got := couponIds(applicable); !reflect.DeepEqual(got, []int64{101, 105}) {
+		t.Errorf("applicable = %v, want [101 105]", got)
+	}
+	if !reflect.DeepEqual(discounts, []int64{500, 300}) {
+		t.Errorf("discounts = %v, want [500 300]", discounts)
+	}
+}
@@ -47,7 +47,7 @@ func (d DemoDomain) CreateDemoOrder(demoOrder *do.DemoOrder) (*do.DemoOrder, err
 		return nil, errcode.Wrap("创建 Demo 出错了", err)
 	}
 
-	// 写订单快照，这里不演示了
+	// 写订单快照，这里不演示了, 真实订单的明细快照见 OrderDomain.saveOrder
 
 	err = utils.CopyStruct(demoOrder, demoOrderModel)
 	return demoOrder, nil
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/internal/dal/cache"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/eventbus"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// EventDomain 把订单状态变化、新消息等事件实时推送给用户
+// 推送失败只记录日志, 客户端重连或者主动刷新时仍然能拿到最新数据
+type EventDomain struct {
+	ctx context.Context
+}
+
+func NewEventDomain(ctx context.Context) *EventDomain {
+	return &EventDomain{ctx: ctx}
+}
+
+// Subscribe 订阅用户的实时事件, 返回lastEventId之后错过的事件
+func (domain *EventDomain) Subscribe(userId int64, lastEventId string) (*eventbus.Subscription, []*eventbus.Event, error) {
+	sub, missed, err := cache.EventBus().Subscribe(domain.ctx, userId, lastEventId)
+	if err != nil {
+		return nil, nil, errcode.Wrap("订阅实时事件失败", err)
+	}
+	return sub, missed, nil
+}
+
+// PublishOrderStateInTx 事务提交后推送订单状态变化, 事务回滚时不推送
+func (domain *EventDomain) PublishOrderStateInTx(tx *gorm.DB, order *do.Order, fromState, toState int8) {
+	userId := order.UserId
+	event := &do.OrderStateEvent{
+		OrderNo:       order.OrderNo,
+		ParentOrderNo: order.ParentOrderNo,
+		FromState:     fromState,
+		State:         toState,
+		StateName:     enum.OrderStateToName(toState),
+	}
+	dao.AfterCommit(tx, func() {
+		domain.publish(userId, enum.EventTypeOrderState, event)
+	})
+}
+
+// PublishNotification 推送用户收到的新站内消息
+func (domain *EventDomain) PublishNotification(message *do.NotificationMessage) {
+	domain.publish(message.UserId, enum.EventTypeNotification, message)
+}
+
+// PublishBroadcast 通知在线用户有新的广播消息, 客户端收到后刷新消息中心拉取广播
+func (domain *EventDomain) PublishBroadcast(broadcast *do.NotificationBroadcast) {
+	if cache.EventBus() == nil {
+		return
+	}
+	if err := cache.EventBus().PublishOnline(domain.ctx, enum.EventTypeBroadcast, broadcast); err != nil {
+		logger.NewLogger(domain.ctx).Warn("PublishBroadcastEventError", "broadcastId", broadcast.ID, "err", err)
+	}
+}
+
+func (domain *EventDomain) publish(userId int64, eventType string, payload interface{}) {
+	if cache.EventBus() == nil {
+		return
+	}
+	if _, err := cache.EventBus().Publish(domain.ctx, userId, eventType, payload); err != nil {
+		logger.NewLogger(domain.ctx).Warn("PublishEventError", "userId", userId, "type", eventType, "err", err)
+	}
+}
@@ -0,0 +1,336 @@
+package domain
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// subscriptionNotifyBatchSize SKU变化后每批检查的订阅数
+const subscriptionNotifyBatchSize = 200
+
+type FavoriteDomain struct {
+	ctx                context.Context
+	favoriteDao        *dao.FavoriteDao
+	goodsDao           *dao.GoodsDao
+	notificationDomain *NotificationDomain
+}
+
+func NewFavoriteDomain(ctx context.Context) *FavoriteDomain {
+	return &FavoriteDomain{
+		ctx:                ctx,
+		favoriteDao:        dao.NewFavoriteDao(ctx),
+		goodsDao:           dao.NewGoodsDao(ctx),
+		notificationDomain: NewNotificationDomain(ctx),
+	}
+}
+
+// AddFavorite 收藏商品, 重复收藏不报错
+func (domain *FavoriteDomain) AddFavorite(userId, goodsId int64) error {
+	goods, err := domain.goodsDao.FindGoodsByIds([]int64{goodsId})
+	if err != nil {
+		return errcode.Wrap("查询商品失败", err)
+	}
+	if len(goods) == 0 {
+		return errcode.ErrGoodsNotFound
+	}
+	if err = domain.favoriteDao.CreateFavorite(&model.Favorite{UserId: userId, GoodsId: goodsId}); err != nil {
+		return errcode.Wrap("收藏商品失败", err)
+	}
+	return nil
+}
+
+// RemoveFavorite 取消收藏
+func (domain *FavoriteDomain) RemoveFavorite(userId, goodsId int64) error {
+	if err := domain.favoriteDao.DeleteFavorite(userId, goodsId); err != nil {
+		return errcode.Wrap("取消收藏失败", err)
+	}
+	return nil
+}
+
+// GetFavorites 分页查询用户的收藏夹, 带上商品当前的最低价和是否有货
+func (domain *FavoriteDomain) GetFavorites(userId int64, pageNum, pageSize int) ([]*do.Favorite, int64, error) {
+	favorites, total, err := domain.favoriteDao.FindUserFavorites(userId, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询收藏失败", err)
+	}
+	favoriteDos := make([]*do.Favorite, 0, len(favorites))
+	if len(favorites) == 0 {
+		return favoriteDos, total, nil
+	}
+	goodsIds := make([]int64, 0, len(favorites))
+	for _, favorite := range favorites {
+		goodsIds = append(goodsIds, favorite.GoodsId)
+	}
+	goods, err := domain.goodsDao.FindGoodsByIds(goodsIds)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询商品失败", err)
+	}
+	goodsMap := make(map[int64]*model.Goods, len(goods))
+	for _, g := range goods {
+		goodsMap[g.ID] = g
+	}
+	skus, err := domain.goodsDao.FindSkusByGoodsIds(goodsIds)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询商品SKU失败", err)
+	}
+	favoriteMap := make(map[int64]*do.Favorite, len(favorites))
+	for _, favorite := range favorites {
+		favoriteDo := &do.Favorite{
+			ID:        favorite.ID,
+			GoodsId:   favorite.GoodsId,
+			CreatedAt: favorite.CreatedAt,
+		}
+		// 商品已删除时仍然展示收藏记录, 由用户自己取消收藏
+		if g, ok := goodsMap[favorite.GoodsId]; ok {
+			favoriteDo.Title, favoriteDo.Image, favoriteDo.State = g.Title, g.Image, g.State
+		}
+		favoriteMap[favorite.GoodsId] = favoriteDo
+		favoriteDos = append(favoriteDos, favoriteDo)
+	}
+	for _, sku := range skus {
+		favoriteDo, ok := favoriteMap[sku.GoodsId]
+		if !ok {
+			continue
+		}
+		if favoriteDo.MinPrice == 0 || sku.Price < favoriteDo.MinPrice {
+			favoriteDo.MinPrice = sku.Price
+		}
+		if sku.Stock > 0 {
+			favoriteDo.InStock = true
+		}
+	}
+	return favoriteDos, total, nil
+}
+
+// Subscribe 订阅SKU的到货或降价提醒, 降价提醒以当前售价作为比较基准, 有货的SKU不能订阅到货提醒
+func (domain *FavoriteDomain) Subscribe(userId, skuId int64, subscriptionType int8, targetPrice int64) (*do.GoodsSubscription, error) {
+	sku, _, err := domain.getSku(skuId)
+	if err != nil {
+		return nil, err
+	}
+	if subscriptionType == enum.SubscriptionTypeBackInStock && sku.Stock > 0 {
+		return nil, errcode.ErrSubscriptionInStock
+	}
+	subscription := &model.GoodsSubscription{
+		UserId:        userId,
+		SkuId:         skuId,
+		GoodsId:       sku.GoodsId,
+		Type:          subscriptionType,
+		State:         enum.SubscriptionStateActive,
+		BaselinePrice: sku.Price,
+		TargetPrice:   targetPrice,
+	}
+	if err = domain.favoriteDao.UpsertSubscription(subscription); err != nil {
+		return nil, errcode.Wrap("订阅提醒失败", err)
+	}
+	subscriptionDo := new(do.GoodsSubscription)
+	_ = utils.CopyStruct(subscriptionDo, subscription)
+	return subscriptionDo, nil
+}
+
+// CancelSubscription 用户退订自己的提醒
+func (domain *FavoriteDomain) CancelSubscription(userId, subscriptionId int64) error {
+	subscription, err := domain.favoriteDao.FindSubscription(subscriptionId)
+	if err != nil {
+		return errcode.Wrap("查询订阅失败", err)
+	}
+	if subscription == nil || subscription.UserId != userId {
+		return errcode.ErrSubscriptionNotFound
+	}
+	if _, err = domain.favoriteDao.CancelSubscription(subscriptionId); err != nil {
+		return errcode.Wrap("退订失败", err)
+	}
+	return nil
+}
+
+// Unsubscribe 通过通知里的退订链接退订, 不需要登录, 链接由订阅ID签名防止伪造, 重复点击不报错
+func (domain *FavoriteDomain) Unsubscribe(token string) error {
+	subscriptionId, ok := parseUnsubscribeToken(token)
+	if !ok {
+		return errcode.ErrUnsubscribeToken
+	}
+	subscription, err := domain.favoriteDao.FindSubscription(subscriptionId)
+	if err != nil {
+		return errcode.Wrap("查询订阅失败", err)
+	}
+	if subscription == nil {
+		return errcode.ErrUnsubscribeToken
+	}
+	if _, err = domain.favoriteDao.CancelSubscription(subscriptionId); err != nil {
+		return errcode.Wrap("退订失败", err)
+	}
+	logger.NewLogger(domain.ctx).Info("SubscriptionUnsubscribed", "subscriptionId", subscriptionId, "userId", subscription.UserId)
+	return nil
+}
+
+// GetSubscriptions 分页查询用户订阅中的提醒
+func (domain *FavoriteDomain) GetSubscriptions(userId int64, pageNum, pageSize int) ([]*do.GoodsSubscription, int64, error) {
+	subscriptions, total, err := domain.favoriteDao.FindUserSubscriptions(userId, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询订阅失败", err)
+	}
+	subscriptionDos := make([]*do.GoodsSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscriptionDo := new(do.GoodsSubscription)
+		_ = utils.CopyStruct(subscriptionDo, subscription)
+		subscriptionDos = append(subscriptionDos, subscriptionDo)
+	}
+	return subscriptionDos, total, nil
+}
+
+// NotifySkuChanged SKU库存或价格变化后通知满足条件的订阅者, 返回本次通知的人数
+// 有货时通知全部到货提醒并结束订阅; 售价低于订阅基准且达到目标价时通知降价提醒, 并把基准更新为当前售价, 只有继续降价才会再次通知
+// 每个订阅先用条件更新抢到通知权再发送, 重复或并发的检查不会重复通知; 商品下架时不通知
+func (domain *FavoriteDomain) NotifySkuChanged(skuId int64) (int, error) {
+	sku, goods, err := domain.getSku(skuId)
+	if err == errcode.ErrGoodsNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if goods.State != enum.GoodsStateOnSale {
+		return 0, nil
+	}
+	var notified int
+	if sku.Stock > 0 {
+		var afterId int64
+		for {
+			subscriptions, err := domain.favoriteDao.FindBackInStockSubscriptions(skuId, afterId, subscriptionNotifyBatchSize)
+			if err != nil {
+				return notified, errcode.Wrap("查询到货提醒失败", err)
+			}
+			for _, subscription := range subscriptions {
+				claimed, err := domain.favoriteDao.ClaimBackInStock(subscription.ID, time.Now())
+				if err != nil {
+					return notified, errcode.Wrap("更新到货提醒失败", err)
+				}
+				if !claimed {
+					continue
+				}
+				domain.sendSubscriptionNotification(subscription, &do.Notification{
+					Type:     enum.NotificationTypeSystem,
+					Title:    "到货提醒",
+					Content:  fmt.Sprintf("您订阅的「%s %s」已到货, 库存有限, 快去看看吧", goods.Title, sku.Spec),
+					DedupKey: fmt.Sprintf("sub_%d_stock_%d", subscription.ID, subscription.UpdatedAt.Unix()),
+				})
+				notified++
+			}
+			if len(subscriptions) < subscriptionNotifyBatchSize {
+				break
+			}
+			afterId = subscriptions[len(subscriptions)-1].ID
+		}
+	}
+
+	var afterId int64
+	for {
+		subscriptions, err := domain.favoriteDao.FindPriceDropSubscriptions(skuId, sku.Price, afterId, subscriptionNotifyBatchSize)
+		if err != nil {
+			return notified, errcode.Wrap("查询降价提醒失败", err)
+		}
+		for _, subscription := range subscriptions {
+			claimed, err := domain.favoriteDao.ClaimPriceDrop(subscription.ID, subscription.BaselinePrice, sku.Price, time.Now())
+			if err != nil {
+				return notified, errcode.Wrap("更新降价提醒失败", err)
+			}
+			if !claimed {
+				continue
+			}
+			domain.sendSubscriptionNotification(subscription, &do.Notification{
+				Type:  enum.NotificationTypePromotion,
+				Title: "降价提醒",
+				Content: fmt.Sprintf("您关注的「%s %s」降价了, 现价%s元, 比之前便宜%s元", goods.Title, sku.Spec,
+					formatYuan(sku.Price), formatYuan(subscription.BaselinePrice-sku.Price)),
+				DedupKey: fmt.Sprintf("sub_%d_price_%d", subscription.ID, sku.Price),
+			})
+			notified++
+		}
+		if len(subscriptions) < subscriptionNotifyBatchSize {
+			break
+		}
+		afterId = subscriptions[len(subscriptions)-1].ID
+	}
+	if notified > 0 {
+		logger.NewLogger(domain.ctx).Info("SkuSubscribersNotified", "skuId", skuId, "stock", sku.Stock, "price", sku.Price, "notified", notified)
+	}
+	return notified, nil
+}
+
+// sendSubscriptionNotification 发送订阅提醒, 通知权已经抢到, 发送失败只记录日志不回滚订阅状态, 避免重试时重复打扰其他用户
+func (domain *FavoriteDomain) sendSubscriptionNotification(subscription *model.GoodsSubscription, notification *do.Notification) {
+	notification.UserId = subscription.UserId
+	notification.Link = fmt.Sprintf("/goods/%d?sku_id=%d", subscription.GoodsId, subscription.SkuId)
+	notification.UnsubscribeUrl = unsubscribeUrl(subscription.ID)
+	if _, err := domain.notificationDomain.Send(notification); err != nil {
+		logger.NewLogger(domain.ctx).Error("SubscriptionNotifyError", "subscriptionId", subscription.ID, "userId", subscription.UserId, "err", err)
+	}
+}
+
+// getSku 查询SKU和所属商品, 任一不存在时返回ErrGoodsNotFound
+func (domain *FavoriteDomain) getSku(skuId int64) (*model.GoodsSku, *model.Goods, error) {
+	skus, err := domain.goodsDao.FindSkusByIds([]int64{skuId})
+	if err != nil {
+		return nil, nil, errcode.Wrap("查询商品SKU失败", err)
+	}
+	if len(skus) == 0 {
+		return nil, nil, errcode.ErrGoodsNotFound
+	}
+	goods, err := domain.goodsDao.FindGoodsByIds([]int64{skus[0].GoodsId})
+	if err != nil {
+		return nil, nil, errcode.Wrap("查询商品失败", err)
+	}
+	if len(goods) == 0 {
+		return nil, nil, errcode.ErrGoodsNotFound
+	}
+	return skus[0], goods[0], nil
+}
+
+// unsubscribeUrl 订阅的退订链接
+func unsubscribeUrl(subscriptionId int64) string {
+	return config.AppConfig().Notification.UnsubscribeUrl + "?token=" + url.QueryEscape(unsubscribeToken(subscriptionId))
+}
+
+// unsubscribeToken 退订凭证: 订阅ID.签名
+func unsubscribeToken(subscriptionId int64) string {
+	id := strconv.FormatInt(subscriptionId, 10)
+	return id + "." + signUnsubscribe(id)
+}
+
+func parseUnsubscribeToken(token string) (int64, bool) {
+	id, sign, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(sign), []byte(signUnsubscribe(id))) {
+		return 0, false
+	}
+	subscriptionId, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return subscriptionId, true
+}
+
+func signUnsubscribe(id string) string {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig().Notification.UnsubscribeSecret))
+	mac.Write([]byte("unsubscribe:" + id))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// formatYuan 把以分为单位的金额格式化为元
+func formatYuan(fen int64) string {
+	return fmt.Sprintf("%d.%02d", fen/100, fen%100)
+}
@@ -0,0 +1,349 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/utils"
+)
+
+// 运费按运费模板计算: 订单中的商品按模板分组, 每组按收货地址命中的地区规则(没有命中时用模板的默认规则)
+// 计算运费, 订单运费为各组之和。商品没有指定模板时使用默认模板, 也没有默认模板时按配置的固定运费计算。
+
+type FreightDomain struct {
+	ctx        context.Context
+	freightDao *dao.FreightDao
+	goodsDao   *dao.GoodsDao
+}
+
+func NewFreightDomain(ctx context.Context) *FreightDomain {
+	return &FreightDomain{
+		ctx:        ctx,
+		freightDao: dao.NewFreightDao(ctx),
+		goodsDao:   dao.NewGoodsDao(ctx),
+	}
+}
+
+// CreateTemplate 创建运费模板
+func (domain *FreightDomain) CreateTemplate(template *do.FreightTemplate) error {
+	if err := checkFreightTemplate(template); err != nil {
+		return err
+	}
+	templateModel := new(model.FreightTemplate)
+	_ = utils.CopyStruct(templateModel, template)
+	ruleModels := make([]*model.FreightRegionRule, 0, len(template.Rules))
+	for _, rule := range template.Rules {
+		ruleModel := new(model.FreightRegionRule)
+		_ = utils.CopyStruct(ruleModel, rule)
+		regions, _ := json.Marshal(rule.Regions)
+		ruleModel.Regions = string(regions)
+		ruleModels = append(ruleModels, ruleModel)
+	}
+	if err := domain.freightDao.CreateTemplate(templateModel, ruleModels); err != nil {
+		return errcode.Wrap("创建运费模板失败", err)
+	}
+	template.ID = templateModel.ID
+	return nil
+}
+
+// GetTemplates 分页查询运费模板和它们的地区规则
+func (domain *FreightDomain) GetTemplates(pageNum, pageSize int) ([]*do.FreightTemplate, int64, error) {
+	templates, total, err := domain.freightDao.FindTemplates((pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询运费模板失败", err)
+	}
+	templateDos, err := domain.toTemplateDos(templates)
+	if err != nil {
+		return nil, 0, err
+	}
+	return templateDos, total, nil
+}
+
+// BindGoods 设置商品的运费模板, templateId为0时恢复使用默认模板
+func (domain *FreightDomain) BindGoods(goodsIds []int64, templateId int64) error {
+	if templateId > 0 {
+		template, err := domain.freightDao.FindTemplateById(templateId)
+		if err != nil {
+			return errcode.Wrap("查询运费模板失败", err)
+		}
+		if template == nil {
+			return errcode.ErrFreightTemplateNotFound
+		}
+	}
+	if err := domain.freightDao.BindGoods(goodsIds, templateId); err != nil {
+		return errcode.Wrap("设置商品运费模板失败", err)
+	}
+	return nil
+}
+
+// ShippingFee 按运费模板计算运费, 实现结算计价的ShippingCalculator
+// 没有收货地址时(结算页未选择地址)按模板的默认规则试算
+func (domain *FreightDomain) ShippingFee(_ context.Context, lines []*do.PriceLine, address *do.ShippingAddress) (int64, error) {
+	goodsIds := make([]int64, 0, len(lines))
+	skuIds := make([]int64, 0, len(lines))
+	for _, line := range lines {
+		goodsIds = append(goodsIds, line.GoodsId)
+		skuIds = append(skuIds, line.SkuId)
+	}
+	goods, err := domain.goodsDao.FindGoodsByIds(goodsIds)
+	if err != nil {
+		return 0, errcode.Wrap("查询商品失败", err)
+	}
+	skus, err := domain.goodsDao.FindSkusByIds(skuIds)
+	if err != nil {
+		return 0, errcode.Wrap("查询商品SKU失败", err)
+	}
+	goodsTemplate := make(map[int64]int64, len(goods))
+	for _, g := range goods {
+		goodsTemplate[g.ID] = g.FreightTemplateId
+	}
+	skuWeight := make(map[int64]int64, len(skus))
+	for _, sku := range skus {
+		skuWeight[sku.ID] = sku.Weight
+	}
+
+	templateMap, defaultTemplate, err := domain.loadShippingTemplates(goodsTemplate)
+	if err != nil {
+		return 0, err
+	}
+	// 按模板分组汇总件数、重量和优惠后的商品金额, 找不到模板的商品按配置的固定运费计算
+	groups := make(map[int64]*freightGroup)
+	groupIds := make([]int64, 0)
+	flatLines := make([]*do.PriceLine, 0)
+	for _, line := range lines {
+		template, ok := templateMap[goodsTemplate[line.GoodsId]]
+		if !ok {
+			template = defaultTemplate
+		}
+		if template == nil {
+			flatLines = append(flatLines, line)
+			continue
+		}
+		group, ok := groups[template.ID]
+		if !ok {
+			group = &freightGroup{template: template}
+			groups[template.ID] = group
+			groupIds = append(groupIds, template.ID)
+		}
+		group.pieces += int64(line.Quantity)
+		group.weight += skuWeight[line.SkuId] * int64(line.Quantity)
+		group.amount += line.Subtotal - line.PromotionDiscount - line.CouponDiscount
+	}
+
+	var fee int64
+	for _, templateId := range groupIds {
+		groupFee, err := groups[templateId].fee(address)
+		if err != nil {
+			return 0, err
+		}
+		fee += groupFee
+	}
+	if len(flatLines) > 0 {
+		flatFee, _ := flatShipping{}.ShippingFee(domain.ctx, flatLines, address)
+		fee += flatFee
+	}
+	return fee, nil
+}
+
+// loadShippingTemplates 查询商品用到的运费模板, 有商品没有指定模板时同时查询默认模板
+func (domain *FreightDomain) loadShippingTemplates(goodsTemplate map[int64]int64) (map[int64]*do.FreightTemplate, *do.FreightTemplate, error) {
+	templateIds := make([]int64, 0, len(goodsTemplate))
+	seen := make(map[int64]bool, len(goodsTemplate))
+	needDefault := false
+	for _, templateId := range goodsTemplate {
+		if templateId <= 0 {
+			needDefault = true
+		} else if !seen[templateId] {
+			seen[templateId] = true
+			templateIds = append(templateIds, templateId)
+		}
+	}
+	templates := make([]*model.FreightTemplate, 0, len(templateIds)+1)
+	if len(templateIds) > 0 {
+		found, err := domain.freightDao.FindTemplatesByIds(templateIds)
+		if err != nil {
+			return nil, nil, errcode.Wrap("查询运费模板失败", err)
+		}
+		// 商品的模板被删除时也使用默认模板
+		needDefault = needDefault || len(found) < len(templateIds)
+		templates = append(templates, found...)
+	}
+	var defaultId int64
+	if needDefault {
+		defaultTemplate, err := domain.freightDao.FindDefaultTemplate()
+		if err != nil {
+			return nil, nil, errcode.Wrap("查询默认运费模板失败", err)
+		}
+		if defaultTemplate != nil {
+			defaultId = defaultTemplate.ID
+			templates = append(templates, defaultTemplate)
+		}
+	}
+	templateDos, err := domain.toTemplateDos(templates)
+	if err != nil {
+		return nil, nil, err
+	}
+	templateMap := make(map[int64]*do.FreightTemplate, len(templateDos))
+	for _, template := range templateDos {
+		templateMap[template.ID] = template
+	}
+	return templateMap, templateMap[defaultId], nil
+}
+
+// toTemplateDos 转换运费模板并带上各自的地区规则
+func (domain *FreightDomain) toTemplateDos(templates []*model.FreightTemplate) ([]*do.FreightTemplate, error) {
+	templateDos := make([]*do.FreightTemplate, 0, len(templates))
+	if len(templates) == 0 {
+		return templateDos, nil
+	}
+	templateIds := make([]int64, 0, len(templates))
+	for _, template := range templates {
+		templateIds = append(templateIds, template.ID)
+	}
+	rules, err := domain.freightDao.FindRegionRules(templateIds)
+	if err != nil {
+		return nil, errcode.Wrap("查询运费模板地区规则失败", err)
+	}
+	ruleMap := make(map[int64][]*do.FreightRegionRule, len(templates))
+	for _, rule := range rules {
+		ruleDo := new(do.FreightRegionRule)
+		_ = utils.CopyStruct(ruleDo, rule)
+		ruleDo.Regions = make([]string, 0)
+		_ = json.Unmarshal([]byte(rule.Regions), &ruleDo.Regions)
+		ruleMap[rule.TemplateId] = append(ruleMap[rule.TemplateId], ruleDo)
+	}
+	for _, template := range templates {
+		templateDo := new(do.FreightTemplate)
+		_ = utils.CopyStruct(templateDo, template)
+		templateDo.Rules = ruleMap[template.ID]
+		if templateDo.Rules == nil {
+			templateDo.Rules = make([]*do.FreightRegionRule, 0)
+		}
+		templateDos = append(templateDos, templateDo)
+	}
+	return templateDos, nil
+}
+
+// freightGroup 使用同一个运费模板的商品
+type freightGroup struct {
+	template *do.FreightTemplate
+	pieces   int64 // 件数
+	weight   int64 // 总重量(克)
+	amount   int64 // 优惠后的商品金额(分)
+}
+
+// freightCharge 一套计费规则, 模板的默认规则和地区规则共用
+type freightCharge struct {
+	firstUnit      int64
+	firstFee       int64
+	additionalUnit int64
+	additionalFee  int64
+	freeThreshold  int64
+}
+
+// fee 计算这一组商品的运费, 收货地址命中不配送的地区时返回ErrRegionNotDeliverable
+func (group *freightGroup) fee(address *do.ShippingAddress) (int64, error) {
+	template := group.template
+	charge := freightCharge{
+		firstUnit:      template.FirstUnit,
+		firstFee:       template.FirstFee,
+		additionalUnit: template.AdditionalUnit,
+		additionalFee:  template.AdditionalFee,
+		freeThreshold:  template.FreeThreshold,
+	}
+	if rule := matchRegionRule(template.Rules, address); rule != nil {
+		if rule.Undeliverable {
+			return 0, errcode.ErrRegionNotDeliverable
+		}
+		charge = freightCharge{
+			firstUnit:      rule.FirstUnit,
+			firstFee:       rule.FirstFee,
+			additionalUnit: rule.AdditionalUnit,
+			additionalFee:  rule.AdditionalFee,
+			freeThreshold:  rule.FreeThreshold,
+		}
+	}
+	if charge.freeThreshold > 0 && group.amount >= charge.freeThreshold {
+		return 0, nil
+	}
+	var units int64
+	switch template.ChargeType {
+	case enum.FreightChargePiece:
+		units = group.pieces
+	case enum.FreightChargeWeight:
+		units = group.weight
+	default:
+		return charge.firstFee, nil
+	}
+	fee := charge.firstFee
+	// 超出首件/首重的部分按续件/续重单位向上取整计费
+	if units > charge.firstUnit && charge.additionalUnit > 0 {
+		extra := units - charge.firstUnit
+		fee += (extra + charge.additionalUnit - 1) / charge.additionalUnit * charge.additionalFee
+	}
+	return fee, nil
+}
+
+// matchRegionRule 查找收货地址命中的地区规则, 市级规则优先于省级规则, 没有命中时返回nil
+func matchRegionRule(rules []*do.FreightRegionRule, address *do.ShippingAddress) *do.FreightRegionRule {
+	if address == nil {
+		return nil
+	}
+	city := address.Province + "/" + address.City
+	var provinceRule *do.FreightRegionRule
+	for _, rule := range rules {
+		for _, region := range rule.Regions {
+			if region == city {
+				return rule
+			}
+			if region == address.Province && provinceRule == nil {
+				provinceRule = rule
+			}
+		}
+	}
+	return provinceRule
+}
+
+// checkFreightTemplate 校验运费模板的计费规则, 同一个地区不能出现在多条地区规则里
+func checkFreightTemplate(template *do.FreightTemplate) error {
+	switch template.ChargeType {
+	case enum.FreightChargeFlat, enum.FreightChargePiece, enum.FreightChargeWeight:
+	default:
+		return errcode.ErrFreightTemplateParams
+	}
+	charge := freightCharge{template.FirstUnit, template.FirstFee, template.AdditionalUnit, template.AdditionalFee, template.FreeThreshold}
+	if !checkFreightCharge(template.ChargeType, charge) {
+		return errcode.ErrFreightTemplateParams
+	}
+	regions := make(map[string]bool)
+	for _, rule := range template.Rules {
+		if len(rule.Regions) == 0 {
+			return errcode.ErrFreightTemplateParams
+		}
+		for _, region := range rule.Regions {
+			if region == "" || regions[region] {
+				return errcode.ErrFreightTemplateParams
+			}
+			regions[region] = true
+		}
+		charge = freightCharge{rule.FirstUnit, rule.FirstFee, rule.AdditionalUnit, rule.AdditionalFee, rule.FreeThreshold}
+		if !rule.Undeliverable && !checkFreightCharge(template.ChargeType, charge) {
+			return errcode.ErrFreightTemplateParams
+		}
+	}
+	return nil
+}
+
+func checkFreightCharge(chargeType int8, charge freightCharge) bool {
+	if charge.firstFee < 0 || charge.additionalFee < 0 || charge.freeThreshold < 0 {
+		return false
+	}
+	if chargeType == enum.FreightChargeFlat {
+		return true
+	}
+	return charge.firstUnit > 0 && charge.additionalUnit >= 0
+}
@@ -0,0 +1,326 @@
+package domain
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"gorm.io/gorm"
+	"time"
+)
+
+// 积分账户和会员等级
+//
+// 积分流水只追加不修改, 账户上的余额和成长值是流水的汇总, 写流水时在同一个事务里锁定账户并更新。
+// 积分按到期时间先到期先使用: 到期未用完的积分 = 已到期的增加流水之和 - 所有减少流水之和(不小于0),
+// 所以不需要在每笔增加的积分上记录剩余数量, 过期任务按这个公式补一条过期流水即可, 重复执行结果不变。
+// 会员等级只由成长值决定, 成长值随订单完成增加、随退款扣回, 不会过期。
+
+type MemberDomain struct {
+	ctx          context.Context
+	pointsDao    *dao.PointsDao
+	afterSaleDao *dao.AfterSaleDao
+}
+
+func NewMemberDomain(ctx context.Context) *MemberDomain {
+	return &MemberDomain{
+		ctx:          ctx,
+		pointsDao:    dao.NewPointsDao(ctx),
+		afterSaleDao: dao.NewAfterSaleDao(ctx),
+	}
+}
+
+// GetMember 查询用户的积分余额、成长值和会员等级, 还没有积分账户的用户按零积分的普通会员返回
+func (domain *MemberDomain) GetMember(userId int64) (*do.Member, error) {
+	account, err := domain.pointsDao.FindAccount(userId)
+	if err != nil {
+		return nil, errcode.Wrap("查询积分账户失败", err)
+	}
+	member := &do.Member{UserId: userId}
+	if account != nil {
+		member.Balance, member.GrowthValue = account.Balance, account.GrowthValue
+	}
+	tiers, err := domain.GetTiers()
+	if err != nil {
+		return nil, err
+	}
+	for i, tier := range tiers {
+		if member.GrowthValue >= tier.MinGrowth {
+			member.Tier = tier
+			member.NextTier = nil
+			if i+1 < len(tiers) {
+				member.NextTier = tiers[i+1]
+			}
+		}
+	}
+	if member.Tier == nil {
+		member.Tier = &do.MemberTier{}
+	}
+	return member, nil
+}
+
+// GetTiers 按成长值从低到高返回配置的会员等级, 配置不是按成长值升序时返回ErrMemberTierInvalid
+func (domain *MemberDomain) GetTiers() ([]*do.MemberTier, error) {
+	tierConfigs := config.AppConfig().Member.Tiers
+	tiers := make([]*do.MemberTier, 0, len(tierConfigs))
+	for i, tierConfig := range tierConfigs {
+		if i > 0 && tierConfig.MinGrowth <= tierConfigs[i-1].MinGrowth {
+			return nil, errcode.ErrMemberTierInvalid
+		}
+		if tierConfig.DiscountBps < 0 || tierConfig.DiscountBps > 10000 {
+			return nil, errcode.ErrMemberTierInvalid
+		}
+		tier := new(do.MemberTier)
+		_ = utils.CopyStruct(tier, &tierConfig)
+		tiers = append(tiers, tier)
+	}
+	return tiers, nil
+}
+
+// GetLedgers 分页查询用户的积分流水
+func (domain *MemberDomain) GetLedgers(userId int64, pageNum, pageSize int) ([]*do.PointsLedger, int64, error) {
+	ledgers, total, err := domain.pointsDao.FindUserLedgers(userId, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询积分流水失败", err)
+	}
+	ledgerDos := make([]*do.PointsLedger, 0, len(ledgers))
+	for _, ledger := range ledgers {
+		ledgerDo := new(do.PointsLedger)
+		_ = utils.CopyStruct(ledgerDo, ledger)
+		ledgerDos = append(ledgerDos, ledgerDo)
+	}
+	return ledgerDos, total, nil
+}
+
+// SpendPointsInTx 在下单的事务中扣减订单抵扣使用的积分, 余额不足时返回ErrPointsNotEnough
+func (domain *MemberDomain) SpendPointsInTx(tx *gorm.DB, userId int64, orderNo string, points int64) error {
+	if points <= 0 {
+		return nil
+	}
+	return domain.appendLedgerInTx(tx, &model.PointsLedger{
+		UserId:  userId,
+		Type:    enum.PointsTypeSpend,
+		BizNo:   orderNo,
+		OrderNo: orderNo,
+		Points:  -points,
+		Remark:  "下单抵扣",
+	})
+}
+
+// ReturnOrderPointsInTx 订单取消时在同一个事务里退回下单抵扣的积分, 退回的积分重新计算有效期
+func (domain *MemberDomain) ReturnOrderPointsInTx(tx *gorm.DB, order *do.Order) error {
+	if order.PointsUsed <= 0 {
+		return nil
+	}
+	return domain.appendLedgerInTx(tx, &model.PointsLedger{
+		UserId:   order.UserId,
+		Type:     enum.PointsTypeReturn,
+		BizNo:    order.OrderNo,
+		OrderNo:  order.OrderNo,
+		Points:   order.PointsUsed,
+		ExpireAt: pointsExpireAt(),
+		Remark:   "订单取消, 退回抵扣的积分",
+	})
+}
+
+// EarnOrderPointsInTx 订单完成时在同一个事务里发放积分和成长值
+// 按实付金额扣除运费和已退款金额计算
+func (domain *MemberDomain) EarnOrderPointsInTx(tx *gorm.DB, order *do.Order) error {
+	paid := order.PayMoney - order.ShippingFee
+	afterSales, err := domain.afterSaleDao.FindOrderAfterSales(tx, order.ID)
+	if err != nil {
+		return err
+	}
+	for _, afterSale := range afterSales {
+		if afterSale.State == enum.AfterSaleStateRefunded {
+			paid -= afterSale.RefundAmount
+		}
+	}
+	if paid <= 0 {
+		return nil
+	}
+	memberConfig := config.AppConfig().Member
+	points, growth := paid*memberConfig.EarnRate/100, paid*memberConfig.GrowthRate/100
+	if points <= 0 && growth <= 0 {
+		return nil
+	}
+	return domain.appendLedgerInTx(tx, &model.PointsLedger{
+		UserId:   order.UserId,
+		Type:     enum.PointsTypeEarn,
+		BizNo:    order.OrderNo,
+		OrderNo:  order.OrderNo,
+		Points:   points,
+		Growth:   growth,
+		ExpireAt: pointsExpireAt(),
+		Remark:   "订单完成",
+	})
+}
+
+// RefundPointsInTx 售后退款成功时在同一个事务里退回该商品抵扣的积分, 并按退款金额扣回订单完成时发放的积分和成长值
+// 扣回的积分超过当前余额时只扣到0, 不让账户出现负数, 差额记日志
+func (domain *MemberDomain) RefundPointsInTx(tx *gorm.DB, order *do.Order, afterSale *do.AfterSale) error {
+	if afterSale.RefundPoints > 0 {
+		err := domain.appendLedgerInTx(tx, &model.PointsLedger{
+			UserId:   order.UserId,
+			Type:     enum.PointsTypeReturn,
+			BizNo:    afterSale.AfterSaleNo,
+			OrderNo:  order.OrderNo,
+			Points:   afterSale.RefundPoints,
+			ExpireAt: pointsExpireAt(),
+			Remark:   "售后退款, 退回抵扣的积分",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	earnedPoints, earnedGrowth, err := domain.pointsDao.SumOrderLedgers(tx, order.UserId, order.OrderNo, enum.PointsTypeEarn)
+	if err != nil {
+		return err
+	}
+	if earnedPoints <= 0 && earnedGrowth <= 0 {
+		// 订单还没完成, 完成时会扣除已退款金额再发放
+		return nil
+	}
+	reversedPoints, reversedGrowth, err := domain.pointsDao.SumOrderLedgers(tx, order.UserId, order.OrderNo, enum.PointsTypeReverse)
+	if err != nil {
+		return err
+	}
+	memberConfig := config.AppConfig().Member
+	points := min(afterSale.RefundAmount*memberConfig.EarnRate/100, earnedPoints+reversedPoints)
+	growth := min(afterSale.RefundAmount*memberConfig.GrowthRate/100, earnedGrowth+reversedGrowth)
+	account, err := domain.pointsDao.LockAccount(tx, order.UserId)
+	if err != nil {
+		return err
+	}
+	if points > account.Balance {
+		logger.NewLogger(domain.ctx).Warn("PointsReverseShortfall", "afterSaleNo", afterSale.AfterSaleNo,
+			"userId", order.UserId, "points", points, "balance", account.Balance)
+		points = account.Balance
+	}
+	if points <= 0 && growth <= 0 {
+		return nil
+	}
+	return domain.appendLedgerInTx(tx, &model.PointsLedger{
+		UserId:  order.UserId,
+		Type:    enum.PointsTypeReverse,
+		BizNo:   afterSale.AfterSaleNo,
+		OrderNo: order.OrderNo,
+		Points:  -points,
+		Growth:  -growth,
+		Remark:  "售后退款, 扣回订单获得的积分",
+	})
+}
+
+// ExpirePoints 作废用户已到期未使用的积分, 返回本次作废的积分数, 重复执行时没有新到期的积分则不做处理
+func (domain *MemberDomain) ExpirePoints(userId int64, now time.Time) (int64, error) {
+	var expired int64
+	err := dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+		account, err := domain.pointsDao.LockAccount(tx, userId)
+		if err != nil {
+			return err
+		}
+		credits, err := domain.pointsDao.SumExpiredCredits(tx, userId, now)
+		if err != nil {
+			return err
+		}
+		debits, err := domain.pointsDao.SumDebits(tx, userId)
+		if err != nil {
+			return err
+		}
+		expired = min(max(credits-debits, 0), account.Balance)
+		if expired <= 0 {
+			return nil
+		}
+		return domain.appendLedgerInTx(tx, &model.PointsLedger{
+			UserId: userId,
+			Type:   enum.PointsTypeExpire,
+			BizNo:  "EXPIRE" + now.Format("20060102150405"),
+			Points: -expired,
+			Remark: "积分到期",
+		})
+	})
+	if err != nil {
+		return 0, errcode.Wrap("积分过期处理失败", err)
+	}
+	if expired > 0 {
+		logger.NewLogger(domain.ctx).Info("PointsExpired", "userId", userId, "points", expired)
+	}
+	return expired, nil
+}
+
+// GetExpiringUserIds 分批查询在(from, to]之间有积分到期的用户
+func (domain *MemberDomain) GetExpiringUserIds(from, to time.Time, afterUserId int64, limit int) ([]int64, error) {
+	userIds, err := domain.pointsDao.FindExpiringUserIds(from, to, afterUserId, limit)
+	if err != nil {
+		return nil, errcode.Wrap("查询积分到期用户失败", err)
+	}
+	return userIds, nil
+}
+
+// appendLedgerInTx 锁定账户后追加一条流水并更新账户余额和成长值
+// 同一用户、类型、业务单号的流水已经存在时直接返回, 保证重复执行不会重复加减积分
+func (domain *MemberDomain) appendLedgerInTx(tx *gorm.DB, ledger *model.PointsLedger) error {
+	account, err := domain.pointsDao.LockAccount(tx, ledger.UserId)
+	if err != nil {
+		return err
+	}
+	existed, err := domain.pointsDao.FindLedger(tx, ledger.UserId, ledger.Type, ledger.BizNo)
+	if err != nil {
+		return err
+	}
+	if existed != nil {
+		return nil
+	}
+	if account.Balance+ledger.Points < 0 {
+		return errcode.ErrPointsNotEnough
+	}
+	account.Balance += ledger.Points
+	account.GrowthValue = max(account.GrowthValue+ledger.Growth, 0)
+	ledger.Balance = account.Balance
+	if err = domain.pointsDao.CreateLedger(tx, ledger); err != nil {
+		return err
+	}
+	return domain.pointsDao.UpdateAccount(tx, account)
+}
+
+// pointsExpireAt 现在获得的积分的过期时间, 没有配置有效期时默认一年
+func pointsExpireAt() time.Time {
+	ttl := config.AppConfig().Member.PointsTtl
+	if ttl <= 0 {
+		ttl = 365 * 24 * time.Hour
+	}
+	return time.Now().Add(ttl)
+}
+
+// memberDiscountRule 会员折扣, 按用户当前等级的折扣率计算每行的优惠, 作为营销活动参与结算计价
+type memberDiscountRule struct {
+	memberDomain *MemberDomain
+}
+
+func (rule *memberDiscountRule) Name() string {
+	return "会员折扣"
+}
+
+func (rule *memberDiscountRule) Discounts(_ context.Context, userId int64, lines []*do.PriceLine) ([]int64, error) {
+	discounts := make([]int64, len(lines))
+	member, err := rule.memberDomain.GetMember(userId)
+	if err != nil {
+		return nil, err
+	}
+	bps := member.Tier.DiscountBps
+	if bps <= 0 || bps >= 10000 {
+		return discounts, nil
+	}
+	for i, line := range lines {
+		// 折后金额四舍五入到分, 优惠金额 = 剩余金额 - 折后金额
+		remaining := line.Subtotal - line.PromotionDiscount
+		discounts[i] = remaining - utils.RoundDiv(remaining*bps, 10000)
+	}
+	return discounts, nil
+}
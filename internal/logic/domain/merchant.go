@@ -0,0 +1,219 @@
+package domain
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/utils"
+)
+
+type MerchantDomain struct {
+	ctx         context.Context
+	merchantDao *dao.MerchantDao
+	goodsDao    *dao.GoodsDao
+	userDao     *dao.UserDao
+}
+
+func NewMerchantDomain(ctx context.Context) *MerchantDomain {
+	return &MerchantDomain{
+		ctx:         ctx,
+		merchantDao: dao.NewMerchantDao(ctx),
+		goodsDao:    dao.NewGoodsDao(ctx),
+		userDao:     dao.NewUserDao(ctx),
+	}
+}
+
+// CreateMerchant 平台为用户开通店铺, 每个用户只能开一个店铺, 店铺名称不能重复
+func (domain *MerchantDomain) CreateMerchant(merchantDo *do.Merchant) (*do.Merchant, error) {
+	users, err := domain.userDao.FindUsersByIds([]int64{merchantDo.OwnerUserId})
+	if err != nil {
+		return nil, errcode.Wrap("查询用户失败", err)
+	}
+	if len(users) == 0 {
+		return nil, errcode.ErrUserNotFound
+	}
+	existed, err := domain.merchantDao.FindMerchantByOwner(merchantDo.OwnerUserId)
+	if err != nil {
+		return nil, errcode.Wrap("查询店铺失败", err)
+	}
+	if existed != nil {
+		return nil, errcode.ErrMerchantExisted
+	}
+	existed, err = domain.merchantDao.FindMerchantByName(merchantDo.Name)
+	if err != nil {
+		return nil, errcode.Wrap("查询店铺失败", err)
+	}
+	if existed != nil {
+		return nil, errcode.ErrMerchantExisted
+	}
+
+	merchant := new(model.Merchant)
+	_ = utils.CopyStruct(merchant, merchantDo)
+	merchant.ID = 0
+	merchant.State = enum.MerchantStateOpen
+	// 并发开通时由唯一索引兜底, 插入失败按服务错误返回
+	if err = domain.merchantDao.CreateMerchant(merchant); err != nil {
+		return nil, errcode.Wrap("创建店铺失败", err)
+	}
+	logger.NewLogger(domain.ctx).Info("MerchantCreated", "merchantId", merchant.ID, "ownerUserId", merchant.OwnerUserId)
+	result := new(do.Merchant)
+	_ = utils.CopyStruct(result, merchant)
+	return result, nil
+}
+
+// GetMerchant 查询店铺
+func (domain *MerchantDomain) GetMerchant(merchantId int64) (*do.Merchant, error) {
+	merchant, err := domain.merchantDao.FindMerchantById(merchantId)
+	if err != nil {
+		return nil, errcode.Wrap("查询店铺失败", err)
+	}
+	if merchant == nil {
+		return nil, errcode.ErrMerchantNotFound
+	}
+	merchantDo := new(do.Merchant)
+	_ = utils.CopyStruct(merchantDo, merchant)
+	return merchantDo, nil
+}
+
+// GetOwnerMerchant 查询用户名下营业中的店铺, 店铺被关闭后店主不能再使用商家后台
+func (domain *MerchantDomain) GetOwnerMerchant(ownerUserId int64) (*do.Merchant, error) {
+	merchant, err := domain.merchantDao.FindMerchantByOwner(ownerUserId)
+	if err != nil {
+		return nil, errcode.Wrap("查询店铺失败", err)
+	}
+	if merchant == nil {
+		return nil, errcode.ErrMerchantNotFound
+	}
+	if merchant.State != enum.MerchantStateOpen {
+		return nil, errcode.ErrMerchantClosed
+	}
+	merchantDo := new(do.Merchant)
+	_ = utils.CopyStruct(merchantDo, merchant)
+	return merchantDo, nil
+}
+
+// GetMerchants 分页查询店铺列表
+func (domain *MerchantDomain) GetMerchants(state int8, pageNum, pageSize int) ([]*do.Merchant, int64, error) {
+	merchants, total, err := domain.merchantDao.FindMerchants(state, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询店铺列表失败", err)
+	}
+	merchantDos := make([]*do.Merchant, 0, len(merchants))
+	for _, merchant := range merchants {
+		merchantDo := new(do.Merchant)
+		_ = utils.CopyStruct(merchantDo, merchant)
+		merchantDos = append(merchantDos, merchantDo)
+	}
+	return merchantDos, total, nil
+}
+
+// SetMerchantState 平台开启或关闭店铺, 关闭后店铺的商品不能下单, 已有订单不受影响
+func (domain *MerchantDomain) SetMerchantState(merchantId int64, state int8) error {
+	if _, err := domain.GetMerchant(merchantId); err != nil {
+		return err
+	}
+	if err := domain.merchantDao.UpdateMerchantState(merchantId, state); err != nil {
+		return errcode.Wrap("修改店铺状态失败", err)
+	}
+	logger.NewLogger(domain.ctx).Info("MerchantStateChanged", "merchantId", merchantId, "state", state)
+	return nil
+}
+
+// GetMerchantGoods 分页查询店铺的商品及其SKU, state小于0时查询全部状态
+func (domain *MerchantDomain) GetMerchantGoods(merchantId int64, state int, pageNum, pageSize int) ([]*do.MerchantGoods, int64, error) {
+	goods, total, err := domain.goodsDao.FindMerchantGoods(merchantId, state, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询商品列表失败", err)
+	}
+	goodsDos := make([]*do.MerchantGoods, 0, len(goods))
+	if len(goods) == 0 {
+		return goodsDos, total, nil
+	}
+	goodsIds := make([]int64, 0, len(goods))
+	for _, g := range goods {
+		goodsIds = append(goodsIds, g.ID)
+	}
+	skus, err := domain.goodsDao.FindSkusByGoodsIds(goodsIds)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询商品SKU失败", err)
+	}
+	skuMap := make(map[int64][]*do.GoodsSku, len(goods))
+	for _, sku := range skus {
+		skuDo := new(do.GoodsSku)
+		_ = utils.CopyStruct(skuDo, sku)
+		skuMap[sku.GoodsId] = append(skuMap[sku.GoodsId], skuDo)
+	}
+	for _, g := range goods {
+		goodsDo := new(do.MerchantGoods)
+		_ = utils.CopyStruct(goodsDo, g)
+		goodsDo.Skus = skuMap[g.ID]
+		if goodsDo.Skus == nil {
+			goodsDo.Skus = make([]*do.GoodsSku, 0)
+		}
+		goodsDos = append(goodsDos, goodsDo)
+	}
+	return goodsDos, total, nil
+}
+
+// SetGoodsState 店铺上下架自己的商品, 包含不属于该店铺的商品时整体拒绝
+func (domain *MerchantDomain) SetGoodsState(merchantId int64, goodsIds []int64, state int) error {
+	goods, err := domain.goodsDao.FindGoodsByIds(goodsIds)
+	if err != nil {
+		return errcode.Wrap("查询商品失败", err)
+	}
+	owned := make(map[int64]bool, len(goods))
+	for _, g := range goods {
+		if g.MerchantId == merchantId {
+			owned[g.ID] = true
+		}
+	}
+	for _, goodsId := range goodsIds {
+		if !owned[goodsId] {
+			return errcode.ErrGoodsNotFound
+		}
+	}
+	if _, err = domain.goodsDao.UpdateMerchantGoodsState(merchantId, goodsIds, state); err != nil {
+		return errcode.Wrap("修改商品状态失败", err)
+	}
+	logger.NewLogger(domain.ctx).Info("MerchantGoodsStateChanged", "merchantId", merchantId, "goodsIds", goodsIds, "state", state)
+	return nil
+}
+
+// UpdateSku 修改SKU的售价或库存, merchantId为0时是平台管理员, 可以修改任意商品
+func (domain *MerchantDomain) UpdateSku(merchantId, skuId int64, price *int64, stock *int) error {
+	skus, err := domain.goodsDao.FindSkusByIds([]int64{skuId})
+	if err != nil {
+		return errcode.Wrap("查询商品SKU失败", err)
+	}
+	if len(skus) == 0 {
+		return errcode.ErrGoodsNotFound
+	}
+	if merchantId > 0 {
+		goods, err := domain.goodsDao.FindGoodsByIds([]int64{skus[0].GoodsId})
+		if err != nil {
+			return errcode.Wrap("查询商品失败", err)
+		}
+		if len(goods) == 0 || goods[0].MerchantId != merchantId {
+			return errcode.ErrGoodsNotFound
+		}
+	}
+	fields := make(map[string]interface{}, 2)
+	if price != nil {
+		fields["price"] = *price
+	}
+	if stock != nil {
+		fields["stock"] = *stock
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	if err = domain.goodsDao.UpdateSku(skuId, fields); err != nil {
+		return errcode.Wrap("修改商品SKU失败", err)
+	}
+	logger.NewLogger(domain.ctx).Info("GoodsSkuUpdated", "merchantId", merchantId, "skuId", skuId, "fields", fields)
+	return nil
+}
@@ -0,0 +1,362 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"github.com/Cospk/go-mall/external/notify"
+	"github.com/Cospk/go-mall/internal/dal/cache"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"gorm.io/gorm"
+	"time"
+)
+
+// broadcastSyncBatchSize 每批拉取的广播条数
+const broadcastSyncBatchSize = 100
+
+type NotificationDomain struct {
+	ctx             context.Context
+	notificationDao *dao.NotificationDao
+	eventDomain     *EventDomain
+}
+
+func NewNotificationDomain(ctx context.Context) *NotificationDomain {
+	return &NotificationDomain{
+		ctx:             ctx,
+		notificationDao: dao.NewNotificationDao(ctx),
+		eventDomain:     NewEventDomain(ctx),
+	}
+}
+
+// Send 把通知写入用户的消息中心并发到所有触达渠道, 返回是否实际发送
+// 带去重键的通知在去重时间内只发送一次, 全部渠道都发送失败时释放去重键, 由调用方决定是否重试
+func (domain *NotificationDomain) Send(notification *do.Notification) (bool, error) {
+	log := logger.NewLogger(domain.ctx)
+	if notification.DedupKey != "" {
+		ttl := config.AppConfig().Notification.DedupTtl
+		if ttl <= 0 {
+			ttl = 24 * time.Hour
+		}
+		ok, err := cache.SetNotifyDedup(domain.ctx, notification.DedupKey, ttl)
+		if err != nil {
+			return false, errcode.Wrap("通知去重失败", err)
+		}
+		if !ok {
+			log.Info("NotificationDeduplicated", "userId", notification.UserId, "dedupKey", notification.DedupKey)
+			return false, nil
+		}
+	}
+	var sent int
+	if notification.Type > 0 {
+		// 站内消息是消息中心的数据来源, 写入失败时按发送失败处理
+		if err := domain.createMessage(notification); err != nil {
+			domain.releaseDedup(notification.DedupKey)
+			return false, err
+		}
+		sent++
+	}
+	msg := &notify.Message{
+		UserId:         notification.UserId,
+		Title:          notification.Title,
+		Content:        notification.Content,
+		Link:           notification.Link,
+		UnsubscribeUrl: notification.UnsubscribeUrl,
+	}
+	var lastErr error
+	for _, sender := range notify.Senders() {
+		if err := sender.Send(domain.ctx, msg); err != nil {
+			// 单个渠道失败不影响其他渠道
+			log.Error("NotificationSendError", "channel", sender.Channel(), "userId", notification.UserId, "err", err)
+			lastErr = err
+			continue
+		}
+		sent++
+	}
+	if sent == 0 && lastErr != nil {
+		domain.releaseDedup(notification.DedupKey)
+		return false, errcode.Wrap("发送通知失败", lastErr)
+	}
+	return sent > 0, nil
+}
+
+// NotifyOrderPaid 支付成功后给用户发订单消息, 同一支付单只发一次
+func (domain *NotificationDomain) NotifyOrderPaid(payment *do.Payment) {
+	domain.sendOrderNotification(&do.Notification{
+		UserId:   payment.UserId,
+		Title:    "支付成功",
+		Content:  fmt.Sprintf("订单%s已支付%s元, 我们会尽快为您发货", payment.ParentOrderNo, formatYuan(payment.Amount)),
+		Link:     fmt.Sprintf("/order/%s", payment.ParentOrderNo),
+		DedupKey: fmt.Sprintf("order_paid_%s", payment.PaymentNo),
+	})
+}
+
+// NotifyOrderShipped 订单发货后给用户发订单消息
+func (domain *NotificationDomain) NotifyOrderShipped(shipment *do.Shipment) {
+	domain.sendOrderNotification(&do.Notification{
+		UserId:   shipment.UserId,
+		Title:    "订单已发货",
+		Content:  fmt.Sprintf("订单%s已发货, 物流单号%s", shipment.OrderNo, shipment.TrackingNo),
+		Link:     fmt.Sprintf("/order/%s", shipment.OrderNo),
+		DedupKey: fmt.Sprintf("order_shipped_%d", shipment.ID),
+	})
+}
+
+// NotifyRefunded 售后退款完成后给用户发订单消息
+func (domain *NotificationDomain) NotifyRefunded(afterSale *do.AfterSale) {
+	domain.sendOrderNotification(&do.Notification{
+		UserId:   afterSale.UserId,
+		Title:    "退款成功",
+		Content:  fmt.Sprintf("售后单%s已退款%s元, 将原路退回您的支付账户", afterSale.AfterSaleNo, formatYuan(afterSale.RefundAmount)),
+		Link:     fmt.Sprintf("/after-sale/%s", afterSale.AfterSaleNo),
+		DedupKey: fmt.Sprintf("refunded_%s", afterSale.AfterSaleNo),
+	})
+}
+
+// sendOrderNotification 发送订单消息, 发送失败只记录日志, 不影响订单流程
+func (domain *NotificationDomain) sendOrderNotification(notification *do.Notification) {
+	notification.Type = enum.NotificationTypeOrder
+	if _, err := domain.Send(notification); err != nil {
+		logger.NewLogger(domain.ctx).Error("OrderNotifyError", "userId", notification.UserId, "title", notification.Title, "err", err)
+	}
+}
+
+// SendToUsers 给指定用户逐个发送通知, 单个用户发送失败只记录日志, 返回实际发送的用户数
+func (domain *NotificationDomain) SendToUsers(userIds []int64, notification *do.Notification) int {
+	var sent int
+	for _, userId := range userIds {
+		userNotification := *notification
+		userNotification.UserId = userId
+		ok, err := domain.Send(&userNotification)
+		if err != nil {
+			logger.NewLogger(domain.ctx).Error("SendToUserError", "userId", userId, "err", err)
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent
+}
+
+// Broadcast 发布一条全体用户的广播消息
+// 发布时只写一条广播, 用户打开消息中心时再把还没拉取的广播复制到自己的收件箱
+func (domain *NotificationDomain) Broadcast(adminId int64, messageType int8, title, content, link string) (*do.NotificationBroadcast, error) {
+	broadcast := &model.NotificationBroadcast{
+		Type:      messageType,
+		Title:     title,
+		Content:   content,
+		Link:      link,
+		CreatedBy: adminId,
+		CreatedAt: time.Now(),
+	}
+	if err := domain.notificationDao.CreateBroadcast(broadcast); err != nil {
+		return nil, errcode.Wrap("发布广播消息失败", err)
+	}
+	broadcastDo := new(do.NotificationBroadcast)
+	if err := utils.CopyStruct(broadcastDo, broadcast); err != nil {
+		return nil, errcode.Wrap("转换广播消息失败", err)
+	}
+	domain.eventDomain.PublishBroadcast(broadcastDo)
+	return broadcastDo, nil
+}
+
+// GetMessages 分页查询用户的站内消息, messageType为0时查询全部类型
+func (domain *NotificationDomain) GetMessages(userId int64, messageType int8, pageNum, pageSize int) ([]*do.NotificationMessage, int64, error) {
+	if err := domain.syncBroadcasts(userId); err != nil {
+		return nil, 0, err
+	}
+	messages, total, err := domain.notificationDao.FindUserMessages(userId, messageType, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询站内消息失败", err)
+	}
+	messageDos := make([]*do.NotificationMessage, 0, len(messages))
+	if err := utils.CopyStruct(&messageDos, messages); err != nil {
+		return nil, 0, errcode.Wrap("转换站内消息失败", err)
+	}
+	return messageDos, total, nil
+}
+
+// MarkRead 把用户的指定消息标记为已读, 不属于该用户的消息忽略
+func (domain *NotificationDomain) MarkRead(userId int64, messageIds []int64) (int64, error) {
+	updated, err := domain.notificationDao.MarkRead(userId, messageIds, time.Now())
+	if err != nil {
+		return 0, errcode.Wrap("标记消息已读失败", err)
+	}
+	if updated > 0 {
+		domain.invalidateUnreadCounts(userId)
+	}
+	return updated, nil
+}
+
+// MarkAllRead 把用户的全部未读消息标记为已读, messageType为0时不限类型
+func (domain *NotificationDomain) MarkAllRead(userId int64, messageType int8) (int64, error) {
+	// 先拉取广播, 避免还没拉取的广播在标记后才进入收件箱变成未读
+	if err := domain.syncBroadcasts(userId); err != nil {
+		return 0, err
+	}
+	updated, err := domain.notificationDao.MarkAllRead(userId, messageType, time.Now())
+	if err != nil {
+		return 0, errcode.Wrap("标记消息已读失败", err)
+	}
+	if updated > 0 {
+		domain.invalidateUnreadCounts(userId)
+	}
+	return updated, nil
+}
+
+// GetUnreadCounts 查询用户各类型的未读消息数, 优先读Redis缓存, 缓存不存在时从数据库统计并回写
+func (domain *NotificationDomain) GetUnreadCounts(userId int64) (*do.UnreadCount, error) {
+	if err := domain.syncBroadcasts(userId); err != nil {
+		return nil, err
+	}
+	counts, err := cache.GetUnreadCounts(domain.ctx, userId)
+	if err != nil {
+		// 缓存不可用时降级查数据库
+		logger.NewLogger(domain.ctx).Warn("GetUnreadCountsCacheError", "userId", userId, "err", err)
+	}
+	if counts == nil {
+		counts, err = domain.notificationDao.CountUnread(userId)
+		if err != nil {
+			return nil, errcode.Wrap("统计未读消息失败", err)
+		}
+		ttl := config.AppConfig().Notification.UnreadTtl
+		if ttl <= 0 {
+			ttl = time.Hour
+		}
+		if err := cache.SetUnreadCounts(domain.ctx, userId, counts, ttl); err != nil {
+			logger.NewLogger(domain.ctx).Warn("SetUnreadCountsCacheError", "userId", userId, "err", err)
+		}
+	}
+	unread := &do.UnreadCount{ByType: make(map[int8]int64, len(enum.NotificationTypes))}
+	for _, messageType := range enum.NotificationTypes {
+		unread.ByType[messageType] = counts[messageType]
+		unread.Total += counts[messageType]
+	}
+	return unread, nil
+}
+
+// syncBroadcasts 把用户还没拉取的广播复制到收件箱
+// 只拉取BroadcastLookback以内发布的广播, 新用户不会收到很久以前的广播
+func (domain *NotificationDomain) syncBroadcasts(userId int64) error {
+	latestId, err := domain.notificationDao.FindLatestBroadcastId()
+	if err != nil {
+		return errcode.Wrap("查询广播消息失败", err)
+	}
+	if latestId == 0 {
+		return nil
+	}
+	cursor, err := domain.notificationDao.FindCursor(userId)
+	if err != nil {
+		return errcode.Wrap("查询广播拉取进度失败", err)
+	}
+	if cursor != nil && cursor.LastBroadcastId >= latestId {
+		return nil
+	}
+	lookback := config.AppConfig().Notification.BroadcastLookback
+	if lookback <= 0 {
+		lookback = 7 * 24 * time.Hour
+	}
+	since := time.Now().Add(-lookback)
+	synced := make(map[int8]int64)
+	err = dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+		// 锁定拉取进度, 同一用户的并发请求不会重复复制广播
+		cursor, err := domain.notificationDao.LockCursor(tx, userId)
+		if err != nil {
+			return err
+		}
+		lastId := cursor.LastBroadcastId
+		for {
+			broadcasts, err := domain.notificationDao.FindBroadcastsAfter(tx, lastId, since, broadcastSyncBatchSize)
+			if err != nil {
+				return err
+			}
+			if len(broadcasts) == 0 {
+				break
+			}
+			messages := make([]*model.NotificationMessage, 0, len(broadcasts))
+			for _, broadcast := range broadcasts {
+				messages = append(messages, &model.NotificationMessage{
+					UserId:      userId,
+					Type:        broadcast.Type,
+					BroadcastId: broadcast.ID,
+					Title:       broadcast.Title,
+					Content:     broadcast.Content,
+					Link:        broadcast.Link,
+					CreatedAt:   broadcast.CreatedAt,
+				})
+				synced[broadcast.Type]++
+			}
+			if err := domain.notificationDao.CreateMessagesInTx(tx, messages); err != nil {
+				return err
+			}
+			lastId = broadcasts[len(broadcasts)-1].ID
+			if len(broadcasts) < broadcastSyncBatchSize {
+				break
+			}
+		}
+		// 没有需要复制的广播时也推进进度, 回看时间之外的旧广播不再检查
+		lastId = max(lastId, latestId)
+		if lastId == cursor.LastBroadcastId {
+			return nil
+		}
+		return domain.notificationDao.UpdateCursor(tx, userId, lastId)
+	})
+	if err != nil {
+		return errcode.Wrap("拉取广播消息失败", err)
+	}
+	for messageType, count := range synced {
+		domain.incrUnreadCount(userId, messageType, count)
+	}
+	return nil
+}
+
+// createMessage 把通知写入用户的收件箱, 累加未读数并推送给在线的客户端
+func (domain *NotificationDomain) createMessage(notification *do.Notification) error {
+	message := &model.NotificationMessage{
+		UserId:    notification.UserId,
+		Type:      notification.Type,
+		Title:     notification.Title,
+		Content:   notification.Content,
+		Link:      notification.Link,
+		CreatedAt: time.Now(),
+	}
+	if err := domain.notificationDao.CreateMessage(message); err != nil {
+		return errcode.Wrap("写入站内消息失败", err)
+	}
+	domain.incrUnreadCount(notification.UserId, notification.Type, 1)
+	messageDo := new(do.NotificationMessage)
+	if err := utils.CopyStruct(messageDo, message); err == nil {
+		domain.eventDomain.PublishNotification(messageDo)
+	}
+	return nil
+}
+
+// incrUnreadCount 累加缓存的未读数, 失败时删除缓存等下次查询重新统计
+func (domain *NotificationDomain) incrUnreadCount(userId int64, messageType int8, delta int64) {
+	if err := cache.IncrUnreadCount(domain.ctx, userId, messageType, delta); err != nil {
+		logger.NewLogger(domain.ctx).Warn("IncrUnreadCountError", "userId", userId, "err", err)
+		domain.invalidateUnreadCounts(userId)
+	}
+}
+
+func (domain *NotificationDomain) invalidateUnreadCounts(userId int64) {
+	if err := cache.DelUnreadCounts(domain.ctx, userId); err != nil {
+		logger.NewLogger(domain.ctx).Warn("DelUnreadCountsError", "userId", userId, "err", err)
+	}
+}
+
+// releaseDedup 发送失败时释放去重键, 允许调用方重试
+func (domain *NotificationDomain) releaseDedup(dedupKey string) {
+	if dedupKey == "" {
+		return
+	}
+	if err := cache.DelNotifyDedup(domain.ctx, dedupKey); err != nil {
+		logger.NewLogger(domain.ctx).Warn("DelNotifyDedupError", "dedupKey", dedupKey, "err", err)
+	}
+}
@@ -0,0 +1,713 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Cospk/go-mall/internal/dal/cache"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/metrics"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"gorm.io/gorm"
+	"sort"
+)
+
+type OrderDomain struct {
+	ctx           context.Context
+	orderDao      *dao.OrderDao
+	goodsDao      *dao.GoodsDao
+	merchantDao   *dao.MerchantDao
+	seckillDao    *dao.SeckillDao
+	couponDomain  *CouponDomain
+	memberDomain  *MemberDomain
+	pricingDomain *PricingDomain
+	eventDomain   *EventDomain
+}
+
+func NewOrderDomain(ctx context.Context) *OrderDomain {
+	return &OrderDomain{
+		ctx:           ctx,
+		orderDao:      dao.NewOrderDao(ctx),
+		goodsDao:      dao.NewGoodsDao(ctx),
+		merchantDao:   dao.NewMerchantDao(ctx),
+		seckillDao:    dao.NewSeckillDao(ctx),
+		couponDomain:  NewCouponDomain(ctx),
+		memberDomain:  NewMemberDomain(ctx),
+		pricingDomain: NewPricingDomain(ctx),
+		eventDomain:   NewEventDomain(ctx),
+	}
+}
+
+// CreateOrder 创建订单, 同一个事务里扣减库存、锁定优惠券、扣减抵扣的积分、写订单、订单明细和初始的状态记录
+// items 只需要带上SkuId和Quantity, 价格以下单时商品的售价为准; coupons为nil时不使用优惠券, points为0时不使用积分
+// 购买多个店铺的商品时按店铺拆分成多个子订单, 子订单共用一个父订单号, 按父订单合并支付
+func (domain *OrderDomain) CreateOrder(userId int64, items []*do.OrderItem, address *do.ShippingAddress, coupons *do.CouponSelection, points int64, remark string) ([]*do.Order, error) {
+	items, err := domain.FillOrderItems(items)
+	if err != nil {
+		return nil, err
+	}
+	breakdown, err := domain.pricingDomain.Price(&do.PricingRequest{
+		UserId:  userId,
+		Items:   items,
+		Address: address,
+		Coupons: coupons,
+		Points:  points,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return domain.saveOrders(userId, items, address, breakdown, remark, nil)
+}
+
+// CreateActivityOrder 按活动价创建单个商品的订单(秒杀等), 不使用优惠券也不参加其他营销活动
+// fn在创建订单的同一个事务里执行, 供活动记录自己的数据, 返回错误时整个下单回滚
+func (domain *OrderDomain) CreateActivityOrder(userId int64, item *do.OrderItem, address *do.ShippingAddress, remark string, fn func(tx *gorm.DB, order *model.Order) error) (*do.Order, error) {
+	unitPrice := item.UnitPrice
+	items, err := domain.FillOrderItems([]*do.OrderItem{item})
+	if err != nil {
+		return nil, err
+	}
+	items[0].UnitPrice = unitPrice
+	items[0].Amount = unitPrice * int64(items[0].Quantity)
+	breakdown, err := domain.pricingDomain.Price(&do.PricingRequest{
+		UserId:      userId,
+		Items:       items,
+		Address:     address,
+		NoPromotion: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 只有一个商品, 只会生成一个子订单
+	orders, err := domain.saveOrders(userId, items, address, breakdown, remark, fn)
+	if err != nil {
+		return nil, err
+	}
+	return orders[0], nil
+}
+
+// PreviewOrder 结算页试算, 返回和下单时一致的计价明细, 不扣库存也不锁定优惠券
+func (domain *OrderDomain) PreviewOrder(userId int64, items []*do.OrderItem, address *do.ShippingAddress, coupons *do.CouponSelection, points int64) (*do.PriceBreakdown, error) {
+	items, err := domain.FillOrderItems(items)
+	if err != nil {
+		return nil, err
+	}
+	return domain.pricingDomain.Price(&do.PricingRequest{
+		UserId:  userId,
+		Items:   items,
+		Address: address,
+		Coupons: coupons,
+		Points:  points,
+	})
+}
+
+// saveOrders 按店铺拆分子订单, 在一个事务里扣减库存、写子订单和明细、锁定优惠券、写初始的状态记录,
+// 每个子订单写完后执行调用方的fn; breakdown的计价行和items一一对应
+func (domain *OrderDomain) saveOrders(userId int64, items []*do.OrderItem, address *do.ShippingAddress, breakdown *do.PriceBreakdown, remark string, fn func(tx *gorm.DB, order *model.Order) error) ([]*do.Order, error) {
+	parentOrderNo := utils.GenParentOrderNo(userId)
+	orderModels := make([]*model.Order, 0, len(breakdown.Shops))
+	itemModels := make([][]*model.OrderItem, 0, len(breakdown.Shops))
+	for _, shop := range breakdown.Shops {
+		order := &model.Order{
+			OrderNo:         utils.GenOrderNo(userId),
+			ParentOrderNo:   parentOrderNo,
+			MerchantId:      shop.MerchantId,
+			UserId:          userId,
+			BillMoney:       shop.Subtotal,
+			PromotionAmount: shop.PromotionDiscount,
+			CouponAmount:    shop.CouponDiscount,
+			PointsAmount:    shop.PointsDiscount,
+			PointsUsed:      shop.PointsUsed,
+			ShippingFee:     shop.ShippingFee,
+			TaxAmount:       shop.Tax,
+			PayMoney:        shop.Payable,
+			State:           enum.OrderStateCreated,
+			Remark:          remark,
+		}
+		if address != nil {
+			order.ReceiverName = address.ReceiverName
+			order.ReceiverPhone = address.ReceiverPhone
+			order.Province = address.Province
+			order.City = address.City
+			order.District = address.District
+			order.Address = address.Detail
+		}
+		shopItems := make([]*model.OrderItem, 0, len(shop.LineIndexes))
+		for _, i := range shop.LineIndexes {
+			shopItems = append(shopItems, orderItemModel(items[i], breakdown.Lines[i]))
+		}
+		orderModels = append(orderModels, order)
+		itemModels = append(itemModels, shopItems)
+	}
+	couponOrders := couponOwnerShops(breakdown)
+
+	err := dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+		for _, item := range items {
+			if err := domain.goodsDao.DeductSkuStock(tx, item.SkuId, item.Quantity); err != nil {
+				return err
+			}
+		}
+		for i, order := range orderModels {
+			if err := domain.orderDao.CreateOrder(tx, order, itemModels[i]); err != nil {
+				return err
+			}
+			err := domain.orderDao.CreateOrderStateLog(tx, &model.OrderStateLog{
+				OrderId:      order.ID,
+				OrderNo:      order.OrderNo,
+				ToState:      enum.OrderStateCreated,
+				OperatorType: enum.OrderOperatorUser,
+				OperatorId:   userId,
+				Remark:       "创建订单",
+			})
+			if err != nil {
+				return err
+			}
+			plan := &do.CouponPlan{Coupons: couponOrders[i]}
+			if err = domain.couponDomain.LockCouponsInTx(tx, userId, order.ID, plan); err != nil {
+				return err
+			}
+			if err = domain.memberDomain.SpendPointsInTx(tx, userId, order.OrderNo, order.PointsUsed); err != nil {
+				return err
+			}
+			if fn != nil {
+				if err = fn(tx, order); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// 库存不足、优惠券不可用等预定义的业务错误原样返回
+		if appErr, ok := err.(*errcode.AppError); ok && appErr.Code() > 0 {
+			return nil, err
+		}
+		return nil, errcode.Wrap("创建订单失败", err)
+	}
+
+	orderDos := make([]*do.Order, 0, len(orderModels))
+	for i, order := range orderModels {
+		metrics.OrderState(enum.OrderStateToName(enum.OrderStateCreated))
+		orderDo := new(do.Order)
+		_ = utils.CopyStruct(orderDo, order)
+		orderDo.Items = make([]*do.OrderItem, 0, len(itemModels[i]))
+		for j, itemModel := range itemModels[i] {
+			item := items[breakdown.Shops[i].LineIndexes[j]]
+			item.ID = itemModel.ID
+			item.OrderId = itemModel.OrderId
+			orderDo.Items = append(orderDo.Items, item)
+		}
+		orderDos = append(orderDos, orderDo)
+	}
+	return orderDos, nil
+}
+
+// orderItemModel 用计价结果补全订单明细的金额, 生成要落库的明细快照
+func orderItemModel(item *do.OrderItem, line *do.PriceLine) *model.OrderItem {
+	item.PromotionAmount = line.PromotionDiscount
+	item.CouponAmount = line.CouponDiscount
+	item.PointsAmount = line.PointsDiscount
+	item.PointsUsed = line.PointsUsed
+	item.TaxAmount = line.Tax
+	item.PayAmount = line.Payable
+	item.Promotions = line.Promotions
+	if item.Promotions == nil {
+		item.Promotions = make([]*do.AppliedPromotion, 0)
+	}
+	promotions, _ := json.Marshal(item.Promotions)
+	return &model.OrderItem{
+		GoodsId:         item.GoodsId,
+		SkuId:           item.SkuId,
+		GoodsTitle:      item.GoodsTitle,
+		SkuSpec:         item.SkuSpec,
+		GoodsImage:      item.GoodsImage,
+		Quantity:        item.Quantity,
+		UnitPrice:       item.UnitPrice,
+		Amount:          item.Amount,
+		PromotionAmount: item.PromotionAmount,
+		CouponAmount:    item.CouponAmount,
+		PointsAmount:    item.PointsAmount,
+		PointsUsed:      item.PointsUsed,
+		TaxAmount:       item.TaxAmount,
+		PayAmount:       item.PayAmount,
+		Promotions:      string(promotions),
+	}
+}
+
+// couponOwnerShops 决定跨店铺使用的优惠券锁定在哪个子订单上, 返回值和breakdown.Shops一一对应
+// 每张券归属到分摊金额最多的店铺; 子订单合并支付、一起取消, 券随归属的子订单核销或退回
+func couponOwnerShops(breakdown *do.PriceBreakdown) [][]*do.UserCoupon {
+	owners := make([][]*do.UserCoupon, len(breakdown.Shops))
+	if breakdown.CouponPlan == nil {
+		return owners
+	}
+	for i, coupon := range breakdown.CouponPlan.Coupons {
+		owner, ownerShare := 0, int64(-1)
+		for j, shop := range breakdown.Shops {
+			var share int64
+			for _, lineIndex := range shop.LineIndexes {
+				share += breakdown.CouponPlan.CouponShares[i][lineIndex]
+			}
+			if share > ownerShare {
+				owner, ownerShare = j, share
+			}
+		}
+		owners[owner] = append(owners[owner], coupon)
+	}
+	return owners
+}
+
+// OrderCouponLines 把订单明细转换成优惠券规则引擎的计算行, 顺序和明细一致
+func OrderCouponLines(items []*do.OrderItem) []*do.CouponLine {
+	lines := make([]*do.CouponLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, &do.CouponLine{SkuId: item.SkuId, CategoryId: item.CategoryId, Amount: item.Amount})
+	}
+	return lines
+}
+
+// FillOrderItems 合并相同SKU的明细, 并根据商品信息补全明细的价格、分类和下单快照需要的标题、规格、图片
+func (domain *OrderDomain) FillOrderItems(items []*do.OrderItem) ([]*do.OrderItem, error) {
+	if len(items) == 0 {
+		return nil, errcode.ErrOrderParams
+	}
+	merged := make(map[int64]*do.OrderItem, len(items))
+	skuIds := make([]int64, 0, len(items))
+	for _, item := range items {
+		if item.SkuId <= 0 || item.Quantity <= 0 {
+			return nil, errcode.ErrOrderParams
+		}
+		if existed, ok := merged[item.SkuId]; ok {
+			existed.Quantity += item.Quantity
+			continue
+		}
+		merged[item.SkuId] = &do.OrderItem{SkuId: item.SkuId, Quantity: item.Quantity}
+		skuIds = append(skuIds, item.SkuId)
+	}
+	// 按SKU ID排序后再扣库存, 避免并发下单时互相等待行锁造成死锁
+	sort.Slice(skuIds, func(i, j int) bool { return skuIds[i] < skuIds[j] })
+
+	skus, err := domain.goodsDao.FindSkusByIds(skuIds)
+	if err != nil {
+		return nil, errcode.Wrap("查询商品SKU失败", err)
+	}
+	if len(skus) != len(skuIds) {
+		return nil, errcode.ErrGoodsNotFound
+	}
+	skuMap := make(map[int64]*model.GoodsSku, len(skus))
+	goodsIds := make([]int64, 0, len(skus))
+	for _, sku := range skus {
+		skuMap[sku.ID] = sku
+		goodsIds = append(goodsIds, sku.GoodsId)
+	}
+	goods, err := domain.goodsDao.FindGoodsByIds(goodsIds)
+	if err != nil {
+		return nil, errcode.Wrap("查询商品失败", err)
+	}
+	goodsMap := make(map[int64]*model.Goods, len(goods))
+	merchantIds := make([]int64, 0, len(goods))
+	for _, g := range goods {
+		goodsMap[g.ID] = g
+		if g.MerchantId != enum.MerchantIdSelf {
+			merchantIds = append(merchantIds, g.MerchantId)
+		}
+	}
+	if err = domain.checkMerchantsOpen(merchantIds); err != nil {
+		return nil, err
+	}
+
+	result := make([]*do.OrderItem, 0, len(skuIds))
+	for _, skuId := range skuIds {
+		item, sku := merged[skuId], skuMap[skuId]
+		g, ok := goodsMap[sku.GoodsId]
+		if !ok {
+			return nil, errcode.ErrGoodsNotFound
+		}
+		if g.State != enum.GoodsStateOnSale {
+			return nil, errcode.ErrGoodsOffSale
+		}
+		item.GoodsId = sku.GoodsId
+		item.CategoryId = g.CategoryId
+		item.MerchantId = g.MerchantId
+		item.GoodsTitle = g.Title
+		item.SkuSpec = sku.Spec
+		item.GoodsImage = sku.Image
+		if item.GoodsImage == "" {
+			item.GoodsImage = g.Image
+		}
+		item.UnitPrice = sku.Price
+		item.Amount = sku.Price * int64(item.Quantity)
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// checkMerchantsOpen 检查商品所属的店铺都在营业, 被平台关闭的店铺不能下单
+func (domain *OrderDomain) checkMerchantsOpen(merchantIds []int64) error {
+	if len(merchantIds) == 0 {
+		return nil
+	}
+	merchants, err := domain.merchantDao.FindMerchantsByIds(merchantIds)
+	if err != nil {
+		return errcode.Wrap("查询店铺失败", err)
+	}
+	merchantMap := make(map[int64]*model.Merchant, len(merchants))
+	for _, merchant := range merchants {
+		merchantMap[merchant.ID] = merchant
+	}
+	for _, merchantId := range merchantIds {
+		merchant, ok := merchantMap[merchantId]
+		if !ok {
+			return errcode.ErrMerchantNotFound
+		}
+		if merchant.State != enum.MerchantStateOpen {
+			return errcode.ErrMerchantClosed
+		}
+	}
+	return nil
+}
+
+// GetOrder 查询订单和订单明细
+func (domain *OrderDomain) GetOrder(orderNo string) (*do.Order, error) {
+	order, err := domain.orderDao.FindOrderByOrderNo(orderNo)
+	if err != nil {
+		return nil, errcode.Wrap("查询订单失败", err)
+	}
+	if order == nil {
+		return nil, errcode.ErrOrderNotFound
+	}
+	items, err := domain.orderDao.FindOrderItems(order.ID)
+	if err != nil {
+		return nil, errcode.Wrap("查询订单明细失败", err)
+	}
+	orderDo := new(do.Order)
+	_ = utils.CopyStruct(orderDo, order)
+	orderDo.Items = make([]*do.OrderItem, 0, len(items))
+	for _, item := range items {
+		orderDo.Items = append(orderDo.Items, orderItemDo(item))
+	}
+	return orderDo, nil
+}
+
+// GetUserOrder 查询用户自己的订单, 订单不属于该用户时按订单不存在处理
+func (domain *OrderDomain) GetUserOrder(userId int64, orderNo string) (*do.Order, error) {
+	order, err := domain.GetOrder(orderNo)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserId != userId {
+		return nil, errcode.ErrOrderNotFound
+	}
+	return order, nil
+}
+
+// GetMerchantOrder 查询店铺的订单, 不属于该店铺时按订单不存在处理, merchantId为0表示平台管理员, 不限制店铺
+func (domain *OrderDomain) GetMerchantOrder(merchantId int64, orderNo string) (*do.Order, error) {
+	order, err := domain.GetOrder(orderNo)
+	if err != nil {
+		return nil, err
+	}
+	if merchantId != 0 && order.MerchantId != merchantId {
+		return nil, errcode.ErrOrderNotFound
+	}
+	return order, nil
+}
+
+// GetUserOrders 分页查询用户的订单列表
+func (domain *OrderDomain) GetUserOrders(userId int64, state int8, pageNum, pageSize int) ([]*do.Order, int64, error) {
+	orders, total, err := domain.orderDao.FindUserOrders(userId, state, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询订单列表失败", err)
+	}
+	orderDos := make([]*do.Order, 0, len(orders))
+	for _, order := range orders {
+		orderDo := new(do.Order)
+		_ = utils.CopyStruct(orderDo, order)
+		orderDos = append(orderDos, orderDo)
+	}
+	return orderDos, total, nil
+}
+
+// GetOrders 管理后台分页查询订单, merchantId为0时查询所有店铺
+func (domain *OrderDomain) GetOrders(merchantId int64, state int8, pageNum, pageSize int) ([]*do.Order, int64, error) {
+	orders, total, err := domain.orderDao.FindOrders(merchantId, state, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询订单列表失败", err)
+	}
+	orderDos := make([]*do.Order, 0, len(orders))
+	for _, order := range orders {
+		orderDo := new(do.Order)
+		_ = utils.CopyStruct(orderDo, order)
+		orderDos = append(orderDos, orderDo)
+	}
+	return orderDos, total, nil
+}
+
+// GetParentOrders 查询和order同属一个父订单的所有子订单(含order本身和明细), 按创建顺序排列
+func (domain *OrderDomain) GetParentOrders(order *do.Order) ([]*do.Order, error) {
+	if order.ParentOrderNo == "" {
+		// 拆单之前创建的订单没有父订单号, 自己就是一个独立支付的订单
+		return []*do.Order{order}, nil
+	}
+	return domain.GetOrdersByParentNo(order.ParentOrderNo)
+}
+
+// GetOrdersByParentNo 查询父订单下的所有子订单和明细, 父订单不存在时返回ErrOrderNotFound
+func (domain *OrderDomain) GetOrdersByParentNo(parentOrderNo string) ([]*do.Order, error) {
+	orders, err := domain.orderDao.FindOrdersByParentNo(parentOrderNo)
+	if err != nil {
+		return nil, errcode.Wrap("查询订单失败", err)
+	}
+	if len(orders) == 0 {
+		return nil, errcode.ErrOrderNotFound
+	}
+	orderDos := make([]*do.Order, 0, len(orders))
+	for _, order := range orders {
+		items, err := domain.orderDao.FindOrderItems(order.ID)
+		if err != nil {
+			return nil, errcode.Wrap("查询订单明细失败", err)
+		}
+		orderDo := new(do.Order)
+		_ = utils.CopyStruct(orderDo, order)
+		orderDo.Items = make([]*do.OrderItem, 0, len(items))
+		for _, item := range items {
+			orderDo.Items = append(orderDo.Items, orderItemDo(item))
+		}
+		orderDos = append(orderDos, orderDo)
+	}
+	return orderDos, nil
+}
+
+// ChangeOrderState 变更订单状态, 非法的状态流转返回ErrOrderStateTransition, 并发修改返回ErrOrderConcurrentUpdate
+func (domain *OrderDomain) ChangeOrderState(order *do.Order, toState int8, operator *do.OrderOperator, remark string) error {
+	return domain.transitInTx(order, func(tx *gorm.DB) error {
+		return domain.transit(tx, order, toState, operator, remark)
+	})
+}
+
+// ChangeOrderStateInTx 在调用方的事务中变更订单状态, 供其他领域把订单状态变更和自己的数据写入放到同一个事务
+// 事务回滚时需要调用方自行丢弃order对象
+func (domain *OrderDomain) ChangeOrderStateInTx(tx *gorm.DB, order *do.Order, toState int8, operator *do.OrderOperator, remark string) error {
+	return domain.transit(tx, order, toState, operator, remark)
+}
+
+// LockOrderInTx 在调用方的事务中锁定订单并返回最新的订单数据(含明细)
+func (domain *OrderDomain) LockOrderInTx(tx *gorm.DB, orderId int64) (*do.Order, error) {
+	order, err := domain.orderDao.LockOrder(tx, orderId)
+	if err != nil {
+		return nil, err
+	}
+	items, err := domain.orderDao.FindOrderItemsInTx(tx, orderId)
+	if err != nil {
+		return nil, err
+	}
+	orderDo := new(do.Order)
+	_ = utils.CopyStruct(orderDo, order)
+	for _, item := range items {
+		orderDo.Items = append(orderDo.Items, orderItemDo(item))
+	}
+	return orderDo, nil
+}
+
+// EnterRefundingInTx 订单有售后申请时进入退款中, 已经是退款中时不做处理
+func (domain *OrderDomain) EnterRefundingInTx(tx *gorm.DB, order *do.Order, operator *do.OrderOperator, remark string) error {
+	if order.State == enum.OrderStateRefunding {
+		return nil
+	}
+	return domain.transit(tx, order, enum.OrderStateRefunding, operator, remark)
+}
+
+// LeaveRefundingInTx 订单的售后全部结束后离开退款中
+// 所有商品都已退款时流转到已退款, 否则回到申请退款前的状态
+func (domain *OrderDomain) LeaveRefundingInTx(tx *gorm.DB, order *do.Order, allRefunded bool, operator *do.OrderOperator, remark string) error {
+	if order.State != enum.OrderStateRefunding {
+		return nil
+	}
+	if allRefunded {
+		return domain.transit(tx, order, enum.OrderStateRefunded, operator, remark)
+	}
+	stateLog, err := domain.orderDao.FindLastStateLogTo(tx, order.ID, enum.OrderStateRefunding)
+	if err != nil {
+		return err
+	}
+	if stateLog == nil {
+		return errcode.ErrOrderStateTransition
+	}
+	return domain.transit(tx, order, stateLog.FromState, operator, remark)
+}
+
+// CancelOrder 取消待支付的订单并归还库存, 返回实际取消的子订单
+// 同一父订单下的子订单合并支付、共用优惠券, 所以父订单下待支付的子订单总是一起取消
+func (domain *OrderDomain) CancelOrder(order *do.Order, operator *do.OrderOperator, reason string) ([]*do.Order, error) {
+	if order.State != enum.OrderStateCreated {
+		return nil, errcode.ErrOrderStateTransition
+	}
+	siblings, err := domain.GetParentOrders(order)
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]*do.Order, 0, len(siblings))
+	for _, sibling := range siblings {
+		if sibling.State == enum.OrderStateCreated {
+			pending = append(pending, sibling)
+		}
+	}
+	err = domain.transitAllInTx(pending, func(tx *gorm.DB) error {
+		for _, pendingOrder := range pending {
+			if err := domain.transit(tx, pendingOrder, enum.OrderStateCancelled, operator, reason); err != nil {
+				return err
+			}
+			for _, item := range pendingOrder.Items {
+				if err := domain.goodsDao.RestoreSkuStock(tx, item.SkuId, item.Quantity); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, pendingOrder := range pending {
+		if pendingOrder.ID == order.ID {
+			order.State, order.Version = pendingOrder.State, pendingOrder.Version
+		}
+	}
+	return pending, nil
+}
+
+// ConfirmReceipt 确认收货, 已发货未签收的订单先补一条签收记录再流转到已完成
+func (domain *OrderDomain) ConfirmReceipt(order *do.Order, operator *do.OrderOperator) error {
+	return domain.transitInTx(order, func(tx *gorm.DB) error {
+		if order.State == enum.OrderStateShipped {
+			if err := domain.transit(tx, order, enum.OrderStateDelivered, operator, "确认收货"); err != nil {
+				return err
+			}
+		}
+		return domain.transit(tx, order, enum.OrderStateCompleted, operator, "确认收货")
+	})
+}
+
+// GetOrderStateLogs 查询订单的状态流转记录
+func (domain *OrderDomain) GetOrderStateLogs(orderId int64) ([]*do.OrderStateLog, error) {
+	logs, err := domain.orderDao.FindOrderStateLogs(orderId)
+	if err != nil {
+		return nil, errcode.Wrap("查询订单状态记录失败", err)
+	}
+	logDos := make([]*do.OrderStateLog, 0, len(logs))
+	for _, stateLog := range logs {
+		logDo := new(do.OrderStateLog)
+		_ = utils.CopyStruct(logDo, stateLog)
+		logDos = append(logDos, logDo)
+	}
+	return logDos, nil
+}
+
+// transit 在事务中校验并执行一次状态流转, 成功后同步更新order上的状态和版本号
+func (domain *OrderDomain) transit(tx *gorm.DB, order *do.Order, toState int8, operator *do.OrderOperator, remark string) error {
+	if !canTransitOrderState(order.State, toState) {
+		return errcode.ErrOrderStateTransition
+	}
+	err := domain.orderDao.UpdateOrderState(tx, order.ID, order.State, toState, order.Version)
+	if err != nil {
+		return err
+	}
+	err = domain.orderDao.CreateOrderStateLog(tx, &model.OrderStateLog{
+		OrderId:      order.ID,
+		OrderNo:      order.OrderNo,
+		FromState:    order.State,
+		ToState:      toState,
+		OperatorType: operator.Type,
+		OperatorId:   operator.Id,
+		Remark:       remark,
+	})
+	if err != nil {
+		return err
+	}
+	// 订单锁定的优惠券随订单状态核销或退回, 抵扣的积分和秒杀名额随取消退回, 订单完成时发放积分, 和状态变更在同一个事务里
+	switch toState {
+	case enum.OrderStatePaid:
+		if order.State == enum.OrderStateCreated {
+			err = domain.couponDomain.ConsumeOrderCouponsInTx(tx, order.ID)
+		}
+	case enum.OrderStateCancelled:
+		if err = domain.couponDomain.ReleaseOrderCouponsInTx(tx, order.ID); err == nil {
+			err = domain.memberDomain.ReturnOrderPointsInTx(tx, order)
+		}
+		if err == nil {
+			err = domain.releaseSeckillInTx(tx, order)
+		}
+	case enum.OrderStateCompleted:
+		err = domain.memberDomain.EarnOrderPointsInTx(tx, order)
+	}
+	if err != nil {
+		return err
+	}
+	domain.eventDomain.PublishOrderStateInTx(tx, order, order.State, toState)
+	dao.AfterCommit(tx, func() {
+		metrics.OrderState(enum.OrderStateToName(toState))
+	})
+	order.State = toState
+	order.Version++
+	return nil
+}
+
+// releaseSeckillInTx 秒杀订单取消时退回活动已售数量, 事务提交后退回Redis库存和用户已购数量, 名额可以被其他用户抢到
+// SKU库存和普通订单一样由取消订单时归还
+func (domain *OrderDomain) releaseSeckillInTx(tx *gorm.DB, order *do.Order) error {
+	seckillOrder, err := domain.seckillDao.FindSeckillOrderByOrderId(tx, order.ID)
+	if err != nil || seckillOrder == nil {
+		return err
+	}
+	if err = domain.seckillDao.DecrSoldCount(tx, seckillOrder.ActivityId, seckillOrder.Quantity); err != nil {
+		return err
+	}
+	dao.AfterCommit(tx, func() {
+		err := cache.RestoreSeckillStock(domain.ctx, seckillOrder.ActivityId, seckillOrder.UserId, seckillOrder.Quantity)
+		if err != nil {
+			logger.NewLogger(domain.ctx).Error("RestoreSeckillStockError", "orderNo", order.OrderNo, "activityId", seckillOrder.ActivityId, "err", err)
+		}
+		seckillSoldOut.Delete(seckillOrder.ActivityId)
+	})
+	return nil
+}
+
+// transitInTx 在事务中执行状态流转, 事务回滚时把order上的状态和版本号一起恢复
+// 项目预定义的业务错误(如ErrOrderStateTransition)原样返回给上层判断, 其他错误包装后返回
+func (domain *OrderDomain) transitInTx(order *do.Order, fn func(tx *gorm.DB) error) error {
+	return domain.transitAllInTx([]*do.Order{order}, fn)
+}
+
+// transitAllInTx 在一个事务中流转多个订单的状态, 事务回滚时恢复所有订单上的状态和版本号
+func (domain *OrderDomain) transitAllInTx(orders []*do.Order, fn func(tx *gorm.DB) error) error {
+	states, versions := make([]int8, len(orders)), make([]int, len(orders))
+	for i, order := range orders {
+		states[i], versions[i] = order.State, order.Version
+	}
+	err := dao.Transaction(domain.ctx, fn)
+	if err == nil {
+		return nil
+	}
+	for i, order := range orders {
+		order.State, order.Version = states[i], versions[i]
+	}
+	if appErr, ok := err.(*errcode.AppError); ok && appErr.Code() > 0 {
+		return err
+	}
+	return errcode.Wrap("订单状态变更失败", err)
+}
+
+// orderItemDo 把订单明细快照转换成领域对象
+func orderItemDo(item *model.OrderItem) *do.OrderItem {
+	itemDo := new(do.OrderItem)
+	_ = utils.CopyStruct(itemDo, item)
+	itemDo.Promotions = make([]*do.AppliedPromotion, 0)
+	if item.Promotions != "" {
+		_ = json.Unmarshal([]byte(item.Promotions), &itemDo.Promotions)
+	}
+	return itemDo
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "github.com/Cospk/go-mall/pkg/enum"
+
+// 订单状态机
+//
+//	待支付 --支付--> 待发货 --发货--> 已发货 --签收--> 已签收 --确认收货--> 已完成
+//	  |               |               |               |                    |
+//	取消/超时          +------------ 申请退款 ----------+--------------------+
+//	  ↓                                    ↓
+//	已取消                               退款中 --同意--> 已退款
+//	                                       |
+//	                                     驳回 --> 回到申请退款前的状态
+//
+// 已取消、已退款是终态, 不允许再流转
+
+// orderStateTransitions key为当前状态, value为允许流转到的状态
+var orderStateTransitions = map[int8][]int8{
+	enum.OrderStateCreated:   {enum.OrderStatePaid, enum.OrderStateCancelled},
+	enum.OrderStatePaid:      {enum.OrderStateShipped, enum.OrderStateRefunding},
+	enum.OrderStateShipped:   {enum.OrderStateDelivered, enum.OrderStateRefunding},
+	enum.OrderStateDelivered: {enum.OrderStateCompleted, enum.OrderStateRefunding},
+	enum.OrderStateCompleted: {enum.OrderStateRefunding},
+	enum.OrderStateRefunding: {
+		enum.OrderStateRefunded,
+		// 退款被驳回时回到申请退款前的状态
+		enum.OrderStatePaid, enum.OrderStateShipped, enum.OrderStateDelivered, enum.OrderStateCompleted,
+	},
+}
+
+// canTransitOrderState 判断订单能否从from状态流转到to状态
+func canTransitOrderState(from, to int8) bool {
+	for _, state := range orderStateTransitions[from] {
+		if state == to {
+			return true
+		}
+	}
+	return false
+}
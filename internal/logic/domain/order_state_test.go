@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"github.com/Cospk/go-mall/pkg/enum"
+	"testing"
+)
+
+func TestCanTransitOrderState(t *testing.T) {
+	tests := []struct {
+		name string
+		from int8
+		to   int8
+		want bool
+	}{
+		{"支付", enum.OrderStateCreated, enum.OrderStatePaid, true},
+		{"取消待支付", enum.OrderStateCreated, enum.OrderStateCancelled, true},
+		{"待支付不能发货", enum.OrderStateCreated, enum.OrderStateShipped, false},
+		{"待支付不能退款", enum.OrderStateCreated, enum.OrderStateRefunding, false},
+		{"发货", enum.OrderStatePaid, enum.OrderStateShipped, true},
+		{"已支付不能取消", enum.OrderStatePaid, enum.OrderStateCancelled, false},
+		{"已支付申请退款", enum.OrderStatePaid, enum.OrderStateRefunding, true},
+		{"签收", enum.OrderStateShipped, enum.OrderStateDelivered, true},
+		{"已发货不能直接完成", enum.OrderStateShipped, enum.OrderStateCompleted, false},
+		{"确认收货", enum.OrderStateDelivered, enum.OrderStateCompleted, true},
+		{"已完成申请退款", enum.OrderStateCompleted, enum.OrderStateRefunding, true},
+		{"退款成功", enum.OrderStateRefunding, enum.OrderStateRefunded, true},
+		{"驳回回到已支付", enum.OrderStateRefunding, enum.OrderStatePaid, true},
+		{"驳回回到已完成", enum.OrderStateRefunding, enum.OrderStateCompleted, true},
+		{"退款中不能取消", enum.OrderStateRefunding, enum.OrderStateCancelled, false},
+		{"已取消是终态", enum.OrderStateCancelled, enum.OrderStatePaid, false},
+		{"已退款是终态", enum.OrderStateRefunded, enum.OrderStateRefunding, false},
+		{"不能原地流转", enum.OrderStatePaid, enum.OrderStatePaid, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canTransitOrderState(tt.from, tt.to); got != tt.want {
+				t.Errorf("canTransitOrderState(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
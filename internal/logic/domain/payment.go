@@ -0,0 +1,344 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Cospk/go-mall/external/payment"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/metrics"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+// errPaymentProcessed 支付单已经处理过, 只在领域内部用来中断事务
+var errPaymentProcessed = errors.New("payment already processed")
+
+type PaymentDomain struct {
+	ctx          context.Context
+	paymentDao   *dao.PaymentDao
+	orderDomain  *OrderDomain
+	walletDomain *WalletDomain
+}
+
+func NewPaymentDomain(ctx context.Context) *PaymentDomain {
+	return &PaymentDomain{
+		ctx:          ctx,
+		paymentDao:   dao.NewPaymentDao(ctx),
+		orderDomain:  NewOrderDomain(ctx),
+		walletDomain: NewWalletDomain(ctx),
+	}
+}
+
+// CreatePayment 为父订单下所有待支付的子订单创建一个支付单并在支付渠道下单, order为父订单下的任一子订单
+// 同一父订单在同一渠道已有金额一致的待支付支付单时复用, 避免用户重复点击支付产生多笔支付
+// 钱包余额支付不经过外部渠道, 创建支付单后直接扣款并确认支付成功
+func (domain *PaymentDomain) CreatePayment(order *do.Order, providerName string) (*do.Payment, error) {
+	var provider payment.Provider
+	if providerName != enum.PaymentProviderWallet {
+		var ok bool
+		if provider, ok = payment.GetProvider(providerName); !ok {
+			return nil, errcode.ErrPaymentProviderNotSupported
+		}
+	}
+	orders, err := domain.orderDomain.GetParentOrders(order)
+	if err != nil {
+		return nil, err
+	}
+	parentOrderNo := orders[0].ParentOrderNo
+	if parentOrderNo == "" {
+		// 拆单之前创建的订单用自己的订单号作为父订单号
+		parentOrderNo = orders[0].OrderNo
+	}
+	var amount int64
+	for _, subOrder := range orders {
+		if subOrder.State == enum.OrderStateCreated {
+			amount += subOrder.PayMoney
+		}
+	}
+	if amount <= 0 {
+		return nil, errcode.ErrOrderStateTransition
+	}
+
+	paymentModel, err := domain.paymentDao.FindParentOrderPayment(parentOrderNo, providerName, enum.PaymentStateCreated)
+	if err != nil {
+		return nil, errcode.Wrap("查询支付单失败", err)
+	}
+	if paymentModel == nil || paymentModel.Amount != amount {
+		paymentModel = &model.Payment{
+			PaymentNo:     utils.GenPaymentNo(order.UserId),
+			ParentOrderNo: parentOrderNo,
+			UserId:        order.UserId,
+			Provider:      providerName,
+			Amount:        amount,
+			State:         enum.PaymentStateCreated,
+		}
+		if err = domain.paymentDao.CreatePayment(paymentModel); err != nil {
+			return nil, errcode.Wrap("创建支付单失败", err)
+		}
+		metrics.PaymentCreated(providerName)
+	}
+	if providerName == enum.PaymentProviderWallet {
+		return domain.payByWallet(paymentModel, orders)
+	}
+
+	result, err := provider.CreatePayment(domain.ctx, &payment.CreatePaymentRequest{
+		PaymentNo: paymentModel.PaymentNo,
+		Amount:    paymentModel.Amount,
+		Subject:   fmt.Sprintf("go-mall订单%s", parentOrderNo),
+		NotifyUrl: config.Payment().NotifyUrl + "/" + providerName,
+		ExpireAt:  orders[0].CreatedAt.Add(config.AppConfig().Order.PayTimeout),
+	})
+	if err != nil {
+		logger.NewLogger(domain.ctx).Error("CreateProviderPaymentError", "paymentNo", paymentModel.PaymentNo, "provider", providerName, "err", err)
+		metrics.PaymentFailed(providerName)
+		return nil, errcode.ErrPaymentProviderFailed
+	}
+
+	paymentDo := new(do.Payment)
+	_ = utils.CopyStruct(paymentDo, paymentModel)
+	paymentDo.TradeNo = result.TradeNo
+	paymentDo.PayUrl = result.PayUrl
+	return paymentDo, nil
+}
+
+// GetPayment 查询支付单
+func (domain *PaymentDomain) GetPayment(paymentNo string) (*do.Payment, error) {
+	paymentModel, err := domain.paymentDao.FindPaymentByPaymentNo(paymentNo)
+	if err != nil {
+		return nil, errcode.Wrap("查询支付单失败", err)
+	}
+	if paymentModel == nil {
+		return nil, errcode.ErrPaymentNotFound
+	}
+	paymentDo := new(do.Payment)
+	_ = utils.CopyStruct(paymentDo, paymentModel)
+	return paymentDo, nil
+}
+
+// HandleNotification 处理支付渠道的异步通知, 重复通知只会让订单变成已支付一次
+func (domain *PaymentDomain) HandleNotification(providerName string, header http.Header, body []byte) (*do.Payment, error) {
+	provider, ok := payment.GetProvider(providerName)
+	if !ok {
+		return nil, errcode.ErrPaymentProviderNotSupported
+	}
+	notification, err := provider.VerifyNotification(domain.ctx, header, body)
+	if errors.Is(err, payment.ErrInvalidSignature) {
+		return nil, errcode.ErrPaymentSignature
+	}
+	if err != nil {
+		return nil, errcode.Wrap("解析支付通知失败", err)
+	}
+	paymentDo, err := domain.GetPayment(notification.PaymentNo)
+	if err != nil {
+		return nil, err
+	}
+	if notification.State != payment.TradeStateSuccess {
+		return paymentDo, nil
+	}
+	err = domain.confirmPaid(paymentDo, notification.TradeNo, notification.Amount, notification.PaidAt)
+	return paymentDo, err
+}
+
+// SyncPayment 主动向支付渠道查询待支付的支付单, 渠道已支付成功时按收到通知处理, 用来补偿丢失的回调
+// 钱包余额支付没有渠道可查, 待支付的余额支付单就是扣款失败了
+func (domain *PaymentDomain) SyncPayment(paymentDo *do.Payment) error {
+	if paymentDo.State != enum.PaymentStateCreated || paymentDo.Provider == enum.PaymentProviderWallet {
+		return nil
+	}
+	provider, ok := payment.GetProvider(paymentDo.Provider)
+	if !ok {
+		return errcode.ErrPaymentProviderNotSupported
+	}
+	result, err := provider.QueryPayment(domain.ctx, paymentDo.PaymentNo)
+	if err != nil {
+		return errcode.Wrap("查询渠道支付单失败", err)
+	}
+	if result.State != payment.TradeStateSuccess {
+		return nil
+	}
+	return domain.confirmPaid(paymentDo, result.TradeNo, result.Amount, result.PaidAt)
+}
+
+// confirmPaid 确认支付成功: 支付单 待支付->支付成功, 父订单下的子订单 待支付->已支付, 在同一个事务里完成
+// 支付单的条件更新保证并发或重复的通知只有一个能执行成功
+func (domain *PaymentDomain) confirmPaid(paymentDo *do.Payment, tradeNo string, amount int64, paidAt time.Time) error {
+	log := logger.NewLogger(domain.ctx)
+	if paymentDo.State == enum.PaymentStateSuccess {
+		return nil
+	}
+	if amount != paymentDo.Amount {
+		log.Error("PaymentAmountMismatch", "paymentNo", paymentDo.PaymentNo, "expect", paymentDo.Amount, "actual", amount)
+		metrics.PaymentFailed(paymentDo.Provider)
+		return errcode.ErrPaymentAmountMismatch
+	}
+	orders, err := domain.GetPaymentOrders(paymentDo)
+	if err != nil {
+		return err
+	}
+
+	err = dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+		return domain.markPaidInTx(tx, paymentDo, orders, tradeNo, paidAt)
+	})
+	if errors.Is(err, errPaymentProcessed) {
+		return nil
+	}
+	if err != nil {
+		if appErr, ok := err.(*errcode.AppError); ok && appErr.Code() > 0 {
+			return err
+		}
+		return errcode.Wrap("确认支付结果失败", err)
+	}
+	paymentDo.State = enum.PaymentStateSuccess
+	paymentDo.TradeNo = tradeNo
+	paymentDo.PaidAt = paidAt
+	log.Info("PaymentConfirmed", "paymentNo", paymentDo.PaymentNo, "parentOrderNo", paymentDo.ParentOrderNo, "amount", amount)
+	metrics.PaymentSucceeded(paymentDo.Provider, amount)
+	return nil
+}
+
+// payByWallet 用钱包余额支付: 钱包扣款、支付单 待支付->支付成功、子订单 待支付->已支付 在同一个事务里完成
+// 扣款按支付单号幂等, 余额不足时整个事务回滚, 支付单保持待支付, 用户可以改用其他渠道支付
+func (domain *PaymentDomain) payByWallet(paymentModel *model.Payment, orders []*do.Order) (*do.Payment, error) {
+	paymentDo := new(do.Payment)
+	_ = utils.CopyStruct(paymentDo, paymentModel)
+	paidAt := time.Now()
+	var tradeNo string
+	err := dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+		transaction, err := domain.walletDomain.PayOrderInTx(tx, paymentDo.UserId, paymentDo.PaymentNo, paymentDo.Amount)
+		if err != nil {
+			return err
+		}
+		tradeNo = transaction.TxNo
+		return domain.markPaidInTx(tx, paymentDo, orders, tradeNo, paidAt)
+	})
+	if errors.Is(err, errPaymentProcessed) {
+		return domain.GetPayment(paymentDo.PaymentNo)
+	}
+	if err != nil {
+		metrics.PaymentFailed(enum.PaymentProviderWallet)
+		if appErr, ok := err.(*errcode.AppError); ok && appErr.Code() > 0 {
+			return nil, err
+		}
+		return nil, errcode.Wrap("余额支付失败", err)
+	}
+	paymentDo.State = enum.PaymentStateSuccess
+	paymentDo.TradeNo = tradeNo
+	paymentDo.PaidAt = paidAt
+	logger.NewLogger(domain.ctx).Info("PaymentConfirmed", "paymentNo", paymentDo.PaymentNo, "parentOrderNo", paymentDo.ParentOrderNo, "amount", paymentDo.Amount, "provider", enum.PaymentProviderWallet)
+	metrics.PaymentSucceeded(enum.PaymentProviderWallet, paymentDo.Amount)
+	return paymentDo, nil
+}
+
+// markPaidInTx 在事务中把支付单更新为支付成功, 并把父订单下待支付的子订单流转到已支付
+// 支付单已经不是待支付时返回errPaymentProcessed
+func (domain *PaymentDomain) markPaidInTx(tx *gorm.DB, paymentDo *do.Payment, orders []*do.Order, tradeNo string, paidAt time.Time) error {
+	updated, err := domain.paymentDao.MarkPaymentSuccess(tx, paymentDo.ID, tradeNo, paidAt)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errPaymentProcessed
+	}
+	operator := &do.OrderOperator{Type: enum.OrderOperatorSystem}
+	var closedAmount int64
+	for _, order := range orders {
+		if order.State != enum.OrderStateCreated {
+			// 子订单已超时取消后才收到支付成功, 支付单照常记为成功, 这部分金额由退款任务原路退回
+			logger.NewLogger(domain.ctx).Warn("PaidOrderNotPayable", "paymentNo", paymentDo.PaymentNo, "orderNo", order.OrderNo, "orderState", order.State)
+			closedAmount += order.PayMoney
+			continue
+		}
+		err = domain.orderDomain.ChangeOrderStateInTx(tx, order, enum.OrderStatePaid, operator, "支付成功, 支付单号"+paymentDo.PaymentNo)
+		if err != nil {
+			return err
+		}
+	}
+	if closedAmount > 0 {
+		// 子订单的实付金额之和就是支付金额, 退款金额不会超过支付金额
+		closedAmount = min(closedAmount, paymentDo.Amount)
+		if _, err = domain.paymentDao.MarkClosedRefund(tx, paymentDo.ID, closedAmount); err != nil {
+			return err
+		}
+		paymentDo.ClosedRefundAmount = closedAmount
+	}
+	return nil
+}
+
+// RefundClosedOrders 把支付成功时已经取消的子订单的金额原路退回, 钱包余额支付的退回钱包
+// 退款单号由支付单号生成, 渠道按退款单号做幂等, 任务重试时不会多退钱; 已经退款成功时直接返回
+func (domain *PaymentDomain) RefundClosedOrders(paymentNo string) error {
+	paymentModel, err := domain.paymentDao.FindPaymentByPaymentNo(paymentNo)
+	if err != nil {
+		return errcode.Wrap("查询支付单失败", err)
+	}
+	if paymentModel == nil {
+		return errcode.ErrPaymentNotFound
+	}
+	if paymentModel.ClosedRefundAmount <= 0 || paymentModel.ClosedRefundTradeNo != "" {
+		return nil
+	}
+	refundNo := closedRefundNo(paymentModel.PaymentNo)
+	var refundTradeNo string
+	if paymentModel.Provider != enum.PaymentProviderWallet {
+		provider, ok := payment.GetProvider(paymentModel.Provider)
+		if !ok {
+			return errcode.ErrPaymentProviderNotSupported
+		}
+		result, err := provider.Refund(domain.ctx, &payment.RefundRequest{
+			PaymentNo:   paymentModel.PaymentNo,
+			RefundNo:    refundNo,
+			Amount:      paymentModel.ClosedRefundAmount,
+			TotalAmount: paymentModel.Amount,
+			Reason:      "订单已取消",
+		})
+		if err != nil || !result.Success {
+			logger.NewLogger(domain.ctx).Error("ClosedOrderRefundError", "paymentNo", paymentNo, "err", err)
+			return errcode.ErrPaymentProviderFailed
+		}
+		refundTradeNo = result.RefundTradeNo
+	}
+	err = dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+		if paymentModel.Provider == enum.PaymentProviderWallet {
+			transaction, err := domain.walletDomain.RefundClosedOrderInTx(tx, paymentModel.UserId, paymentModel.PaymentNo, paymentModel.ClosedRefundAmount)
+			if err != nil {
+				return err
+			}
+			refundTradeNo = transaction.TxNo
+		}
+		_, err := domain.paymentDao.MarkClosedRefundDone(tx, paymentModel.ID, refundTradeNo)
+		return err
+	})
+	if err != nil {
+		return errcode.Wrap("记录已取消订单的退款失败", err)
+	}
+	logger.NewLogger(domain.ctx).Info("ClosedOrderRefunded", "paymentNo", paymentNo, "amount", paymentModel.ClosedRefundAmount, "refundTradeNo", refundTradeNo)
+	return nil
+}
+
+// closedRefundNo 已取消子订单的退款单号, 同一支付单固定不变, 保证重复发起退款时渠道能识别为同一笔
+func closedRefundNo(paymentNo string) string {
+	return "C" + paymentNo
+}
+
+// GetPaymentOrders 查询支付单对应的子订单
+func (domain *PaymentDomain) GetPaymentOrders(paymentDo *do.Payment) ([]*do.Order, error) {
+	orders, err := domain.orderDomain.GetOrdersByParentNo(paymentDo.ParentOrderNo)
+	if err != errcode.ErrOrderNotFound {
+		return orders, err
+	}
+	// 拆单之前创建的订单, 支付单上的父订单号就是订单号
+	order, err := domain.orderDomain.GetOrder(paymentDo.ParentOrderNo)
+	if err != nil {
+		return nil, err
+	}
+	return []*do.Order{order}, nil
+}
@@ -0,0 +1,258 @@
+package domain
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/utils"
+)
+
+// 结算计价, 金额全部是以分为单位的整数, 按固定的顺序计算:
+//
+//	小计 -> 营销活动优惠(含会员折扣) -> 优惠券优惠 -> 积分抵扣 -> 税费 -> 运费 -> 应付
+//
+// 每一步的优惠都按各行的剩余金额比例分摊回每一行, 零头按最大余数法分配, 各行之和严格等于总额,
+// 同样的输入总是得到同样的结果。订单明细上保存每行的实付金额, 部分退款时直接按行计算可退金额。
+// 营销活动和优惠券按整单计算, 可以跨店铺使用; 运费按店铺分别计算, 下单时每个店铺拆成一个子订单。
+// 积分抵扣相当于用积分支付, 不影响包邮门槛的判断; 会员等级带包邮权益时所有店铺都免运费。
+
+// PromotionRule 营销活动规则, 在优惠券之前按注册顺序计算
+type PromotionRule interface {
+	Name() string
+	// Discounts 返回每行商品的优惠金额, 顺序和lines一致, 每行的优惠不能超过该行的剩余金额
+	Discounts(ctx context.Context, userId int64, lines []*do.PriceLine) ([]int64, error)
+}
+
+// ShippingCalculator 运费计算
+type ShippingCalculator interface {
+	// ShippingFee 根据优惠后的商品和收货地址计算运费
+	ShippingFee(ctx context.Context, lines []*do.PriceLine, address *do.ShippingAddress) (int64, error)
+}
+
+type PricingDomain struct {
+	ctx            context.Context
+	couponDomain   *CouponDomain
+	memberDomain   *MemberDomain
+	promotionRules []PromotionRule
+	shipping       ShippingCalculator
+}
+
+func NewPricingDomain(ctx context.Context) *PricingDomain {
+	memberDomain := NewMemberDomain(ctx)
+	return &PricingDomain{
+		ctx:            ctx,
+		couponDomain:   NewCouponDomain(ctx),
+		memberDomain:   memberDomain,
+		promotionRules: []PromotionRule{&memberDiscountRule{memberDomain: memberDomain}},
+		shipping:       NewFreightDomain(ctx),
+	}
+}
+
+// Price 计算订单的计价明细
+func (domain *PricingDomain) Price(pricingRequest *do.PricingRequest) (*do.PriceBreakdown, error) {
+	breakdown := &do.PriceBreakdown{
+		Lines:      make([]*do.PriceLine, 0, len(pricingRequest.Items)),
+		Shops:      make([]*do.ShopPrice, 0),
+		Promotions: make([]*do.AppliedPromotion, 0),
+	}
+	for _, item := range pricingRequest.Items {
+		line := &do.PriceLine{
+			SkuId:      item.SkuId,
+			GoodsId:    item.GoodsId,
+			CategoryId: item.CategoryId,
+			MerchantId: item.MerchantId,
+			Quantity:   item.Quantity,
+			UnitPrice:  item.UnitPrice,
+			Subtotal:   item.UnitPrice * int64(item.Quantity),
+		}
+		breakdown.Lines = append(breakdown.Lines, line)
+		breakdown.Subtotal += line.Subtotal
+	}
+
+	if !pricingRequest.NoPromotion {
+		if err := domain.applyPromotions(pricingRequest.UserId, breakdown); err != nil {
+			return nil, err
+		}
+	}
+
+	couponLines := make([]*do.CouponLine, 0, len(breakdown.Lines))
+	for _, line := range breakdown.Lines {
+		couponLines = append(couponLines, &do.CouponLine{
+			SkuId:      line.SkuId,
+			CategoryId: line.CategoryId,
+			Amount:     line.Subtotal - line.PromotionDiscount,
+		})
+	}
+	couponPlan, err := domain.couponDomain.PlanCoupons(pricingRequest.UserId, couponLines, pricingRequest.Coupons)
+	if err != nil {
+		return nil, err
+	}
+	breakdown.CouponPlan = couponPlan
+	for i, line := range breakdown.Lines {
+		line.CouponDiscount = couponPlan.LineDiscounts[i]
+	}
+	for i, coupon := range couponPlan.Coupons {
+		for j, share := range couponPlan.CouponShares[i] {
+			if share > 0 {
+				breakdown.Lines[j].Promotions = append(breakdown.Lines[j].Promotions, &do.AppliedPromotion{
+					Name:     coupon.Template.Name,
+					Discount: share,
+					CouponId: coupon.ID,
+				})
+			}
+		}
+	}
+	breakdown.CouponDiscount = couponPlan.Discount
+
+	member, err := domain.memberDomain.GetMember(pricingRequest.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if err = domain.applyPoints(member, pricingRequest.Points, breakdown); err != nil {
+		return nil, err
+	}
+
+	domain.applyTax(breakdown)
+
+	// 每个店铺单独发货, 运费按店铺分别计算, 包邮门槛也按店铺各自的商品金额判断
+	for _, shop := range groupShops(breakdown.Lines) {
+		shopLines := make([]*do.PriceLine, 0, len(shop.LineIndexes))
+		for _, i := range shop.LineIndexes {
+			line := breakdown.Lines[i]
+			shopLines = append(shopLines, line)
+			shop.Subtotal += line.Subtotal
+			shop.PromotionDiscount += line.PromotionDiscount
+			shop.CouponDiscount += line.CouponDiscount
+			shop.PointsDiscount += line.PointsDiscount
+			shop.PointsUsed += line.PointsUsed
+			shop.Tax += line.Tax
+			shop.Payable += line.Payable
+		}
+		if !member.Tier.FreeShipping {
+			shop.ShippingFee, err = domain.shipping.ShippingFee(domain.ctx, shopLines, pricingRequest.Address)
+			if err != nil {
+				return nil, err
+			}
+		}
+		shop.Payable += shop.ShippingFee
+		breakdown.Shops = append(breakdown.Shops, shop)
+		breakdown.ShippingFee += shop.ShippingFee
+		breakdown.Payable += shop.Payable
+	}
+	return breakdown, nil
+}
+
+// groupShops 把计价行按店铺分组, 店铺按在lines中第一次出现的顺序排列
+func groupShops(lines []*do.PriceLine) []*do.ShopPrice {
+	shops := make([]*do.ShopPrice, 0)
+	shopMap := make(map[int64]*do.ShopPrice)
+	for i, line := range lines {
+		shop, ok := shopMap[line.MerchantId]
+		if !ok {
+			shop = &do.ShopPrice{MerchantId: line.MerchantId}
+			shopMap[line.MerchantId] = shop
+			shops = append(shops, shop)
+		}
+		shop.LineIndexes = append(shop.LineIndexes, i)
+	}
+	return shops
+}
+
+// applyPromotions 依次计算营销活动的优惠, 每个活动的优惠不超过各行当前的剩余金额
+func (domain *PricingDomain) applyPromotions(userId int64, breakdown *do.PriceBreakdown) error {
+	for _, rule := range domain.promotionRules {
+		discounts, err := rule.Discounts(domain.ctx, userId, breakdown.Lines)
+		if err != nil {
+			return errcode.Wrap("计算营销活动优惠失败", err)
+		}
+		var total int64
+		for i, line := range breakdown.Lines {
+			if i >= len(discounts) || discounts[i] <= 0 {
+				continue
+			}
+			discount := discounts[i]
+			if remaining := line.Subtotal - line.PromotionDiscount; discount > remaining {
+				discount = remaining
+			}
+			line.PromotionDiscount += discount
+			line.Promotions = append(line.Promotions, &do.AppliedPromotion{Name: rule.Name(), Discount: discount})
+			total += discount
+		}
+		if total > 0 {
+			breakdown.Promotions = append(breakdown.Promotions, &do.AppliedPromotion{Name: rule.Name(), Discount: total})
+			breakdown.PromotionDiscount += total
+		}
+	}
+	return nil
+}
+
+// applyPoints 计算积分抵扣, 抵扣金额不超过优惠后商品金额的配置比例, 按各行剩余金额比例分摊
+// 请求的积分超过余额时返回ErrPointsNotEnough; 按比例换算后不足1分的积分不使用
+func (domain *PricingDomain) applyPoints(member *do.Member, points int64, breakdown *do.PriceBreakdown) error {
+	if points <= 0 {
+		return nil
+	}
+	memberConfig := config.AppConfig().Member
+	if memberConfig.PointsPerYuan <= 0 {
+		return errcode.ErrPointsNotUsable
+	}
+	if points > member.Balance {
+		return errcode.ErrPointsNotEnough
+	}
+	deductPoints(breakdown, points, memberConfig.PointsPerYuan, memberConfig.MaxDeductBps)
+	return nil
+}
+
+// deductPoints 按pointsPerYuan积分抵扣1元换算抵扣金额, 不超过优惠后商品金额的maxDeductBps/10000
+func deductPoints(breakdown *do.PriceBreakdown, points, pointsPerYuan, maxDeductBps int64) {
+	remaining := make([]int64, len(breakdown.Lines))
+	var remainingTotal int64
+	for i, line := range breakdown.Lines {
+		remaining[i] = line.Subtotal - line.PromotionDiscount - line.CouponDiscount
+		remainingTotal += remaining[i]
+	}
+	deduct := min(points*100/pointsPerYuan, remainingTotal*maxDeductBps/10000)
+	if deduct <= 0 {
+		return
+	}
+	// 抵扣金额换算回积分时向上取整, 不会超过请求的积分
+	used := (deduct*pointsPerYuan + 99) / 100
+	lineDeducts := utils.AllocateByWeight(deduct, remaining)
+	lineUsed := utils.AllocateByWeight(used, lineDeducts)
+	for i, line := range breakdown.Lines {
+		line.PointsDiscount, line.PointsUsed = lineDeducts[i], lineUsed[i]
+	}
+	breakdown.PointsDiscount, breakdown.PointsUsed = deduct, used
+}
+
+// applyTax 按优惠后的商品金额计算整单税费并四舍五入到分, 再按各行金额比例分摊, 运费不计税
+func (domain *PricingDomain) applyTax(breakdown *do.PriceBreakdown) {
+	taxable := make([]int64, len(breakdown.Lines))
+	var taxableTotal int64
+	for i, line := range breakdown.Lines {
+		taxable[i] = line.Subtotal - line.PromotionDiscount - line.CouponDiscount - line.PointsDiscount
+		taxableTotal += taxable[i]
+	}
+	breakdown.Tax = utils.RoundDiv(taxableTotal*config.AppConfig().Pricing.TaxRateBps, 10000)
+	taxes := utils.AllocateByWeight(breakdown.Tax, taxable)
+	for i, line := range breakdown.Lines {
+		line.Tax = taxes[i]
+		line.Payable = taxable[i] + line.Tax
+	}
+}
+
+// flatShipping 按配置的固定运费计算, 优惠后的商品金额达到包邮门槛时免运费, 商品没有可用的运费模板时使用
+type flatShipping struct{}
+
+func (flatShipping) ShippingFee(_ context.Context, lines []*do.PriceLine, _ *do.ShippingAddress) (int64, error) {
+	var goodsAmount int64
+	for _, line := range lines {
+		goodsAmount += line.Subtotal - line.PromotionDiscount - line.CouponDiscount
+	}
+	threshold := config.AppConfig().Pricing.FreeShippingThreshold
+	if threshold > 0 && goodsAmount >= threshold {
+		return 0, nil
+	}
+	return config.AppConfig().Pricing.ShippingFee, nil
+}
@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"reflect"
+	"testing"
+)
+
+func TestDeductPoints(t *testing.T) {
+	tests := []struct {
+		name            string
+		lines           []*do.PriceLine
+		points          int64
+		pointsPerYuan   int64
+		maxDeductBps    int64
+		wantDeduct      int64
+		wantUsed        int64
+		wantLineDeducts []int64
+		wantLineUsed    []int64
+	}{
+		{
+			name:            "按积分全额抵扣",
+			lines:           []*do.PriceLine{{Subtotal: 1000}},
+			points:          150,
+			pointsPerYuan:   100,
+			maxDeductBps:    10000,
+			wantDeduct:      150,
+			wantUsed:        150,
+			wantLineDeducts: []int64{150},
+			wantLineUsed:    []int64{150},
+		},
+		{
+			name:            "换算金额向下取整, 积分向上取整且不超过请求的积分",
+			lines:           []*do.PriceLine{{Subtotal: 1000}},
+			points:          100,
+			pointsPerYuan:   30,
+			maxDeductBps:    10000,
+			wantDeduct:      333,
+			wantUsed:        100,
+			wantLineDeducts: []int64{333},
+			wantLineUsed:    []int64{100},
+		},
+		{
+			name:            "按比例上限抵扣并分摊到各行",
+			lines:           []*do.PriceLine{{Subtotal: 600}, {Subtotal: 399}},
+			points:          100000,
+			pointsPerYuan:   33,
+			maxDeductBps:    5000,
+			wantDeduct:      499,
+			wantUsed:        165,
+			wantLineDeducts: []int64{300, 199},
+			wantLineUsed:    []int64{99, 66},
+		},
+		{
+			name:            "上限按优惠后的金额计算",
+			lines:           []*do.PriceLine{{Subtotal: 1000, PromotionDiscount: 200, CouponDiscount: 300}},
+			points:          100000,
+			pointsPerYuan:   100,
+			maxDeductBps:    5000,
+			wantDeduct:      250,
+			wantUsed:        250,
+			wantLineDeducts: []int64{250},
+			wantLineUsed:    []int64{250},
+		},
+		{
+			name:            "积分不够抵扣1分",
+			lines:           []*do.PriceLine{{Subtotal: 1000}},
+			points:          1,
+			pointsPerYuan:   200,
+			maxDeductBps:    10000,
+			wantLineDeducts: []int64{0},
+			wantLineUsed:    []int64{0},
+		},
+		{
+			name:            "优惠后没有可抵扣的金额",
+			lines:           []*do.PriceLine{{Subtotal: 1000, CouponDiscount: 1000}},
+			points:          500,
+			pointsPerYuan:   100,
+			maxDeductBps:    10000,
+			wantLineDeducts: []int64{0},
+			wantLineUsed:    []int64{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown := &do.PriceBreakdown{Lines: tt.lines}
+			deductPoints(breakdown, tt.points, tt.pointsPerYuan, tt.maxDeductBps)
+			if breakdown.PointsDiscount != tt.wantDeduct || breakdown.PointsUsed != tt.wantUsed {
+				t.Errorf("deduct, used = %d, %d, want %d, %d", breakdown.PointsDiscount, breakdown.PointsUsed, tt.wantDeduct, tt.wantUsed)
+			}
+			lineDeducts, lineUsed := make([]int64, 0), make([]int64, 0)
+			for _, line := range breakdown.Lines {
+				lineDeducts = append(lineDeducts, line.PointsDiscount)
+				lineUsed = append(lineUsed, line.PointsUsed)
+			}
+			if !reflect.DeepEqual(lineDeducts, tt.wantLineDeducts) {
+				t.Errorf("line deducts = %v, want %v", lineDeducts, tt.wantLineDeducts)
+			}
+			if !reflect.DeepEqual(lineUsed, tt.wantLineUsed) {
+				t.Errorf("line used = %v, want %v", lineUsed, tt.wantLineUsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Cospk/go-mall/internal/dal/cache"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"math"
+	"sync"
+	"time"
+)
+
+type ReviewDomain struct {
+	ctx         context.Context
+	reviewDao   *dao.ReviewDao
+	userDao     *dao.UserDao
+	orderDomain *OrderDomain
+}
+
+func NewReviewDomain(ctx context.Context) *ReviewDomain {
+	return &ReviewDomain{
+		ctx:         ctx,
+		reviewDao:   dao.NewReviewDao(ctx),
+		userDao:     dao.NewUserDao(ctx),
+		orderDomain: NewOrderDomain(ctx),
+	}
+}
+
+var (
+	reviewFilterOnce sync.Once
+	reviewFilter     *utils.SensitiveFilter
+)
+
+// sensitiveFilter 评价和回复使用的敏感词过滤器, 第一次使用时按配置构建
+func sensitiveFilter() *utils.SensitiveFilter {
+	reviewFilterOnce.Do(func() {
+		reviewFilter = utils.NewSensitiveFilter(config.AppConfig().Review.SensitiveWords)
+	})
+	return reviewFilter
+}
+
+// CreateReview 评价订单中的一个商品, 只有已完成的订单可以评价, 每个商品只能评价一次
+// 评价内容中的敏感词替换成*后再发布
+func (domain *ReviewDomain) CreateReview(orderNo string, review *do.Review) (*do.Review, error) {
+	order, err := domain.orderDomain.GetUserOrder(review.UserId, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	if order.State != enum.OrderStateCompleted {
+		return nil, errcode.ErrReviewNotAllowed
+	}
+	var item *do.OrderItem
+	for _, orderItem := range order.Items {
+		if orderItem.ID == review.OrderItemId {
+			item = orderItem
+		}
+	}
+	if item == nil {
+		return nil, errcode.ErrReviewNotAllowed
+	}
+	existed, err := domain.reviewDao.FindReviewByOrderItemId(item.ID)
+	if err != nil {
+		return nil, errcode.Wrap("查询评价失败", err)
+	}
+	if existed != nil {
+		return nil, errcode.ErrReviewExisted
+	}
+
+	content, hit := sensitiveFilter().Replace(review.Content)
+	if hit {
+		logger.NewLogger(domain.ctx).Info("ReviewSensitiveWordsReplaced", "userId", review.UserId, "orderItemId", item.ID)
+	}
+	images, _ := json.Marshal(review.Images)
+	reviewModel := &model.Review{
+		OrderId:     order.ID,
+		OrderItemId: item.ID,
+		UserId:      review.UserId,
+		GoodsId:     item.GoodsId,
+		SkuId:       item.SkuId,
+		MerchantId:  order.MerchantId,
+		Rating:      review.Rating,
+		Content:     content,
+		Images:      string(images),
+		HasImages:   len(review.Images) > 0,
+		Anonymous:   review.Anonymous,
+		State:       enum.ReviewStateVisible,
+	}
+	// 并发提交时由订单明细的唯一键兜底, 第二次提交会因为唯一键冲突失败
+	if err = domain.reviewDao.CreateReview(reviewModel); err != nil {
+		return nil, errcode.Wrap("创建评价失败", err)
+	}
+	domain.invalidateSummary(reviewModel.GoodsId)
+	return domain.toReviewDo(reviewModel), nil
+}
+
+// GetReview 查询评价
+func (domain *ReviewDomain) GetReview(reviewId int64) (*do.Review, error) {
+	review, err := domain.reviewDao.FindReviewById(reviewId)
+	if err != nil {
+		return nil, errcode.Wrap("查询评价失败", err)
+	}
+	if review == nil {
+		return nil, errcode.ErrReviewNotFound
+	}
+	return domain.toReviewDo(review), nil
+}
+
+// GetGoodsReviews 分页查询商品展示中的评价, 带上评价人的昵称和头像, 匿名评价脱敏展示
+func (domain *ReviewDomain) GetGoodsReviews(goodsId int64, rating int8, withImages bool, pageNum, pageSize int) ([]*do.Review, int64, error) {
+	reviews, total, err := domain.reviewDao.FindGoodsReviews(goodsId, rating, withImages, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询商品评价失败", err)
+	}
+	reviewDos, err := domain.toReviewDosWithUser(reviews)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviewDos, total, nil
+}
+
+// GetUserReviews 分页查询用户自己发表的评价
+func (domain *ReviewDomain) GetUserReviews(userId int64, pageNum, pageSize int) ([]*do.Review, int64, error) {
+	reviews, total, err := domain.reviewDao.FindUserReviews(userId, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询评价失败", err)
+	}
+	reviewDos := make([]*do.Review, 0, len(reviews))
+	for _, review := range reviews {
+		reviewDos = append(reviewDos, domain.toReviewDo(review))
+	}
+	return reviewDos, total, nil
+}
+
+// GetReviews 后台分页查询评价, merchantId为0时查询所有店铺的
+func (domain *ReviewDomain) GetReviews(merchantId, goodsId int64, state int8, pageNum, pageSize int) ([]*do.Review, int64, error) {
+	reviews, total, err := domain.reviewDao.FindReviews(merchantId, goodsId, state, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询评价失败", err)
+	}
+	reviewDos, err := domain.toReviewDosWithUser(reviews)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reviewDos, total, nil
+}
+
+// ReplyReview 商家回复评价, 每条评价只能回复一次, 回复内容同样过滤敏感词
+// 商家只能回复自己店铺商品的评价, merchantId为0表示平台管理员, 不限制店铺
+func (domain *ReviewDomain) ReplyReview(merchantId, reviewId int64, content string) error {
+	review, err := domain.GetReview(reviewId)
+	if err != nil {
+		return err
+	}
+	if merchantId != 0 && review.MerchantId != merchantId {
+		return errcode.ErrReviewNotFound
+	}
+	content, _ = sensitiveFilter().Replace(content)
+	replied, err := domain.reviewDao.UpdateReply(reviewId, content, time.Now())
+	if err != nil {
+		return errcode.Wrap("回复评价失败", err)
+	}
+	if !replied {
+		return errcode.ErrReviewReplied
+	}
+	return nil
+}
+
+// SetReviewHidden 管理员隐藏或恢复展示评价, 隐藏的评价不计入评分汇总
+func (domain *ReviewDomain) SetReviewHidden(reviewId int64, hidden bool, reason string) error {
+	review, err := domain.GetReview(reviewId)
+	if err != nil {
+		return err
+	}
+	state := enum.ReviewStateVisible
+	if hidden {
+		state = enum.ReviewStateHidden
+	} else {
+		reason = ""
+	}
+	if err = domain.reviewDao.UpdateState(reviewId, state, reason); err != nil {
+		return errcode.Wrap("修改评价状态失败", err)
+	}
+	domain.invalidateSummary(review.GoodsId)
+	return nil
+}
+
+// GetRatingSummary 查询商品的评分汇总, 优先读Redis缓存, 缓存不存在时统计后写入缓存
+func (domain *ReviewDomain) GetRatingSummary(goodsId int64) (*do.RatingSummary, error) {
+	log := logger.NewLogger(domain.ctx)
+	summary, err := cache.GetRatingSummary(domain.ctx, goodsId)
+	if err != nil {
+		// 缓存不可用时降级查库
+		log.Error("GetRatingSummaryCacheError", "goodsId", goodsId, "err", err)
+	}
+	if summary != nil {
+		return summary, nil
+	}
+	stats, err := domain.reviewDao.CountGoodsRatings(goodsId)
+	if err != nil {
+		return nil, errcode.Wrap("统计商品评分失败", err)
+	}
+	summary = buildRatingSummary(goodsId, stats)
+	ttl := config.AppConfig().Review.SummaryTtl
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	if err = cache.SetRatingSummary(domain.ctx, summary, ttl); err != nil {
+		log.Error("SetRatingSummaryCacheError", "goodsId", goodsId, "err", err)
+	}
+	return summary, nil
+}
+
+// invalidateSummary 删除评分汇总缓存, 删除失败时等缓存过期后自然更新
+func (domain *ReviewDomain) invalidateSummary(goodsId int64) {
+	if err := cache.DelRatingSummary(domain.ctx, goodsId); err != nil {
+		logger.NewLogger(domain.ctx).Error("DelRatingSummaryCacheError", "goodsId", goodsId, "err", err)
+	}
+}
+
+// buildRatingSummary 根据按评分分组的统计结果计算汇总数据
+func buildRatingSummary(goodsId int64, stats []*model.ReviewRatingStat) *do.RatingSummary {
+	summary := &do.RatingSummary{GoodsId: goodsId}
+	var scoreSum, goodCount int64
+	for _, stat := range stats {
+		if stat.Rating < 1 || stat.Rating > 5 {
+			continue
+		}
+		summary.RatingCounts[stat.Rating-1] = stat.Count
+		summary.TotalCount += stat.Count
+		summary.ImageCount += stat.ImageCount
+		scoreSum += int64(stat.Rating) * stat.Count
+		if stat.Rating >= 4 {
+			goodCount += stat.Count
+		}
+	}
+	if summary.TotalCount > 0 {
+		summary.AverageScore = math.Round(float64(scoreSum)*10/float64(summary.TotalCount)) / 10
+		summary.GoodRate = int(utils.RoundDiv(goodCount*100, summary.TotalCount))
+	}
+	return summary
+}
+
+// toReviewDosWithUser 转换评价并补充评价人的昵称和头像
+func (domain *ReviewDomain) toReviewDosWithUser(reviews []*model.Review) ([]*do.Review, error) {
+	reviewDos := make([]*do.Review, 0, len(reviews))
+	if len(reviews) == 0 {
+		return reviewDos, nil
+	}
+	userIds := make([]int64, 0, len(reviews))
+	for _, review := range reviews {
+		userIds = append(userIds, review.UserId)
+	}
+	users, err := domain.userDao.FindUsersByIds(userIds)
+	if err != nil {
+		return nil, errcode.Wrap("查询评价人信息失败", err)
+	}
+	userMap := make(map[int64]*model.User, len(users))
+	for _, user := range users {
+		userMap[user.ID] = user
+	}
+	for _, review := range reviews {
+		reviewDo := domain.toReviewDo(review)
+		if user, ok := userMap[review.UserId]; ok {
+			reviewDo.Nickname, reviewDo.Avatar = user.Nickname, user.Avatar
+		}
+		if review.Anonymous {
+			reviewDo.Nickname, reviewDo.Avatar = utils.MaskNickname(reviewDo.Nickname), ""
+		}
+		reviewDos = append(reviewDos, reviewDo)
+	}
+	return reviewDos, nil
+}
+
+func (domain *ReviewDomain) toReviewDo(review *model.Review) *do.Review {
+	reviewDo := new(do.Review)
+	_ = utils.CopyStruct(reviewDo, review)
+	reviewDo.Images = make([]string, 0)
+	if review.Images != "" {
+		_ = json.Unmarshal([]byte(review.Images), &reviewDo.Images)
+	}
+	return reviewDo
+}
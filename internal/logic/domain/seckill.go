@@ -0,0 +1,308 @@
+package domain
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/internal/dal/cache"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"gorm.io/gorm"
+	"sync"
+	"time"
+)
+
+// 秒杀流程:
+//  1. 活动开始前把活动信息和库存预热到Redis
+//  2. 抢购请求依次经过本地售罄标记、按秒计数的准入控制、Lua脚本原子扣减库存和用户限购, 全程不访问数据库
+//  3. 扣减成功后生成抢购凭证, 由延时队列异步创建真实订单, 客户端拿凭证轮询结果
+//  4. 创建订单失败时把Redis库存和用户已购数量退回
+//  5. 秒杀订单取消或超时未支付时, 在订单取消的事务里退回活动已售数量, 提交后退回Redis库存和用户已购数量
+//
+// Redis库存只是入口的闸门, 数据库里的SKU库存和活动已售数量才是最终的校验
+
+const (
+	// seckillSoldOutTTL 本地售罄标记的有效期, 过期后重新询问Redis, 创建订单失败退回的库存还能被抢到
+	seckillSoldOutTTL = time.Second
+	// seckillCacheGrace 活动结束后Redis里的活动数据再保留一段时间, 让排队中的订单能处理完
+	seckillCacheGrace = time.Hour
+)
+
+// seckillSoldOut 本进程内的售罄标记, activityId => 标记时间, 售罄后的请求直接在本地拦截
+var seckillSoldOut sync.Map
+
+type SeckillDomain struct {
+	ctx         context.Context
+	seckillDao  *dao.SeckillDao
+	goodsDao    *dao.GoodsDao
+	orderDomain *OrderDomain
+}
+
+func NewSeckillDomain(ctx context.Context) *SeckillDomain {
+	return &SeckillDomain{
+		ctx:         ctx,
+		seckillDao:  dao.NewSeckillDao(ctx),
+		goodsDao:    dao.NewGoodsDao(ctx),
+		orderDomain: NewOrderDomain(ctx),
+	}
+}
+
+// CreateActivity 创建秒杀活动, 活动库存不能超过SKU当前的库存
+func (domain *SeckillDomain) CreateActivity(activity *do.SeckillActivity) error {
+	if activity.SeckillPrice <= 0 || activity.TotalStock <= 0 || activity.PerUserLimit <= 0 ||
+		!activity.StartAt.Before(activity.EndAt) || !time.Now().Before(activity.EndAt) {
+		return errcode.ErrSeckillParams
+	}
+	skus, err := domain.goodsDao.FindSkusByIds([]int64{activity.SkuId})
+	if err != nil {
+		return errcode.Wrap("查询商品SKU失败", err)
+	}
+	if len(skus) == 0 {
+		return errcode.ErrGoodsNotFound
+	}
+	if skus[0].Stock < activity.TotalStock {
+		return errcode.ErrGoodsStockNotEnough
+	}
+	activity.GoodsId = skus[0].GoodsId
+	activityModel := new(model.SeckillActivity)
+	_ = utils.CopyStruct(activityModel, activity)
+	if err = domain.seckillDao.CreateActivity(activityModel); err != nil {
+		return errcode.Wrap("创建秒杀活动失败", err)
+	}
+	activity.ID = activityModel.ID
+	return nil
+}
+
+// GetActivity 查询秒杀活动
+func (domain *SeckillDomain) GetActivity(activityId int64) (*do.SeckillActivity, error) {
+	activityModel, err := domain.seckillDao.FindActivityById(activityId)
+	if err != nil {
+		return nil, errcode.Wrap("查询秒杀活动失败", err)
+	}
+	if activityModel == nil {
+		return nil, errcode.ErrSeckillNotFound
+	}
+	activity := new(do.SeckillActivity)
+	_ = utils.CopyStruct(activity, activityModel)
+	return activity, nil
+}
+
+// GetActivities 分页查询秒杀活动
+func (domain *SeckillDomain) GetActivities(pageNum, pageSize int) ([]*do.SeckillActivity, int64, error) {
+	activities, total, err := domain.seckillDao.FindActivities((pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询秒杀活动失败", err)
+	}
+	activityDos := make([]*do.SeckillActivity, 0, len(activities))
+	for _, activity := range activities {
+		activityDo := new(do.SeckillActivity)
+		_ = utils.CopyStruct(activityDo, activity)
+		activityDos = append(activityDos, activityDo)
+	}
+	return activityDos, total, nil
+}
+
+// GetRemainingStock 查询Redis中的剩余库存, 还没有预热时按活动库存减已售数量计算
+func (domain *SeckillDomain) GetRemainingStock(activity *do.SeckillActivity) int {
+	stock, err := cache.GetSeckillStock(domain.ctx, activity.ID)
+	if err != nil || stock < 0 {
+		return activity.TotalStock - activity.SoldCount
+	}
+	return stock
+}
+
+// WarmUp 把活动信息和剩余库存预热到Redis, 重复预热不会覆盖进行中的库存
+func (domain *SeckillDomain) WarmUp(activityId int64) error {
+	activity, err := domain.GetActivity(activityId)
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(activity.EndAt) + seckillCacheGrace
+	if !time.Now().Before(activity.EndAt) {
+		return errcode.ErrSeckillEnded
+	}
+	if err = cache.SetSeckillActivity(domain.ctx, activity, ttl); err != nil {
+		return errcode.Wrap("缓存秒杀活动失败", err)
+	}
+	created, err := cache.InitSeckillStock(domain.ctx, activity.ID, activity.TotalStock-activity.SoldCount, ttl)
+	if err != nil {
+		return errcode.Wrap("预热秒杀库存失败", err)
+	}
+	logger.NewLogger(domain.ctx).Info("SeckillWarmedUp", "activityId", activity.ID, "stockCreated", created, "stock", activity.TotalStock-activity.SoldCount)
+	return nil
+}
+
+// Grab 抢购, 成功扣减Redis库存后返回抢购凭证, 调用方负责投递异步创建订单的任务
+func (domain *SeckillDomain) Grab(userId, activityId int64, quantity int, address *do.ShippingAddress) (*do.SeckillGrab, error) {
+	if markedAt, ok := seckillSoldOut.Load(activityId); ok && time.Since(markedAt.(time.Time)) < seckillSoldOutTTL {
+		return nil, errcode.ErrSeckillSoldOut
+	}
+	activity, err := cache.GetSeckillActivity(domain.ctx, activityId)
+	if err != nil {
+		return nil, errcode.Wrap("读取秒杀活动失败", err)
+	}
+	if activity == nil {
+		// 没有预热的活动要么不存在要么还没到预热时间
+		return nil, errcode.ErrSeckillNotStarted
+	}
+	now := time.Now()
+	if now.Before(activity.StartAt) {
+		return nil, errcode.ErrSeckillNotStarted
+	}
+	if !now.Before(activity.EndAt) {
+		return nil, errcode.ErrSeckillEnded
+	}
+	if quantity <= 0 || quantity > activity.PerUserLimit {
+		return nil, errcode.ErrSeckillLimitExceeded
+	}
+	admitted, err := cache.AdmitSeckillRequest(domain.ctx, activityId, config.AppConfig().Seckill.AdmissionQps)
+	if err != nil {
+		return nil, errcode.Wrap("秒杀准入控制失败", err)
+	}
+	if !admitted {
+		return nil, errcode.ErrSeckillBusy
+	}
+	code, err := cache.DeductSeckillStock(domain.ctx, activityId, userId, quantity, activity.PerUserLimit)
+	if err != nil {
+		return nil, errcode.Wrap("扣减秒杀库存失败", err)
+	}
+	switch code {
+	case cache.SeckillDeductSoldOut:
+		seckillSoldOut.Store(activityId, time.Now())
+		return nil, errcode.ErrSeckillSoldOut
+	case cache.SeckillDeductLimit:
+		return nil, errcode.ErrSeckillLimitExceeded
+	case cache.SeckillDeductNotWarmedUp:
+		return nil, errcode.ErrSeckillNotStarted
+	}
+
+	grab := &do.SeckillGrab{
+		Ticket:     utils.GenSeckillTicket(userId),
+		ActivityId: activityId,
+		UserId:     userId,
+		Quantity:   quantity,
+		Address:    address,
+	}
+	err = domain.saveResult(&do.SeckillResult{
+		Ticket:     grab.Ticket,
+		ActivityId: activityId,
+		UserId:     userId,
+		State:      enum.SeckillResultQueued,
+	})
+	if err != nil {
+		domain.AbandonGrab(grab, "保存抢购结果失败")
+		return nil, errcode.Wrap("保存抢购结果失败", err)
+	}
+	return grab, nil
+}
+
+// AbandonGrab 抢到的库存没能生成订单, 退回Redis库存并把结果标记为失败
+func (domain *SeckillDomain) AbandonGrab(grab *do.SeckillGrab, reason string) {
+	log := logger.NewLogger(domain.ctx)
+	if err := cache.RestoreSeckillStock(domain.ctx, grab.ActivityId, grab.UserId, grab.Quantity); err != nil {
+		log.Error("RestoreSeckillStockError", "ticket", grab.Ticket, "err", err)
+	}
+	seckillSoldOut.Delete(grab.ActivityId)
+	err := domain.saveResult(&do.SeckillResult{
+		Ticket:     grab.Ticket,
+		ActivityId: grab.ActivityId,
+		UserId:     grab.UserId,
+		State:      enum.SeckillResultFailed,
+		Reason:     reason,
+	})
+	if err != nil {
+		log.Error("SaveSeckillResultError", "ticket", grab.Ticket, "err", err)
+	}
+}
+
+// GetResult 查询用户自己的抢购结果
+func (domain *SeckillDomain) GetResult(userId int64, ticket string) (*do.SeckillResult, error) {
+	result, err := cache.GetSeckillResult(domain.ctx, ticket)
+	if err != nil {
+		return nil, errcode.Wrap("查询抢购结果失败", err)
+	}
+	if result == nil || result.UserId != userId {
+		return nil, errcode.ErrSeckillTicketNotFound
+	}
+	return result, nil
+}
+
+// CreateOrder 把抢购请求变成真实订单并返回订单号, 凭证唯一键保证同一个凭证重复执行只会生成一个订单
+// 库存不足等业务失败时退回Redis库存并返回空订单号, 不再重试; 其他错误返回给延时队列重试, 重试耗尽后由AbandonOrder退回
+func (domain *SeckillDomain) CreateOrder(grab *do.SeckillGrab) (string, error) {
+	existed, err := domain.seckillDao.FindSeckillOrderByTicket(grab.Ticket)
+	if err != nil {
+		return "", errcode.Wrap("查询秒杀订单失败", err)
+	}
+	if existed != nil {
+		return existed.OrderNo, domain.markSuccess(grab, existed.OrderNo)
+	}
+	activity, err := domain.GetActivity(grab.ActivityId)
+	if err != nil {
+		return "", err
+	}
+	item := &do.OrderItem{SkuId: activity.SkuId, Quantity: grab.Quantity, UnitPrice: activity.SeckillPrice}
+	order, err := domain.orderDomain.CreateActivityOrder(grab.UserId, item, grab.Address, "秒杀活动:"+activity.Title,
+		func(tx *gorm.DB, order *model.Order) error {
+			if err := domain.seckillDao.IncrSoldCount(tx, activity.ID, grab.Quantity); err != nil {
+				return err
+			}
+			return domain.seckillDao.CreateSeckillOrder(tx, &model.SeckillOrder{
+				Ticket:     grab.Ticket,
+				ActivityId: activity.ID,
+				UserId:     grab.UserId,
+				Quantity:   grab.Quantity,
+				OrderId:    order.ID,
+				OrderNo:    order.OrderNo,
+			})
+		})
+	if err != nil {
+		// 凭证唯一键冲突等数据库错误交给延时队列重试, 重试时会查到已生成的订单
+		if appErr, ok := err.(*errcode.AppError); ok && appErr.Code() > 0 {
+			logger.NewLogger(domain.ctx).Warn("SeckillOrderFailed", "ticket", grab.Ticket, "err", err)
+			domain.AbandonGrab(grab, appErr.Msg())
+			return "", nil
+		}
+		return "", err
+	}
+	if err = domain.markSuccess(grab, order.OrderNo); err != nil {
+		// 订单已经创建, 结果写失败时客户端可以到订单列表里查看, 不需要重试
+		logger.NewLogger(domain.ctx).Error("SaveSeckillResultError", "ticket", grab.Ticket, "err", err)
+	}
+	return order.OrderNo, nil
+}
+
+// AbandonOrder 下单任务重试耗尽后放弃抢购, 已经生成订单的按成功处理, 没有生成订单的退回库存并标记为失败
+func (domain *SeckillDomain) AbandonOrder(grab *do.SeckillGrab) error {
+	existed, err := domain.seckillDao.FindSeckillOrderByTicket(grab.Ticket)
+	if err != nil {
+		return errcode.Wrap("查询秒杀订单失败", err)
+	}
+	if existed != nil {
+		return domain.markSuccess(grab, existed.OrderNo)
+	}
+	domain.AbandonGrab(grab, "下单失败")
+	return nil
+}
+
+func (domain *SeckillDomain) markSuccess(grab *do.SeckillGrab, orderNo string) error {
+	return domain.saveResult(&do.SeckillResult{
+		Ticket:     grab.Ticket,
+		ActivityId: grab.ActivityId,
+		UserId:     grab.UserId,
+		State:      enum.SeckillResultSuccess,
+		OrderNo:    orderNo,
+	})
+}
+
+func (domain *SeckillDomain) saveResult(result *do.SeckillResult) error {
+	ttl := config.AppConfig().Seckill.ResultTtl
+	if ttl <= 0 {
+		ttl = 30 * time.Minute
+	}
+	return cache.SetSeckillResult(domain.ctx, result, ttl)
+}
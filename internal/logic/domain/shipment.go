@@ -0,0 +1,197 @@
+package domain
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/external/carrier"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"gorm.io/gorm"
+	"time"
+)
+
+type ShipmentDomain struct {
+	ctx         context.Context
+	shipmentDao *dao.ShipmentDao
+	orderDomain *OrderDomain
+}
+
+func NewShipmentDomain(ctx context.Context) *ShipmentDomain {
+	return &ShipmentDomain{
+		ctx:         ctx,
+		shipmentDao: dao.NewShipmentDao(ctx),
+		orderDomain: NewOrderDomain(ctx),
+	}
+}
+
+// trackStatusToState 物流公司的轨迹状态对应的物流单状态
+var trackStatusToState = map[carrier.TrackStatus]int8{
+	carrier.TrackStatusPickedUp:       enum.ShipmentStateShipped,
+	carrier.TrackStatusInTransit:      enum.ShipmentStateInTransit,
+	carrier.TrackStatusOutForDelivery: enum.ShipmentStateDelivering,
+	carrier.TrackStatusDelivered:      enum.ShipmentStateDelivered,
+	carrier.TrackStatusException:      enum.ShipmentStateException,
+}
+
+// ShipOrder 订单发货, 同一个事务里把订单流转到已发货并创建物流单
+func (domain *ShipmentDomain) ShipOrder(orderNo, carrierCode, trackingNo string, operator *do.OrderOperator) (*do.Shipment, error) {
+	if _, ok := carrier.GetCarrier(carrierCode); !ok {
+		return nil, errcode.ErrCarrierNotSupported
+	}
+	order, err := domain.orderDomain.GetOrder(orderNo)
+	if err != nil {
+		return nil, err
+	}
+	shipment := &model.Shipment{
+		OrderId:    order.ID,
+		OrderNo:    order.OrderNo,
+		UserId:     order.UserId,
+		Carrier:    carrierCode,
+		TrackingNo: trackingNo,
+		State:      enum.ShipmentStateShipped,
+		ShippedAt:  time.Now(),
+	}
+	err = dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+		lockedOrder, err := domain.orderDomain.LockOrderInTx(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		err = domain.orderDomain.ChangeOrderStateInTx(tx, lockedOrder, enum.OrderStateShipped, operator, "发货, 物流单号: "+trackingNo)
+		if err != nil {
+			return err
+		}
+		return domain.shipmentDao.CreateShipment(tx, shipment)
+	})
+	if err != nil {
+		return nil, wrapShipmentError(err)
+	}
+	return domain.toShipmentDo(shipment, nil), nil
+}
+
+// GetShipment 查询物流单, 不带轨迹
+func (domain *ShipmentDomain) GetShipment(shipmentId int64) (*do.Shipment, error) {
+	shipment, err := domain.shipmentDao.FindShipmentById(shipmentId)
+	if err != nil {
+		return nil, errcode.Wrap("查询物流单失败", err)
+	}
+	if shipment == nil {
+		return nil, errcode.ErrShipmentNotFound
+	}
+	return domain.toShipmentDo(shipment, nil), nil
+}
+
+// GetOrderShipments 查询订单的物流单和物流轨迹
+func (domain *ShipmentDomain) GetOrderShipments(orderId int64) ([]*do.Shipment, error) {
+	shipments, err := domain.shipmentDao.FindShipmentsByOrderId(orderId)
+	if err != nil {
+		return nil, errcode.Wrap("查询物流单失败", err)
+	}
+	if len(shipments) == 0 {
+		return nil, errcode.ErrShipmentNotFound
+	}
+	shipmentIds := make([]int64, 0, len(shipments))
+	for _, shipment := range shipments {
+		shipmentIds = append(shipmentIds, shipment.ID)
+	}
+	events, err := domain.shipmentDao.FindShipmentEvents(shipmentIds)
+	if err != nil {
+		return nil, errcode.Wrap("查询物流轨迹失败", err)
+	}
+	eventMap := make(map[int64][]*model.ShipmentEvent, len(shipments))
+	for _, event := range events {
+		eventMap[event.ShipmentId] = append(eventMap[event.ShipmentId], event)
+	}
+	shipmentDos := make([]*do.Shipment, 0, len(shipments))
+	for _, shipment := range shipments {
+		shipmentDos = append(shipmentDos, domain.toShipmentDo(shipment, eventMap[shipment.ID]))
+	}
+	return shipmentDos, nil
+}
+
+// SyncTracking 从物流公司同步物流轨迹, 物流显示已签收时订单从已发货流转到已签收
+// 轨迹按唯一键去重, 重复同步是安全的
+func (domain *ShipmentDomain) SyncTracking(shipment *do.Shipment) error {
+	if shipment.State == enum.ShipmentStateDelivered {
+		return nil
+	}
+	shipmentCarrier, ok := carrier.GetCarrier(shipment.Carrier)
+	if !ok {
+		return errcode.ErrCarrierNotSupported
+	}
+	trackEvents, err := shipmentCarrier.Track(domain.ctx, shipment.TrackingNo)
+	if err != nil {
+		return errcode.ErrCarrierTrackFailed.WithCause(err)
+	}
+
+	shipmentModel := &model.Shipment{
+		ID:            shipment.ID,
+		State:         shipment.State,
+		DeliveredAt:   shipment.DeliveredAt,
+		LastTrackedAt: time.Now(),
+	}
+	eventModels := make([]*model.ShipmentEvent, 0, len(trackEvents))
+	for _, event := range trackEvents {
+		eventModels = append(eventModels, &model.ShipmentEvent{
+			ShipmentId:  shipment.ID,
+			EventTime:   event.Time,
+			Status:      string(event.Status),
+			Location:    event.Location,
+			Description: event.Description,
+		})
+		// 轨迹按时间升序, 最后一个节点决定物流单的状态
+		if state, ok := trackStatusToState[event.Status]; ok {
+			shipmentModel.State = state
+		}
+		if event.Status == carrier.TrackStatusDelivered {
+			shipmentModel.DeliveredAt = event.Time
+		}
+	}
+	err = dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+		if err := domain.shipmentDao.SaveShipmentEvents(tx, eventModels); err != nil {
+			return err
+		}
+		if err := domain.shipmentDao.UpdateShipmentTrack(tx, shipmentModel); err != nil {
+			return err
+		}
+		if shipmentModel.State != enum.ShipmentStateDelivered {
+			return nil
+		}
+		order, err := domain.orderDomain.LockOrderInTx(tx, shipment.OrderId)
+		if err != nil {
+			return err
+		}
+		// 用户已经确认收货或者订单在售后中时不再变更订单状态
+		if order.State != enum.OrderStateShipped {
+			return nil
+		}
+		operator := &do.OrderOperator{Type: enum.OrderOperatorSystem}
+		return domain.orderDomain.ChangeOrderStateInTx(tx, order, enum.OrderStateDelivered, operator, "物流显示已签收")
+	})
+	if err != nil {
+		return wrapShipmentError(err)
+	}
+	shipment.State, shipment.DeliveredAt, shipment.LastTrackedAt = shipmentModel.State, shipmentModel.DeliveredAt, shipmentModel.LastTrackedAt
+	return nil
+}
+
+func (domain *ShipmentDomain) toShipmentDo(shipment *model.Shipment, events []*model.ShipmentEvent) *do.Shipment {
+	shipmentDo := new(do.Shipment)
+	_ = utils.CopyStruct(shipmentDo, shipment)
+	shipmentDo.Events = make([]*do.ShipmentEvent, 0, len(events))
+	for _, event := range events {
+		eventDo := new(do.ShipmentEvent)
+		_ = utils.CopyStruct(eventDo, event)
+		shipmentDo.Events = append(shipmentDo.Events, eventDo)
+	}
+	return shipmentDo
+}
+
+func wrapShipmentError(err error) error {
+	if appErr, ok := err.(*errcode.AppError); ok && appErr.Code() > 0 {
+		return err
+	}
+	return errcode.Wrap("物流单处理失败", err)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/Cospk/go-mall/pkg/enum"
 	"github.com/Cospk/go-mall/pkg/errcode"
 	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/metrics"
 	"github.com/Cospk/go-mall/pkg/utils"
 	"github.com/gin-gonic/gin"
 	"time"
@@ -31,13 +32,14 @@ func (domain *UserDomain) LoginUser(Name, password, platform string) (*do.TokenI
 	if err != nil {
 		return nil, errcode.Wrap("UserDomainSvcLoginUserError", err)
 	}
-	if existedUser.ID == 0 {
-		return nil, errcode.ErrUserPasswordError
-	}
-	if !utils.BcryptCompare(existedUser.Password, password) {
+	if existedUser.ID == 0 || !utils.BcryptCompare(existedUser.Password, password) {
+		metrics.Login(false)
 		return nil, errcode.ErrUserPasswordError
 	}
 	token, err := domain.GenAuthToken(existedUser.ID, platform, "")
+	if err == nil {
+		metrics.Login(true)
+	}
 	return token, err
 }
 
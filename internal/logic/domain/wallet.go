@@ -0,0 +1,543 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/Cospk/go-mall/internal/dal/dao"
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"gorm.io/gorm"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 钱包和礼品卡的资金按复式记账: 每笔资金变动是一笔记账交易, 交易下的分录从一些账户出账、向另一些账户入账, 金额之和为0
+// 用户钱包和礼品卡的余额不能为负, 资金的来源和去向记在系统账户上, 所以全部账户的余额之和也恒为0
+
+// giftCardCodeLength 礼品卡卡密长度
+const giftCardCodeLength = 16
+
+// ledgerCheckBatchSize 对账时每批检查的账户数
+const ledgerCheckBatchSize = 500
+
+// ledgerCheckReportLimit 对账结果中最多列出的异常条数
+const ledgerCheckReportLimit = 100
+
+type WalletDomain struct {
+	ctx       context.Context
+	walletDao *dao.WalletDao
+}
+
+func NewWalletDomain(ctx context.Context) *WalletDomain {
+	return &WalletDomain{
+		ctx:       ctx,
+		walletDao: dao.NewWalletDao(ctx),
+	}
+}
+
+// GetWallet 查询用户钱包, 还没有钱包账户时余额为0
+func (domain *WalletDomain) GetWallet(userId int64) (*do.Wallet, error) {
+	account, err := domain.walletDao.FindAccountByNo(walletAccountNo(userId))
+	if err != nil {
+		return nil, errcode.Wrap("查询钱包失败", err)
+	}
+	wallet := &do.Wallet{UserId: userId}
+	if account != nil {
+		wallet.Balance = account.Balance
+	}
+	return wallet, nil
+}
+
+// GetWalletEntries 分页查询用户钱包的收支明细
+func (domain *WalletDomain) GetWalletEntries(userId int64, pageNum, pageSize int) ([]*do.WalletEntry, int64, error) {
+	entryDos := make([]*do.WalletEntry, 0)
+	account, err := domain.walletDao.FindAccountByNo(walletAccountNo(userId))
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询钱包失败", err)
+	}
+	if account == nil {
+		return entryDos, 0, nil
+	}
+	entries, total, err := domain.walletDao.FindAccountEntries(account.ID, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询钱包明细失败", err)
+	}
+	if len(entries) == 0 {
+		return entryDos, total, nil
+	}
+	transactionIds := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		transactionIds = append(transactionIds, entry.TransactionId)
+	}
+	transactions, err := domain.walletDao.FindTransactionsByIds(transactionIds)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询钱包明细失败", err)
+	}
+	transactionMap := make(map[int64]*model.LedgerTransaction, len(transactions))
+	for _, transaction := range transactions {
+		transactionMap[transaction.ID] = transaction
+	}
+	for _, entry := range entries {
+		entryDo := &do.WalletEntry{
+			ID:        entry.ID,
+			Amount:    entry.Amount,
+			Balance:   entry.Balance,
+			CreatedAt: entry.CreatedAt,
+		}
+		if transaction, ok := transactionMap[entry.TransactionId]; ok {
+			entryDo.TxNo = transaction.TxNo
+			entryDo.Type = transaction.Type
+			entryDo.BizNo = transaction.BizNo
+			entryDo.Remark = transaction.Remark
+		}
+		entryDos = append(entryDos, entryDo)
+	}
+	return entryDos, total, nil
+}
+
+// PostInTx 在事务中记一笔账, 分录金额之和必须为0, 用户钱包和礼品卡出账后余额不能为负
+// 幂等键已经记过账时直接返回已有的交易, 同一个幂等键用在不同的业务上时返回ErrIdempotencyKeyConflict
+func (domain *WalletDomain) PostInTx(tx *gorm.DB, posting *do.LedgerPosting) (*do.LedgerTransaction, error) {
+	amount, err := postingAmount(posting)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按账户号顺序加锁, 并发记账时加锁顺序一致不会死锁; 先加锁再查幂等键, 同一个幂等键的并发请求在这里串行
+	accountNos := make([]string, 0, len(posting.Entries))
+	postingEntries := make(map[string]*do.LedgerPostingEntry, len(posting.Entries))
+	for _, entry := range posting.Entries {
+		if _, ok := postingEntries[entry.AccountNo]; !ok {
+			accountNos = append(accountNos, entry.AccountNo)
+		}
+		postingEntries[entry.AccountNo] = entry
+	}
+	sort.Strings(accountNos)
+	accounts := make(map[string]*model.LedgerAccount, len(accountNos))
+	for _, accountNo := range accountNos {
+		entry := postingEntries[accountNo]
+		account, err := domain.walletDao.LockAccount(tx, entry.AccountNo, entry.AccountType, entry.OwnerId)
+		if err != nil {
+			return nil, err
+		}
+		accounts[accountNo] = account
+	}
+	existed, err := domain.walletDao.FindTransactionByKey(tx, posting.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if existed != nil {
+		if !isSamePosting(existed, posting, amount) {
+			return nil, errcode.ErrIdempotencyKeyConflict
+		}
+		return transactionDo(existed), nil
+	}
+
+	entries, ownerId, err := applyPostingEntries(accounts, posting.Entries)
+	if err != nil {
+		return nil, err
+	}
+	transaction := &model.LedgerTransaction{
+		TxNo:           utils.GenLedgerTxNo(ownerId),
+		IdempotencyKey: posting.IdempotencyKey,
+		Type:           posting.Type,
+		BizNo:          posting.BizNo,
+		Amount:         amount,
+		Remark:         posting.Remark,
+	}
+	if err = domain.walletDao.CreateTransaction(tx, transaction); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		entry.TransactionId = transaction.ID
+	}
+	if err = domain.walletDao.CreateEntries(tx, entries); err != nil {
+		return nil, err
+	}
+	for _, accountNo := range accountNos {
+		account := accounts[accountNo]
+		if err = domain.walletDao.UpdateAccountBalance(tx, account.ID, account.Balance); err != nil {
+			return nil, err
+		}
+	}
+	transaction.CreatedAt = time.Now()
+	return transactionDo(transaction), nil
+}
+
+// postingAmount 校验分录借贷平衡, 返回交易金额(出账分录金额之和)
+func postingAmount(posting *do.LedgerPosting) (int64, error) {
+	var sum, amount int64
+	for _, entry := range posting.Entries {
+		if entry.Amount == 0 {
+			return 0, errcode.ErrLedgerUnbalanced
+		}
+		sum += entry.Amount
+		if entry.Amount < 0 {
+			amount -= entry.Amount
+		}
+	}
+	if len(posting.Entries) < 2 || sum != 0 {
+		return 0, errcode.ErrLedgerUnbalanced
+	}
+	return amount, nil
+}
+
+// isSamePosting 幂等键已经记过账时, 判断已有的交易和这次记账是不是同一笔业务
+func isSamePosting(existed *model.LedgerTransaction, posting *do.LedgerPosting, amount int64) bool {
+	return existed.Type == posting.Type && existed.BizNo == posting.BizNo && existed.Amount == amount
+}
+
+// applyPostingEntries 把分录金额记到已锁定的账户上, 返回带变动后余额的分录和交易归属的用户
+// 用户钱包和礼品卡余额不能为负, 系统账户可以
+func applyPostingEntries(accounts map[string]*model.LedgerAccount, postingEntries []*do.LedgerPostingEntry) ([]*model.LedgerEntry, int64, error) {
+	var ownerId int64
+	entries := make([]*model.LedgerEntry, 0, len(postingEntries))
+	for _, entry := range postingEntries {
+		account := accounts[entry.AccountNo]
+		account.Balance += entry.Amount
+		if account.Type != enum.LedgerAccountTypeSystem && account.Balance < 0 {
+			return nil, 0, errcode.ErrWalletBalanceNotEnough
+		}
+		if ownerId == 0 {
+			ownerId = account.OwnerId
+		}
+		entries = append(entries, &model.LedgerEntry{
+			AccountId: account.ID,
+			Amount:    entry.Amount,
+			Balance:   account.Balance,
+		})
+	}
+	return entries, ownerId, nil
+}
+
+// PayOrderInTx 在支付单的事务里从用户钱包扣款, 按支付单号幂等
+func (domain *WalletDomain) PayOrderInTx(tx *gorm.DB, userId int64, paymentNo string, amount int64) (*do.LedgerTransaction, error) {
+	return domain.PostInTx(tx, &do.LedgerPosting{
+		IdempotencyKey: "order_pay:" + paymentNo,
+		Type:           enum.LedgerTxTypeOrderPay,
+		BizNo:          paymentNo,
+		Remark:         "余额支付, 支付单号" + paymentNo,
+		Entries: []*do.LedgerPostingEntry{
+			walletEntry(userId, -amount),
+			systemEntry(enum.LedgerAccountOrderClearing, amount),
+		},
+	})
+}
+
+// RefundOrderInTx 在售后单的事务里把余额支付的退款退回用户钱包, 按售后单号幂等
+func (domain *WalletDomain) RefundOrderInTx(tx *gorm.DB, userId int64, afterSaleNo string, amount int64) (*do.LedgerTransaction, error) {
+	return domain.PostInTx(tx, &do.LedgerPosting{
+		IdempotencyKey: "order_refund:" + afterSaleNo,
+		Type:           enum.LedgerTxTypeOrderRefund,
+		BizNo:          afterSaleNo,
+		Remark:         "订单退款, 售后单号" + afterSaleNo,
+		Entries: []*do.LedgerPostingEntry{
+			systemEntry(enum.LedgerAccountOrderClearing, -amount),
+			walletEntry(userId, amount),
+		},
+	})
+}
+
+// RefundClosedOrderInTx 支付成功时子订单已经取消, 把这部分金额退回用户钱包, 按支付单号幂等
+func (domain *WalletDomain) RefundClosedOrderInTx(tx *gorm.DB, userId int64, paymentNo string, amount int64) (*do.LedgerTransaction, error) {
+	return domain.PostInTx(tx, &do.LedgerPosting{
+		IdempotencyKey: "order_closed_refund:" + paymentNo,
+		Type:           enum.LedgerTxTypeOrderRefund,
+		BizNo:          paymentNo,
+		Remark:         "订单已取消, 退回支付金额, 支付单号" + paymentNo,
+		Entries: []*do.LedgerPostingEntry{
+			systemEntry(enum.LedgerAccountOrderClearing, -amount),
+			walletEntry(userId, amount),
+		},
+	})
+}
+
+// AdjustWallet 人工调整用户钱包余额, amount为正数时加款、负数时扣款, 调用方提供幂等键防止重复调账
+func (domain *WalletDomain) AdjustWallet(userId, amount int64, idempotencyKey, remark string) (*do.LedgerTransaction, error) {
+	var transaction *do.LedgerTransaction
+	err := dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+		var err error
+		transaction, err = domain.PostInTx(tx, &do.LedgerPosting{
+			IdempotencyKey: "adjust:" + idempotencyKey,
+			Type:           enum.LedgerTxTypeAdjust,
+			BizNo:          strconv.FormatInt(userId, 10),
+			Remark:         remark,
+			Entries: []*do.LedgerPostingEntry{
+				systemEntry(enum.LedgerAccountAdjustment, -amount),
+				walletEntry(userId, amount),
+			},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, wrapWalletError("调整钱包余额失败", err)
+	}
+	logger.NewLogger(domain.ctx).Info("WalletAdjusted", "userId", userId, "amount", amount, "txNo", transaction.TxNo)
+	return transaction, nil
+}
+
+// IssueGiftCards 按批次发行礼品卡, 整批在一笔记账交易里从礼品卡发行账户入账到每张卡
+// 批次号是调用方提供的幂等键, 批次已经发行过时返回已有的卡且不再返回卡密, 参数不一致时返回ErrIdempotencyKeyConflict
+func (domain *WalletDomain) IssueGiftCards(adminId int64, batchNo string, count int, amount int64, expireAt time.Time) ([]*do.GiftCard, error) {
+	cardDos := make([]*do.GiftCard, 0, count)
+	err := dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+		// 先锁定发行账户, 同一批次的并发请求在这里串行
+		if _, err := domain.walletDao.LockAccount(tx, enum.LedgerAccountGiftCardIssue, enum.LedgerAccountTypeSystem, 0); err != nil {
+			return err
+		}
+		existed, err := domain.walletDao.FindGiftCardsByBatch(tx, batchNo)
+		if err != nil {
+			return err
+		}
+		if len(existed) > 0 {
+			if len(existed) != count || existed[0].Amount != amount {
+				return errcode.ErrIdempotencyKeyConflict
+			}
+			for _, card := range existed {
+				cardDos = append(cardDos, giftCardDo(card))
+			}
+			return nil
+		}
+
+		codes := make(map[string]string, count)
+		cards := make([]*model.GiftCard, 0, count)
+		for len(cards) < count {
+			cardNo, code := utils.GenGiftCardNo(), utils.SecureString(giftCardCodeLength, utils.Readable)
+			if _, ok := codes[cardNo]; ok {
+				continue
+			}
+			codes[cardNo] = code
+			cards = append(cards, &model.GiftCard{
+				CardNo:    cardNo,
+				CodeHash:  hashGiftCardCode(code),
+				BatchNo:   batchNo,
+				Amount:    amount,
+				State:     enum.GiftCardStateActive,
+				ExpireAt:  expireAt,
+				CreatedBy: adminId,
+			})
+		}
+		if err = domain.walletDao.CreateGiftCards(tx, cards); err != nil {
+			return err
+		}
+		entries := make([]*do.LedgerPostingEntry, 0, count+1)
+		entries = append(entries, systemEntry(enum.LedgerAccountGiftCardIssue, -amount*int64(count)))
+		for _, card := range cards {
+			entries = append(entries, &do.LedgerPostingEntry{
+				AccountNo:   giftCardAccountNo(card.CardNo),
+				AccountType: enum.LedgerAccountTypeGiftCard,
+				Amount:      amount,
+			})
+		}
+		_, err = domain.PostInTx(tx, &do.LedgerPosting{
+			IdempotencyKey: "gift_card_issue:" + batchNo,
+			Type:           enum.LedgerTxTypeGiftCardIssue,
+			BizNo:          batchNo,
+			Remark:         "发行礼品卡",
+			Entries:        entries,
+		})
+		if err != nil {
+			return err
+		}
+		for _, card := range cards {
+			cardDo := giftCardDo(card)
+			cardDo.Code = codes[card.CardNo]
+			cardDos = append(cardDos, cardDo)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, wrapWalletError("发行礼品卡失败", err)
+	}
+	logger.NewLogger(domain.ctx).Info("GiftCardsIssued", "batchNo", batchNo, "count", count, "amount", amount, "adminId", adminId)
+	return cardDos, nil
+}
+
+// RedeemGiftCard 用户兑换礼品卡, 卡上的余额整笔转入用户钱包, 之后可以用钱包余额支付订单
+// 同一用户重复兑换同一张卡时直接返回兑换结果
+func (domain *WalletDomain) RedeemGiftCard(userId int64, code string) (*do.GiftCard, error) {
+	var cardDo *do.GiftCard
+	err := dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+		card, err := domain.walletDao.LockGiftCardByCode(tx, hashGiftCardCode(code))
+		if err != nil {
+			return err
+		}
+		if card == nil {
+			return errcode.ErrGiftCardNotFound
+		}
+		if card.State == enum.GiftCardStateRedeemed {
+			if card.RedeemedBy != userId {
+				return errcode.ErrGiftCardRedeemed
+			}
+			cardDo = giftCardDo(card)
+			return nil
+		}
+		now := time.Now()
+		if now.After(card.ExpireAt) {
+			return errcode.ErrGiftCardExpired
+		}
+		_, err = domain.PostInTx(tx, &do.LedgerPosting{
+			IdempotencyKey: "gift_card_redeem:" + card.CardNo,
+			Type:           enum.LedgerTxTypeGiftCardRedeem,
+			BizNo:          card.CardNo,
+			Remark:         "兑换礼品卡" + card.CardNo,
+			Entries: []*do.LedgerPostingEntry{
+				{AccountNo: giftCardAccountNo(card.CardNo), AccountType: enum.LedgerAccountTypeGiftCard, Amount: -card.Amount},
+				walletEntry(userId, card.Amount),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err = domain.walletDao.MarkGiftCardRedeemed(tx, card.ID, userId, now); err != nil {
+			return err
+		}
+		card.State, card.RedeemedBy, card.RedeemedAt = enum.GiftCardStateRedeemed, userId, now
+		cardDo = giftCardDo(card)
+		return nil
+	})
+	if err != nil {
+		return nil, wrapWalletError("兑换礼品卡失败", err)
+	}
+	logger.NewLogger(domain.ctx).Info("GiftCardRedeemed", "cardNo", cardDo.CardNo, "userId", userId, "amount", cardDo.Amount)
+	return cardDo, nil
+}
+
+// GetGiftCards 分页查询礼品卡
+func (domain *WalletDomain) GetGiftCards(batchNo string, state int8, pageNum, pageSize int) ([]*do.GiftCard, int64, error) {
+	cards, total, err := domain.walletDao.FindGiftCards(batchNo, state, (pageNum-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, errcode.Wrap("查询礼品卡失败", err)
+	}
+	cardDos := make([]*do.GiftCard, 0, len(cards))
+	for _, card := range cards {
+		cardDos = append(cardDos, giftCardDo(card))
+	}
+	return cardDos, total, nil
+}
+
+// CheckLedger 对账: 每个账户的余额等于它全部分录的金额之和, 每笔交易的分录金额之和为0, 全部账户的余额之和为0
+// 账户余额和分录汇总在同一个事务里按批读取, 可重复读隔离级别下两者来自同一个快照, 对账期间的记账不会造成误报
+func (domain *WalletDomain) CheckLedger() (*do.LedgerCheckReport, error) {
+	report := &do.LedgerCheckReport{
+		CheckedAt:              time.Now(),
+		AccountMismatches:      make([]*do.LedgerAccountMismatch, 0),
+		UnbalancedTransactions: make([]*do.LedgerUnbalancedTx, 0),
+	}
+	var afterId int64
+	for {
+		var accounts []*model.LedgerAccount
+		err := dao.Transaction(domain.ctx, func(tx *gorm.DB) error {
+			var err error
+			accounts, err = domain.walletDao.FindAccounts(tx, afterId, ledgerCheckBatchSize)
+			if err != nil || len(accounts) == 0 {
+				return err
+			}
+			accountIds := make([]int64, 0, len(accounts))
+			for _, account := range accounts {
+				accountIds = append(accountIds, account.ID)
+			}
+			sums, err := domain.walletDao.SumAccountEntries(tx, accountIds)
+			if err != nil {
+				return err
+			}
+			for _, account := range accounts {
+				if sums[account.ID] != account.Balance && len(report.AccountMismatches) < ledgerCheckReportLimit {
+					report.AccountMismatches = append(report.AccountMismatches, &do.LedgerAccountMismatch{
+						AccountId: account.ID,
+						AccountNo: account.AccountNo,
+						Balance:   account.Balance,
+						EntrySum:  sums[account.ID],
+					})
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errcode.Wrap("核对账户余额失败", err)
+		}
+		report.Accounts += int64(len(accounts))
+		if len(accounts) < ledgerCheckBatchSize {
+			break
+		}
+		afterId = accounts[len(accounts)-1].ID
+	}
+
+	unbalanced, err := domain.walletDao.FindUnbalancedTransactions(ledgerCheckReportLimit)
+	if err != nil {
+		return nil, errcode.Wrap("核对记账交易失败", err)
+	}
+	for transactionId, entrySum := range unbalanced {
+		report.UnbalancedTransactions = append(report.UnbalancedTransactions, &do.LedgerUnbalancedTx{
+			TransactionId: transactionId,
+			EntrySum:      entrySum,
+		})
+	}
+	sort.Slice(report.UnbalancedTransactions, func(i, j int) bool {
+		return report.UnbalancedTransactions[i].TransactionId < report.UnbalancedTransactions[j].TransactionId
+	})
+	if report.TotalBalance, err = domain.walletDao.SumAccountBalances(); err != nil {
+		return nil, errcode.Wrap("汇总账户余额失败", err)
+	}
+	report.Consistent = report.TotalBalance == 0 && len(report.AccountMismatches) == 0 && len(report.UnbalancedTransactions) == 0
+	return report, nil
+}
+
+func walletAccountNo(userId int64) string {
+	return "wallet:" + strconv.FormatInt(userId, 10)
+}
+
+func giftCardAccountNo(cardNo string) string {
+	return "gift_card:" + cardNo
+}
+
+func walletEntry(userId, amount int64) *do.LedgerPostingEntry {
+	return &do.LedgerPostingEntry{
+		AccountNo:   walletAccountNo(userId),
+		AccountType: enum.LedgerAccountTypeWallet,
+		OwnerId:     userId,
+		Amount:      amount,
+	}
+}
+
+func systemEntry(accountNo string, amount int64) *do.LedgerPostingEntry {
+	return &do.LedgerPostingEntry{
+		AccountNo:   accountNo,
+		AccountType: enum.LedgerAccountTypeSystem,
+		Amount:      amount,
+	}
+}
+
+// hashGiftCardCode 卡密的哈希, 忽略大小写、空格和分隔符, 方便用户手工输入
+func hashGiftCardCode(code string) string {
+	code = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func transactionDo(transaction *model.LedgerTransaction) *do.LedgerTransaction {
+	transactionDo := new(do.LedgerTransaction)
+	_ = utils.CopyStruct(transactionDo, transaction)
+	return transactionDo
+}
+
+func giftCardDo(card *model.GiftCard) *do.GiftCard {
+	cardDo := new(do.GiftCard)
+	_ = utils.CopyStruct(cardDo, card)
+	return cardDo
+}
+
+// wrapWalletError 项目预定义的业务错误原样返回给上层判断, 其他错误包装后返回
+func wrapWalletError(msg string, err error) error {
+	if appErr, ok := err.(*errcode.AppError); ok && appErr.Code() > 0 {
+		return err
+	}
+	return errcode.Wrap(msg, err)
+}
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"github.com/Cospk/go-mall/internal/dal/model"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"reflect"
+	"testing"
+)
+
+func testPostingEntry(accountNo string, amount int64) *do.LedgerPostingEntry {
+	return &do.LedgerPostingEntry{AccountNo: accountNo, Amount: amount}
+}
+
+func TestPostingAmount(t *testing.T) {
+	tests := []struct {
+		name       string
+		entries    []*do.LedgerPostingEntry
+		wantAmount int64
+		wantErr    error
+	}{
+		{
+			name:       "一借一贷",
+			entries:    []*do.LedgerPostingEntry{testPostingEntry("wallet:1", -500), testPostingEntry(enum.LedgerAccountOrderClearing, 500)},
+			wantAmount: 500,
+		},
+		{
+			name: "多个出账分录",
+			entries: []*do.LedgerPostingEntry{
+				testPostingEntry("gift_card:1", -300), testPostingEntry("wallet:1", -200), testPostingEntry(enum.LedgerAccountOrderClearing, 500),
+			},
+			wantAmount: 500,
+		},
+		{
+			name:    "借贷不平",
+			entries: []*do.LedgerPostingEntry{testPostingEntry("wallet:1", -500), testPostingEntry(enum.LedgerAccountOrderClearing, 400)},
+			wantErr: errcode.ErrLedgerUnbalanced,
+		},
+		{
+			name:    "只有一个分录",
+			entries: []*do.LedgerPostingEntry{testPostingEntry("wallet:1", 0)},
+			wantErr: errcode.ErrLedgerUnbalanced,
+		},
+		{
+			name:    "分录金额为0",
+			entries: []*do.LedgerPostingEntry{testPostingEntry("wallet:1", 0), testPostingEntry(enum.LedgerAccountOrderClearing, 0)},
+			wantErr: errcode.ErrLedgerUnbalanced,
+		},
+		{
+			name:    "没有分录",
+			entries: nil,
+			wantErr: errcode.ErrLedgerUnbalanced,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, err := postingAmount(&do.LedgerPosting{Entries: tt.entries})
+			if err != tt.wantErr {
+				t.Fatalf("postingAmount() err = %v, want %v", err, tt.wantErr)
+			}
+			if amount != tt.wantAmount {
+				t.Errorf("postingAmount() = %d, want %d", amount, tt.wantAmount)
+			}
+		})
+	}
+}
+
+func TestIsSamePosting(t *testing.T) {
+	existed := &model.LedgerTransaction{Type: enum.LedgerTxTypeOrderPay, BizNo: "P001", Amount: 500}
+	tests := []struct {
+		name    string
+		posting *do.LedgerPosting
+		amount  int64
+		want    bool
+	}{
+		{"同一笔业务重复记账", &do.LedgerPosting{Type: enum.LedgerTxTypeOrderPay, BizNo: "P001"}, 500, true},
+		{"交易类型不同", &do.LedgerPosting{Type: enum.LedgerTxTypeOrderRefund, BizNo: "P001"}, 500, false},
+		{"业务单号不同", &do.LedgerPosting{Type: enum.LedgerTxTypeOrderPay, BizNo: "P002"}, 500, false},
+		{"金额不同", &do.LedgerPosting{Type: enum.LedgerTxTypeOrderPay, BizNo: "P001"}, 600, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSamePosting(existed, tt.posting, tt.amount); got != tt.want {
+				t.Errorf("isSamePosting() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPostingEntries(t *testing.T) {
+	tests := []struct {
+		name         string
+		balances     map[string]int64
+		entries      []*do.LedgerPostingEntry
+		wantErr      error
+		wantBalances []int64
+		wantOwnerId  int64
+	}{
+		{
+			name:         "余额足够",
+			balances:     map[string]int64{"wallet:1": 1000, enum.LedgerAccountOrderClearing: 0},
+			entries:      []*do.LedgerPostingEntry{testPostingEntry("wallet:1", -600), testPostingEntry(enum.LedgerAccountOrderClearing, 600)},
+			wantBalances: []int64{400, 600},
+			wantOwnerId:  1,
+		},
+		{
+			name:         "正好用完余额",
+			balances:     map[string]int64{"wallet:1": 600, enum.LedgerAccountOrderClearing: 0},
+			entries:      []*do.LedgerPostingEntry{testPostingEntry("wallet:1", -600), testPostingEntry(enum.LedgerAccountOrderClearing, 600)},
+			wantBalances: []int64{0, 600},
+			wantOwnerId:  1,
+		},
+		{
+			name:     "用户钱包余额不足",
+			balances: map[string]int64{"wallet:1": 500, enum.LedgerAccountOrderClearing: 0},
+			entries:  []*do.LedgerPostingEntry{testPostingEntry("wallet:1", -600), testPostingEntry(enum.LedgerAccountOrderClearing, 600)},
+			wantErr:  errcode.ErrWalletBalanceNotEnough,
+		},
+		{
+			name:         "系统账户可以为负",
+			balances:     map[string]int64{enum.LedgerAccountOrderClearing: 0, "wallet:1": 0},
+			entries:      []*do.LedgerPostingEntry{testPostingEntry(enum.LedgerAccountOrderClearing, -600), testPostingEntry("wallet:1", 600)},
+			wantBalances: []int64{-600, 600},
+			wantOwnerId:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts := map[string]*model.LedgerAccount{
+				"wallet:1":                      {ID: 1, AccountNo: "wallet:1", Type: enum.LedgerAccountTypeWallet, OwnerId: 1},
+				enum.LedgerAccountOrderClearing: {ID: 2, AccountNo: enum.LedgerAccountOrderClearing, Type: enum.LedgerAccountTypeSystem},
+			}
+			for accountNo, balance := range tt.balances {
+				accounts[accountNo].Balance = balance
+			}
+			entries, ownerId, err := applyPostingEntries(accounts, tt.entries)
+			if err != tt.wantErr {
+				t.Fatalf("applyPostingEntries() err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			balances := make([]int64, 0, len(entries))
+			for _, entry := range entries {
+				balances = append(balances, entry.Balance)
+			}
+			if !reflect.DeepEqual(balances, tt.wantBalances) {
+				t.Errorf("balances = %v, want %v", balances, tt.wantBalances)
+			}
+			if ownerId != tt.wantOwnerId {
+				t.Errorf("ownerId = %d, want %d", ownerId, tt.wantOwnerId)
+			}
+		})
+	}
+}
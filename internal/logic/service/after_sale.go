@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/internal/task"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/Cospk/go-mall/pkg/utils"
+)
+
+type AfterSaleSvc struct {
+	ctx             context.Context
+	afterSaleDomain *domain.AfterSaleDomain
+}
+
+func NewAfterSaleSvc(ctx context.Context) *AfterSaleSvc {
+	return &AfterSaleSvc{
+		ctx:             ctx,
+		afterSaleDomain: domain.NewAfterSaleDomain(ctx),
+	}
+}
+
+// ApplyAfterSale 用户申请售后
+func (svc *AfterSaleSvc) ApplyAfterSale(userId int64, applyRequest *request.AfterSaleApply) (*reply.AfterSale, error) {
+	apply := &do.AfterSale{
+		UserId:      userId,
+		OrderItemId: applyRequest.OrderItemId,
+		Type:        applyRequest.Type,
+		Quantity:    applyRequest.Quantity,
+		Reason:      applyRequest.Reason,
+		Description: applyRequest.Description,
+		Images:      applyRequest.Images,
+	}
+	afterSale, err := svc.afterSaleDomain.ApplyAfterSale(applyRequest.OrderNo, apply)
+	if err != nil {
+		return nil, err
+	}
+	logger.NewLogger(svc.ctx).Info("ApplyAfterSaleSuccess", "afterSaleNo", afterSale.AfterSaleNo, "orderNo", afterSale.OrderNo, "refundAmount", afterSale.RefundAmount)
+	// 商家超时未审核自动同意, 投递失败不影响申请结果, 记日志人工处理
+	if err = task.PushAfterSaleAutoApprove(svc.ctx, afterSale.AfterSaleNo); err != nil {
+		logger.NewLogger(svc.ctx).Error("PushAfterSaleAutoApproveError", "afterSaleNo", afterSale.AfterSaleNo, "err", err)
+	}
+	return svc.afterSaleReply(afterSale), nil
+}
+
+// AfterSaleInfo 售后单详情, 包含处理记录
+func (svc *AfterSaleSvc) AfterSaleInfo(userId int64, afterSaleNo string) (*reply.AfterSale, error) {
+	afterSale, err := svc.afterSaleDomain.GetUserAfterSale(userId, afterSaleNo)
+	if err != nil {
+		return nil, err
+	}
+	return svc.afterSaleDetailReply(afterSale)
+}
+
+// AfterSaleList 用户的售后单列表
+func (svc *AfterSaleSvc) AfterSaleList(userId int64, listRequest *request.AfterSaleList, pageInfo *resp.PageInfo) ([]*reply.AfterSale, error) {
+	return svc.afterSaleList(userId, 0, listRequest, pageInfo)
+}
+
+// CancelAfterSale 用户撤销售后申请
+func (svc *AfterSaleSvc) CancelAfterSale(userId int64, afterSaleNo string) error {
+	afterSale, err := svc.afterSaleDomain.GetUserAfterSale(userId, afterSaleNo)
+	if err != nil {
+		return err
+	}
+	operator := &do.OrderOperator{Type: enum.OrderOperatorUser, Id: userId}
+	if err = svc.afterSaleDomain.Cancel(afterSale, operator); err != nil {
+		return err
+	}
+	task.RemoveAfterSaleTimeouts(svc.ctx, afterSaleNo)
+	return nil
+}
+
+// SubmitReturn 用户填写退货物流, 商家超时未确认收货时自动收货
+func (svc *AfterSaleSvc) SubmitReturn(userId int64, returnRequest *request.AfterSaleReturn) error {
+	afterSale, err := svc.afterSaleDomain.GetUserAfterSale(userId, returnRequest.AfterSaleNo)
+	if err != nil {
+		return err
+	}
+	operator := &do.OrderOperator{Type: enum.OrderOperatorUser, Id: userId}
+	err = svc.afterSaleDomain.SubmitReturn(afterSale, returnRequest.Carrier, returnRequest.TrackingNo, operator)
+	if err != nil {
+		return err
+	}
+	if err = task.PushAfterSaleAutoReceive(svc.ctx, afterSale.AfterSaleNo); err != nil {
+		logger.NewLogger(svc.ctx).Error("PushAfterSaleAutoReceiveError", "afterSaleNo", afterSale.AfterSaleNo, "err", err)
+	}
+	return nil
+}
+
+// AdminAfterSaleList 管理后台售后单列表, 商家后台只能看到自己店铺的售后单, merchantId为0表示平台管理员
+func (svc *AfterSaleSvc) AdminAfterSaleList(merchantId int64, listRequest *request.AfterSaleList, pageInfo *resp.PageInfo) ([]*reply.AfterSale, error) {
+	return svc.afterSaleList(0, merchantId, listRequest, pageInfo)
+}
+
+// AdminAfterSaleInfo 管理后台售后单详情
+func (svc *AfterSaleSvc) AdminAfterSaleInfo(merchantId int64, afterSaleNo string) (*reply.AfterSale, error) {
+	afterSale, err := svc.afterSaleDomain.GetMerchantAfterSale(merchantId, afterSaleNo)
+	if err != nil {
+		return nil, err
+	}
+	return svc.afterSaleDetailReply(afterSale)
+}
+
+// ApproveAfterSale 商家同意售后申请, 仅退款的直接发起退款
+func (svc *AfterSaleSvc) ApproveAfterSale(merchantId, operatorId int64, reviewRequest *request.AfterSaleReview) error {
+	afterSale, err := svc.afterSaleDomain.GetMerchantAfterSale(merchantId, reviewRequest.AfterSaleNo)
+	if err != nil {
+		return err
+	}
+	operator := &do.OrderOperator{Type: enum.OrderOperatorMerchant, Id: operatorId}
+	if err = svc.afterSaleDomain.Approve(afterSale, operator, reviewRequest.Remark); err != nil {
+		return err
+	}
+	task.RemoveAfterSaleTimeouts(svc.ctx, afterSale.AfterSaleNo)
+	svc.pushRefund(afterSale)
+	return nil
+}
+
+// RejectAfterSale 商家驳回售后申请或拒绝收货
+func (svc *AfterSaleSvc) RejectAfterSale(merchantId, operatorId int64, reviewRequest *request.AfterSaleReview) error {
+	afterSale, err := svc.afterSaleDomain.GetMerchantAfterSale(merchantId, reviewRequest.AfterSaleNo)
+	if err != nil {
+		return err
+	}
+	operator := &do.OrderOperator{Type: enum.OrderOperatorMerchant, Id: operatorId}
+	if err = svc.afterSaleDomain.Reject(afterSale, operator, reviewRequest.Remark); err != nil {
+		return err
+	}
+	task.RemoveAfterSaleTimeouts(svc.ctx, afterSale.AfterSaleNo)
+	return nil
+}
+
+// ConfirmReturnReceived 商家确认收到退货并发起退款
+func (svc *AfterSaleSvc) ConfirmReturnReceived(merchantId, operatorId int64, reviewRequest *request.AfterSaleReview) error {
+	afterSale, err := svc.afterSaleDomain.GetMerchantAfterSale(merchantId, reviewRequest.AfterSaleNo)
+	if err != nil {
+		return err
+	}
+	operator := &do.OrderOperator{Type: enum.OrderOperatorMerchant, Id: operatorId}
+	if err = svc.afterSaleDomain.ConfirmReturnReceived(afterSale, operator, reviewRequest.Remark); err != nil {
+		return err
+	}
+	task.RemoveAfterSaleTimeouts(svc.ctx, afterSale.AfterSaleNo)
+	svc.pushRefund(afterSale)
+	return nil
+}
+
+// pushRefund 售后单进入退款中后投递退款任务, 由后台任务调用支付渠道退款和失败重试
+func (svc *AfterSaleSvc) pushRefund(afterSale *do.AfterSale) {
+	if afterSale.State != enum.AfterSaleStateRefunding {
+		return
+	}
+	if err := task.PushAfterSaleRefund(svc.ctx, afterSale.AfterSaleNo); err != nil {
+		logger.NewLogger(svc.ctx).Error("PushAfterSaleRefundError", "afterSaleNo", afterSale.AfterSaleNo, "err", err)
+	}
+}
+
+func (svc *AfterSaleSvc) afterSaleList(userId, merchantId int64, listRequest *request.AfterSaleList, pageInfo *resp.PageInfo) ([]*reply.AfterSale, error) {
+	afterSales, total, err := svc.afterSaleDomain.GetAfterSales(userId, merchantId, listRequest.State, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.AfterSale, 0, len(afterSales))
+	for _, afterSale := range afterSales {
+		replies = append(replies, svc.afterSaleReply(afterSale))
+	}
+	return replies, nil
+}
+
+func (svc *AfterSaleSvc) afterSaleDetailReply(afterSale *do.AfterSale) (*reply.AfterSale, error) {
+	logs, err := svc.afterSaleDomain.GetAfterSaleLogs(afterSale.ID)
+	if err != nil {
+		return nil, err
+	}
+	afterSaleReply := svc.afterSaleReply(afterSale)
+	for _, afterSaleLog := range logs {
+		logReply := new(reply.AfterSaleLog)
+		_ = utils.CopyStruct(logReply, afterSaleLog)
+		logReply.ToStateName = enum.AfterSaleStateToName(afterSaleLog.ToState)
+		afterSaleReply.Logs = append(afterSaleReply.Logs, logReply)
+	}
+	return afterSaleReply, nil
+}
+
+func (svc *AfterSaleSvc) afterSaleReply(afterSale *do.AfterSale) *reply.AfterSale {
+	afterSaleReply := new(reply.AfterSale)
+	_ = utils.CopyStruct(afterSaleReply, afterSale)
+	afterSaleReply.StateName = enum.AfterSaleStateToName(afterSale.State)
+	return afterSaleReply
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"time"
+)
+
+type CouponSvc struct {
+	ctx          context.Context
+	couponDomain *domain.CouponDomain
+	orderDomain  *domain.OrderDomain
+}
+
+func NewCouponSvc(ctx context.Context) *CouponSvc {
+	return &CouponSvc{
+		ctx:          ctx,
+		couponDomain: domain.NewCouponDomain(ctx),
+		orderDomain:  domain.NewOrderDomain(ctx),
+	}
+}
+
+// CreateTemplate 后台创建优惠券模板
+func (svc *CouponSvc) CreateTemplate(templateRequest *request.CouponTemplateCreate) (*reply.CouponTemplate, error) {
+	template := new(do.CouponTemplate)
+	if err := utils.CopyStruct(template, templateRequest); err != nil {
+		return nil, errcode.Wrap("请求转换成领域对象失败", err)
+	}
+	if err := svc.couponDomain.CreateTemplate(template); err != nil {
+		return nil, err
+	}
+	logger.NewLogger(svc.ctx).Info("CreateCouponTemplate", "templateId", template.ID, "name", template.Name)
+	return svc.templateReply(template), nil
+}
+
+// TemplateList 后台优惠券模板列表
+func (svc *CouponSvc) TemplateList(pageInfo *resp.PageInfo) ([]*reply.CouponTemplate, error) {
+	templates, total, err := svc.couponDomain.GetTemplates(pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	return svc.templateReplies(templates), nil
+}
+
+// ClaimableList 当前可以领取的优惠券
+func (svc *CouponSvc) ClaimableList() ([]*reply.CouponTemplate, error) {
+	templates, err := svc.couponDomain.GetClaimableTemplates()
+	if err != nil {
+		return nil, err
+	}
+	return svc.templateReplies(templates), nil
+}
+
+// ClaimCoupon 用户领取优惠券
+func (svc *CouponSvc) ClaimCoupon(userId, templateId int64) (*reply.UserCoupon, error) {
+	coupon, err := svc.couponDomain.ClaimCoupon(userId, templateId)
+	if err != nil {
+		return nil, err
+	}
+	return svc.userCouponReply(coupon), nil
+}
+
+// IssueCoupons 后台给用户发放优惠券, 部分用户失败不影响其他用户
+func (svc *CouponSvc) IssueCoupons(issueRequest *request.CouponIssue) *reply.CouponIssue {
+	coupons, failures := svc.couponDomain.IssueCoupons(issueRequest.TemplateId, issueRequest.UserIds)
+	issueReply := &reply.CouponIssue{Issued: len(coupons), Failures: make([]*reply.CouponIssueFailure, 0, len(failures))}
+	for _, userId := range issueRequest.UserIds {
+		err, ok := failures[userId]
+		if !ok {
+			continue
+		}
+		reason := err.Error()
+		if appErr, ok := err.(*errcode.AppError); ok {
+			reason = appErr.Msg()
+		}
+		issueReply.Failures = append(issueReply.Failures, &reply.CouponIssueFailure{UserId: userId, Reason: reason})
+	}
+	logger.NewLogger(svc.ctx).Info("IssueCoupons", "templateId", issueRequest.TemplateId, "issued", issueReply.Issued, "failed", len(issueReply.Failures))
+	return issueReply
+}
+
+// UserCouponList 我的优惠券
+func (svc *CouponSvc) UserCouponList(userId int64, listRequest *request.UserCouponList, pageInfo *resp.PageInfo) ([]*reply.UserCoupon, error) {
+	coupons, total, err := svc.couponDomain.GetUserCoupons(userId, listRequest.State, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.UserCoupon, 0, len(coupons))
+	for _, coupon := range coupons {
+		replies = append(replies, svc.userCouponReply(coupon))
+	}
+	return replies, nil
+}
+
+// Checkout 结算页展示可用的优惠券和系统推荐的最优组合, 下单时传best_coupons使用同样的组合
+func (svc *CouponSvc) Checkout(userId int64, checkoutRequest *request.CouponCheckout) (*reply.CouponCheckout, error) {
+	items := make([]*do.OrderItem, 0, len(checkoutRequest.Items))
+	for _, item := range checkoutRequest.Items {
+		items = append(items, &do.OrderItem{SkuId: item.SkuId, Quantity: item.Quantity})
+	}
+	items, err := svc.orderDomain.FillOrderItems(items)
+	if err != nil {
+		return nil, err
+	}
+	usable, err := svc.couponDomain.GetUsableCoupons(userId)
+	if err != nil {
+		return nil, err
+	}
+	lines := domain.OrderCouponLines(items)
+	checkoutReply := &reply.CouponCheckout{Coupons: make([]*reply.CheckoutCoupon, 0), BestCouponIds: make([]int64, 0)}
+	for _, line := range lines {
+		checkoutReply.BillMoney += line.Amount
+	}
+	applicable, discounts := domain.ApplicableCoupons(lines, usable)
+	for i, coupon := range applicable {
+		checkoutReply.Coupons = append(checkoutReply.Coupons, &reply.CheckoutCoupon{
+			UserCoupon:     svc.userCouponReply(coupon),
+			DiscountAmount: discounts[i],
+		})
+	}
+	best := domain.BestCouponPlan(lines, usable)
+	for _, coupon := range best.Coupons {
+		checkoutReply.BestCouponIds = append(checkoutReply.BestCouponIds, coupon.ID)
+	}
+	checkoutReply.BestDiscount = best.Discount
+	checkoutReply.PayMoney = checkoutReply.BillMoney - best.Discount
+	return checkoutReply, nil
+}
+
+func (svc *CouponSvc) templateReplies(templates []*do.CouponTemplate) []*reply.CouponTemplate {
+	replies := make([]*reply.CouponTemplate, 0, len(templates))
+	for _, template := range templates {
+		replies = append(replies, svc.templateReply(template))
+	}
+	return replies
+}
+
+func (svc *CouponSvc) templateReply(template *do.CouponTemplate) *reply.CouponTemplate {
+	templateReply := new(reply.CouponTemplate)
+	_ = utils.CopyStruct(templateReply, template)
+	return templateReply
+}
+
+func (svc *CouponSvc) userCouponReply(coupon *do.UserCoupon) *reply.UserCoupon {
+	couponReply := new(reply.UserCoupon)
+	_ = utils.CopyStruct(couponReply, coupon.Template)
+	_ = utils.CopyStruct(couponReply, coupon)
+	couponReply.Expired = !time.Now().Before(coupon.ValidEndAt)
+	return couponReply
+}
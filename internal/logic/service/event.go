@@ -0,0 +1,24 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/eventbus"
+)
+
+type EventSvc struct {
+	ctx         context.Context
+	eventDomain *domain.EventDomain
+}
+
+func NewEventSvc(ctx context.Context) *EventSvc {
+	return &EventSvc{
+		ctx:         ctx,
+		eventDomain: domain.NewEventDomain(ctx),
+	}
+}
+
+// Subscribe 订阅用户的订单状态变化和新消息, 返回lastEventId之后错过的事件
+func (svc *EventSvc) Subscribe(userId int64, lastEventId string) (*eventbus.Subscription, []*eventbus.Event, error) {
+	return svc.eventDomain.Subscribe(userId, lastEventId)
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/Cospk/go-mall/pkg/utils"
+)
+
+type FavoriteSvc struct {
+	ctx            context.Context
+	favoriteDomain *domain.FavoriteDomain
+}
+
+func NewFavoriteSvc(ctx context.Context) *FavoriteSvc {
+	return &FavoriteSvc{
+		ctx:            ctx,
+		favoriteDomain: domain.NewFavoriteDomain(ctx),
+	}
+}
+
+// AddFavorite 收藏商品
+func (svc *FavoriteSvc) AddFavorite(userId int64, favoriteRequest *request.FavoriteGoods) error {
+	return svc.favoriteDomain.AddFavorite(userId, favoriteRequest.GoodsId)
+}
+
+// RemoveFavorite 取消收藏
+func (svc *FavoriteSvc) RemoveFavorite(userId int64, favoriteRequest *request.FavoriteGoods) error {
+	return svc.favoriteDomain.RemoveFavorite(userId, favoriteRequest.GoodsId)
+}
+
+// FavoriteList 我的收藏夹
+func (svc *FavoriteSvc) FavoriteList(userId int64, pageInfo *resp.PageInfo) ([]*reply.Favorite, error) {
+	favorites, total, err := svc.favoriteDomain.GetFavorites(userId, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.Favorite, 0, len(favorites))
+	for _, favorite := range favorites {
+		favoriteReply := new(reply.Favorite)
+		_ = utils.CopyStruct(favoriteReply, favorite)
+		replies = append(replies, favoriteReply)
+	}
+	return replies, nil
+}
+
+// Subscribe 订阅到货或降价提醒
+func (svc *FavoriteSvc) Subscribe(userId int64, subscribeRequest *request.SubscriptionCreate) (*reply.GoodsSubscription, error) {
+	subscription, err := svc.favoriteDomain.Subscribe(userId, subscribeRequest.SkuId, subscribeRequest.Type, subscribeRequest.TargetPrice)
+	if err != nil {
+		return nil, err
+	}
+	subscriptionReply := new(reply.GoodsSubscription)
+	_ = utils.CopyStruct(subscriptionReply, subscription)
+	return subscriptionReply, nil
+}
+
+// CancelSubscription 退订提醒
+func (svc *FavoriteSvc) CancelSubscription(userId int64, cancelRequest *request.SubscriptionCancel) error {
+	return svc.favoriteDomain.CancelSubscription(userId, cancelRequest.SubscriptionId)
+}
+
+// Unsubscribe 通过退订链接退订
+func (svc *FavoriteSvc) Unsubscribe(unsubscribeRequest *request.Unsubscribe) error {
+	return svc.favoriteDomain.Unsubscribe(unsubscribeRequest.Token)
+}
+
+// SubscriptionList 我订阅中的提醒
+func (svc *FavoriteSvc) SubscriptionList(userId int64, pageInfo *resp.PageInfo) ([]*reply.GoodsSubscription, error) {
+	subscriptions, total, err := svc.favoriteDomain.GetSubscriptions(userId, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.GoodsSubscription, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscriptionReply := new(reply.GoodsSubscription)
+		_ = utils.CopyStruct(subscriptionReply, subscription)
+		replies = append(replies, subscriptionReply)
+	}
+	return replies, nil
+}
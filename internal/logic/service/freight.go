@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/Cospk/go-mall/pkg/utils"
+)
+
+type FreightSvc struct {
+	ctx           context.Context
+	freightDomain *domain.FreightDomain
+}
+
+func NewFreightSvc(ctx context.Context) *FreightSvc {
+	return &FreightSvc{
+		ctx:           ctx,
+		freightDomain: domain.NewFreightDomain(ctx),
+	}
+}
+
+// CreateTemplate 后台创建运费模板
+func (svc *FreightSvc) CreateTemplate(templateRequest *request.FreightTemplateCreate) (*reply.FreightTemplate, error) {
+	template := new(do.FreightTemplate)
+	if err := utils.CopyStruct(template, templateRequest); err != nil {
+		return nil, errcode.Wrap("请求转换成领域对象失败", err)
+	}
+	template.Rules = make([]*do.FreightRegionRule, 0, len(templateRequest.Rules))
+	for _, ruleRequest := range templateRequest.Rules {
+		rule := new(do.FreightRegionRule)
+		_ = utils.CopyStruct(rule, ruleRequest)
+		rule.Regions = ruleRequest.Regions
+		template.Rules = append(template.Rules, rule)
+	}
+	if err := svc.freightDomain.CreateTemplate(template); err != nil {
+		return nil, err
+	}
+	logger.NewLogger(svc.ctx).Info("CreateFreightTemplate", "templateId", template.ID, "name", template.Name)
+	return svc.templateReply(template), nil
+}
+
+// TemplateList 后台运费模板列表
+func (svc *FreightSvc) TemplateList(pageInfo *resp.PageInfo) ([]*reply.FreightTemplate, error) {
+	templates, total, err := svc.freightDomain.GetTemplates(pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.FreightTemplate, 0, len(templates))
+	for _, template := range templates {
+		replies = append(replies, svc.templateReply(template))
+	}
+	return replies, nil
+}
+
+// BindGoods 设置商品的运费模板
+func (svc *FreightSvc) BindGoods(bindRequest *request.FreightTemplateBindGoods) error {
+	return svc.freightDomain.BindGoods(bindRequest.GoodsIds, bindRequest.TemplateId)
+}
+
+func (svc *FreightSvc) templateReply(template *do.FreightTemplate) *reply.FreightTemplate {
+	templateReply := new(reply.FreightTemplate)
+	_ = utils.CopyStruct(templateReply, template)
+	templateReply.Rules = make([]*reply.FreightRegionRule, 0, len(template.Rules))
+	for _, rule := range template.Rules {
+		ruleReply := new(reply.FreightRegionRule)
+		_ = utils.CopyStruct(ruleReply, rule)
+		ruleReply.Regions = rule.Regions
+		templateReply.Rules = append(templateReply.Rules, ruleReply)
+	}
+	return templateReply
+}
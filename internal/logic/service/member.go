@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/Cospk/go-mall/pkg/utils"
+)
+
+type MemberSvc struct {
+	ctx          context.Context
+	memberDomain *domain.MemberDomain
+}
+
+func NewMemberSvc(ctx context.Context) *MemberSvc {
+	return &MemberSvc{
+		ctx:          ctx,
+		memberDomain: domain.NewMemberDomain(ctx),
+	}
+}
+
+// MemberInfo 用户的积分余额、成长值和会员等级
+func (svc *MemberSvc) MemberInfo(userId int64) (*reply.Member, error) {
+	member, err := svc.memberDomain.GetMember(userId)
+	if err != nil {
+		return nil, err
+	}
+	memberReply := new(reply.Member)
+	_ = utils.CopyStruct(memberReply, member)
+	if member.NextTier != nil {
+		memberReply.GrowthToNext = member.NextTier.MinGrowth - member.GrowthValue
+	}
+	return memberReply, nil
+}
+
+// MemberTiers 会员等级及权益
+func (svc *MemberSvc) MemberTiers() ([]*reply.MemberTier, error) {
+	tiers, err := svc.memberDomain.GetTiers()
+	if err != nil {
+		return nil, err
+	}
+	replies := make([]*reply.MemberTier, 0, len(tiers))
+	for _, tier := range tiers {
+		tierReply := new(reply.MemberTier)
+		_ = utils.CopyStruct(tierReply, tier)
+		replies = append(replies, tierReply)
+	}
+	return replies, nil
+}
+
+// PointsLedgers 用户的积分流水
+func (svc *MemberSvc) PointsLedgers(userId int64, pageInfo *resp.PageInfo) ([]*reply.PointsLedger, error) {
+	ledgers, total, err := svc.memberDomain.GetLedgers(userId, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.PointsLedger, 0, len(ledgers))
+	for _, ledger := range ledgers {
+		ledgerReply := new(reply.PointsLedger)
+		_ = utils.CopyStruct(ledgerReply, ledger)
+		ledgerReply.TypeName = enum.PointsTypeToName(ledger.Type)
+		if ledger.Points <= 0 {
+			ledgerReply.ExpireAt = ""
+		}
+		replies = append(replies, ledgerReply)
+	}
+	return replies, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/internal/task"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/Cospk/go-mall/pkg/utils"
+)
+
+type MerchantSvc struct {
+	ctx            context.Context
+	merchantDomain *domain.MerchantDomain
+}
+
+func NewMerchantSvc(ctx context.Context) *MerchantSvc {
+	return &MerchantSvc{
+		ctx:            ctx,
+		merchantDomain: domain.NewMerchantDomain(ctx),
+	}
+}
+
+// CreateMerchant 管理员为用户开通店铺
+func (svc *MerchantSvc) CreateMerchant(adminId int64, createRequest *request.MerchantCreate) (*reply.Merchant, error) {
+	merchantDo := new(do.Merchant)
+	_ = utils.CopyStruct(merchantDo, createRequest)
+	merchant, err := svc.merchantDomain.CreateMerchant(merchantDo)
+	if err != nil {
+		return nil, err
+	}
+	logger.NewLogger(svc.ctx).Info("CreateMerchantSuccess", "merchantId", merchant.ID, "adminId", adminId)
+	return svc.merchantReply(merchant), nil
+}
+
+// MerchantList 后台店铺列表
+func (svc *MerchantSvc) MerchantList(listRequest *request.MerchantList, pageInfo *resp.PageInfo) ([]*reply.Merchant, error) {
+	merchants, total, err := svc.merchantDomain.GetMerchants(listRequest.State, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.Merchant, 0, len(merchants))
+	for _, merchant := range merchants {
+		replies = append(replies, svc.merchantReply(merchant))
+	}
+	return replies, nil
+}
+
+// SetMerchantState 管理员开启或关闭店铺
+func (svc *MerchantSvc) SetMerchantState(adminId int64, stateRequest *request.MerchantState) error {
+	if err := svc.merchantDomain.SetMerchantState(stateRequest.MerchantId, stateRequest.State); err != nil {
+		return err
+	}
+	logger.NewLogger(svc.ctx).Info("SetMerchantStateSuccess", "merchantId", stateRequest.MerchantId, "state", stateRequest.State, "adminId", adminId)
+	return nil
+}
+
+// OwnerMerchantId 查询用户名下营业中的店铺ID, 商家后台鉴权使用
+func (svc *MerchantSvc) OwnerMerchantId(userId int64) (int64, error) {
+	merchant, err := svc.merchantDomain.GetOwnerMerchant(userId)
+	if err != nil {
+		return 0, err
+	}
+	return merchant.ID, nil
+}
+
+// MerchantInfo 店铺信息
+func (svc *MerchantSvc) MerchantInfo(merchantId int64) (*reply.Merchant, error) {
+	merchant, err := svc.merchantDomain.GetMerchant(merchantId)
+	if err != nil {
+		return nil, err
+	}
+	return svc.merchantReply(merchant), nil
+}
+
+// MerchantGoodsList 店铺商品列表
+func (svc *MerchantSvc) MerchantGoodsList(merchantId int64, listRequest *request.MerchantGoodsList, pageInfo *resp.PageInfo) ([]*reply.MerchantGoods, error) {
+	state := -1
+	if listRequest.State != nil {
+		state = *listRequest.State
+	}
+	goods, total, err := svc.merchantDomain.GetMerchantGoods(merchantId, state, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.MerchantGoods, 0, len(goods))
+	for _, g := range goods {
+		goodsReply := new(reply.MerchantGoods)
+		_ = utils.CopyStruct(goodsReply, g)
+		replies = append(replies, goodsReply)
+	}
+	return replies, nil
+}
+
+// SetGoodsState 店铺批量上下架商品
+func (svc *MerchantSvc) SetGoodsState(merchantId, operatorId int64, stateRequest *request.MerchantGoodsState) error {
+	if err := svc.merchantDomain.SetGoodsState(merchantId, stateRequest.GoodsIds, *stateRequest.State); err != nil {
+		return err
+	}
+	logger.NewLogger(svc.ctx).Info("SetMerchantGoodsStateSuccess", "merchantId", merchantId, "goodsIds", stateRequest.GoodsIds, "operatorId", operatorId)
+	return nil
+}
+
+// UpdateSku 修改SKU的售价或库存, merchantId为0时是平台管理员; 修改后检查到货和降价订阅
+func (svc *MerchantSvc) UpdateSku(merchantId, operatorId int64, updateRequest *request.GoodsSkuUpdate) error {
+	if err := svc.merchantDomain.UpdateSku(merchantId, updateRequest.SkuId, updateRequest.Price, updateRequest.Stock); err != nil {
+		return err
+	}
+	logger.NewLogger(svc.ctx).Info("UpdateGoodsSkuSuccess", "merchantId", merchantId, "skuId", updateRequest.SkuId, "operatorId", operatorId)
+	task.PushSkuChanged(svc.ctx, updateRequest.SkuId)
+	return nil
+}
+
+func (svc *MerchantSvc) merchantReply(merchant *do.Merchant) *reply.Merchant {
+	merchantReply := new(reply.Merchant)
+	_ = utils.CopyStruct(merchantReply, merchant)
+	return merchantReply
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/Cospk/go-mall/pkg/utils"
+)
+
+type NotificationSvc struct {
+	ctx                context.Context
+	notificationDomain *domain.NotificationDomain
+}
+
+func NewNotificationSvc(ctx context.Context) *NotificationSvc {
+	return &NotificationSvc{
+		ctx:                ctx,
+		notificationDomain: domain.NewNotificationDomain(ctx),
+	}
+}
+
+// NotificationList 我的站内消息
+func (svc *NotificationSvc) NotificationList(userId int64, listRequest *request.NotificationList, pageInfo *resp.PageInfo) ([]*reply.NotificationMessage, error) {
+	messages, total, err := svc.notificationDomain.GetMessages(userId, listRequest.Type, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.NotificationMessage, 0, len(messages))
+	for _, message := range messages {
+		messageReply := new(reply.NotificationMessage)
+		_ = utils.CopyStruct(messageReply, message)
+		messageReply.TypeName = enum.NotificationTypeToName(message.Type)
+		if !message.IsRead {
+			messageReply.ReadAt = ""
+		}
+		replies = append(replies, messageReply)
+	}
+	return replies, nil
+}
+
+// MarkRead 把指定消息标记为已读
+func (svc *NotificationSvc) MarkRead(userId int64, readRequest *request.NotificationRead) (*reply.NotificationReadResult, error) {
+	updated, err := svc.notificationDomain.MarkRead(userId, readRequest.Ids)
+	if err != nil {
+		return nil, err
+	}
+	return &reply.NotificationReadResult{Updated: updated}, nil
+}
+
+// MarkAllRead 全部标记为已读
+func (svc *NotificationSvc) MarkAllRead(userId int64, readRequest *request.NotificationReadAll) (*reply.NotificationReadResult, error) {
+	updated, err := svc.notificationDomain.MarkAllRead(userId, readRequest.Type)
+	if err != nil {
+		return nil, err
+	}
+	return &reply.NotificationReadResult{Updated: updated}, nil
+}
+
+// UnreadCount 各类型的未读消息数
+func (svc *NotificationSvc) UnreadCount(userId int64) (*reply.NotificationUnread, error) {
+	unread, err := svc.notificationDomain.GetUnreadCounts(userId)
+	if err != nil {
+		return nil, err
+	}
+	return &reply.NotificationUnread{
+		Total:     unread.Total,
+		System:    unread.ByType[enum.NotificationTypeSystem],
+		Order:     unread.ByType[enum.NotificationTypeOrder],
+		Promotion: unread.ByType[enum.NotificationTypePromotion],
+	}, nil
+}
+
+// Broadcast 管理员发布全体用户的广播消息
+func (svc *NotificationSvc) Broadcast(adminId int64, broadcastRequest *request.NotificationBroadcast) (*reply.NotificationBroadcast, error) {
+	broadcast, err := svc.notificationDomain.Broadcast(adminId, broadcastRequest.Type, broadcastRequest.Title, broadcastRequest.Content, broadcastRequest.Link)
+	if err != nil {
+		return nil, err
+	}
+	logger.NewLogger(svc.ctx).Info("NotificationBroadcastSuccess", "broadcastId", broadcast.ID, "adminId", adminId)
+	broadcastReply := new(reply.NotificationBroadcast)
+	_ = utils.CopyStruct(broadcastReply, broadcast)
+	return broadcastReply, nil
+}
+
+// SendToUsers 管理员给指定用户发站内消息
+func (svc *NotificationSvc) SendToUsers(adminId int64, sendRequest *request.NotificationSend) *reply.NotificationSendResult {
+	sent := svc.notificationDomain.SendToUsers(sendRequest.UserIds, &do.Notification{
+		Type:    sendRequest.Type,
+		Title:   sendRequest.Title,
+		Content: sendRequest.Content,
+		Link:    sendRequest.Link,
+	})
+	logger.NewLogger(svc.ctx).Info("NotificationSendSuccess", "users", len(sendRequest.UserIds), "sent", sent, "adminId", adminId)
+	return &reply.NotificationSendResult{Sent: sent}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/internal/task"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/Cospk/go-mall/pkg/utils"
+)
+
+type OrderSvc struct {
+	ctx         context.Context
+	orderDomain *domain.OrderDomain
+}
+
+func NewOrderSvc(ctx context.Context) *OrderSvc {
+	return &OrderSvc{
+		ctx:         ctx,
+		orderDomain: domain.NewOrderDomain(ctx),
+	}
+}
+
+// CreateOrder 创建订单, 商品分属多个店铺时按店铺拆成多个子订单
+func (svc *OrderSvc) CreateOrder(userId int64, orderRequest *request.OrderCreate) (*reply.OrderCreate, error) {
+	items := make([]*do.OrderItem, 0, len(orderRequest.Items))
+	for _, item := range orderRequest.Items {
+		items = append(items, &do.OrderItem{SkuId: item.SkuId, Quantity: item.Quantity})
+	}
+	coupons := &do.CouponSelection{UserCouponIds: orderRequest.CouponIds, Best: orderRequest.BestCoupons}
+	orders, err := svc.orderDomain.CreateOrder(userId, items, shippingAddress(orderRequest.Address), coupons, orderRequest.Points, orderRequest.Remark)
+	if err != nil {
+		return nil, err
+	}
+	createReply := &reply.OrderCreate{
+		ParentOrderNo: orders[0].ParentOrderNo,
+		Orders:        make([]*reply.Order, 0, len(orders)),
+	}
+	for _, order := range orders {
+		logger.NewLogger(svc.ctx).Info("CreateOrderSuccess", "orderNo", order.OrderNo, "parentOrderNo", order.ParentOrderNo,
+			"merchantId", order.MerchantId, "userId", userId, "billMoney", order.BillMoney)
+		// 超时未支付自动取消, 投递失败不影响下单结果, 记日志发告警人工处理
+		if err = task.PushOrderAutoCancel(svc.ctx, order.OrderNo); err != nil {
+			logger.NewLogger(svc.ctx).Error("PushOrderAutoCancelError", "orderNo", order.OrderNo, "err", err)
+		}
+		createReply.PayMoney += order.PayMoney
+		createReply.Orders = append(createReply.Orders, svc.orderReply(order))
+	}
+
+	return createReply, nil
+}
+
+// Checkout 结算页试算, 返回逐行的优惠、税费分摊和运费, 下单时传同样的参数得到同样的金额
+func (svc *OrderSvc) Checkout(userId int64, checkoutRequest *request.OrderCheckout) (*reply.OrderCheckout, error) {
+	items := make([]*do.OrderItem, 0, len(checkoutRequest.Items))
+	for _, item := range checkoutRequest.Items {
+		items = append(items, &do.OrderItem{SkuId: item.SkuId, Quantity: item.Quantity})
+	}
+	coupons := &do.CouponSelection{UserCouponIds: checkoutRequest.CouponIds, Best: checkoutRequest.BestCoupons}
+	breakdown, err := svc.orderDomain.PreviewOrder(userId, items, shippingAddress(checkoutRequest.Address), coupons, checkoutRequest.Points)
+	if err != nil {
+		return nil, err
+	}
+	checkoutReply := new(reply.OrderCheckout)
+	_ = utils.CopyStruct(checkoutReply, breakdown)
+	// 列表字段逐个转换, 不依赖 CopyStruct 对嵌套切片的处理
+	checkoutReply.Lines = make([]*reply.CheckoutLine, 0, len(breakdown.Lines))
+	checkoutReply.Shops = make([]*reply.CheckoutShop, 0, len(breakdown.Shops))
+	checkoutReply.Promotions = make([]*reply.CheckoutPromotion, 0, len(breakdown.Promotions))
+	checkoutReply.CouponIds = make([]int64, 0, len(breakdown.CouponPlan.Coupons))
+	for _, line := range breakdown.Lines {
+		lineReply := new(reply.CheckoutLine)
+		_ = utils.CopyStruct(lineReply, line)
+		checkoutReply.Lines = append(checkoutReply.Lines, lineReply)
+	}
+	for _, shop := range breakdown.Shops {
+		shopReply := new(reply.CheckoutShop)
+		_ = utils.CopyStruct(shopReply, shop)
+		checkoutReply.Shops = append(checkoutReply.Shops, shopReply)
+	}
+	for _, promotion := range breakdown.Promotions {
+		checkoutReply.Promotions = append(checkoutReply.Promotions, &reply.CheckoutPromotion{Name: promotion.Name, Discount: promotion.Discount})
+	}
+	for _, coupon := range breakdown.CouponPlan.Coupons {
+		checkoutReply.CouponIds = append(checkoutReply.CouponIds, coupon.ID)
+	}
+	return checkoutReply, nil
+}
+
+// OrderInfo 订单详情
+func (svc *OrderSvc) OrderInfo(userId int64, orderNo string) (*reply.Order, error) {
+	order, err := svc.orderDomain.GetUserOrder(userId, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	return svc.orderReply(order), nil
+}
+
+// OrderList 用户订单列表
+func (svc *OrderSvc) OrderList(userId int64, listRequest *request.OrderList, pageInfo *resp.PageInfo) ([]*reply.Order, error) {
+	orders, total, err := svc.orderDomain.GetUserOrders(userId, listRequest.State, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.Order, 0, len(orders))
+	for _, order := range orders {
+		replies = append(replies, svc.orderReply(order))
+	}
+	return replies, nil
+}
+
+// CancelOrder 用户取消订单
+func (svc *OrderSvc) CancelOrder(userId int64, cancelRequest *request.OrderCancel) error {
+	order, err := svc.orderDomain.GetUserOrder(userId, cancelRequest.OrderNo)
+	if err != nil {
+		return err
+	}
+	reason := cancelRequest.Reason
+	if reason == "" {
+		reason = "用户取消订单"
+	}
+	operator := &do.OrderOperator{Type: enum.OrderOperatorUser, Id: userId}
+	// 同一父订单下未支付的子订单共用一笔支付, 需要一起取消
+	cancelled, err := svc.orderDomain.CancelOrder(order, operator, reason)
+	if err != nil {
+		return err
+	}
+	task.PushOrdersSkuChanged(svc.ctx, cancelled)
+	for _, cancelledOrder := range cancelled {
+		if err = task.RemoveOrderAutoCancel(svc.ctx, cancelledOrder.OrderNo); err != nil {
+			logger.NewLogger(svc.ctx).Warn("RemoveOrderAutoCancelError", "orderNo", cancelledOrder.OrderNo, "err", err)
+		}
+	}
+	return nil
+}
+
+// AdminOrderList 后台订单列表, merchantId 为 0 时是平台管理员查看全部订单, 否则只看本店铺的订单
+func (svc *OrderSvc) AdminOrderList(merchantId int64, listRequest *request.OrderList, pageInfo *resp.PageInfo) ([]*reply.Order, error) {
+	orders, total, err := svc.orderDomain.GetOrders(merchantId, listRequest.State, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.Order, 0, len(orders))
+	for _, order := range orders {
+		replies = append(replies, svc.orderReply(order))
+	}
+	return replies, nil
+}
+
+// AdminOrderInfo 后台订单详情
+func (svc *OrderSvc) AdminOrderInfo(merchantId int64, orderNo string) (*reply.Order, error) {
+	order, err := svc.orderDomain.GetMerchantOrder(merchantId, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	return svc.orderReply(order), nil
+}
+
+// ConfirmReceipt 用户确认收货
+func (svc *OrderSvc) ConfirmReceipt(userId int64, orderNo string) error {
+	order, err := svc.orderDomain.GetUserOrder(userId, orderNo)
+	if err != nil {
+		return err
+	}
+	operator := &do.OrderOperator{Type: enum.OrderOperatorUser, Id: userId}
+	return svc.orderDomain.ConfirmReceipt(order, operator)
+}
+
+// OrderStateLogs 订单状态流转记录
+func (svc *OrderSvc) OrderStateLogs(userId int64, orderNo string) ([]*reply.OrderStateLog, error) {
+	order, err := svc.orderDomain.GetUserOrder(userId, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := svc.orderDomain.GetOrderStateLogs(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	replies := make([]*reply.OrderStateLog, 0, len(logs))
+	for _, stateLog := range logs {
+		logReply := new(reply.OrderStateLog)
+		_ = utils.CopyStruct(logReply, stateLog)
+		logReply.ToStateName = enum.OrderStateToName(stateLog.ToState)
+		replies = append(replies, logReply)
+	}
+	return replies, nil
+}
+
+func (svc *OrderSvc) orderReply(order *do.Order) *reply.Order {
+	orderReply := new(reply.Order)
+	_ = utils.CopyStruct(orderReply, order)
+	orderReply.StateName = enum.OrderStateToName(order.State)
+	return orderReply
+}
+
+// shippingAddress 把请求中的收货地址转换成领域对象, 未传地址时返回nil
+func shippingAddress(addressRequest *request.ShippingAddress) *do.ShippingAddress {
+	if addressRequest == nil {
+		return nil
+	}
+	address := new(do.ShippingAddress)
+	_ = utils.CopyStruct(address, addressRequest)
+	return address
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/internal/task"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"net/http"
+)
+
+type PaymentSvc struct {
+	ctx                context.Context
+	orderDomain        *domain.OrderDomain
+	paymentDomain      *domain.PaymentDomain
+	notificationDomain *domain.NotificationDomain
+}
+
+func NewPaymentSvc(ctx context.Context) *PaymentSvc {
+	return &PaymentSvc{
+		ctx:                ctx,
+		orderDomain:        domain.NewOrderDomain(ctx),
+		paymentDomain:      domain.NewPaymentDomain(ctx),
+		notificationDomain: domain.NewNotificationDomain(ctx),
+	}
+}
+
+// CreatePayment 用户发起支付, 一次支付同一父订单下所有待支付的子订单
+func (svc *PaymentSvc) CreatePayment(userId int64, paymentRequest *request.PaymentCreate) (*reply.Payment, error) {
+	order, err := svc.orderDomain.GetUserOrder(userId, paymentRequest.OrderNo)
+	if err != nil {
+		return nil, err
+	}
+	paymentDo, err := svc.paymentDomain.CreatePayment(order, paymentRequest.Provider)
+	if err != nil {
+		return nil, err
+	}
+	// 钱包余额支付在创建支付单时就已经支付成功
+	svc.afterPaid(paymentDo)
+	return svc.paymentReply(paymentDo), nil
+}
+
+// PaymentInfo 查询支付结果, 支付单还是待支付时主动向渠道查询一次
+func (svc *PaymentSvc) PaymentInfo(userId int64, paymentNo string) (*reply.Payment, error) {
+	paymentDo, err := svc.paymentDomain.GetPayment(paymentNo)
+	if err != nil {
+		return nil, err
+	}
+	if paymentDo.UserId != userId {
+		return nil, errcode.ErrPaymentNotFound
+	}
+	if paymentDo.State == enum.PaymentStateCreated {
+		if err = svc.paymentDomain.SyncPayment(paymentDo); err != nil {
+			// 查询渠道失败不影响返回本地的支付状态
+			logger.NewLogger(svc.ctx).Warn("SyncPaymentError", "paymentNo", paymentNo, "err", err)
+		} else {
+			svc.afterPaid(paymentDo)
+		}
+	}
+	return svc.paymentReply(paymentDo), nil
+}
+
+// HandleNotification 处理支付渠道的异步通知
+func (svc *PaymentSvc) HandleNotification(provider string, header http.Header, body []byte) error {
+	paymentDo, err := svc.paymentDomain.HandleNotification(provider, header, body)
+	if err != nil {
+		return err
+	}
+	svc.afterPaid(paymentDo)
+	return nil
+}
+
+// afterPaid 支付成功后的外围逻辑
+func (svc *PaymentSvc) afterPaid(paymentDo *do.Payment) {
+	if paymentDo.State != enum.PaymentStateSuccess {
+		return
+	}
+	if paymentDo.ClosedRefundAmount > 0 && paymentDo.ClosedRefundTradeNo == "" {
+		// 部分子订单在支付前已经取消, 这部分金额需要退回
+		if err := task.PushPaymentClosedRefund(svc.ctx, paymentDo.PaymentNo); err != nil {
+			logger.NewLogger(svc.ctx).Error("PushPaymentClosedRefundError", "paymentNo", paymentDo.PaymentNo, "err", err)
+		}
+	}
+	svc.notificationDomain.NotifyOrderPaid(paymentDo)
+	orders, err := svc.paymentDomain.GetPaymentOrders(paymentDo)
+	if err != nil {
+		logger.NewLogger(svc.ctx).Warn("GetPaymentOrdersError", "paymentNo", paymentDo.PaymentNo, "err", err)
+		return
+	}
+	// 订单已支付, 删掉超时自动取消的任务, 删除失败时任务执行时也会因为订单状态不对而跳过
+	for _, order := range orders {
+		if err = task.RemoveOrderAutoCancel(svc.ctx, order.OrderNo); err != nil {
+			logger.NewLogger(svc.ctx).Warn("RemoveOrderAutoCancelError", "orderNo", order.OrderNo, "err", err)
+		}
+	}
+}
+
+func (svc *PaymentSvc) paymentReply(paymentDo *do.Payment) *reply.Payment {
+	paymentReply := new(reply.Payment)
+	_ = utils.CopyStruct(paymentReply, paymentDo)
+	return paymentReply
+}
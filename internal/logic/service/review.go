@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/Cospk/go-mall/pkg/utils"
+)
+
+type ReviewSvc struct {
+	ctx          context.Context
+	reviewDomain *domain.ReviewDomain
+}
+
+func NewReviewSvc(ctx context.Context) *ReviewSvc {
+	return &ReviewSvc{
+		ctx:          ctx,
+		reviewDomain: domain.NewReviewDomain(ctx),
+	}
+}
+
+// CreateReview 用户评价已完成订单中的商品
+func (svc *ReviewSvc) CreateReview(userId int64, createRequest *request.ReviewCreate) (*reply.Review, error) {
+	review := &do.Review{
+		OrderItemId: createRequest.OrderItemId,
+		UserId:      userId,
+		Rating:      createRequest.Rating,
+		Content:     createRequest.Content,
+		Images:      createRequest.Images,
+		Anonymous:   createRequest.Anonymous,
+	}
+	review, err := svc.reviewDomain.CreateReview(createRequest.OrderNo, review)
+	if err != nil {
+		return nil, err
+	}
+	logger.NewLogger(svc.ctx).Info("CreateReviewSuccess", "reviewId", review.ID, "userId", userId, "goodsId", review.GoodsId, "rating", review.Rating)
+	return svc.reviewReply(review), nil
+}
+
+// GoodsReviews 商品的评价列表
+func (svc *ReviewSvc) GoodsReviews(listRequest *request.GoodsReviewList, pageInfo *resp.PageInfo) ([]*reply.Review, error) {
+	reviews, total, err := svc.reviewDomain.GetGoodsReviews(listRequest.GoodsId, listRequest.Rating, listRequest.WithImages, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.Review, 0, len(reviews))
+	for _, review := range reviews {
+		replies = append(replies, svc.reviewReply(review))
+	}
+	return replies, nil
+}
+
+// UserReviews 我发表的评价
+func (svc *ReviewSvc) UserReviews(userId int64, pageInfo *resp.PageInfo) ([]*reply.AdminReview, error) {
+	reviews, total, err := svc.reviewDomain.GetUserReviews(userId, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	return svc.adminReviewReplies(reviews), nil
+}
+
+// RatingSummary 商品的评分汇总
+func (svc *ReviewSvc) RatingSummary(goodsId int64) (*reply.RatingSummary, error) {
+	summary, err := svc.reviewDomain.GetRatingSummary(goodsId)
+	if err != nil {
+		return nil, err
+	}
+	summaryReply := new(reply.RatingSummary)
+	_ = utils.CopyStruct(summaryReply, summary)
+	summaryReply.RatingCounts = summary.RatingCounts
+	return summaryReply, nil
+}
+
+// AdminReviews 后台评价列表, 商家后台只能看到自己店铺的评价, merchantId为0表示平台管理员
+func (svc *ReviewSvc) AdminReviews(merchantId int64, listRequest *request.AdminReviewList, pageInfo *resp.PageInfo) ([]*reply.AdminReview, error) {
+	reviews, total, err := svc.reviewDomain.GetReviews(merchantId, listRequest.GoodsId, listRequest.State, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	return svc.adminReviewReplies(reviews), nil
+}
+
+// ReplyReview 商家回复评价, merchantId为0表示平台管理员
+func (svc *ReviewSvc) ReplyReview(merchantId, operatorId int64, replyRequest *request.ReviewReply) error {
+	if err := svc.reviewDomain.ReplyReview(merchantId, replyRequest.ReviewId, replyRequest.Content); err != nil {
+		return err
+	}
+	logger.NewLogger(svc.ctx).Info("ReplyReviewSuccess", "reviewId", replyRequest.ReviewId, "merchantId", merchantId, "operatorId", operatorId)
+	return nil
+}
+
+// HideReview 管理员隐藏或恢复展示评价
+func (svc *ReviewSvc) HideReview(adminId int64, hideRequest *request.ReviewHide) error {
+	if err := svc.reviewDomain.SetReviewHidden(hideRequest.ReviewId, hideRequest.Hidden, hideRequest.Reason); err != nil {
+		return err
+	}
+	logger.NewLogger(svc.ctx).Info("HideReviewSuccess", "reviewId", hideRequest.ReviewId, "hidden", hideRequest.Hidden, "adminId", adminId)
+	return nil
+}
+
+func (svc *ReviewSvc) reviewReply(review *do.Review) *reply.Review {
+	reviewReply := new(reply.Review)
+	_ = utils.CopyStruct(reviewReply, review)
+	reviewReply.Images = review.Images
+	return reviewReply
+}
+
+func (svc *ReviewSvc) adminReviewReplies(reviews []*do.Review) []*reply.AdminReview {
+	replies := make([]*reply.AdminReview, 0, len(reviews))
+	for _, review := range reviews {
+		reviewReply := &reply.AdminReview{Review: *svc.reviewReply(review)}
+		_ = utils.CopyStruct(reviewReply, review)
+		replies = append(replies, reviewReply)
+	}
+	return replies
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/internal/task"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/Cospk/go-mall/pkg/utils"
+)
+
+type SeckillSvc struct {
+	ctx           context.Context
+	seckillDomain *domain.SeckillDomain
+}
+
+func NewSeckillSvc(ctx context.Context) *SeckillSvc {
+	return &SeckillSvc{
+		ctx:           ctx,
+		seckillDomain: domain.NewSeckillDomain(ctx),
+	}
+}
+
+// CreateActivity 后台创建秒杀活动, 并投递活动开始前预热库存的任务
+func (svc *SeckillSvc) CreateActivity(activityRequest *request.SeckillActivityCreate) (*reply.SeckillActivity, error) {
+	activity := new(do.SeckillActivity)
+	if err := utils.CopyStruct(activity, activityRequest); err != nil {
+		return nil, errcode.Wrap("请求转换成领域对象失败", err)
+	}
+	if err := svc.seckillDomain.CreateActivity(activity); err != nil {
+		return nil, err
+	}
+	warmupAt := activity.StartAt.Add(-config.AppConfig().Seckill.WarmupAhead)
+	if err := task.PushSeckillWarmup(svc.ctx, activity.ID, warmupAt); err != nil {
+		// 预热任务投递失败时可以在后台手动预热
+		logger.NewLogger(svc.ctx).Error("PushSeckillWarmupError", "activityId", activity.ID, "err", err)
+	}
+	logger.NewLogger(svc.ctx).Info("CreateSeckillActivity", "activityId", activity.ID, "skuId", activity.SkuId, "stock", activity.TotalStock)
+	return svc.activityReply(activity), nil
+}
+
+// WarmUp 后台手动预热秒杀库存
+func (svc *SeckillSvc) WarmUp(activityId int64) error {
+	return svc.seckillDomain.WarmUp(activityId)
+}
+
+// ActivityList 秒杀活动列表
+func (svc *SeckillSvc) ActivityList(pageInfo *resp.PageInfo) ([]*reply.SeckillActivity, error) {
+	activities, total, err := svc.seckillDomain.GetActivities(pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.SeckillActivity, 0, len(activities))
+	for _, activity := range activities {
+		replies = append(replies, svc.activityReply(activity))
+	}
+	return replies, nil
+}
+
+// Grab 抢购, 抢到库存后投递异步下单任务, 返回凭证给客户端轮询结果
+func (svc *SeckillSvc) Grab(userId int64, grabRequest *request.SeckillGrab) (*reply.SeckillGrab, error) {
+	grab, err := svc.seckillDomain.Grab(userId, grabRequest.ActivityId, grabRequest.Quantity, shippingAddress(grabRequest.Address))
+	if err != nil {
+		return nil, err
+	}
+	if err = task.PushSeckillOrder(svc.ctx, grab); err != nil {
+		logger.NewLogger(svc.ctx).Error("PushSeckillOrderError", "ticket", grab.Ticket, "err", err)
+		svc.seckillDomain.AbandonGrab(grab, "下单排队失败")
+		return nil, errcode.ErrSeckillBusy
+	}
+	return &reply.SeckillGrab{Ticket: grab.Ticket}, nil
+}
+
+// Result 查询抢购结果
+func (svc *SeckillSvc) Result(userId int64, ticket string) (*reply.SeckillResult, error) {
+	result, err := svc.seckillDomain.GetResult(userId, ticket)
+	if err != nil {
+		return nil, err
+	}
+	resultReply := new(reply.SeckillResult)
+	_ = utils.CopyStruct(resultReply, result)
+	return resultReply, nil
+}
+
+func (svc *SeckillSvc) activityReply(activity *do.SeckillActivity) *reply.SeckillActivity {
+	activityReply := new(reply.SeckillActivity)
+	_ = utils.CopyStruct(activityReply, activity)
+	activityReply.RemainingStock = svc.seckillDomain.GetRemainingStock(activity)
+	return activityReply
+}
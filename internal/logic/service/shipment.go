@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/external/carrier"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/internal/task"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/utils"
+)
+
+type ShipmentSvc struct {
+	ctx                context.Context
+	shipmentDomain     *domain.ShipmentDomain
+	orderDomain        *domain.OrderDomain
+	notificationDomain *domain.NotificationDomain
+}
+
+func NewShipmentSvc(ctx context.Context) *ShipmentSvc {
+	return &ShipmentSvc{
+		ctx:                ctx,
+		shipmentDomain:     domain.NewShipmentDomain(ctx),
+		orderDomain:        domain.NewOrderDomain(ctx),
+		notificationDomain: domain.NewNotificationDomain(ctx),
+	}
+}
+
+// ShipOrder 后台订单发货, 发货后定时同步物流轨迹
+// 商家只能给自己店铺的订单发货, merchantId为0表示平台管理员
+func (svc *ShipmentSvc) ShipOrder(merchantId, operatorId int64, shipRequest *request.OrderShip) (*reply.Shipment, error) {
+	if _, err := svc.orderDomain.GetMerchantOrder(merchantId, shipRequest.OrderNo); err != nil {
+		return nil, err
+	}
+	operator := &do.OrderOperator{Type: enum.OrderOperatorAdmin, Id: operatorId}
+	if merchantId != 0 {
+		operator.Type = enum.OrderOperatorMerchant
+	}
+	shipment, err := svc.shipmentDomain.ShipOrder(shipRequest.OrderNo, shipRequest.Carrier, shipRequest.TrackingNo, operator)
+	if err != nil {
+		return nil, err
+	}
+	logger.NewLogger(svc.ctx).Info("ShipOrderSuccess", "orderNo", shipment.OrderNo, "carrier", shipment.Carrier, "trackingNo", shipment.TrackingNo)
+	// 投递失败不影响发货结果, 用户和后台查看物流时仍然可以看到物流单号
+	if err = task.PushShipmentTrack(svc.ctx, shipment.ID, 1); err != nil {
+		logger.NewLogger(svc.ctx).Error("PushShipmentTrackError", "shipmentId", shipment.ID, "err", err)
+	}
+	svc.notificationDomain.NotifyOrderShipped(shipment)
+	return svc.shipmentReply(shipment), nil
+}
+
+// OrderShipments 用户查看自己订单的物流
+func (svc *ShipmentSvc) OrderShipments(userId int64, orderNo string) ([]*reply.Shipment, error) {
+	order, err := svc.orderDomain.GetUserOrder(userId, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	return svc.orderShipments(order.ID)
+}
+
+// AdminOrderShipments 后台查看订单的物流
+func (svc *ShipmentSvc) AdminOrderShipments(merchantId int64, orderNo string) ([]*reply.Shipment, error) {
+	order, err := svc.orderDomain.GetMerchantOrder(merchantId, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	return svc.orderShipments(order.ID)
+}
+
+func (svc *ShipmentSvc) orderShipments(orderId int64) ([]*reply.Shipment, error) {
+	shipments, err := svc.shipmentDomain.GetOrderShipments(orderId)
+	if err != nil {
+		return nil, err
+	}
+	replies := make([]*reply.Shipment, 0, len(shipments))
+	for _, shipment := range shipments {
+		replies = append(replies, svc.shipmentReply(shipment))
+	}
+	return replies, nil
+}
+
+func (svc *ShipmentSvc) shipmentReply(shipment *do.Shipment) *reply.Shipment {
+	shipmentReply := new(reply.Shipment)
+	_ = utils.CopyStruct(shipmentReply, shipment)
+	shipmentReply.StateName = enum.ShipmentStateName[shipment.State]
+	if shipmentCarrier, ok := carrier.GetCarrier(shipment.Carrier); ok {
+		shipmentReply.CarrierName = shipmentCarrier.Name()
+	}
+	shipmentReply.Events = make([]*reply.ShipmentEvent, 0, len(shipment.Events))
+	for _, event := range shipment.Events {
+		eventReply := new(reply.ShipmentEvent)
+		_ = utils.CopyStruct(eventReply, event)
+		shipmentReply.Events = append(shipmentReply.Events, eventReply)
+	}
+	return shipmentReply
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/internal/task"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+)
+
+type TaskSvc struct {
+	ctx context.Context
+}
+
+func NewTaskSvc(ctx context.Context) *TaskSvc {
+	return &TaskSvc{ctx: ctx}
+}
+
+// PendingDelayJobs 分页查询延时队列中等待执行的任务
+func (svc *TaskSvc) PendingDelayJobs(pageInfo *resp.PageInfo) (*reply.DelayQueueJobs, error) {
+	queue := task.DelayQueue()
+	jobs, total, err := queue.Pending(svc.ctx, (pageInfo.PageNum-1)*pageInfo.PageSize, pageInfo.PageSize)
+	if err != nil {
+		return nil, errcode.Wrap("查询延时任务失败", err)
+	}
+	deadCount, err := queue.DeadCount(svc.ctx)
+	if err != nil {
+		return nil, errcode.Wrap("查询死信任务数失败", err)
+	}
+	pageInfo.Total = int(total)
+
+	jobsReply := &reply.DelayQueueJobs{
+		DeadCount: deadCount,
+		Jobs:      make([]*reply.DelayQueueJob, 0, len(jobs)),
+	}
+	for _, job := range jobs {
+		jobsReply.Jobs = append(jobsReply.Jobs, &reply.DelayQueueJob{
+			Id:       job.Id,
+			Topic:    job.Topic,
+			Payload:  string(job.Payload),
+			Attempts: job.Attempts,
+			RunAt:    job.RunAt.Format("2006-01-02 15:04:05"),
+			LastErr:  job.LastErr,
+		})
+	}
+	return jobsReply, nil
+}
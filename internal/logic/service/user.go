@@ -105,12 +105,13 @@ func (svc *UserService) UserLogout(userId int64, platform string) error {
 
 // PasswordResetApply 申请重置密码
 func (svc *UserService) PasswordResetApply(request *request.PasswordResetApply) (*reply.PasswordResetApply, error) {
-	passwordResetToken, code, err := svc.userDomain.ApplyForPasswordReset(request.LoginName)
-	// TODO 把验证码通过邮件/短信发送给用户, 练习中就不实际去发送了, 记一条日志代替。
-	logger.NewLogger(svc.ctx).Info("PasswordResetApply", "token", passwordResetToken, "code", code)
+	passwordResetToken, _, err := svc.userDomain.ApplyForPasswordReset(request.LoginName)
 	if err != nil {
 		return nil, err
 	}
+	// TODO 把验证码通过邮件/短信发送给用户, 练习中就不实际去发送了, 记一条日志代替。
+	// 凭令牌和验证码就能重置密码, 日志里不记录它们
+	logger.NewLogger(svc.ctx).Info("PasswordResetApply", "loginName", utils.MaskLoginName(request.LoginName))
 	reply := new(reply.PasswordResetApply)
 	reply.PasswordResetToken = passwordResetToken
 	return reply, nil
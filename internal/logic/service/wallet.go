@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/api/reply"
+	"github.com/Cospk/go-mall/api/request"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"time"
+)
+
+type WalletSvc struct {
+	ctx          context.Context
+	walletDomain *domain.WalletDomain
+}
+
+func NewWalletSvc(ctx context.Context) *WalletSvc {
+	return &WalletSvc{
+		ctx:          ctx,
+		walletDomain: domain.NewWalletDomain(ctx),
+	}
+}
+
+// WalletInfo 用户的钱包余额
+func (svc *WalletSvc) WalletInfo(userId int64) (*reply.Wallet, error) {
+	wallet, err := svc.walletDomain.GetWallet(userId)
+	if err != nil {
+		return nil, err
+	}
+	return &reply.Wallet{Balance: wallet.Balance}, nil
+}
+
+// WalletEntries 用户钱包的收支明细
+func (svc *WalletSvc) WalletEntries(userId int64, pageInfo *resp.PageInfo) ([]*reply.WalletEntry, error) {
+	entries, total, err := svc.walletDomain.GetWalletEntries(userId, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.WalletEntry, 0, len(entries))
+	for _, entry := range entries {
+		entryReply := new(reply.WalletEntry)
+		_ = utils.CopyStruct(entryReply, entry)
+		entryReply.TypeName = enum.LedgerTxTypeToName(entry.Type)
+		replies = append(replies, entryReply)
+	}
+	return replies, nil
+}
+
+// RedeemGiftCard 用户兑换礼品卡到钱包
+func (svc *WalletSvc) RedeemGiftCard(userId int64, redeemRequest *request.GiftCardRedeem) (*reply.GiftCardRedeem, error) {
+	card, err := svc.walletDomain.RedeemGiftCard(userId, redeemRequest.Code)
+	if err != nil {
+		return nil, err
+	}
+	wallet, err := svc.walletDomain.GetWallet(userId)
+	if err != nil {
+		return nil, err
+	}
+	return &reply.GiftCardRedeem{CardNo: card.CardNo, Amount: card.Amount, Balance: wallet.Balance}, nil
+}
+
+// IssueGiftCards 平台发行礼品卡, 卡密只在这里返回一次
+func (svc *WalletSvc) IssueGiftCards(adminId int64, issueRequest *request.GiftCardIssue) ([]*reply.GiftCard, error) {
+	expireAt := time.Now().AddDate(0, 0, issueRequest.ExpireDays)
+	cards, err := svc.walletDomain.IssueGiftCards(adminId, issueRequest.BatchNo, issueRequest.Count, issueRequest.Amount, expireAt)
+	if err != nil {
+		return nil, err
+	}
+	replies := make([]*reply.GiftCard, 0, len(cards))
+	for _, card := range cards {
+		replies = append(replies, svc.giftCardReply(card))
+	}
+	return replies, nil
+}
+
+// GiftCardList 后台礼品卡列表
+func (svc *WalletSvc) GiftCardList(listRequest *request.GiftCardList, pageInfo *resp.PageInfo) ([]*reply.GiftCard, error) {
+	cards, total, err := svc.walletDomain.GetGiftCards(listRequest.BatchNo, listRequest.State, pageInfo.PageNum, pageInfo.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	pageInfo.Total = int(total)
+	replies := make([]*reply.GiftCard, 0, len(cards))
+	for _, card := range cards {
+		replies = append(replies, svc.giftCardReply(card))
+	}
+	return replies, nil
+}
+
+// AdjustWallet 管理员人工调整用户钱包余额
+func (svc *WalletSvc) AdjustWallet(adminId int64, adjustRequest *request.WalletAdjust) (*reply.WalletAdjust, error) {
+	transaction, err := svc.walletDomain.AdjustWallet(adjustRequest.UserId, adjustRequest.Amount, adjustRequest.IdempotencyKey, adjustRequest.Remark)
+	if err != nil {
+		return nil, err
+	}
+	logger.NewLogger(svc.ctx).Info("AdjustWalletSuccess", "userId", adjustRequest.UserId, "amount", adjustRequest.Amount, "adminId", adminId)
+	wallet, err := svc.walletDomain.GetWallet(adjustRequest.UserId)
+	if err != nil {
+		return nil, err
+	}
+	return &reply.WalletAdjust{TxNo: transaction.TxNo, Balance: wallet.Balance}, nil
+}
+
+// CheckLedger 手动执行一次对账
+func (svc *WalletSvc) CheckLedger() (*reply.LedgerCheckReport, error) {
+	report, err := svc.walletDomain.CheckLedger()
+	if err != nil {
+		return nil, err
+	}
+	reportReply := new(reply.LedgerCheckReport)
+	_ = utils.CopyStruct(reportReply, report)
+	return reportReply, nil
+}
+
+func (svc *WalletSvc) giftCardReply(card *do.GiftCard) *reply.GiftCard {
+	cardReply := new(reply.GiftCard)
+	_ = utils.CopyStruct(cardReply, card)
+	if card.State != enum.GiftCardStateRedeemed {
+		cardReply.RedeemedAt = ""
+	}
+	return cardReply
+}
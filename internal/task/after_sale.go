@@ -0,0 +1,141 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/delayqueue"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"time"
+)
+
+const (
+	TopicAfterSaleAutoApprove = "after_sale_auto_approve"
+	TopicAfterSaleAutoReceive = "after_sale_auto_receive"
+	TopicAfterSaleRefund      = "after_sale_refund"
+)
+
+type afterSalePayload struct {
+	AfterSaleNo string `json:"after_sale_no"`
+}
+
+// PushAfterSaleAutoApprove 投递商家超时未审核自动同意的任务
+func PushAfterSaleAutoApprove(ctx context.Context, afterSaleNo string) error {
+	timeout := config.AppConfig().AfterSale.ReviewTimeout
+	if timeout <= 0 {
+		timeout = 48 * time.Hour
+	}
+	return delayQueue.Push(ctx, TopicAfterSaleAutoApprove, afterSaleNo, &afterSalePayload{AfterSaleNo: afterSaleNo}, timeout)
+}
+
+// PushAfterSaleAutoReceive 投递商家超时未确认收货自动收货的任务
+func PushAfterSaleAutoReceive(ctx context.Context, afterSaleNo string) error {
+	timeout := config.AppConfig().AfterSale.ReceiveTimeout
+	if timeout <= 0 {
+		timeout = 7 * 24 * time.Hour
+	}
+	return delayQueue.Push(ctx, TopicAfterSaleAutoReceive, afterSaleNo, &afterSalePayload{AfterSaleNo: afterSaleNo}, timeout)
+}
+
+// PushAfterSaleRefund 投递立即执行的退款任务, 渠道调用失败时由延时队列按退避策略重试
+func PushAfterSaleRefund(ctx context.Context, afterSaleNo string) error {
+	return delayQueue.Push(ctx, TopicAfterSaleRefund, afterSaleNo, &afterSalePayload{AfterSaleNo: afterSaleNo}, 0)
+}
+
+// RemoveAfterSaleTimeouts 售后单被处理后删掉超时任务, 删除失败不影响正确性, 任务执行时会再校验售后单状态
+func RemoveAfterSaleTimeouts(ctx context.Context, afterSaleNo string) {
+	for _, topic := range []string{TopicAfterSaleAutoApprove, TopicAfterSaleAutoReceive} {
+		if err := delayQueue.Remove(ctx, topic, afterSaleNo); err != nil {
+			logger.NewLogger(ctx).Error("RemoveAfterSaleTimeoutError", "afterSaleNo", afterSaleNo, "topic", topic, "err", err)
+		}
+	}
+}
+
+// handleAfterSaleAutoApprove 商家超时未审核的售后申请自动同意, 仅退款的直接发起退款
+func handleAfterSaleAutoApprove(ctx context.Context, job *delayqueue.Job) error {
+	afterSaleDomain := domain.NewAfterSaleDomain(ctx)
+	afterSale, err := loadAfterSale(ctx, afterSaleDomain, job, enum.AfterSaleStateApplied)
+	if afterSale == nil {
+		return err
+	}
+	operator := &do.OrderOperator{Type: enum.OrderOperatorSystem}
+	err = afterSaleDomain.Approve(afterSale, operator, "商家超时未处理, 系统自动同意")
+	if err == errcode.ErrAfterSaleStateTransition {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return pushAfterSaleNext(ctx, afterSale)
+}
+
+// handleAfterSaleAutoReceive 商家超时未确认收到退货时自动确认收货并发起退款
+func handleAfterSaleAutoReceive(ctx context.Context, job *delayqueue.Job) error {
+	afterSaleDomain := domain.NewAfterSaleDomain(ctx)
+	afterSale, err := loadAfterSale(ctx, afterSaleDomain, job, enum.AfterSaleStateReturned)
+	if afterSale == nil {
+		return err
+	}
+	operator := &do.OrderOperator{Type: enum.OrderOperatorSystem}
+	err = afterSaleDomain.ConfirmReturnReceived(afterSale, operator, "商家超时未确认收货, 系统自动收货")
+	if err == errcode.ErrAfterSaleStateTransition {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return pushAfterSaleNext(ctx, afterSale)
+}
+
+// handleAfterSaleRefund 执行退款, 售后单不在退款中时视为已经处理过
+func handleAfterSaleRefund(ctx context.Context, job *delayqueue.Job) error {
+	afterSaleDomain := domain.NewAfterSaleDomain(ctx)
+	afterSale, err := loadAfterSale(ctx, afterSaleDomain, job, enum.AfterSaleStateRefunding)
+	if afterSale == nil {
+		return err
+	}
+	err = afterSaleDomain.ExecuteRefund(afterSale)
+	if err == errcode.ErrAfterSaleStateTransition {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	domain.NewNotificationDomain(ctx).NotifyRefunded(afterSale)
+	if afterSale.Restock {
+		PushSkuChanged(ctx, afterSale.SkuId)
+	}
+	return nil
+}
+
+// pushAfterSaleNext 售后单状态推进后投递下一步的任务
+func pushAfterSaleNext(ctx context.Context, afterSale *do.AfterSale) error {
+	if afterSale.State == enum.AfterSaleStateRefunding {
+		return PushAfterSaleRefund(ctx, afterSale.AfterSaleNo)
+	}
+	return nil
+}
+
+// loadAfterSale 解析任务并查询售后单, 售后单不存在或者不是期望的状态时返回nil, 任务直接视为处理成功
+func loadAfterSale(ctx context.Context, afterSaleDomain *domain.AfterSaleDomain, job *delayqueue.Job, expectState int8) (*do.AfterSale, error) {
+	payload := new(afterSalePayload)
+	if err := json.Unmarshal(job.Payload, payload); err != nil {
+		logger.NewLogger(ctx).Error("AfterSalePayloadError", "jobId", job.Id, "err", err)
+		return nil, nil
+	}
+	afterSale, err := afterSaleDomain.GetAfterSale(payload.AfterSaleNo)
+	if err == errcode.ErrAfterSaleNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if afterSale.State != expectState {
+		return nil, nil
+	}
+	return afterSale, nil
+}
@@ -0,0 +1,57 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/delayqueue"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"strconv"
+	"time"
+)
+
+const TopicSkuChanged = "sku_changed"
+
+type skuChangedPayload struct {
+	SkuId int64 `json:"sku_id"`
+}
+
+// PushSkuChanged SKU库存或价格变化后投递检查订阅的任务, 以SKU ID作为任务ID,
+// 延迟时间内同一SKU的多次变化会覆盖成一个任务, 只检查一次
+func PushSkuChanged(ctx context.Context, skuIds ...int64) {
+	delay := config.AppConfig().Notification.SkuChangeDelay
+	if delay <= 0 {
+		delay = 10 * time.Second
+	}
+	for _, skuId := range skuIds {
+		id := strconv.FormatInt(skuId, 10)
+		if err := delayQueue.Push(ctx, TopicSkuChanged, id, &skuChangedPayload{SkuId: skuId}, delay); err != nil {
+			// 投递失败只会漏发提醒, 不影响库存和价格的修改
+			logger.NewLogger(ctx).Warn("PushSkuChangedError", "skuId", skuId, "err", err)
+		}
+	}
+}
+
+// PushOrdersSkuChanged 取消订单归还库存后检查订单里SKU的到货提醒
+func PushOrdersSkuChanged(ctx context.Context, orders []*do.Order) {
+	skuIds := make([]int64, 0)
+	for _, order := range orders {
+		for _, item := range order.Items {
+			skuIds = append(skuIds, item.SkuId)
+		}
+	}
+	PushSkuChanged(ctx, skuIds...)
+}
+
+// handleSkuChanged 通知SKU的到货和降价订阅者
+func handleSkuChanged(ctx context.Context, job *delayqueue.Job) error {
+	payload := new(skuChangedPayload)
+	if err := json.Unmarshal(job.Payload, payload); err != nil {
+		logger.NewLogger(ctx).Error("SkuChangedPayloadError", "jobId", job.Id, "err", err)
+		return nil
+	}
+	_, err := domain.NewFavoriteDomain(ctx).NotifySkuChanged(payload.SkuId)
+	return err
+}
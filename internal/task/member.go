@@ -0,0 +1,79 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/delayqueue"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"strconv"
+	"time"
+)
+
+const TopicPointsExpire = "points_expire"
+
+// pointsExpireBatchSize 过期任务每批处理的用户数
+const pointsExpireBatchSize = 200
+
+type pointsExpirePayload struct {
+	RunAt time.Time `json:"run_at"`
+}
+
+// SchedulePointsExpire 投递下一次积分过期任务, 执行时间按配置的间隔对齐,
+// 以执行时间作为任务ID, 多个实例启动时重复投递只会保留一个任务
+func SchedulePointsExpire(ctx context.Context) error {
+	interval := pointsExpireInterval()
+	runAt := time.Now().Truncate(interval).Add(interval)
+	id := strconv.FormatInt(runAt.Unix(), 10)
+	return delayQueue.Push(ctx, TopicPointsExpire, id, &pointsExpirePayload{RunAt: runAt}, time.Until(runAt))
+}
+
+// handlePointsExpire 作废到期未使用的积分, 每次回溯检查一段时间内到期的积分, 服务停机漏掉的批次会在下次补上
+// 先投递下一次任务再处理本次, 本次处理失败重试时不会影响后续的调度
+func handlePointsExpire(ctx context.Context, job *delayqueue.Job) error {
+	if err := SchedulePointsExpire(ctx); err != nil {
+		logger.NewLogger(ctx).Error("SchedulePointsExpireError", "err", err)
+	}
+	payload := new(pointsExpirePayload)
+	if err := json.Unmarshal(job.Payload, payload); err != nil {
+		logger.NewLogger(ctx).Error("PointsExpirePayloadError", "jobId", job.Id, "err", err)
+		return nil
+	}
+	lookback := config.AppConfig().Member.ExpireLookback
+	if lookback <= 0 {
+		lookback = 30 * 24 * time.Hour
+	}
+	now := time.Now()
+	memberDomain := domain.NewMemberDomain(ctx)
+	var afterUserId, expiredUsers int64
+	for {
+		userIds, err := memberDomain.GetExpiringUserIds(now.Add(-lookback), now, afterUserId, pointsExpireBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, userId := range userIds {
+			expired, err := memberDomain.ExpirePoints(userId, now)
+			if err != nil {
+				return err
+			}
+			if expired > 0 {
+				expiredUsers++
+			}
+		}
+		if len(userIds) < pointsExpireBatchSize {
+			break
+		}
+		afterUserId = userIds[len(userIds)-1]
+	}
+	logger.NewLogger(ctx).Info("PointsExpireFinished", "runAt", payload.RunAt, "expiredUsers", expiredUsers, "attempts", job.Attempts)
+	return nil
+}
+
+func pointsExpireInterval() time.Duration {
+	interval := config.AppConfig().Member.ExpireInterval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	return interval
+}
@@ -0,0 +1,77 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/delayqueue"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"time"
+)
+
+const TopicOrderAutoCancel = "order_auto_cancel"
+
+type orderAutoCancelPayload struct {
+	OrderNo string `json:"order_no"`
+}
+
+// PushOrderAutoCancel 投递订单超时未支付自动取消的任务
+func PushOrderAutoCancel(ctx context.Context, orderNo string) error {
+	timeout := config.AppConfig().Order.PayTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Minute
+	}
+	return delayQueue.Push(ctx, TopicOrderAutoCancel, orderNo, &orderAutoCancelPayload{OrderNo: orderNo}, timeout)
+}
+
+// RemoveOrderAutoCancel 订单已支付或已取消后删掉自动取消任务, 删除失败不影响正确性, 任务执行时会再校验订单状态
+func RemoveOrderAutoCancel(ctx context.Context, orderNo string) error {
+	return delayQueue.Remove(ctx, TopicOrderAutoCancel, orderNo)
+}
+
+// handleOrderAutoCancel 取消超时未支付的订单并归还库存
+// 任务可能被重复执行, 订单不是待支付状态时直接视为处理成功
+func handleOrderAutoCancel(ctx context.Context, job *delayqueue.Job) error {
+	payload := new(orderAutoCancelPayload)
+	if err := json.Unmarshal(job.Payload, payload); err != nil {
+		// 数据格式错误重试也没用, 记录日志后丢弃
+		logger.NewLogger(ctx).Error("OrderAutoCancelPayloadError", "jobId", job.Id, "err", err)
+		return nil
+	}
+	orderDomain := domain.NewOrderDomain(ctx)
+	order, err := orderDomain.GetOrder(payload.OrderNo)
+	if err == errcode.ErrOrderNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if order.State != enum.OrderStateCreated {
+		return nil
+	}
+	operator := &do.OrderOperator{Type: enum.OrderOperatorSystem}
+	// 同一父订单下未支付的子订单会一起取消
+	cancelled, err := orderDomain.CancelOrder(order, operator, "订单超时未支付自动取消")
+	if err == errcode.ErrOrderStateTransition {
+		// 读取订单后订单被支付或取消了
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	PushOrdersSkuChanged(ctx, cancelled)
+	for _, sibling := range cancelled {
+		if sibling.OrderNo == order.OrderNo {
+			continue
+		}
+		if err := RemoveOrderAutoCancel(ctx, sibling.OrderNo); err != nil {
+			logger.NewLogger(ctx).Warn("RemoveOrderAutoCancelError", "orderNo", sibling.OrderNo, "err", err)
+		}
+	}
+	logger.NewLogger(ctx).Info("OrderAutoCancelled", "orderNo", order.OrderNo, "parentOrderNo", order.ParentOrderNo, "attempts", job.Attempts)
+	return nil
+}
@@ -0,0 +1,35 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/delayqueue"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+)
+
+const TopicPaymentClosedRefund = "payment_closed_refund"
+
+type paymentPayload struct {
+	PaymentNo string `json:"payment_no"`
+}
+
+// PushPaymentClosedRefund 投递立即执行的退款任务, 退回支付成功时已经取消的子订单的金额, 渠道调用失败时按退避策略重试
+func PushPaymentClosedRefund(ctx context.Context, paymentNo string) error {
+	return delayQueue.Push(ctx, TopicPaymentClosedRefund, paymentNo, &paymentPayload{PaymentNo: paymentNo}, 0)
+}
+
+// handlePaymentClosedRefund 退回已取消子订单的支付金额, 已经退过时直接视为处理成功
+func handlePaymentClosedRefund(ctx context.Context, job *delayqueue.Job) error {
+	payload := new(paymentPayload)
+	if err := json.Unmarshal(job.Payload, payload); err != nil {
+		logger.NewLogger(ctx).Error("PaymentPayloadError", "jobId", job.Id, "err", err)
+		return nil
+	}
+	err := domain.NewPaymentDomain(ctx).RefundClosedOrders(payload.PaymentNo)
+	if err == errcode.ErrPaymentNotFound {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Cospk/go-mall/internal/logic/do"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/delayqueue"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"strconv"
+	"time"
+)
+
+const (
+	TopicSeckillOrder  = "seckill_order"
+	TopicSeckillWarmup = "seckill_warmup"
+)
+
+type seckillWarmupPayload struct {
+	ActivityId int64 `json:"activity_id"`
+}
+
+// PushSeckillOrder 投递立即执行的秒杀下单任务, 以抢购凭证作为任务ID
+func PushSeckillOrder(ctx context.Context, grab *do.SeckillGrab) error {
+	return delayQueue.Push(ctx, TopicSeckillOrder, grab.Ticket, grab, 0)
+}
+
+// PushSeckillWarmup 投递在指定时间预热秒杀库存的任务
+func PushSeckillWarmup(ctx context.Context, activityId int64, runAt time.Time) error {
+	delay := time.Until(runAt)
+	if delay < 0 {
+		delay = 0
+	}
+	id := strconv.FormatInt(activityId, 10)
+	return delayQueue.Push(ctx, TopicSeckillWarmup, id, &seckillWarmupPayload{ActivityId: activityId}, delay)
+}
+
+// handleSeckillOrder 把抢购请求变成真实订单, 订单创建后和普通订单一样超时未支付自动取消
+func handleSeckillOrder(ctx context.Context, job *delayqueue.Job) error {
+	grab := new(do.SeckillGrab)
+	if err := json.Unmarshal(job.Payload, grab); err != nil {
+		logger.NewLogger(ctx).Error("SeckillOrderPayloadError", "jobId", job.Id, "err", err)
+		return nil
+	}
+	orderNo, err := domain.NewSeckillDomain(ctx).CreateOrder(grab)
+	if err != nil {
+		return err
+	}
+	if orderNo == "" {
+		return nil
+	}
+	if err = PushOrderAutoCancel(ctx, orderNo); err != nil {
+		logger.NewLogger(ctx).Error("PushOrderAutoCancelError", "orderNo", orderNo, "err", err)
+	}
+	return nil
+}
+
+// handleSeckillOrderDead 下单任务重试耗尽进入死信, 退回占用的库存和限购次数, 抢购结果标记为失败
+func handleSeckillOrderDead(ctx context.Context, job *delayqueue.Job) error {
+	grab := new(do.SeckillGrab)
+	if err := json.Unmarshal(job.Payload, grab); err != nil {
+		return err
+	}
+	return domain.NewSeckillDomain(ctx).AbandonOrder(grab)
+}
+
+// handleSeckillWarmup 预热秒杀库存, 活动不存在或已结束时不再重试
+func handleSeckillWarmup(ctx context.Context, job *delayqueue.Job) error {
+	payload := new(seckillWarmupPayload)
+	if err := json.Unmarshal(job.Payload, payload); err != nil {
+		logger.NewLogger(ctx).Error("SeckillWarmupPayloadError", "jobId", job.Id, "err", err)
+		return nil
+	}
+	err := domain.NewSeckillDomain(ctx).WarmUp(payload.ActivityId)
+	if err == errcode.ErrSeckillNotFound || err == errcode.ErrSeckillEnded {
+		return nil
+	}
+	return err
+}
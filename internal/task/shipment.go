@@ -0,0 +1,61 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/delayqueue"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"time"
+)
+
+const TopicShipmentTrack = "shipment_track"
+
+type shipmentTrackPayload struct {
+	ShipmentId int64 `json:"shipment_id"`
+	Round      int   `json:"round"` // 第几次同步, 从1开始
+}
+
+// PushShipmentTrack 投递同步物流轨迹的任务, 每一轮使用不同的任务ID,
+// 避免在任务处理过程中投递下一轮时被本轮任务的确认删掉
+func PushShipmentTrack(ctx context.Context, shipmentId int64, round int) error {
+	interval := config.AppConfig().Shipment.TrackInterval
+	if interval <= 0 {
+		interval = 2 * time.Hour
+	}
+	id := fmt.Sprintf("%d:%d", shipmentId, round)
+	return delayQueue.Push(ctx, TopicShipmentTrack, id, &shipmentTrackPayload{ShipmentId: shipmentId, Round: round}, interval)
+}
+
+// handleShipmentTrack 同步物流轨迹, 还没签收时投递下一轮, 超过最大轮数后不再自动同步
+func handleShipmentTrack(ctx context.Context, job *delayqueue.Job) error {
+	payload := new(shipmentTrackPayload)
+	if err := json.Unmarshal(job.Payload, payload); err != nil {
+		logger.NewLogger(ctx).Error("ShipmentTrackPayloadError", "jobId", job.Id, "err", err)
+		return nil
+	}
+	shipmentDomain := domain.NewShipmentDomain(ctx)
+	shipment, err := shipmentDomain.GetShipment(payload.ShipmentId)
+	if err == errcode.ErrShipmentNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err = shipmentDomain.SyncTracking(shipment); err != nil {
+		return err
+	}
+	if shipment.State == enum.ShipmentStateDelivered {
+		return nil
+	}
+	maxRounds := config.AppConfig().Shipment.TrackMaxRounds
+	if maxRounds > 0 && payload.Round >= maxRounds {
+		logger.NewLogger(ctx).Warn("ShipmentTrackGiveUp", "shipmentId", shipment.ID, "trackingNo", shipment.TrackingNo, "rounds", payload.Round)
+		return nil
+	}
+	return PushShipmentTrack(ctx, shipment.ID, payload.Round+1)
+}
@@ -0,0 +1,57 @@
+package task
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/internal/dal/cache"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/delayqueue"
+	"github.com/Cospk/go-mall/pkg/enum"
+	"github.com/Cospk/go-mall/pkg/logger"
+)
+
+// task 后台任务层, 负责延时任务的投递、注册和处理, 任务处理函数只编排领域服务不写业务规则
+
+var delayQueue *delayqueue.Queue
+
+func DelayQueue() *delayqueue.Queue {
+	return delayQueue
+}
+
+// InitDelayQueue 初始化延时队列并注册各主题的处理函数, 需要在InitRedis之后调用
+func InitDelayQueue() {
+	queueConfig := config.AppConfig().DelayQueue
+	delayQueue = delayqueue.New(cache.Redis(), enum.REDIS_KEY_DELAY_QUEUE,
+		delayqueue.WithWorkers(queueConfig.Workers),
+		delayqueue.WithPollInterval(queueConfig.PollInterval),
+		delayqueue.WithVisibilityTimeout(queueConfig.VisibilityTimeout),
+		delayqueue.WithMaxAttempts(queueConfig.MaxAttempts),
+	)
+	delayQueue.Register(TopicOrderAutoCancel, handleOrderAutoCancel)
+	delayQueue.Register(TopicPaymentClosedRefund, handlePaymentClosedRefund)
+	delayQueue.Register(TopicAfterSaleAutoApprove, handleAfterSaleAutoApprove)
+	delayQueue.Register(TopicAfterSaleAutoReceive, handleAfterSaleAutoReceive)
+	delayQueue.Register(TopicAfterSaleRefund, handleAfterSaleRefund)
+	delayQueue.Register(TopicSeckillOrder, handleSeckillOrder)
+	delayQueue.RegisterDeadHook(TopicSeckillOrder, handleSeckillOrderDead)
+	delayQueue.Register(TopicSeckillWarmup, handleSeckillWarmup)
+	delayQueue.Register(TopicShipmentTrack, handleShipmentTrack)
+	delayQueue.Register(TopicPointsExpire, handlePointsExpire)
+	delayQueue.Register(TopicLedgerCheck, handleLedgerCheck)
+	delayQueue.Register(TopicSkuChanged, handleSkuChanged)
+}
+
+// StartWorkers 启动延时队列的协程池, 并投递周期执行的任务
+func StartWorkers(ctx context.Context) {
+	delayQueue.Start(ctx)
+	if err := SchedulePointsExpire(ctx); err != nil {
+		logger.NewLogger(ctx).Error("SchedulePointsExpireError", "err", err)
+	}
+	if err := ScheduleLedgerCheck(ctx); err != nil {
+		logger.NewLogger(ctx).Error("ScheduleLedgerCheckError", "err", err)
+	}
+}
+
+// StopWorkers 停止领取新任务, 等待处理中的任务完成, 超过ctx的期限时返回错误
+func StopWorkers(ctx context.Context) error {
+	return delayQueue.Stop(ctx)
+}
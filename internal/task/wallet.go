@@ -0,0 +1,52 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Cospk/go-mall/internal/logic/domain"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/delayqueue"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"strconv"
+	"time"
+)
+
+const TopicLedgerCheck = "ledger_check"
+
+type ledgerCheckPayload struct {
+	RunAt time.Time `json:"run_at"`
+}
+
+// ScheduleLedgerCheck 投递下一次钱包对账任务, 执行时间按配置的间隔对齐, 以执行时间作为任务ID, 多个实例启动时只会保留一个任务
+func ScheduleLedgerCheck(ctx context.Context) error {
+	interval := config.AppConfig().Wallet.CheckInterval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	runAt := time.Now().Truncate(interval).Add(interval)
+	id := strconv.FormatInt(runAt.Unix(), 10)
+	return delayQueue.Push(ctx, TopicLedgerCheck, id, &ledgerCheckPayload{RunAt: runAt}, time.Until(runAt))
+}
+
+// handleLedgerCheck 核对钱包和礼品卡的账户余额与记账分录, 对不上时记错误日志告警, 对账只读不自动修正
+func handleLedgerCheck(ctx context.Context, job *delayqueue.Job) error {
+	if err := ScheduleLedgerCheck(ctx); err != nil {
+		logger.NewLogger(ctx).Error("ScheduleLedgerCheckError", "err", err)
+	}
+	payload := new(ledgerCheckPayload)
+	if err := json.Unmarshal(job.Payload, payload); err != nil {
+		logger.NewLogger(ctx).Error("LedgerCheckPayloadError", "jobId", job.Id, "err", err)
+		return nil
+	}
+	report, err := domain.NewWalletDomain(ctx).CheckLedger()
+	if err != nil {
+		return err
+	}
+	if !report.Consistent {
+		logger.NewLogger(ctx).Error("LedgerInconsistent", "runAt", payload.RunAt, "totalBalance", report.TotalBalance,
+			"accountMismatches", report.AccountMismatches, "unbalancedTransactions", report.UnbalancedTransactions)
+		return nil
+	}
+	logger.NewLogger(ctx).Info("LedgerCheckPassed", "runAt", payload.RunAt, "accounts", report.Accounts, "attempts", job.Attempts)
+	return nil
+}
@@ -2,30 +2,146 @@ package config
 
 import "time"
 
-var (
-	Database  *databaseConfig
-	AppConfig *appConfig
-	Redis     *RedisConfig
-)
-
 type appConfig struct {
 	Name      string `mapstructure:"name"`
 	Env       string `mapstructure:"env"`
 	JwtSecret string `mapstructure:"jwtSecret"`
-	Log       struct {
+	Server    struct {
+		Addr              string        `mapstructure:"addr"`                // 监听地址
+		ReadTimeout       time.Duration `mapstructure:"read_timeout"`        // 读取整个请求(含请求体)的超时时间
+		ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"` // 读取请求头的超时时间
+		WriteTimeout      time.Duration `mapstructure:"write_timeout"`       // 写响应的超时时间, SSE等长连接会自行取消
+		IdleTimeout       time.Duration `mapstructure:"idle_timeout"`        // keep-alive连接的空闲超时时间
+		ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout"`    // 收到退出信号后等待处理中的请求和延时任务完成的最长时间
+		DrainDelay        time.Duration `mapstructure:"drain_delay"`         // 就绪检查返回未就绪后继续接收请求的时间, 等负载均衡摘掉实例后再停止监听
+	} `mapstructure:"server"`
+	Startup struct {
+		WaitDependencies bool          `mapstructure:"wait_dependencies"` // 启动时数据库或Redis不可用是否等待重试, 为false时直接退出
+		WaitTimeout      time.Duration `mapstructure:"wait_timeout"`      // 最长等待时间, 0表示一直等待
+		RetryInterval    time.Duration `mapstructure:"retry_interval"`    // 第一次重试的间隔, 之后每次翻倍, 最长30秒
+	} `mapstructure:"startup"`
+	Health struct {
+		CheckTimeout time.Duration `mapstructure:"check_timeout"` // 健康检查中每一项检查的超时时间
+	} `mapstructure:"health"`
+	RateLimit struct {
+		Enabled          bool                     `mapstructure:"enabled"`
+		FallbackCooldown time.Duration            `mapstructure:"fallback_cooldown"` // Redis出错后多久内只使用本地限流
+		Rules            map[string]RateLimitRule `mapstructure:"rules"`             // 按规则名配置, 路由上用规则名挂载限流中间件, 修改后热加载生效
+		ApiKeys          map[string]string        `mapstructure:"api_keys"`          // 调用方名称 -> API Key, 只有这里配置过的Key才按api_key维度限流
+	} `mapstructure:"rate_limit"`
+	Idempotency struct {
+		TTL             time.Duration `mapstructure:"ttl"`               // 响应保存多久, 这段时间内相同幂等键的重试都重放响应
+		LockTimeout     time.Duration `mapstructure:"lock_timeout"`      // 请求处理超过这个时间没有完成时锁自动过期
+		MaxResponseSize int           `mapstructure:"max_response_size"` // 超过这个大小(字节)的响应不保存, 重试时重新处理
+	} `mapstructure:"idempotency"`
+	Trace struct {
+		Exporter    string  `mapstructure:"exporter"`     // 链路数据导出方式 none/stdout/file, 按OTLP JSON格式每批一行输出
+		FilePath    string  `mapstructure:"file_path"`    // exporter为file时写入的文件
+		SampleRatio float64 `mapstructure:"sample_ratio"` // 采样比例0~1, 上游已经采样的请求跟随上游
+	} `mapstructure:"trace"`
+	Log struct {
+		Level            string `mapstructure:"level"` // 日志级别 debug/info/warn/error, 为空时开发环境debug、其他环境info, 修改后热加载生效
 		FilePath         string `mapstructure:"path"`
 		FileMaxSize      int    `mapstructure:"max_size"`
 		BackUpFileMaxAge int    `mapstructure:"max_age"`
+		// 访问日志的脱敏和截断配置, 修改后热加载生效
+		MaxBodySize   int          `mapstructure:"max_body_size"`  // 请求体、响应体最多记录的字节数, 超过的部分截断
+		RedactHeaders []string     `mapstructure:"redact_headers"` // 值在日志中隐藏的请求头, 不区分大小写
+		Redact        []RedactRule `mapstructure:"redact"`         // 请求体、响应体中需要脱敏的JSON字段
 	}
 	PageInfo struct {
 		DefaultSize int `mapstructure:"default_size"`
 		MaxSize     int `mapstructure:"max_size"`
-	}
+	} `mapstructure:"page_info"`
+	AdminUserIds []int64 `mapstructure:"admin_user_ids"` // 管理员用户ID, 可以访问admin接口
+	Order        struct {
+		PayTimeout time.Duration `mapstructure:"pay_timeout"` // 订单未支付自动取消的超时时间
+	} `mapstructure:"order"`
+	Pricing struct {
+		ShippingFee           int64 `mapstructure:"shipping_fee"`            // 默认运费(分)
+		FreeShippingThreshold int64 `mapstructure:"free_shipping_threshold"` // 优惠后商品金额满多少包邮(分), 0表示不包邮
+		TaxRateBps            int64 `mapstructure:"tax_rate_bps"`            // 税率, 单位万分之一, 0表示不计税
+	} `mapstructure:"pricing"`
+	Member struct {
+		EarnRate       int64         `mapstructure:"earn_rate"`       // 订单完成后每实付1元获得的积分
+		GrowthRate     int64         `mapstructure:"growth_rate"`     // 订单完成后每实付1元增加的成长值
+		PointsPerYuan  int64         `mapstructure:"points_per_yuan"` // 多少积分抵扣1元, 0表示不能使用积分抵扣
+		MaxDeductBps   int64         `mapstructure:"max_deduct_bps"`  // 积分最多抵扣优惠后商品金额的比例, 单位万分之一
+		PointsTtl      time.Duration `mapstructure:"points_ttl"`      // 积分获得或退回后的有效期
+		ExpireInterval time.Duration `mapstructure:"expire_interval"` // 多久执行一次积分过期
+		ExpireLookback time.Duration `mapstructure:"expire_lookback"` // 过期任务回溯检查的时间范围, 覆盖服务停机期间漏掉的批次
+		Tiers          []MemberTier  `mapstructure:"tiers"`           // 会员等级, 按成长值从低到高配置
+	} `mapstructure:"member"`
+	Wallet struct {
+		CheckInterval time.Duration `mapstructure:"check_interval"` // 多久执行一次账户余额和记账分录的对账
+	} `mapstructure:"wallet"`
+	Notification struct {
+		UnsubscribeUrl    string        `mapstructure:"unsubscribe_url"`    // 通知中退订链接的地址, 实际链接为 UnsubscribeUrl?token=xxx
+		UnsubscribeSecret string        `mapstructure:"unsubscribe_secret"` // 退订链接的签名密钥
+		DedupTtl          time.Duration `mapstructure:"dedup_ttl"`          // 同一条通知的去重时间
+		SkuChangeDelay    time.Duration `mapstructure:"sku_change_delay"`   // SKU库存或价格变化后多久检查订阅, 期间的多次变化合并处理
+		UnreadTtl         time.Duration `mapstructure:"unread_ttl"`         // 未读数在Redis中的缓存时间, 过期后从数据库重新统计
+		BroadcastLookback time.Duration `mapstructure:"broadcast_lookback"` // 用户第一次打开消息中心时拉取多久以内的广播消息
+	} `mapstructure:"notification"`
+	Shipment struct {
+		TrackInterval  time.Duration `mapstructure:"track_interval"`   // 发货后多久同步一次物流轨迹
+		TrackMaxRounds int           `mapstructure:"track_max_rounds"` // 最多同步多少次, 超过后不再自动同步
+	} `mapstructure:"shipment"`
+	AfterSale struct {
+		ReviewTimeout  time.Duration `mapstructure:"review_timeout"`  // 商家超时未审核自动同意
+		ReceiveTimeout time.Duration `mapstructure:"receive_timeout"` // 买家退货后商家超时未确认收货自动确认
+	} `mapstructure:"after_sale"`
+	Review struct {
+		SensitiveWords []string      `mapstructure:"sensitive_words"` // 评价和回复发布前过滤的敏感词
+		SummaryTtl     time.Duration `mapstructure:"summary_ttl"`     // 商品评分汇总在Redis中的缓存时间
+	} `mapstructure:"review"`
+	Seckill struct {
+		AdmissionQps int           `mapstructure:"admission_qps"` // 每个活动每秒放行的抢购请求数
+		WarmupAhead  time.Duration `mapstructure:"warmup_ahead"`  // 活动开始前多久把库存预热到Redis
+		ResultTtl    time.Duration `mapstructure:"result_ttl"`    // 抢购结果在Redis中保留的时间
+	} `mapstructure:"seckill"`
+	DelayQueue struct {
+		Workers           int           `mapstructure:"workers"`
+		PollInterval      time.Duration `mapstructure:"poll_interval"`
+		VisibilityTimeout time.Duration `mapstructure:"visibility_timeout"`
+		MaxAttempts       int           `mapstructure:"max_attempts"`
+	} `mapstructure:"delay_queue"`
+	Realtime struct {
+		StreamMaxLen      int           `mapstructure:"stream_max_len"`     // 每个用户保留多少条最近的事件用于断线补发
+		StreamRetention   time.Duration `mapstructure:"stream_retention"`   // 用户没有新事件多久后删除事件记录
+		BufferSize        int           `mapstructure:"buffer_size"`        // 每个连接的事件缓冲区, 消费不过来时断开连接让客户端重连补发
+		HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"` // 连接空闲时多久发一次心跳, 避免被代理断开
+		RetryInterval     time.Duration `mapstructure:"retry_interval"`     // 建议客户端断线后多久重连
+	} `mapstructure:"realtime"`
+}
+
+// MemberTier 会员等级及权益, 成长值达到MinGrowth即为该等级
+type MemberTier struct {
+	Level        int8   `mapstructure:"level"`
+	Name         string `mapstructure:"name"`
+	MinGrowth    int64  `mapstructure:"min_growth"`
+	DiscountBps  int64  `mapstructure:"discount_bps"`  // 会员折扣, 单位万分之一, 如9500表示95折, 0或10000表示不打折
+	FreeShipping bool   `mapstructure:"free_shipping"` // 是否包邮
+}
+
+// RateLimitRule 限流规则, 按Key区分的每个调用方在Period内最多请求Limit次, 允许Limit次以内的突发请求
+type RateLimitRule struct {
+	Key    string        `mapstructure:"key"` // 限流维度 ip/user/api_key, user和api_key取不到时按ip限流
+	Limit  int           `mapstructure:"limit"`
+	Period time.Duration `mapstructure:"period"`
+}
+
+// RedactRule 日志脱敏规则, Field和Path二选一
+type RedactRule struct {
+	Field string `mapstructure:"field"` // 字段名, 匹配任意层级的同名字段
+	Path  string `mapstructure:"path"`  // 从根开始用.分隔的JSON路径, 数组元素沿用数组的路径, 比如 data.list.receiver_phone
+	Mask  string `mapstructure:"mask"`  // 脱敏方式 hide/phone/email/login_name/name, 为空时hide
 }
 
 type databaseConfig struct {
-	Master DbConnectOption `mapstructure:"master"`
-	Slave  DbConnectOption `mapstructure:"slave"`
+	Master        DbConnectOption `mapstructure:"master"`
+	Slave         DbConnectOption `mapstructure:"slave"`
+	SlowThreshold time.Duration   `mapstructure:"slow_threshold"` // 超过这个时间的SQL记录慢查询日志, 修改后热加载生效
 }
 
 type DbConnectOption struct {
@@ -42,3 +158,19 @@ type RedisConfig struct {
 	PoolSize int    `mapstructure:"pool_size"`
 	DB       int    `mapstructure:"db"`
 }
+
+type paymentConfig struct {
+	NotifyUrl string `mapstructure:"notify_url"` // 支付结果回调地址前缀, 实际地址为 NotifyUrl/{provider}
+	Mock      struct {
+		GatewayUrl  string        `mapstructure:"gateway_url"`  // 模拟支付网关地址
+		Secret      string        `mapstructure:"secret"`       // 回调通知的签名密钥
+		NotifyDelay time.Duration `mapstructure:"notify_delay"` // 下单后多久模拟用户完成支付
+	} `mapstructure:"mock"`
+}
+
+type carrierConfig struct {
+	Mock struct {
+		GatewayUrl   string        `mapstructure:"gateway_url"`   // 模拟物流网关地址
+		StepInterval time.Duration `mapstructure:"step_interval"` // 模拟的物流轨迹每隔多久前进一个节点
+	} `mapstructure:"mock"`
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot 一份校验通过的完整配置, 加载后不再修改
+// 热加载时整体替换成新的快照, 读取方拿到的快照在使用期间保持一致
+type Snapshot struct {
+	App      *appConfig
+	Database *databaseConfig
+	Redis    *RedisConfig
+	Payment  *paymentConfig
+	Carrier  *carrierConfig
+	Env      string
+	Files    []string  // 生效的配置文件
+	Version  int64     // 每次热加载成功加1, 启动时为1
+	LoadedAt time.Time // 加载时间
+}
+
+// ChangeHandler 配置变化的回调, prev是替换前的快照
+type ChangeHandler func(prev, next *Snapshot)
+
+var (
+	current atomic.Pointer[Snapshot]
+
+	subscribersMu sync.Mutex
+	subscribers   []*subscriber
+	subscriberSeq int64
+)
+
+type subscriber struct {
+	id      int64
+	name    string
+	handler ChangeHandler
+}
+
+// Current 当前生效的配置快照
+func Current() *Snapshot {
+	return current.Load()
+}
+
+func AppConfig() *appConfig {
+	return current.Load().App
+}
+
+func Database() *databaseConfig {
+	return current.Load().Database
+}
+
+func Redis() *RedisConfig {
+	return current.Load().Redis
+}
+
+func Payment() *paymentConfig {
+	return current.Load().Payment
+}
+
+func Carrier() *carrierConfig {
+	return current.Load().Carrier
+}
+
+// Subscribe 订阅配置热加载, 新配置生效后按订阅顺序同步回调, 返回取消订阅的函数
+// 每次使用时都从Current读取的配置项不需要订阅; 需要重建对象或者同步状态的(比如日志级别)在回调里处理
+func Subscribe(name string, handler ChangeHandler) (unsubscribe func()) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+	subscriberSeq++
+	id := subscriberSeq
+	subscribers = append(subscribers, &subscriber{id: id, name: name, handler: handler})
+	return func() {
+		subscribersMu.Lock()
+		defer subscribersMu.Unlock()
+		for i, sub := range subscribers {
+			if sub.id == id {
+				subscribers = append(subscribers[:i:i], subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// swap 替换当前快照并通知订阅方
+func swap(next *Snapshot) {
+	prev := current.Swap(next)
+	if prev == nil {
+		return
+	}
+	subscribersMu.Lock()
+	handlers := make([]*subscriber, len(subscribers))
+	copy(handlers, subscribers)
+	subscribersMu.Unlock()
+	for _, sub := range handlers {
+		notify(sub, prev, next)
+	}
+}
+
+// notify 回调订阅方, 单个订阅方panic不影响其他订阅方
+func notify(sub *subscriber, prev, next *Snapshot) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("配置变更回调 %s 执行失败: %v\n", sub.name, r)
+		}
+	}()
+	sub.handler(prev, next)
+}
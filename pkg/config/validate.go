@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// validateConfig 校验必填项和取值范围, 返回的错误列出全部有问题的配置项
+func validateConfig(snapshot *Snapshot) error {
+	v := new(validator)
+	app := snapshot.App
+	if app == nil {
+		return errors.New("配置校验失败: 缺少 app 配置")
+	}
+	v.required("app.name", app.Name)
+	v.required("app.jwtSecret", app.JwtSecret)
+	v.required("app.server.addr", app.Server.Addr)
+	v.nonNegative("app.server.read_timeout", app.Server.ReadTimeout)
+	v.nonNegative("app.server.read_header_timeout", app.Server.ReadHeaderTimeout)
+	v.nonNegative("app.server.write_timeout", app.Server.WriteTimeout)
+	v.nonNegative("app.server.idle_timeout", app.Server.IdleTimeout)
+	v.nonNegative("app.server.shutdown_timeout", app.Server.ShutdownTimeout)
+	v.nonNegative("app.server.drain_delay", app.Server.DrainDelay)
+	v.nonNegative("app.startup.wait_timeout", app.Startup.WaitTimeout)
+	v.nonNegative("app.startup.retry_interval", app.Startup.RetryInterval)
+	v.nonNegative("app.health.check_timeout", app.Health.CheckTimeout)
+	v.check(contains(TraceExporters, app.Trace.Exporter), "app.trace.exporter", "可选值: "+strings.Join(TraceExporters, "/"))
+	if app.Trace.Exporter == "file" {
+		v.required("app.trace.file_path", app.Trace.FilePath)
+	}
+	v.check(app.Trace.SampleRatio >= 0 && app.Trace.SampleRatio <= 1, "app.trace.sample_ratio", "必须在0~1之间")
+	v.required("app.log.path", app.Log.FilePath)
+	v.check(app.Log.Level == "" || contains(LogLevels, app.Log.Level), "app.log.level", "可选值: "+strings.Join(LogLevels, "/"))
+	v.check(app.Log.MaxBodySize > 0, "app.log.max_body_size", "必须大于0")
+	for i, rule := range app.Log.Redact {
+		key := fmt.Sprintf("app.log.redact[%d]", i)
+		v.check((rule.Field == "") != (rule.Path == ""), key, "field和path必须且只能配置一个")
+		v.check(rule.Mask == "" || contains(RedactMasks, rule.Mask), key+".mask", "可选值: "+strings.Join(RedactMasks, "/"))
+	}
+	v.check(app.PageInfo.DefaultSize > 0, "app.page_info.default_size", "必须大于0")
+	v.check(app.PageInfo.MaxSize >= app.PageInfo.DefaultSize, "app.page_info.max_size", "不能小于default_size")
+	v.check(app.Pricing.ShippingFee >= 0, "app.pricing.shipping_fee", "不能为负数")
+	v.check(app.Pricing.TaxRateBps >= 0 && app.Pricing.TaxRateBps <= 10000, "app.pricing.tax_rate_bps", "必须在0~10000之间")
+	v.check(app.Member.MaxDeductBps >= 0 && app.Member.MaxDeductBps <= 10000, "app.member.max_deduct_bps", "必须在0~10000之间")
+	for i, tier := range app.Member.Tiers {
+		key := fmt.Sprintf("app.member.tiers[%d]", i)
+		v.check(tier.DiscountBps >= 0 && tier.DiscountBps <= 10000, key+".discount_bps", "必须在0~10000之间")
+		if i > 0 {
+			v.check(tier.MinGrowth > app.Member.Tiers[i-1].MinGrowth, key+".min_growth", "会员等级必须按成长值从低到高配置")
+		}
+	}
+	v.required("app.notification.unsubscribe_secret", app.Notification.UnsubscribeSecret)
+	v.check(app.Seckill.AdmissionQps >= 0, "app.seckill.admission_qps", "不能为负数")
+	v.check(app.DelayQueue.Workers >= 0, "app.delay_queue.workers", "不能为负数")
+	v.nonNegative("app.rate_limit.fallback_cooldown", app.RateLimit.FallbackCooldown)
+	v.nonNegative("app.idempotency.ttl", app.Idempotency.TTL)
+	v.nonNegative("app.idempotency.lock_timeout", app.Idempotency.LockTimeout)
+	v.check(app.Idempotency.MaxResponseSize > 0, "app.idempotency.max_response_size", "必须大于0")
+	for _, name := range sortedKeys(app.RateLimit.ApiKeys) {
+		v.required("app.rate_limit.api_keys."+name, app.RateLimit.ApiKeys[name])
+	}
+	for _, name := range sortedKeys(app.RateLimit.Rules) {
+		rule, key := app.RateLimit.Rules[name], "app.rate_limit.rules."+name
+		v.check(contains(RateLimitKeys, rule.Key), key+".key", "可选值: "+strings.Join(RateLimitKeys, "/"))
+		v.check(rule.Limit > 0, key+".limit", "必须大于0")
+		v.check(rule.Period > 0, key+".period", "必须大于0")
+	}
+
+	if snapshot.Database == nil {
+		v.add("database", "缺少数据库配置")
+	} else {
+		v.dbOption("database.master", snapshot.Database.Master)
+		v.dbOption("database.slave", snapshot.Database.Slave)
+		v.nonNegative("database.slow_threshold", snapshot.Database.SlowThreshold)
+	}
+	if snapshot.Redis == nil {
+		v.add("redis", "缺少Redis配置")
+	} else {
+		v.required("redis.address", snapshot.Redis.Address)
+	}
+	if snapshot.Payment == nil {
+		v.add("payment", "缺少支付配置")
+	} else {
+		v.required("payment.notify_url", snapshot.Payment.NotifyUrl)
+		v.required("payment.mock.secret", snapshot.Payment.Mock.Secret)
+	}
+	if snapshot.Carrier == nil {
+		v.add("carrier", "缺少物流配置")
+	}
+
+	// 生产环境不能使用开发环境的弱密钥
+	if app.Env == "prod" {
+		v.strongSecret("app.jwtSecret", app.JwtSecret)
+		v.strongSecret("app.notification.unsubscribe_secret", app.Notification.UnsubscribeSecret)
+		v.check(len(app.AdminUserIds) > 0, "app.admin_user_ids", "生产环境必须显式配置管理员")
+	}
+	return v.err()
+}
+
+// LogLevels 支持的日志级别
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// RedactMasks 支持的日志脱敏方式
+var RedactMasks = []string{"hide", "phone", "email", "login_name", "name"}
+
+// RateLimitKeys 支持的限流维度
+var RateLimitKeys = []string{"ip", "user", "api_key"}
+
+// TraceExporters 支持的链路导出方式
+var TraceExporters = []string{"none", "stdout", "file"}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(key, problem string) {
+	v.problems = append(v.problems, fmt.Sprintf("%s: %s", key, problem))
+}
+
+func (v *validator) check(ok bool, key, problem string) {
+	if !ok {
+		v.add(key, problem)
+	}
+}
+
+func (v *validator) required(key, value string) {
+	v.check(strings.TrimSpace(value) != "", key, "不能为空")
+}
+
+// strongSecret 密钥为空时已经由required报告, 这里只检查长度
+func (v *validator) strongSecret(key, value string) {
+	v.check(value == "" || len(value) >= 16, key, "生产环境至少16个字符")
+}
+
+func (v *validator) dbOption(key string, option DbConnectOption) {
+	v.required(key+".type", option.Type)
+	v.required(key+".dsn", option.DSN)
+	v.check(option.MaxOpenConn >= 0, key+".maxopen", "不能为负数")
+}
+
+func (v *validator) nonNegative(key string, d time.Duration) {
+	v.check(d >= 0, key, "不能为负数")
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("配置校验失败, 共%d项:\n  - %s\n可以修改配置文件, 或者用 %s_ 开头的环境变量设置, 比如 %s_REDIS_ADDRESS",
+		len(v.problems), strings.Join(v.problems, "\n  - "), envPrefix, envPrefix)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,53 +1,270 @@
 package config
 
 import (
+	"flag"
 	"fmt"
 	"github.com/fsnotify/fsnotify"
 	"github.com/spf13/viper"
 	"log"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
+// 配置按以下顺序加载, 后面的覆盖前面的:
+//
+//  1. 基础配置文件, 默认是工作目录下的 pkg/config/application.env.yaml, 可以用 --config 或 GOMALL_CONFIG 指定
+//  2. 环境配置文件, 和基础配置文件同目录的 application.{env}.yaml, 不存在时跳过
+//  3. GOMALL_ 开头的环境变量, 键名中的 . 换成 _ 并转成大写, 比如 GOMALL_DATABASE_MASTER_DSN、GOMALL_APP_JWTSECRET
+//
+// 运行环境依次取 --env、GOMALL_ENV、基础配置文件中的 app.env, 都没有时为 dev
+
+const envPrefix = "GOMALL"
+
+var (
+	configFile = flag.String("config", "", "基础配置文件路径, 默认为 pkg/config/application.env.yaml")
+	configEnv  = flag.String("env", "", "运行环境 dev/test/prod, 默认取配置文件中的 app.env")
+)
+
+// Envs 支持的运行环境
+var Envs = []string{"dev", "test", "prod"}
+
+// loadResult 一次加载用到的配置来源, 用于启动时输出配置报告
+type loadResult struct {
+	env       string
+	files     []string
+	overrides []string // 生效的环境变量名, 不记录值, 避免密钥进入日志
+}
+
+// InitConfig 加载并校验配置, 配置有误时列出全部问题后退出
+// 使用 --config、--env 参数时需要在调用前执行 flag.Parse()
 func InitConfig() {
-	// 使用viper加载配置信息
+	snapshot, result, err := buildSnapshot()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	snapshot.Version = 1
+	current.Store(snapshot)
+	printReport(result)
+	watchConfig(result.files)
+}
+
+// buildSnapshot 按完整的顺序加载配置, 解析并校验后生成快照
+func buildSnapshot() (*Snapshot, *loadResult, error) {
+	config, result, err := loadConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	snapshot, err := parseConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	snapshot.Env, snapshot.Files, snapshot.LoadedAt = result.env, result.files, time.Now()
+	if err = validateConfig(snapshot); err != nil {
+		return nil, nil, err
+	}
+	return snapshot, result, nil
+}
+
+// loadConfig 依次读取基础配置文件、环境配置文件和环境变量
+func loadConfig() (*viper.Viper, *loadResult, error) {
+	result := new(loadResult)
+	baseFile := *configFile
+	if baseFile == "" {
+		baseFile = os.Getenv(envPrefix + "_CONFIG")
+	}
+	if baseFile == "" {
+		dir, _ := os.Getwd()
+		baseFile = filepath.Join(dir, "pkg", "config", "application.env.yaml")
+	}
+
 	config := viper.New()
-	dir, _ := os.Getwd()
-	config.AddConfigPath(dir + "/pkg/config")
-	config.SetConfigName("application.env")
+	config.SetConfigFile(baseFile)
 	config.SetConfigType("yaml")
-
 	if err := config.ReadInConfig(); err != nil {
-		log.Fatalf("读取配置文件失败: %v", err)
+		return nil, nil, err
 	}
+	result.files = append(result.files, baseFile)
 
-	// 读取的配置信息写到结构体中
-	err := parseConfig(config)
-	if err != nil {
-		// 解析配置文件失败,应该直接panic并抛出错误，否则其他初始化基本无法进行
-		panic(err)
+	result.env = *configEnv
+	if result.env == "" {
+		result.env = os.Getenv(envPrefix + "_ENV")
+	}
+	if result.env == "" {
+		result.env = config.GetString("app.env")
+	}
+	if result.env == "" {
+		result.env = "dev"
+	}
+	if !contains(Envs, result.env) {
+		return nil, nil, fmt.Errorf("运行环境 %q 无效, 可选值: %s", result.env, strings.Join(Envs, "/"))
+	}
+
+	overlayFile := filepath.Join(filepath.Dir(baseFile), "application."+result.env+".yaml")
+	if _, err := os.Stat(overlayFile); err == nil {
+		config.SetConfigFile(overlayFile)
+		if err = config.MergeInConfig(); err != nil {
+			return nil, nil, fmt.Errorf("读取环境配置文件 %s 失败: %w", overlayFile, err)
+		}
+		result.files = append(result.files, overlayFile)
+	}
+
+	// 绑定每一个配置项的环境变量, 配置文件里没有的键(比如不想写进文件的密钥)也能通过环境变量设置
+	config.SetEnvPrefix(envPrefix)
+	config.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	for key, fieldType := range configSections() {
+		bindEnvs(config, key, fieldType)
+	}
+	for _, key := range config.AllKeys() {
+		envName := envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		if _, ok := os.LookupEnv(envName); ok {
+			result.overrides = append(result.overrides, envName)
+		}
+	}
+	sort.Strings(result.overrides)
+	config.Set("app.env", result.env)
+	return config, result, nil
+}
+
+// configSections 顶层配置键和对应的结构体类型
+func configSections() map[string]reflect.Type {
+	return map[string]reflect.Type{
+		"app":      reflect.TypeOf(appConfig{}),
+		"database": reflect.TypeOf(databaseConfig{}),
+		"redis":    reflect.TypeOf(RedisConfig{}),
+		"payment":  reflect.TypeOf(paymentConfig{}),
+		"carrier":  reflect.TypeOf(carrierConfig{}),
 	}
+}
 
-	// 监听配置文件变化
-	config.WatchConfig()
-	config.OnConfigChange(func(e fsnotify.Event) {
-		fmt.Println("配置文件已修改:", e.Name)
-		err = parseConfig(config)
-		if err != nil {
-			panic(err)
+// bindEnvs 按mapstructure标签递归绑定结构体每个字段的环境变量
+// 结构体切片(比如会员等级)和map(比如限流规则)不能用一个环境变量表达, 不绑定
+func bindEnvs(config *viper.Viper, prefix string, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		key := strings.ToLower(prefix + "." + name)
+		switch {
+		case field.Type.Kind() == reflect.Struct && field.Type.PkgPath() != "time":
+			bindEnvs(config, key, field.Type)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct,
+			field.Type.Kind() == reflect.Map:
+			continue
+		default:
+			_ = config.BindEnv(key)
 		}
-	})
+	}
+}
+
+// parseConfig 把合并后的配置解析成快照
+// 整体Unmarshal才会带上环境变量, 按键UnmarshalKey时只能读到配置文件里的值
+func parseConfig(config *viper.Viper) (*Snapshot, error) {
+	var sections struct {
+		App      *appConfig      `mapstructure:"app"`
+		Redis    *RedisConfig    `mapstructure:"redis"`
+		Database *databaseConfig `mapstructure:"database"`
+		Payment  *paymentConfig  `mapstructure:"payment"`
+		Carrier  *carrierConfig  `mapstructure:"carrier"`
+	}
+	if err := config.Unmarshal(&sections); err != nil {
+		return nil, fmt.Errorf("解析配置失败: %w", err)
+	}
+	return &Snapshot{
+		App:      sections.App,
+		Redis:    sections.Redis,
+		Database: sections.Database,
+		Payment:  sections.Payment,
+		Carrier:  sections.Carrier,
+	}, nil
+}
+
+// printReport 输出配置来源, 日志还没有初始化, 直接打印到标准输出
+func printReport(result *loadResult) {
+	fmt.Printf("配置加载完成, 运行环境: %s\n", result.env)
+	for _, file := range result.files {
+		fmt.Printf("  配置文件: %s\n", file)
+	}
+	if len(result.overrides) > 0 {
+		fmt.Printf("  环境变量覆盖: %s\n", strings.Join(result.overrides, ", "))
+	}
+}
 
+// reloadMu 串行化热加载, 编辑器保存文件时可能连续触发多次变更事件
+var reloadMu sync.Mutex
+
+// watchConfig 监听配置文件变化, 任一文件修改后重新按完整的顺序加载
+func watchConfig(files []string) {
+	for _, file := range files {
+		watcher := viper.New()
+		watcher.SetConfigFile(file)
+		watcher.WatchConfig()
+		watcher.OnConfigChange(func(e fsnotify.Event) {
+			fmt.Println("配置文件已修改:", e.Name)
+			reload()
+		})
+	}
+}
+
+// reload 重新加载配置, 新配置校验通过后整体替换并通知订阅方, 否则保留原来的配置
+func reload() {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	next, _, err := buildSnapshot()
+	if err != nil {
+		fmt.Printf("重新加载配置失败, 继续使用版本%d的配置: %v\n", Current().Version, err)
+		return
+	}
+	prev := Current()
+	next.Version = prev.Version + 1
+	if keys := restartRequired(prev, next); len(keys) > 0 {
+		fmt.Printf("以下配置需要重启服务才能生效: %s\n", strings.Join(keys, ", "))
+	}
+	swap(next)
+	fmt.Printf("配置已重新加载, 当前版本%d\n", next.Version)
 }
 
-func parseConfig(config *viper.Viper) error {
-	if err := config.UnmarshalKey("app", &AppConfig); err != nil {
-		return fmt.Errorf("解析AppConfig失败: %w", err)
+// restartRequired 启动时就建立好的监听地址、连接池等配置, 热加载不会生效
+func restartRequired(prev, next *Snapshot) []string {
+	keys := make([]string, 0)
+	if prev.Env != next.Env {
+		keys = append(keys, "app.env")
+	}
+	if prev.App.Server != next.App.Server {
+		keys = append(keys, "app.server")
 	}
-	if err := config.UnmarshalKey("redis", &Redis); err != nil {
-		return fmt.Errorf("解析Redis配置失败: %w", err)
+	if prev.App.Log.FilePath != next.App.Log.FilePath {
+		keys = append(keys, "app.log.path")
 	}
-	if err := config.UnmarshalKey("database", &Database); err != nil {
-		return fmt.Errorf("解析Database配置失败: %w", err)
+	if prev.App.RateLimit.FallbackCooldown != next.App.RateLimit.FallbackCooldown {
+		keys = append(keys, "app.rate_limit.fallback_cooldown")
+	}
+	if prev.App.Idempotency.TTL != next.App.Idempotency.TTL || prev.App.Idempotency.LockTimeout != next.App.Idempotency.LockTimeout {
+		keys = append(keys, "app.idempotency")
+	}
+	if prev.App.Trace != next.App.Trace {
+		keys = append(keys, "app.trace")
+	}
+	if prev.Database.Master != next.Database.Master || prev.Database.Slave != next.Database.Slave {
+		keys = append(keys, "database")
+	}
+	if *prev.Redis != *next.Redis {
+		keys = append(keys, "redis")
+	}
+	return keys
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
-	return nil
+	return false
 }
@@ -0,0 +1,437 @@
+package delayqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 基于Redis有序集合的延时队列
+//
+//	{name}:delayed  ZSET  member为任务ID, score为任务下次可执行的时间戳(毫秒)
+//	{name}:jobs     HASH  任务ID -> 任务详情JSON
+//	{name}:dead     ZSET  超过最大重试次数的任务, score为进入死信的时间
+//
+// 轮询协程领取到期任务时不会把任务从delayed中删掉, 而是把score推后一个可见性超时(租约),
+// 任务处理成功后才真正删除。进程在处理过程中崩溃时, 租约到期后任务会被重新领取,
+// 以此保证任务至少被处理一次(at-least-once), 所以任务的处理函数必须是幂等的。
+
+// claimScript 原子地领取到期任务并续租
+var claimScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, id in ipairs(ids) do
+	redis.call('ZADD', KEYS[1], ARGV[3], id)
+end
+return ids
+`)
+
+// retryScript 任务失败后按新的执行时间放回队列, 执行期间任务已经被Remove删掉时不再放回
+// KEYS[1] delayed KEYS[2] jobs; ARGV[1] 任务ID ARGV[2] 任务详情 ARGV[3] 下次执行时间
+var retryScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[1], ARGV[3], ARGV[1])
+return 1
+`)
+
+// deadScript 任务移入死信, 执行期间任务已经被Remove删掉时不再移入
+// KEYS[1] delayed KEYS[2] jobs KEYS[3] dead; ARGV[1] 任务ID ARGV[2] 任务详情 ARGV[3] 进入死信的时间
+var deadScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[3], ARGV[3], ARGV[1])
+return 1
+`)
+
+// ErrHandlerNotFound 任务的主题没有注册处理函数
+var ErrHandlerNotFound = errors.New("handler not found")
+
+// Job 延时任务
+type Job struct {
+	Id       string          `json:"id"`
+	Topic    string          `json:"topic"`
+	Payload  json.RawMessage `json:"payload"`
+	Attempts int             `json:"attempts"` // 已经尝试执行的次数
+	RunAt    time.Time       `json:"run_at"`   // 下次执行时间
+	LastErr  string          `json:"last_err"`
+}
+
+// Handler 任务处理函数, 返回error时任务会按退避时间重试
+type Handler func(ctx context.Context, job *Job) error
+
+type Queue struct {
+	client    redis.UniversalClient
+	name      string
+	config    *Config
+	handlers  map[string]Handler
+	deadHooks map[string]Handler
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	running   atomic.Bool
+	lastPoll  atomic.Int64 // 轮询协程最近一次领取任务的时间(纳秒)
+}
+
+// Config 队列的配置参数, 通过Option设置
+type Config struct {
+	workers           int           // 处理任务的协程数
+	pollInterval      time.Duration // 没有到期任务时的轮询间隔
+	batchSize         int           // 每次领取的最大任务数
+	visibilityTimeout time.Duration // 任务领取后的租约时长, 超过这个时间未确认会被重新领取
+	maxAttempts       int           // 最大尝试次数, 超过后进入死信
+	retryBackoff      time.Duration // 失败重试的基础退避时间, 按尝试次数线性递增
+}
+
+type Option func(c *Config)
+
+func WithWorkers(n int) Option {
+	return func(c *Config) {
+		if n > 0 {
+			c.workers = n
+		}
+	}
+}
+
+func WithPollInterval(d time.Duration) Option {
+	return func(c *Config) {
+		if d > 0 {
+			c.pollInterval = d
+		}
+	}
+}
+
+func WithVisibilityTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		if d > 0 {
+			c.visibilityTimeout = d
+		}
+	}
+}
+
+func WithMaxAttempts(n int) Option {
+	return func(c *Config) {
+		if n > 0 {
+			c.maxAttempts = n
+		}
+	}
+}
+
+func WithRetryBackoff(d time.Duration) Option {
+	return func(c *Config) {
+		if d > 0 {
+			c.retryBackoff = d
+		}
+	}
+}
+
+// New 创建延时队列, name作为Redis键名前缀
+func New(client redis.UniversalClient, name string, opts ...Option) *Queue {
+	config := &Config{
+		workers:           4,
+		pollInterval:      time.Second,
+		batchSize:         100,
+		visibilityTimeout: 30 * time.Second,
+		maxAttempts:       10,
+		retryBackoff:      5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return &Queue{
+		client:    client,
+		name:      name,
+		config:    config,
+		handlers:  make(map[string]Handler),
+		deadHooks: make(map[string]Handler),
+	}
+}
+
+// Register 注册主题的处理函数, 需要在Start之前调用
+func (q *Queue) Register(topic string, handler Handler) {
+	q.handlers[topic] = handler
+}
+
+// RegisterDeadHook 注册任务进入死信时的回调, 用来补偿重试耗尽后业务上占用的资源, 需要在Start之前调用
+func (q *Queue) RegisterDeadHook(topic string, hook Handler) {
+	q.deadHooks[topic] = hook
+}
+
+// Push 投递任务, delay后执行。相同ID的任务重复投递只会保留一个, 执行时间以最后一次投递为准
+func (q *Queue) Push(ctx context.Context, topic, id string, payload interface{}, delay time.Duration) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	job := &Job{
+		Id:      topic + ":" + id,
+		Topic:   topic,
+		Payload: data,
+		RunAt:   time.Now().Add(delay),
+	}
+	jobBytes, _ := json.Marshal(job)
+	pipe := q.client.TxPipeline()
+	pipe.HSet(ctx, q.jobsKey(), job.Id, jobBytes)
+	pipe.ZAdd(ctx, q.delayedKey(), redis.Z{Score: float64(job.RunAt.UnixMilli()), Member: job.Id})
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Remove 删除尚未执行的任务, 比如订单已支付时删掉它的超时取消任务
+func (q *Queue) Remove(ctx context.Context, topic, id string) error {
+	return q.ack(ctx, topic+":"+id)
+}
+
+// ack 任务处理完成, 删除任务
+func (q *Queue) ack(ctx context.Context, jobId string) error {
+	pipe := q.client.TxPipeline()
+	pipe.ZRem(ctx, q.delayedKey(), jobId)
+	pipe.HDel(ctx, q.jobsKey(), jobId)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Pending 分页查询等待执行的任务(包含正在执行中的), 按执行时间升序
+func (q *Queue) Pending(ctx context.Context, offset, limit int) ([]*Job, int64, error) {
+	total, err := q.client.ZCard(ctx, q.delayedKey()).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	ids, err := q.client.ZRange(ctx, q.delayedKey(), int64(offset), int64(offset+limit-1)).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	jobs, err := q.getJobs(ctx, ids)
+	return jobs, total, err
+}
+
+// DeadCount 死信任务的数量
+func (q *Queue) DeadCount(ctx context.Context) (int64, error) {
+	return q.client.ZCard(ctx, q.deadKey()).Result()
+}
+
+// Start 启动轮询协程和处理任务的协程池
+func (q *Queue) Start(ctx context.Context) {
+	ctx, q.cancel = context.WithCancel(ctx)
+	jobCh := make(chan string, q.config.batchSize)
+
+	q.running.Store(true)
+	q.lastPoll.Store(time.Now().UnixNano())
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		defer q.running.Store(false)
+		defer close(jobCh)
+		q.poll(ctx, jobCh)
+	}()
+
+	for i := 0; i < q.config.workers; i++ {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			q.work(ctx, jobCh)
+		}()
+	}
+}
+
+// work 处理协程, 停止队列后不再取新的任务, 只等正在执行的任务完成
+func (q *Queue) work(ctx context.Context, jobCh <-chan string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case jobId, ok := <-jobCh:
+			// ctx和jobCh同时就绪时select随机选一个, 取到任务后再检查一次
+			if !ok || ctx.Err() != nil {
+				return
+			}
+			q.process(ctx, jobId)
+		}
+	}
+}
+
+// Stop 停止领取新任务并等待正在执行的任务完成, ctx到期时不再等待并返回ctx的错误
+// 已领取但还没开始执行的任务留在队列里, 租约到期后被重新领取
+func (q *Queue) Stop(ctx context.Context) error {
+	if q.cancel != nil {
+		q.cancel()
+	}
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Check 检查轮询协程是否在正常领取任务, 用于健康检查
+// 处理协程全部阻塞时轮询协程没法把任务交出去, 超过租约时长没有领取新任务视为异常
+func (q *Queue) Check(ctx context.Context) error {
+	if !q.running.Load() {
+		return errors.New("延时队列未运行")
+	}
+	stallTimeout := max(q.config.visibilityTimeout, 3*q.config.pollInterval)
+	if idle := time.Since(time.Unix(0, q.lastPoll.Load())); idle > stallTimeout {
+		return fmt.Errorf("轮询协程已经%s没有领取任务", idle.Truncate(time.Second))
+	}
+	return nil
+}
+
+func (q *Queue) poll(ctx context.Context, jobCh chan<- string) {
+	log := logger.NewLogger(ctx)
+	for {
+		now := time.Now()
+		q.lastPoll.Store(now.UnixNano())
+		ids, err := claimScript.Run(ctx, q.client, []string{q.delayedKey()},
+			now.UnixMilli(), q.config.batchSize, now.Add(q.config.visibilityTimeout).UnixMilli(),
+		).StringSlice()
+		if err != nil && !errors.Is(err, context.Canceled) {
+			log.Error("DelayQueueClaimError", "queue", q.name, "err", err)
+		}
+		for _, id := range ids {
+			select {
+			case jobCh <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+		// 领满一批说明可能还有到期任务, 不等待直接进行下一轮
+		if len(ids) == q.config.batchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(q.config.pollInterval):
+		}
+	}
+}
+
+func (q *Queue) process(ctx context.Context, jobId string) {
+	log := logger.NewLogger(ctx)
+	// 执行任务和确认任务使用独立的ctx, 停止队列时让已领取的任务能完整执行
+	jobCtx := context.WithoutCancel(ctx)
+	jobs, err := q.getJobs(jobCtx, []string{jobId})
+	if err != nil {
+		log.Error("DelayQueueGetJobError", "queue", q.name, "jobId", jobId, "err", err)
+		return
+	}
+	if len(jobs) == 0 {
+		// 任务详情不存在(已被删除), 清理掉残留的ID
+		q.client.ZRem(jobCtx, q.delayedKey(), jobId)
+		return
+	}
+	job := jobs[0]
+	job.Attempts++
+	if handler, ok := q.handlers[job.Topic]; ok {
+		err = q.safeHandle(jobCtx, handler, job)
+	} else {
+		// 滚动发布时新增的主题可能被旧实例领取, 主题下线后也可能残留任务,
+		// 按失败处理走退避重试, 交给其他实例处理, 超过最大重试次数后进入死信
+		log.Error("DelayQueueHandlerNotFound", "queue", q.name, "jobId", jobId, "topic", job.Topic)
+		err = ErrHandlerNotFound
+	}
+	if err == nil {
+		if ackErr := q.ack(jobCtx, job.Id); ackErr != nil {
+			log.Error("DelayQueueAckError", "queue", q.name, "jobId", jobId, "err", ackErr)
+		}
+		return
+	}
+
+	log.Warn("DelayQueueJobFailed", "queue", q.name, "jobId", jobId, "attempts", job.Attempts, "err", err)
+	job.LastErr = err.Error()
+	if job.Attempts >= q.config.maxAttempts {
+		q.moveToDead(jobCtx, job)
+		return
+	}
+	job.RunAt = time.Now().Add(time.Duration(job.Attempts) * q.config.retryBackoff)
+	jobBytes, _ := json.Marshal(job)
+	retried, err := retryScript.Run(jobCtx, q.client, []string{q.delayedKey(), q.jobsKey()},
+		job.Id, jobBytes, job.RunAt.UnixMilli()).Int()
+	if err != nil {
+		log.Error("DelayQueueRetryError", "queue", q.name, "jobId", jobId, "err", err)
+		return
+	}
+	if retried == 0 {
+		log.Info("DelayQueueJobRemoved", "queue", q.name, "jobId", jobId)
+	}
+}
+
+// safeHandle 执行任务处理函数, 处理函数panic时转换成error走重试逻辑
+func (q *Queue) safeHandle(ctx context.Context, handler Handler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+func (q *Queue) moveToDead(ctx context.Context, job *Job) {
+	jobBytes, _ := json.Marshal(job)
+	moved, err := deadScript.Run(ctx, q.client, []string{q.delayedKey(), q.jobsKey(), q.deadKey()},
+		job.Id, jobBytes, time.Now().UnixMilli()).Int()
+	if err != nil {
+		logger.NewLogger(ctx).Error("DelayQueueMoveToDeadError", "queue", q.name, "jobId", job.Id, "err", err)
+		return
+	}
+	if moved == 0 {
+		logger.NewLogger(ctx).Info("DelayQueueJobRemoved", "queue", q.name, "jobId", job.Id)
+		return
+	}
+	logger.NewLogger(ctx).Error("DelayQueueJobDead", "queue", q.name, "jobId", job.Id, "attempts", job.Attempts, "lastErr", job.LastErr)
+	if hook, ok := q.deadHooks[job.Topic]; ok {
+		if err = q.safeHandle(ctx, hook, job); err != nil {
+			logger.NewLogger(ctx).Error("DelayQueueDeadHookError", "queue", q.name, "jobId", job.Id, "err", err)
+		}
+	}
+}
+
+func (q *Queue) getJobs(ctx context.Context, ids []string) ([]*Job, error) {
+	if len(ids) == 0 {
+		return []*Job{}, nil
+	}
+	values, err := q.client.HMGet(ctx, q.jobsKey(), ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, 0, len(values))
+	for _, value := range values {
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+		job := new(Job)
+		if err = json.Unmarshal([]byte(str), job); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+func (q *Queue) delayedKey() string {
+	return q.name + ":delayed"
+}
+
+func (q *Queue) jobsKey() string {
+	return q.name + ":jobs"
+}
+
+func (q *Queue) deadKey() string {
+	return q.name + ":dead"
+}
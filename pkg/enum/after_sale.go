@@ -0,0 +1,32 @@
+package enum
+
+// 售后类型
+const (
+	AfterSaleTypeRefund       int8 = 1 // 仅退款
+	AfterSaleTypeReturnRefund int8 = 2 // 退货退款
+)
+
+// 售后单状态
+const (
+	AfterSaleStateApplied   int8 = 1 // 已申请, 待商家审核
+	AfterSaleStateRejected  int8 = 2 // 商家已驳回
+	AfterSaleStateApproved  int8 = 3 // 商家已同意, 待买家退货
+	AfterSaleStateReturned  int8 = 4 // 买家已退货, 待商家收货
+	AfterSaleStateRefunding int8 = 5 // 退款中
+	AfterSaleStateRefunded  int8 = 6 // 已退款
+	AfterSaleStateCancelled int8 = 7 // 买家已撤销
+)
+
+var AfterSaleStateName = map[int8]string{
+	AfterSaleStateApplied:   "待审核",
+	AfterSaleStateRejected:  "已驳回",
+	AfterSaleStateApproved:  "待退货",
+	AfterSaleStateReturned:  "待收货",
+	AfterSaleStateRefunding: "退款中",
+	AfterSaleStateRefunded:  "已退款",
+	AfterSaleStateCancelled: "已撤销",
+}
+
+func AfterSaleStateToName(state int8) string {
+	return AfterSaleStateName[state]
+}
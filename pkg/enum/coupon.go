@@ -0,0 +1,34 @@
+package enum
+
+// 优惠券类型
+const (
+	CouponTypeFixed      int8 = 1 // 立减券, 门槛为0时即无门槛券
+	CouponTypeThreshold  int8 = 2 // 满减券, 满足门槛后减固定金额
+	CouponTypePercentage int8 = 3 // 折扣券, 满足门槛后按折扣率打折
+)
+
+// 优惠券适用范围
+const (
+	CouponScopeAll      int8 = 0 // 全场通用
+	CouponScopeCategory int8 = 1 // 指定商品分类
+	CouponScopeSku      int8 = 2 // 指定SKU
+)
+
+// 优惠券有效期类型
+const (
+	CouponValidFixed    int8 = 1 // 固定的起止时间
+	CouponValidRelative int8 = 2 // 领取后N天内有效
+)
+
+// 用户优惠券的来源
+const (
+	CouponSourceClaim int8 = 1 // 用户主动领取
+	CouponSourceIssue int8 = 2 // 后台发放
+)
+
+// 用户优惠券状态, 过期不单独记录状态, 根据有效期判断
+const (
+	UserCouponStateUnused int8 = 1 // 未使用
+	UserCouponStateLocked int8 = 2 // 已被待支付的订单锁定
+	UserCouponStateUsed   int8 = 3 // 已使用
+)
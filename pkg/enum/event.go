@@ -0,0 +1,8 @@
+package enum
+
+// 实时推送给用户的事件类型
+const (
+	EventTypeOrderState   = "order_state"  // 订单状态变化
+	EventTypeNotification = "notification" // 收到新的站内消息
+	EventTypeBroadcast    = "broadcast"    // 发布了新的广播消息, 只推送给在线用户
+)
@@ -0,0 +1,14 @@
+package enum
+
+// 商品订阅类型
+const (
+	SubscriptionTypeBackInStock int8 = 1 // 到货提醒
+	SubscriptionTypePriceDrop   int8 = 2 // 降价提醒
+)
+
+// 商品订阅状态, 到货提醒通知一次后结束, 降价提醒每次降价都会通知直到退订
+const (
+	SubscriptionStateActive    int8 = 1 // 订阅中
+	SubscriptionStateNotified  int8 = 2 // 已通知
+	SubscriptionStateCancelled int8 = 3 // 已退订
+)
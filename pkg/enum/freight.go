@@ -0,0 +1,25 @@
+package enum
+
+// 运费模板计费方式
+const (
+	FreightChargeFlat   int8 = 1 // 固定运费
+	FreightChargePiece  int8 = 2 // 按件数
+	FreightChargeWeight int8 = 3 // 按重量, 单位克
+)
+
+// 物流单状态
+const (
+	ShipmentStateShipped    int8 = 1 // 已发货, 等待揽收
+	ShipmentStateInTransit  int8 = 2 // 运输中
+	ShipmentStateDelivering int8 = 3 // 派送中
+	ShipmentStateDelivered  int8 = 4 // 已签收
+	ShipmentStateException  int8 = 5 // 异常件
+)
+
+var ShipmentStateName = map[int8]string{
+	ShipmentStateShipped:    "已发货",
+	ShipmentStateInTransit:  "运输中",
+	ShipmentStateDelivering: "派送中",
+	ShipmentStateDelivered:  "已签收",
+	ShipmentStateException:  "异常",
+}
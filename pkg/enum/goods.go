@@ -0,0 +1,7 @@
+package enum
+
+// 商品上下架状态
+const (
+	GoodsStateOffSale = 0
+	GoodsStateOnSale  = 1
+)
@@ -0,0 +1,10 @@
+package enum
+
+// 店铺状态
+const (
+	MerchantStateOpen   int8 = 1 // 营业中
+	MerchantStateClosed int8 = 2 // 已被平台关闭, 商品不能下单, 店主不能再登录商家后台
+)
+
+// MerchantIdSelf 平台自营店铺的ID, 没有归属店铺的历史商品都算自营
+const MerchantIdSelf int64 = 0
@@ -0,0 +1,21 @@
+package enum
+
+// 站内消息类型
+const (
+	NotificationTypeSystem    int8 = 1 // 系统消息
+	NotificationTypeOrder     int8 = 2 // 订单消息
+	NotificationTypePromotion int8 = 3 // 促销消息
+)
+
+var NotificationTypeName = map[int8]string{
+	NotificationTypeSystem:    "系统消息",
+	NotificationTypeOrder:     "订单消息",
+	NotificationTypePromotion: "促销消息",
+}
+
+func NotificationTypeToName(notificationType int8) string {
+	return NotificationTypeName[notificationType]
+}
+
+// NotificationTypes 全部站内消息类型
+var NotificationTypes = []int8{NotificationTypeSystem, NotificationTypeOrder, NotificationTypePromotion}
@@ -0,0 +1,36 @@
+package enum
+
+// 订单状态
+const (
+	OrderStateCreated   int8 = 1 // 已创建, 待支付
+	OrderStatePaid      int8 = 2 // 已支付, 待发货
+	OrderStateShipped   int8 = 3 // 已发货
+	OrderStateDelivered int8 = 4 // 已签收
+	OrderStateCompleted int8 = 5 // 已完成
+	OrderStateCancelled int8 = 6 // 已取消
+	OrderStateRefunding int8 = 7 // 退款中
+	OrderStateRefunded  int8 = 8 // 已退款
+)
+
+var OrderStateName = map[int8]string{
+	OrderStateCreated:   "待支付",
+	OrderStatePaid:      "待发货",
+	OrderStateShipped:   "已发货",
+	OrderStateDelivered: "已签收",
+	OrderStateCompleted: "已完成",
+	OrderStateCancelled: "已取消",
+	OrderStateRefunding: "退款中",
+	OrderStateRefunded:  "已退款",
+}
+
+// 订单状态变更的操作人类型
+const (
+	OrderOperatorUser     = "user"
+	OrderOperatorMerchant = "merchant"
+	OrderOperatorAdmin    = "admin"
+	OrderOperatorSystem   = "system"
+)
+
+func OrderStateToName(state int8) string {
+	return OrderStateName[state]
+}
@@ -0,0 +1,11 @@
+package enum
+
+// 支付单状态
+const (
+	PaymentStateCreated int8 = 1 // 待支付
+	PaymentStateSuccess int8 = 2 // 支付成功
+	PaymentStateClosed  int8 = 3 // 已关闭
+)
+
+// PaymentProviderWallet 钱包余额支付, 不经过外部支付渠道, 在支付单的事务里直接记账扣款
+const PaymentProviderWallet = "wallet"
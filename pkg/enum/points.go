@@ -0,0 +1,22 @@
+package enum
+
+// 积分流水类型, 流水只追加不修改, 积分余额等于全部流水的积分之和
+const (
+	PointsTypeEarn    int8 = 1 // 订单完成获得积分
+	PointsTypeSpend   int8 = 2 // 下单时抵扣
+	PointsTypeExpire  int8 = 3 // 到期作废
+	PointsTypeReturn  int8 = 4 // 订单取消或退款时退回抵扣的积分
+	PointsTypeReverse int8 = 5 // 订单退款时扣回获得的积分
+)
+
+var PointsTypeName = map[int8]string{
+	PointsTypeEarn:    "购物获得",
+	PointsTypeSpend:   "下单抵扣",
+	PointsTypeExpire:  "积分过期",
+	PointsTypeReturn:  "积分退回",
+	PointsTypeReverse: "退款扣回",
+}
+
+func PointsTypeToName(pointsType int8) string {
+	return PointsTypeName[pointsType]
+}
@@ -19,3 +19,43 @@ const (
 	REDISKEY_TOKEN_REFRESH_LOCK  = "token:refresh:lock:%s" // 刷新Token的锁
 	REDISKEY_PASSWORDRESET_TOKEN = "token:pwdreset:%s"     // 密码重置Token
 )
+
+// 秒杀模块
+const (
+	REDIS_KEY_SECKILL_ACTIVITY = "GOMALL:SECKILL:ACTIVITY_%d" // 秒杀活动信息
+	REDIS_KEY_SECKILL_STOCK    = "GOMALL:SECKILL:STOCK_%d"    // 秒杀活动剩余库存
+	REDIS_KEY_SECKILL_BOUGHT   = "GOMALL:SECKILL:BOUGHT_%d"   // 秒杀活动每个用户已抢到的数量, HASH userId => 数量
+	REDIS_KEY_SECKILL_GATE     = "GOMALL:SECKILL:GATE_%d_%d"  // 秒杀活动每秒的放行计数, 活动ID_秒级时间戳
+	REDIS_KEY_SECKILL_RESULT   = "GOMALL:SECKILL:RESULT_%s"   // 抢购结果, 按抢购凭证查询
+)
+
+// 评价模块
+const (
+	REDIS_KEY_REVIEW_SUMMARY = "GOMALL:REVIEW:SUMMARY_%d" // 商品的评分汇总
+)
+
+// 延时队列
+const (
+	REDIS_KEY_DELAY_QUEUE = "GOMALL:DELAY_QUEUE" // 延时队列的键名前缀
+)
+
+// 消息通知
+const (
+	REDIS_KEY_NOTIFY_DEDUP  = "GOMALL:NOTIFY:DEDUP_%s"  // 通知去重, 同一个去重键在有效期内只发送一次
+	REDIS_KEY_NOTIFY_UNREAD = "GOMALL:NOTIFY:UNREAD_%d" // 用户各类型站内消息的未读数, HASH 消息类型 => 未读数
+)
+
+// 实时推送
+const (
+	REDIS_KEY_EVENT_BUS = "GOMALL:EVENT_BUS" // 用户事件总线的键名前缀
+)
+
+// 接口限流
+const (
+	REDIS_KEY_RATE_LIMIT = "GOMALL:RATE_LIMIT" // 限流令牌桶的键名前缀, 完整键名为 前缀:规则名:限流维度:值
+)
+
+// 接口幂等
+const (
+	REDIS_KEY_IDEMPOTENCY = "GOMALL:IDEMPOTENCY" // 幂等键的键名前缀, 完整键名为 前缀:user或ip:值:Idempotency-Key
+)
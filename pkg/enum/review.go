@@ -0,0 +1,7 @@
+package enum
+
+// 评价的展示状态
+const (
+	ReviewStateVisible int8 = 1 // 展示中
+	ReviewStateHidden  int8 = 2 // 已被管理员隐藏
+)
@@ -0,0 +1,8 @@
+package enum
+
+// 抢购结果状态
+const (
+	SeckillResultQueued  = "queued"  // 已抢到库存, 排队创建订单中
+	SeckillResultSuccess = "success" // 订单已创建
+	SeckillResultFailed  = "failed"  // 创建订单失败, 库存已退回
+)
@@ -0,0 +1,42 @@
+package enum
+
+// 账户类型, 用户钱包和礼品卡的余额不能为负, 系统账户记录资金的来源和去向, 余额可以为负
+const (
+	LedgerAccountTypeWallet   int8 = 1 // 用户钱包
+	LedgerAccountTypeGiftCard int8 = 2 // 礼品卡
+	LedgerAccountTypeSystem   int8 = 3 // 系统账户
+)
+
+// 系统账户的账户号
+const (
+	LedgerAccountGiftCardIssue = "system:gift_card_issue" // 礼品卡发行, 发行礼品卡时从这里出账
+	LedgerAccountOrderClearing = "system:order_clearing"  // 订单收款, 余额支付入账、余额支付的订单退款出账
+	LedgerAccountAdjustment    = "system:adjustment"      // 人工调账
+)
+
+// 记账交易类型
+const (
+	LedgerTxTypeGiftCardIssue  int8 = 1 // 发行礼品卡
+	LedgerTxTypeGiftCardRedeem int8 = 2 // 兑换礼品卡到钱包
+	LedgerTxTypeOrderPay       int8 = 3 // 余额支付订单
+	LedgerTxTypeOrderRefund    int8 = 4 // 余额支付的订单退款
+	LedgerTxTypeAdjust         int8 = 5 // 人工调账
+)
+
+var LedgerTxTypeName = map[int8]string{
+	LedgerTxTypeGiftCardIssue:  "发行礼品卡",
+	LedgerTxTypeGiftCardRedeem: "礼品卡充值",
+	LedgerTxTypeOrderPay:       "余额支付",
+	LedgerTxTypeOrderRefund:    "订单退款",
+	LedgerTxTypeAdjust:         "人工调账",
+}
+
+func LedgerTxTypeToName(txType int8) string {
+	return LedgerTxTypeName[txType]
+}
+
+// 礼品卡状态
+const (
+	GiftCardStateActive   int8 = 1 // 未兑换
+	GiftCardStateRedeemed int8 = 2 // 已兑换
+)
@@ -17,6 +17,9 @@ var (
 	ErrForbid          = NewError(10005, "禁止访问")
 	ErrTooManyRequests = NewError(10006, "请求过多")
 	ErrCoverData       = NewError(10007, "数据转换错误")
+
+	ErrIdempotencyKeyInUse    = NewError(10008, "相同幂等键的请求正在处理, 请稍后重试")
+	ErrIdempotencyKeyMismatch = NewError(10009, "幂等键已用于参数不同的请求")
 )
 
 // 用户模块错误码， 预留11000 ~ 11099间的100个错误码
@@ -29,6 +32,112 @@ var (
 	ErrUserPasswordError = NewError(11005, "密码错误")
 )
 
+// 商品模块错误码， 预留12000 ~ 12099间的100个错误码
+var (
+	ErrGoodsNotFound       = NewError(12000, "商品不存在")
+	ErrGoodsOffSale        = NewError(12001, "商品已下架")
+	ErrGoodsStockNotEnough = NewError(12002, "商品库存不足")
+)
+
+// 订单模块错误码， 预留13000 ~ 13099间的100个错误码
+var (
+	ErrOrderNotFound         = NewError(13000, "订单不存在")
+	ErrOrderStateTransition  = NewError(13001, "当前订单状态不允许该操作")
+	ErrOrderConcurrentUpdate = NewError(13002, "订单状态已变更, 请刷新后重试")
+	ErrOrderParams           = NewError(13003, "订单参数错误")
+)
+
+// 支付模块错误码， 预留14000 ~ 14099间的100个错误码
+var (
+	ErrPaymentNotFound             = NewError(14000, "支付单不存在")
+	ErrPaymentProviderNotSupported = NewError(14001, "不支持的支付方式")
+	ErrPaymentSignature            = NewError(14002, "支付通知签名错误")
+	ErrPaymentAmountMismatch       = NewError(14003, "支付金额与订单金额不一致")
+	ErrPaymentProviderFailed       = NewError(14004, "支付渠道请求失败, 请稍后重试")
+)
+
+// 售后模块错误码， 预留15000 ~ 15099间的100个错误码
+var (
+	ErrAfterSaleNotFound        = NewError(15000, "售后单不存在")
+	ErrAfterSaleStateTransition = NewError(15001, "当前售后单状态不允许该操作")
+	ErrAfterSaleNotAllowed      = NewError(15002, "该订单当前不支持申请售后")
+	ErrAfterSaleQuantity        = NewError(15003, "售后商品数量超出可申请数量")
+	ErrAfterSaleConcurrent      = NewError(15004, "售后单状态已变更, 请刷新后重试")
+)
+
+// 优惠券模块错误码， 预留16000 ~ 16099间的100个错误码
+var (
+	ErrCouponTemplateNotFound = NewError(16000, "优惠券不存在")
+	ErrCouponNotClaimable     = NewError(16001, "优惠券当前不可领取")
+	ErrCouponSoldOut          = NewError(16002, "优惠券已被领完")
+	ErrCouponLimitExceeded    = NewError(16003, "已达到该优惠券的领取上限")
+	ErrCouponNotApplicable    = NewError(16004, "所选优惠券不满足使用条件")
+	ErrCouponNotAvailable     = NewError(16005, "优惠券已使用或已过期")
+	ErrCouponParams           = NewError(16006, "优惠券规则配置错误")
+)
+
+// 秒杀模块错误码， 预留17000 ~ 17099间的100个错误码
+var (
+	ErrSeckillNotFound       = NewError(17000, "秒杀活动不存在")
+	ErrSeckillNotStarted     = NewError(17001, "秒杀活动还未开始")
+	ErrSeckillEnded          = NewError(17002, "秒杀活动已结束")
+	ErrSeckillSoldOut        = NewError(17003, "商品已抢光")
+	ErrSeckillLimitExceeded  = NewError(17004, "已达到该活动的限购数量")
+	ErrSeckillBusy           = NewError(17005, "抢购人数过多, 请稍后再试")
+	ErrSeckillParams         = NewError(17006, "秒杀活动配置错误")
+	ErrSeckillTicketNotFound = NewError(17007, "抢购记录不存在或已过期")
+)
+
+// 运费和物流模块错误码， 预留18000 ~ 18099间的100个错误码
+var (
+	ErrFreightTemplateNotFound = NewError(18000, "运费模板不存在")
+	ErrFreightTemplateParams   = NewError(18001, "运费模板配置错误")
+	ErrRegionNotDeliverable    = NewError(18002, "收货地址不在配送范围内")
+	ErrShipmentNotFound        = NewError(18003, "物流信息不存在")
+	ErrCarrierNotSupported     = NewError(18004, "不支持的物流公司")
+	ErrCarrierTrackFailed      = NewError(18005, "查询物流轨迹失败, 请稍后再试")
+)
+
+// 评价模块错误码， 预留19000 ~ 19099间的100个错误码
+var (
+	ErrReviewNotAllowed = NewError(19000, "只能评价已完成订单中的商品")
+	ErrReviewExisted    = NewError(19001, "该商品已经评价过了")
+	ErrReviewNotFound   = NewError(19002, "评价不存在")
+	ErrReviewReplied    = NewError(19003, "该评价已经回复过了")
+)
+
+// 店铺模块错误码， 预留20000 ~ 20099间的100个错误码
+var (
+	ErrMerchantNotFound = NewError(20000, "店铺不存在")
+	ErrMerchantExisted  = NewError(20001, "该用户已经开通过店铺")
+	ErrMerchantClosed   = NewError(20002, "店铺已关闭")
+	ErrMerchantParams   = NewError(20003, "店铺参数错误")
+)
+
+// 积分会员模块错误码， 预留21000 ~ 21099间的100个错误码
+var (
+	ErrPointsNotEnough   = NewError(21000, "积分余额不足")
+	ErrPointsNotUsable   = NewError(21001, "当前订单不能使用积分抵扣")
+	ErrMemberTierInvalid = NewError(21002, "会员等级配置错误")
+)
+
+// 钱包和礼品卡模块错误码， 预留22000 ~ 22099间的100个错误码
+var (
+	ErrWalletBalanceNotEnough = NewError(22000, "钱包余额不足")
+	ErrGiftCardNotFound       = NewError(22001, "礼品卡卡密错误")
+	ErrGiftCardRedeemed       = NewError(22002, "礼品卡已被兑换")
+	ErrGiftCardExpired        = NewError(22003, "礼品卡已过期")
+	ErrLedgerUnbalanced       = NewError(22004, "记账分录借贷不平衡")
+	ErrIdempotencyKeyConflict = NewError(22005, "幂等键已被其他请求使用")
+)
+
+// 收藏和商品订阅模块错误码， 预留23000 ~ 23099间的100个错误码
+var (
+	ErrSubscriptionNotFound = NewError(23000, "订阅不存在")
+	ErrSubscriptionInStock  = NewError(23001, "商品有货, 无需订阅到货提醒")
+	ErrUnsubscribeToken     = NewError(23002, "退订链接无效")
+)
+
 // 其他。。。
 
 func (e *AppError) HttpStatusCode() int {
@@ -45,6 +154,10 @@ func (e *AppError) HttpStatusCode() int {
 		return http.StatusNotFound
 	case ErrTooManyRequests.Code():
 		return http.StatusTooManyRequests
+	case ErrIdempotencyKeyInUse.Code():
+		return http.StatusConflict
+	case ErrIdempotencyKeyMismatch.Code():
+		return http.StatusUnprocessableEntity
 	default:
 		return http.StatusInternalServerError
 	}
@@ -9,10 +9,10 @@ import (
 )
 
 type AppError struct {
-	code     int    `json:"code"`
-	msg      string `json:"msg"`
-	cause    error  `json:"cause"`
-	occurred string `json:"occurred"`
+	code     int
+	msg      string
+	cause    error
+	occurred string
 }
 
 func (e *AppError) Error() string {
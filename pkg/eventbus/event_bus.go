@@ -0,0 +1,329 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 基于Redis的用户事件总线, 把事件实时推送给连接在任意实例上的用户
+//
+//	{name}:stream:{userId}  STREAM  用户最近的事件, 断线重连时按Last-Event-ID补发
+//	{name}:channel          PUBSUB  所有实例订阅同一个频道, 收到事件后分发给本实例上该用户的连接
+//
+// 事件ID使用Stream生成的ID(毫秒时间戳-序号), 同一用户的事件ID单调递增。
+// 连接消费太慢导致缓冲区满时直接关闭连接, 客户端重连后从Stream补发, 不会阻塞其他连接。
+
+// publishScript 写入用户的事件Stream并广播到频道, 返回事件ID
+// data本身是JSON, 直接拼接进消息体, 订阅方按Event结构解析
+var publishScript = redis.NewScript(`
+local id = redis.call('XADD', KEYS[1], 'MAXLEN', '~', ARGV[1], '*', 'type', ARGV[2], 'data', ARGV[3])
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+local message = '{"id":"' .. id .. '","user_id":"' .. ARGV[5] .. '","type":' .. cjson.encode(ARGV[2]) .. ',"data":' .. ARGV[3] .. '}'
+redis.call('PUBLISH', KEYS[2], message)
+return id
+`)
+
+var eventIdPattern = regexp.MustCompile(`^\d+-\d+$`)
+
+// Event 推送给用户的事件
+type Event struct {
+	Id     string          `json:"id"` // 只推送给在线连接的事件没有ID, 不能补发
+	UserId int64           `json:"user_id,string"`
+	Type   string          `json:"type"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Subscription 一个连接对某个用户事件的订阅
+type Subscription struct {
+	userId int64
+	events chan *Event
+	done   chan struct{}
+	once   sync.Once
+	bus    *Bus
+}
+
+// Events 实时事件, 订阅关闭后不再有新事件
+func (s *Subscription) Events() <-chan *Event {
+	return s.events
+}
+
+// Done 订阅被关闭时关闭, 连接消费太慢或者总线停止时都会关闭订阅
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Close 取消订阅
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.remove(s)
+		close(s.done)
+	})
+}
+
+type Bus struct {
+	client      redis.UniversalClient
+	name        string
+	config      *Config
+	mu          sync.RWMutex
+	subscribers map[int64]map[*Subscription]struct{}
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	running     atomic.Bool
+}
+
+// Config 事件总线的配置参数, 通过Option设置
+type Config struct {
+	maxLen     int           // 每个用户保留的最近事件数
+	retention  time.Duration // 用户没有新事件多久后删除事件Stream
+	bufferSize int           // 每个连接的事件缓冲区大小
+}
+
+type Option func(c *Config)
+
+func WithMaxLen(n int) Option {
+	return func(c *Config) {
+		if n > 0 {
+			c.maxLen = n
+		}
+	}
+}
+
+func WithRetention(d time.Duration) Option {
+	return func(c *Config) {
+		if d > 0 {
+			c.retention = d
+		}
+	}
+}
+
+func WithBufferSize(n int) Option {
+	return func(c *Config) {
+		if n > 0 {
+			c.bufferSize = n
+		}
+	}
+}
+
+// New 创建事件总线, name作为Redis键名前缀
+func New(client redis.UniversalClient, name string, opts ...Option) *Bus {
+	config := &Config{
+		maxLen:     100,
+		retention:  24 * time.Hour,
+		bufferSize: 64,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return &Bus{
+		client:      client,
+		name:        name,
+		config:      config,
+		subscribers: make(map[int64]map[*Subscription]struct{}),
+	}
+}
+
+// Publish 发布用户事件, 事件写入用户的Stream后才广播, 保证断线期间的事件可以补发
+func (b *Bus) Publish(ctx context.Context, userId int64, eventType string, payload interface{}) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return publishScript.Run(ctx, b.client, []string{b.streamKey(userId), b.channelKey()},
+		b.config.maxLen, eventType, data, int64(b.config.retention.Seconds()), userId,
+	).Text()
+}
+
+// PublishOnline 给所有在线连接推送事件, 事件不落Stream, 离线用户重连后不会补发
+func (b *Bus) PublishOnline(ctx context.Context, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	message, _ := json.Marshal(&Event{Type: eventType, Data: data})
+	return b.client.Publish(ctx, b.channelKey(), message).Err()
+}
+
+// Subscribe 订阅用户的事件, lastEventId不为空时返回这个ID之后还保留在Stream中的事件
+// 先注册订阅再读取Stream, 读取期间到达的实时事件会和补发的事件重复, 调用方用After过滤
+func (b *Bus) Subscribe(ctx context.Context, userId int64, lastEventId string) (*Subscription, []*Event, error) {
+	sub := &Subscription{
+		userId: userId,
+		events: make(chan *Event, b.config.bufferSize),
+		done:   make(chan struct{}),
+		bus:    b,
+	}
+	b.mu.Lock()
+	if b.subscribers[userId] == nil {
+		b.subscribers[userId] = make(map[*Subscription]struct{})
+	}
+	b.subscribers[userId][sub] = struct{}{}
+	b.mu.Unlock()
+
+	if lastEventId == "" || !eventIdPattern.MatchString(lastEventId) {
+		return sub, []*Event{}, nil
+	}
+	messages, err := b.client.XRangeN(ctx, b.streamKey(userId), "("+lastEventId, "+", int64(b.config.maxLen)).Result()
+	if err != nil {
+		sub.Close()
+		return nil, nil, err
+	}
+	missed := make([]*Event, 0, len(messages))
+	for _, message := range messages {
+		eventType, _ := message.Values["type"].(string)
+		data, _ := message.Values["data"].(string)
+		missed = append(missed, &Event{Id: message.ID, UserId: userId, Type: eventType, Data: json.RawMessage(data)})
+	}
+	return sub, missed, nil
+}
+
+// Start 订阅频道并把事件分发给本实例上的连接, 频道连接断开时go-redis会自动重连
+func (b *Bus) Start(ctx context.Context) {
+	ctx, b.cancel = context.WithCancel(ctx)
+	pubsub := b.client.Subscribe(ctx, b.channelKey())
+	b.running.Store(true)
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer b.running.Store(false)
+		defer pubsub.Close()
+		channel := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-channel:
+				if !ok {
+					return
+				}
+				b.dispatch(ctx, message.Payload)
+			}
+		}
+	}()
+}
+
+// Stop 停止分发并关闭本实例上的所有订阅
+func (b *Bus) Stop() {
+	if b.cancel != nil {
+		b.cancel()
+	}
+	b.wg.Wait()
+	b.mu.RLock()
+	subs := make([]*Subscription, 0)
+	for _, userSubs := range b.subscribers {
+		for sub := range userSubs {
+			subs = append(subs, sub)
+		}
+	}
+	b.mu.RUnlock()
+	for _, sub := range subs {
+		sub.Close()
+	}
+}
+
+// Check 检查分发协程是否在运行, 用于健康检查
+func (b *Bus) Check(ctx context.Context) error {
+	if !b.running.Load() {
+		return errors.New("事件总线未运行")
+	}
+	return nil
+}
+
+func (b *Bus) dispatch(ctx context.Context, payload string) {
+	event := new(Event)
+	if err := json.Unmarshal([]byte(payload), event); err != nil {
+		logger.NewLogger(ctx).Error("EventBusPayloadError", "bus", b.name, "err", err)
+		return
+	}
+	b.mu.RLock()
+	subs := make([]*Subscription, 0)
+	if event.UserId == 0 {
+		for _, userSubs := range b.subscribers {
+			for sub := range userSubs {
+				subs = append(subs, sub)
+			}
+		}
+	} else {
+		for sub := range b.subscribers[event.UserId] {
+			subs = append(subs, sub)
+		}
+	}
+	b.mu.RUnlock()
+	for _, sub := range subs {
+		select {
+		case sub.events <- event:
+		default:
+			// 缓冲区满说明连接消费太慢, 关闭后由客户端带Last-Event-ID重连补发
+			logger.NewLogger(ctx).Warn("EventBusSubscriberOverflow", "bus", b.name, "userId", sub.userId)
+			sub.Close()
+		}
+	}
+}
+
+func (b *Bus) remove(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers[sub.userId], sub)
+	if len(b.subscribers[sub.userId]) == 0 {
+		delete(b.subscribers, sub.userId)
+	}
+}
+
+// Online 本实例上的连接数
+func (b *Bus) Online() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var count int
+	for _, userSubs := range b.subscribers {
+		count += len(userSubs)
+	}
+	return count
+}
+
+// After 事件ID是否在lastEventId之后, 没有ID的事件总是返回true
+func After(eventId, lastEventId string) bool {
+	if eventId == "" || lastEventId == "" {
+		return true
+	}
+	ms, seq, err := parseEventId(eventId)
+	if err != nil {
+		return true
+	}
+	lastMs, lastSeq, err := parseEventId(lastEventId)
+	if err != nil {
+		return true
+	}
+	return ms > lastMs || (ms == lastMs && seq > lastSeq)
+}
+
+func parseEventId(eventId string) (uint64, uint64, error) {
+	msPart, seqPart, ok := strings.Cut(eventId, "-")
+	if !ok {
+		return 0, 0, errors.New("invalid event id")
+	}
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return ms, seq, nil
+}
+
+func (b *Bus) streamKey(userId int64) string {
+	return b.name + ":stream:" + strconv.FormatInt(userId, 10)
+}
+
+func (b *Bus) channelKey() string {
+	return b.name + ":channel"
+}
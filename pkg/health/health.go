@@ -0,0 +1,143 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 健康检查, 分为存活检查和就绪检查:
+//
+//	存活检查 只检查进程内的后台协程, 失败说明进程本身出了问题需要重启。
+//	        数据库、Redis不可用时重启进程也无济于事, 不放在存活检查里
+//	就绪检查 在存活检查的基础上检查数据库、Redis等依赖, 失败时负载均衡不再转发请求。
+//	        服务开始关闭后也返回未就绪
+//
+// 每一项检查并发执行并且有各自的超时时间, 一项依赖卡住不会拖慢其他检查
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Checker 检查一项依赖, 返回error表示不可用
+type Checker func(ctx context.Context) error
+
+// Result 一项检查的结果
+type Result struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report 检查报告, 所有检查项都可用时Status为up
+type Report struct {
+	Status string             `json:"status"`
+	Checks map[string]*Result `json:"checks"`
+}
+
+// Up 所有检查项是否都可用
+func (r *Report) Up() bool {
+	return r.Status == StatusUp
+}
+
+type check struct {
+	name    string
+	checker Checker
+}
+
+var (
+	mu        sync.RWMutex
+	liveness  []check
+	readiness []check
+	draining  atomic.Bool
+)
+
+// AddLiveness 添加存活检查项, 存活检查项也会在就绪检查中执行
+func AddLiveness(name string, checker Checker) {
+	mu.Lock()
+	defer mu.Unlock()
+	liveness = append(liveness, check{name: name, checker: checker})
+}
+
+// AddReadiness 添加就绪检查项
+func AddReadiness(name string, checker Checker) {
+	mu.Lock()
+	defer mu.Unlock()
+	readiness = append(readiness, check{name: name, checker: checker})
+}
+
+// SetDraining 标记服务正在关闭, 之后的就绪检查都返回未就绪
+func SetDraining() {
+	draining.Store(true)
+}
+
+// Live 执行存活检查
+func Live(ctx context.Context, timeout time.Duration) *Report {
+	mu.RLock()
+	checks := append([]check{}, liveness...)
+	mu.RUnlock()
+	return run(ctx, checks, timeout)
+}
+
+// Ready 执行就绪检查
+func Ready(ctx context.Context, timeout time.Duration) *Report {
+	mu.RLock()
+	checks := append(append([]check{}, liveness...), readiness...)
+	mu.RUnlock()
+	if draining.Load() {
+		checks = append(checks, check{name: "shutdown", checker: func(ctx context.Context) error {
+			return errors.New("服务正在关闭")
+		}})
+	}
+	return run(ctx, checks, timeout)
+}
+
+func run(ctx context.Context, checks []check, timeout time.Duration) *Report {
+	report := &Report{Status: StatusUp, Checks: make(map[string]*Result, len(checks))}
+	results := make([]*Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, c.checker, timeout)
+		}(i, c)
+	}
+	wg.Wait()
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// runCheck 执行一项检查, 检查函数不响应ctx取消时也会在超时后返回, 检查函数在后台继续执行完
+func runCheck(ctx context.Context, checker Checker, timeout time.Duration) *Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- checker(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result := &Result{Status: StatusUp, Duration: time.Since(start).Round(time.Microsecond).String()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Error = "检查超时"
+		}
+	}
+	return result
+}
@@ -0,0 +1,166 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// 基于Redis的幂等键存储
+//
+//	{name}:{key}  HASH  fingerprint 请求指纹, token 处理中的请求持有的锁令牌,
+//	                    status/content_type/body 处理完成后保存的响应
+//
+// 第一个请求抢占幂等键后加锁处理, 锁在lockTimeout后过期, 避免进程崩溃后幂等键一直处于处理中;
+// 处理完成后保存响应并延长到ttl, 期间相同幂等键的请求直接重放保存的响应。
+// 保存响应和释放锁都校验锁令牌, 锁过期后被其他请求抢占时, 原来的请求不会覆盖新请求的结果。
+
+// acquireScript 幂等键不存在时加锁, 存在时返回 {请求指纹, 状态码, Content-Type, 响应体}, 处理中的状态码为空
+var acquireScript = redis.NewScript(`
+local state = redis.call('HMGET', KEYS[1], 'fingerprint', 'status', 'content_type', 'body')
+if state[1] then
+	return {state[1], state[2] or '', state[3] or '', state[4] or ''}
+end
+redis.call('HSET', KEYS[1], 'fingerprint', ARGV[1], 'token', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return {}
+`)
+
+// completeScript 锁令牌一致时保存响应并释放锁
+var completeScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'token') ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'status', ARGV[2], 'content_type', ARGV[3], 'body', ARGV[4])
+redis.call('HDEL', KEYS[1], 'token')
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return 1
+`)
+
+// releaseScript 锁令牌一致时删除幂等键, 之后相同幂等键的请求会重新处理
+var releaseScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'token') ~= ARGV[1] then
+	return 0
+end
+return redis.call('DEL', KEYS[1])
+`)
+
+// ErrLockLost 保存响应时锁已经过期, 幂等键被删除或被其他请求抢占
+var ErrLockLost = errors.New("idempotency: lock lost")
+
+// Record 幂等键已有的记录, Status为0表示第一个请求还在处理中
+type Record struct {
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// Completed 第一个请求是否已经处理完成
+func (r *Record) Completed() bool {
+	return r.Status > 0
+}
+
+type Store struct {
+	client redis.UniversalClient
+	name   string
+	config *Config
+}
+
+// Config 幂等键存储的配置参数, 通过Option设置
+type Config struct {
+	ttl         time.Duration // 响应保存多久, 这段时间内的重试都会重放响应
+	lockTimeout time.Duration // 请求处理超过这个时间没有完成时锁自动过期
+}
+
+type Option func(c *Config)
+
+func WithTTL(d time.Duration) Option {
+	return func(c *Config) {
+		if d > 0 {
+			c.ttl = d
+		}
+	}
+}
+
+func WithLockTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		if d > 0 {
+			c.lockTimeout = d
+		}
+	}
+}
+
+// New 创建幂等键存储, name作为Redis键名前缀
+func New(client redis.UniversalClient, name string, opts ...Option) *Store {
+	config := &Config{
+		ttl:         24 * time.Hour,
+		lockTimeout: time.Minute,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return &Store{client: client, name: name, config: config}
+}
+
+// Acquire 抢占幂等键, 抢到时返回锁令牌, 用于之后调用Complete或Release;
+// 幂等键已经被使用时token为空, 返回已有的记录
+func (s *Store) Acquire(ctx context.Context, key, fingerprint string) (token string, record *Record, err error) {
+	token, err = newToken()
+	if err != nil {
+		return "", nil, err
+	}
+	values, err := acquireScript.Run(ctx, s.client, []string{s.key(key)},
+		fingerprint, token, s.config.lockTimeout.Milliseconds()).StringSlice()
+	if err != nil {
+		return "", nil, err
+	}
+	if len(values) == 0 {
+		return token, nil, nil
+	}
+	if len(values) != 4 {
+		return "", nil, fmt.Errorf("idempotency: unexpected reply %v", values)
+	}
+	record = &Record{Fingerprint: values[0], ContentType: values[2], Body: []byte(values[3])}
+	if values[1] != "" {
+		if record.Status, err = strconv.Atoi(values[1]); err != nil {
+			return "", nil, err
+		}
+	}
+	return "", record, nil
+}
+
+// Complete 保存第一个请求的响应, 锁已经过期时返回ErrLockLost
+func (s *Store) Complete(ctx context.Context, key, token string, status int, contentType string, body []byte) error {
+	saved, err := completeScript.Run(ctx, s.client, []string{s.key(key)},
+		token, status, contentType, body, s.config.ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if saved == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// Release 不保存响应直接释放幂等键, 用于服务出错等允许客户端重试的情况
+func (s *Store) Release(ctx context.Context, key, token string) error {
+	return releaseScript.Run(ctx, s.client, []string{s.key(key)}, token).Err()
+}
+
+func (s *Store) key(key string) string {
+	return s.name + ":" + key
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -3,6 +3,7 @@ package logger
 import (
 	"context"
 	"fmt"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"path"
@@ -33,6 +34,10 @@ func NewLogger(ctx context.Context) *logger {
 	if ctx.Value("Parent-Span-Id") != nil {
 		pSanId = ctx.Value("Parent-Span-Id").(string)
 	}
+	// 不是HTTP请求的ctx(比如外部调用、后台任务中创建的span)从span中取链路信息
+	if spanContext := trace.SpanContextFromContext(ctx); traceId == "" && spanContext.IsValid() {
+		traceId, spanId = spanContext.TraceID().String(), spanContext.SpanID().String()
+	}
 	return &logger{
 		ctx:     ctx,
 		traceId: traceId,
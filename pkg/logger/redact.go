@@ -0,0 +1,204 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/utils"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// 日志脱敏, 按配置 app.log.redact 隐藏或打码请求体、响应体中的密码、令牌、手机号等字段,
+// 按 app.log.redact_headers 隐藏Authorization等请求头。每次调用时读取配置, 修改后热加载生效
+
+// redactedValue 隐藏后的字段值
+const redactedValue = "***"
+
+// RedactBody 把JSON或表单格式的body脱敏, 超过 app.log.max_body_size 的部分截断
+// 不管Content-Type声明的是什么都先按JSON解析, 避免客户端没带或带错请求头时密码原样写进日志;
+// 既不是JSON也不是声明为表单的body只记录字节数, 不记录内容
+func RedactBody(contentType string, body []byte) string {
+	return currentRedactor().body(contentType, body)
+}
+
+// RedactHeader 把请求头转换成便于记录的键值, 配置中的请求头只记录是否存在
+func RedactHeader(header http.Header) map[string]string {
+	return currentRedactor().header(header)
+}
+
+// RedactedHeaderClone 复制一份隐藏了敏感请求头的Header, 用于httputil.DumpRequest这类直接输出请求头的场景
+func RedactedHeaderClone(header http.Header) http.Header {
+	return currentRedactor().headerClone(header)
+}
+
+// redactor 一份脱敏配置, 导出的函数每次调用时按当前生效的配置生成
+type redactor struct {
+	rules         []config.RedactRule
+	redactHeaders []string
+	maxBodySize   int
+}
+
+func currentRedactor() *redactor {
+	logConfig := config.AppConfig().Log
+	return &redactor{rules: logConfig.Redact, redactHeaders: logConfig.RedactHeaders, maxBodySize: logConfig.MaxBodySize}
+}
+
+func (r *redactor) body(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if value, ok := decodeJSON(body); ok {
+		return truncate(marshalRedacted(redactValue(value, "", r.rules)), r.maxBodySize)
+	}
+	if !strings.Contains(contentType, "application/x-www-form-urlencoded") {
+		return omitted(body)
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return omitted(body)
+	}
+	for key, items := range values {
+		if rule, ok := matchRedactRule(r.rules, key, key); ok {
+			for i := range items {
+				items[i] = maskString(items[i], rule.Mask)
+			}
+		}
+	}
+	redacted := values.Encode()
+	// 日志只用于阅读, 还原转义后的字符
+	if unescaped, err := url.QueryUnescape(redacted); err == nil {
+		redacted = unescaped
+	}
+	return truncate(redacted, r.maxBodySize)
+}
+
+func (r *redactor) header(header http.Header) map[string]string {
+	fields := make(map[string]string, len(header))
+	for name, values := range header {
+		fields[name] = strings.Join(values, ", ")
+		for _, redactHeader := range r.redactHeaders {
+			if strings.EqualFold(name, redactHeader) {
+				fields[name] = redactedValue
+				break
+			}
+		}
+	}
+	return fields
+}
+
+func (r *redactor) headerClone(header http.Header) http.Header {
+	clone := header.Clone()
+	for _, redactHeader := range r.redactHeaders {
+		if clone.Get(redactHeader) != "" {
+			clone.Set(redactHeader, redactedValue)
+		}
+	}
+	return clone
+}
+
+// redactValue 递归处理JSON值, path为从根开始用.分隔的字段路径, 数组元素沿用数组的路径
+func redactValue(value interface{}, path string, rules []config.RedactRule) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			if rule, ok := matchRedactRule(rules, key, fieldPath); ok {
+				v[key] = maskValue(field, rule.Mask)
+				continue
+			}
+			v[key] = redactValue(field, fieldPath, rules)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item, path, rules)
+		}
+	}
+	return value
+}
+
+func matchRedactRule(rules []config.RedactRule, key, path string) (config.RedactRule, bool) {
+	for _, rule := range rules {
+		if (rule.Field != "" && rule.Field == key) || (rule.Path != "" && rule.Path == path) {
+			return rule, true
+		}
+	}
+	return config.RedactRule{}, false
+}
+
+// maskValue 字符串按脱敏方式打码, 数字、对象等其他类型的值整个隐藏
+func maskValue(value interface{}, mask string) interface{} {
+	if s, ok := value.(string); ok {
+		return maskString(s, mask)
+	}
+	if value == nil {
+		return nil
+	}
+	return redactedValue
+}
+
+func maskString(s, mask string) string {
+	if s == "" {
+		return s
+	}
+	switch mask {
+	case "phone":
+		return utils.MaskPhone(s)
+	case "email":
+		return utils.MaskEmail(s)
+	case "login_name":
+		return utils.MaskLoginName(s)
+	case "name":
+		return utils.MaskRealName(s)
+	default:
+		return redactedValue
+	}
+}
+
+func marshalRedacted(value interface{}) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// decodeJSON 整个body是一个完整的JSON值时才算解析成功, 后面跟着其他内容的不算
+func decodeJSON(body []byte) (interface{}, bool) {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, false
+	}
+	return value, true
+}
+
+// omitted 无法脱敏的内容只记录长度
+func omitted(body []byte) string {
+	return fmt.Sprintf("<%d bytes omitted>", len(body))
+}
+
+// truncate 按字节数截断, 不会截断在多字节字符的中间
+func truncate(s string, maxSize int) string {
+	if maxSize <= 0 || len(s) <= maxSize {
+		return s
+	}
+	cut := maxSize
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s...(共%d字节, 已截断)", s[:cut], len(s))
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"github.com/Cospk/go-mall/pkg/config"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testRedactor 和 application.env.yaml 里的规则同样的写法, 不依赖配置文件
+var testRedactor = &redactor{
+	rules: []config.RedactRule{
+		{Field: "password"},
+		{Field: "access_token"},
+		{Field: "card_no"},
+		{Path: "code"},
+		{Field: "login_name", Mask: "login_name"},
+		{Field: "receiver_phone", Mask: "phone"},
+	},
+	redactHeaders: []string{"Authorization", "Cookie", "X-Api-Key"},
+	maxBodySize:   96,
+}
+
+func TestRedactorBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"空body", "application/json", "", ""},
+		{"JSON隐藏密码并打码登录名", "application/json; charset=utf-8",
+			`{"login_name":"13800001111","password":"secret"}`, `{"login_name":"138****1111","password":"***"}`},
+		{"数组里的字段和按路径匹配的字段", "application/json",
+			`{"code":"ABCD","items":[{"password":"x"}],"data":{"code":"keep"}}`, `{"code":"***","data":{"code":"keep"},"items":[{"password":"***"}]}`},
+		{"非字符串的值整个隐藏", "application/json",
+			`{"card_no":123456,"amount":100}`, `{"amount":100,"card_no":"***"}`},
+		{"表单脱敏", "application/x-www-form-urlencoded",
+			"password=secret&receiver_phone=13800001111", "password=***&receiver_phone=138****1111"},
+		{"没有声明Content-Type的JSON也脱敏", "text/plain", `{"password":"secret"}`, `{"password":"***"}`},
+		{"没有声明为表单的内容不记录", "text/plain", "password=secret&name=bob", "<24 bytes omitted>"},
+		{"JSON格式错误不记录", "application/json", `{"password":"secret"`, "<20 bytes omitted>"},
+		{"JSON后面跟着其他内容不记录", "application/json", `{"a":1} password`, "<16 bytes omitted>"},
+		{"截断超长的内容", "application/json",
+			`{"remark":"` + strings.Repeat("a", 100) + `"}`, `{"remark":"` + strings.Repeat("a", 85) + `...(共113字节, 已截断)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testRedactor.body(tt.contentType, []byte(tt.body)); got != tt.want {
+				t.Errorf("body() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"SSE的access_token", "access_token=abc.def&last_id=10", "access_token=***&last_id=10"},
+		{"没有敏感参数", "page=1&page_size=10", "page=1&page_size=10"},
+		{"空查询串", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testRedactor.body("application/x-www-form-urlencoded", []byte(tt.query)); got != tt.want {
+				t.Errorf("body() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactorHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer token")
+	header.Set("Cookie", "session=abc")
+	header.Set("X-Api-Key", "key")
+	header.Set("Content-Type", "application/json")
+	header.Add("Accept", "text/html")
+	header.Add("Accept", "application/json")
+
+	want := map[string]string{
+		"Authorization": "***",
+		"Cookie":        "***",
+		"X-Api-Key":     "***",
+		"Content-Type":  "application/json",
+		"Accept":        "text/html, application/json",
+	}
+	if got := testRedactor.header(header); !reflect.DeepEqual(got, want) {
+		t.Errorf("header() = %v, want %v", got, want)
+	}
+
+	clone := testRedactor.headerClone(header)
+	if clone.Get("Authorization") != "***" || clone.Get("Content-Type") != "application/json" {
+		t.Errorf("headerClone() = %v", clone)
+	}
+	if header.Get("Authorization") != "Bearer token" {
+		t.Errorf("headerClone() should not modify the original header")
+	}
+}
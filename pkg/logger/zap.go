@@ -10,35 +10,61 @@ import (
 
 var (
 	Logger *zap.Logger
+	// level 日志级别, 配置热加载时调整, 不需要重建Logger
+	level = zap.NewAtomicLevel()
 )
 
 func InitLogger() {
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoder := zapcore.NewJSONEncoder(encoderConfig)
+	level.SetLevel(configLevel(config.AppConfig().Env, config.AppConfig().Log.Level))
 
 	var cores []zapcore.Core
-	if config.AppConfig.Env == "dev" {
-		// 开发环境：控制台和文件都要日志，且是debug级别
+	if config.AppConfig().Env == "dev" {
+		// 开发环境：控制台和文件都要日志，默认是debug级别
 		cores = append(
 			cores,
-			zapcore.NewCore(encoder, getFileLogWriter(), zapcore.DebugLevel),
-			zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), zapcore.DebugLevel),
+			zapcore.NewCore(encoder, getFileLogWriter(), level),
+			zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), level),
 		)
 	} else {
-		// 生产环境: 只要文件日志，且是info级别
-		cores = append(cores, zapcore.NewCore(encoder, getFileLogWriter(), zapcore.InfoLevel))
+		// 生产环境: 只要文件日志，默认是info级别
+		cores = append(cores, zapcore.NewCore(encoder, getFileLogWriter(), level))
 	}
 
 	core := zapcore.NewTee(cores...)
 	Logger = zap.New(core)
+
+	config.Subscribe("logger.level", func(prev, next *config.Snapshot) {
+		newLevel := configLevel(next.App.Env, next.App.Log.Level)
+		if newLevel != level.Level() {
+			Logger.Info("LogLevelChanged", zap.String("from", level.Level().String()), zap.String("to", newLevel.String()))
+			level.SetLevel(newLevel)
+		}
+	})
+}
+
+// configLevel 配置的日志级别, 没有配置时开发环境为debug, 其他环境为info
+func configLevel(env, levelName string) zapcore.Level {
+	if levelName == "" {
+		if env == "dev" {
+			return zapcore.DebugLevel
+		}
+		return zapcore.InfoLevel
+	}
+	configured, err := zapcore.ParseLevel(levelName)
+	if err != nil {
+		return zapcore.InfoLevel
+	}
+	return configured
 }
 
 func getFileLogWriter() (writeSyncer zapcore.WriteSyncer) {
 	// 使用lumberjack 实现logger rotate
 	lumberJackLogger := &lumberjack.Logger{
-		Filename:  config.AppConfig.Log.FilePath,
-		MaxSize:   config.AppConfig.Log.FileMaxSize,
-		MaxAge:    config.AppConfig.Log.BackUpFileMaxAge,
+		Filename:  config.AppConfig().Log.FilePath,
+		MaxSize:   config.AppConfig().Log.FileMaxSize,
+		MaxAge:    config.AppConfig().Log.BackUpFileMaxAge,
 		Compress:  false,
 		LocalTime: true,
 	}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	logins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "用户登录次数, result为success或failure",
+	}, []string{"result"})
+
+	orders = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_total",
+		Help:      "子订单进入各状态的次数, 创建订单计入待支付",
+	}, []string{"state"})
+
+	payments = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payments_total",
+		Help:      "支付单数量, result为created、success或failed",
+	}, []string{"provider", "result"})
+
+	paymentAmount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payment_amount_total",
+		Help:      "支付成功的金额(分)",
+	}, []string{"provider"})
+)
+
+// Login 记录一次登录
+func Login(success bool) {
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+	logins.WithLabelValues(result).Inc()
+}
+
+// OrderState 记录子订单进入某个状态, state使用状态名称
+func OrderState(state string) {
+	orders.WithLabelValues(state).Inc()
+}
+
+// PaymentCreated 记录创建支付单
+func PaymentCreated(provider string) {
+	payments.WithLabelValues(provider, "created").Inc()
+}
+
+// PaymentSucceeded 记录支付成功和金额, 同一个支付单只能记录一次
+func PaymentSucceeded(provider string, amount int64) {
+	payments.WithLabelValues(provider, "success").Inc()
+	paymentAmount.WithLabelValues(provider).Add(float64(amount))
+}
+
+// PaymentFailed 记录支付失败, 比如金额和支付单不一致、余额不足
+func PaymentFailed(provider string) {
+	payments.WithLabelValues(provider, "failed").Inc()
+}
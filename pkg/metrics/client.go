@@ -0,0 +1,25 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"strconv"
+	"time"
+)
+
+var clientDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Subsystem: "http_client",
+	Name:      "request_duration_seconds",
+	Help:      "调用外部接口的耗时, 按目标主机区分, 请求没有拿到响应时status为error",
+	Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+}, []string{"host", "method", "status"})
+
+// ObserveClientRequest 记录一次对外部接口的调用, status为0表示没有拿到响应
+func ObserveClientRequest(host, method string, status int, duration time.Duration) {
+	statusLabel := "error"
+	if status > 0 {
+		statusLabel = strconv.Itoa(status)
+	}
+	clientDuration.WithLabelValues(host, method, statusLabel).Observe(duration.Seconds())
+}
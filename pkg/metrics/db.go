@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"time"
+)
+
+var dbDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Subsystem: "db",
+	Name:      "query_duration_seconds",
+	Help:      "SQL的执行耗时, 按语句类型区分",
+	Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation", "status"})
+
+// ObserveSQL 记录一条SQL的执行耗时, operation为select/insert/update/delete/other, 查询不到记录不算失败
+func ObserveSQL(operation string, failed bool, duration time.Duration) {
+	status := "ok"
+	if failed {
+		status = "error"
+	}
+	dbDuration.WithLabelValues(operation, status).Observe(duration.Seconds())
+}
+
+// RegisterDBStats 采集数据库连接池的状态, 指标为 go_sql_*, 用db_name标签区分主库和从库
+func RegisterDBStats(name string, db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 基于Prometheus的监控指标, 通过 /metrics 暴露, 指标名统一以 gomall_ 开头
+//
+//	gomall_http_*         服务端接口的请求数和耗时, 按路由模板和状态码区分
+//	gomall_db_*           SQL耗时, 连接池状态见 go_sql_* 指标
+//	gomall_redis_*        Redis命令耗时和连接池状态
+//	gomall_http_client_*  调用外部接口的耗时, 按目标主机区分
+//	gomall_*_total        登录、订单、支付等业务计数
+//
+// 标签取值必须是有限集合, 不能放用户ID、订单号、原始URL这类无限增长的值
+
+const namespace = "gomall"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "服务端接口的请求数",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "服务端接口的处理耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// Handler 输出所有指标的HTTP处理器
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveHTTPRequest 记录一次服务端接口请求, route使用注册路由时的路径模板
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	statusLabel := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, statusLabel).Inc()
+	httpDuration.WithLabelValues(method, route, statusLabel).Observe(duration.Seconds())
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+var redisDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Subsystem: "redis",
+	Name:      "command_duration_seconds",
+	Help:      "Redis命令的执行耗时, 管道按一次记录, 命令名为pipeline",
+	Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+}, []string{"command", "status"})
+
+// RedisHook 记录每条Redis命令的耗时, 通过client.AddHook添加
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		observeRedis(cmd.Name(), err, time.Since(start))
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		observeRedis("pipeline", err, time.Since(start))
+		return err
+	}
+}
+
+// observeRedis 键不存在(redis.Nil)不算失败
+func observeRedis(command string, err error, duration time.Duration) {
+	status := "ok"
+	if err != nil && !errors.Is(err, redis.Nil) {
+		status = "error"
+	}
+	redisDuration.WithLabelValues(command, status).Observe(duration.Seconds())
+}
+
+// RegisterRedisPoolStats 采集Redis连接池的状态, 每次抓取指标时读取
+func RegisterRedisPoolStats(client interface{ PoolStats() *redis.PoolStats }) {
+	gauges := map[string]func(stats *redis.PoolStats) uint32{
+		"pool_total_connections": func(stats *redis.PoolStats) uint32 { return stats.TotalConns },
+		"pool_idle_connections":  func(stats *redis.PoolStats) uint32 { return stats.IdleConns },
+	}
+	for name, value := range gauges {
+		value := value
+		promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "redis",
+			Name:      name,
+			Help:      "Redis连接池的连接数",
+		}, func() float64 { return float64(value(client.PoolStats())) })
+	}
+	counters := map[string]func(stats *redis.PoolStats) uint32{
+		"pool_hits_total":     func(stats *redis.PoolStats) uint32 { return stats.Hits },
+		"pool_misses_total":   func(stats *redis.PoolStats) uint32 { return stats.Misses },
+		"pool_timeouts_total": func(stats *redis.PoolStats) uint32 { return stats.Timeouts },
+	}
+	for name, value := range counters {
+		value := value
+		promauto.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "redis",
+			Name:      name,
+			Help:      "从Redis连接池获取连接的次数, hits为复用空闲连接, misses为新建连接, timeouts为等待超时",
+		}, func() float64 { return float64(value(client.PoolStats())) })
+	}
+}
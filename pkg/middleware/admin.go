@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware 管理员鉴权中间件, 需要放在AuthMiddleware之后使用
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId := c.GetInt64("userId")
+		for _, adminId := range config.AppConfig().AdminUserIds {
+			if adminId == userId {
+				c.Next()
+				return
+			}
+		}
+		resp.NewResponse(c).Error(errcode.ErrForbid)
+		c.Abort()
+	}
+}
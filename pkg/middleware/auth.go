@@ -12,27 +12,41 @@ import (
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 获取请求头中的Token
-		token := c.Request.Header.Get("Authorization")
+		authenticate(c, c.Request.Header.Get("Authorization"))
+	}
+}
 
+// StreamAuthMiddleware 实时推送连接的认证中间件
+// 浏览器的EventSource不能设置请求头, 请求头中没有Token时从access_token参数获取, 校验方式和AuthMiddleware相同
+func StreamAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Request.Header.Get("Authorization")
 		if token == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": 403, "msg": "未授权访问"})
-			return
+			token = c.Query("access_token")
 		}
+		authenticate(c, token)
+	}
+}
 
-		tokenVerify, err := service.NewUserService(c).VerifyAccessToken(token)
-		if err != nil { // 验证Token时服务出错
-			resp.NewResponse(c).Error(errcode.ErrServer)
-			c.Abort()
-			return
-		}
-		if !tokenVerify.Approved { // Token未通过验证
-			resp.NewResponse(c).Error(errcode.ErrToken)
-			c.Abort()
-			return
-		}
-		c.Set("userId", tokenVerify.UserId)
-		c.Set("sessionId", tokenVerify.SessionId)
-		c.Set("platform", tokenVerify.Platform)
-		c.Next()
+func authenticate(c *gin.Context, token string) {
+	if token == "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"code": 403, "msg": "未授权访问"})
+		return
+	}
+
+	tokenVerify, err := service.NewUserService(c).VerifyAccessToken(token)
+	if err != nil { // 验证Token时服务出错
+		resp.NewResponse(c).Error(errcode.ErrServer)
+		c.Abort()
+		return
+	}
+	if !tokenVerify.Approved { // Token未通过验证
+		resp.NewResponse(c).Error(errcode.ErrToken)
+		c.Abort()
+		return
 	}
+	c.Set("userId", tokenVerify.UserId)
+	c.Set("sessionId", tokenVerify.SessionId)
+	c.Set("platform", tokenVerify.Platform)
+	c.Next()
 }
@@ -3,8 +3,12 @@ package middleware
 import (
 	"bytes"
 	"github.com/Cospk/go-mall/pkg/logger"
-	"github.com/Cospk/go-mall/pkg/utils"
+	"github.com/Cospk/go-mall/pkg/tracing"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"io"
 	"net"
 	"net/http"
@@ -15,35 +19,72 @@ import (
 	"time"
 )
 
-// TraceMiddleware 添加traceId信息跟踪链路，方便后期做记录（注意：放第一个）
+// TraceMiddleware 为每个请求创建span跟踪链路（注意：放第一个）
+// 上游通过traceparent或旧的Trace-Id/Span-Id请求头传入链路时沿用上游的链路ID, 否则开始一条新链路
+// Trace-Id、Span-Id、Parent-Span-Id仍然放在gin.Context中, 供日志和响应中的request_id使用
 func TraceMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		traceId := c.Request.Header.Get("Trace-Id")
-		pSpanId := c.Request.Header.Get("Span-Id")
-		spanId := utils.GenerateSpanId(c.Request.RemoteAddr)
-		if traceId == "" {
-			traceId = spanId
+		ctx := tracing.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		parent := trace.SpanContextFromContext(ctx)
+		ctx, span := tracing.Start(ctx, c.Request.Method, trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+				attribute.String("user_agent.original", c.Request.UserAgent()),
+			))
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(tracing.SpanKey, span)
+
+		spanContext := span.SpanContext()
+		c.Set("Trace-Id", spanContext.TraceID().String())
+		c.Set("Span-Id", spanContext.SpanID().String())
+		if parent.HasSpanID() {
+			c.Set("Parent-Span-Id", parent.SpanID().String())
+		} else {
+			c.Set("Parent-Span-Id", "")
 		}
-		c.Set("Trace-Id", traceId)
-		c.Set("Span-Id", spanId)
-		c.Set("Parent-Span-Id", pSpanId)
 		c.Next()
+
+		// 路由匹配后才知道路径模板, span名称使用模板避免每个路径参数都是不同的名称
+		status := c.Writer.Status()
+		if route := c.FullPath(); route != "" {
+			span.SetName(c.Request.Method + " " + route)
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
 	}
 }
 
-// 自定义ResponseWriter
+// 自定义ResponseWriter, 把响应缓存到body中, 超过limit后不再缓存
 type bodyLogWriter struct {
 	gin.ResponseWriter
-	body *bytes.Buffer
+	body  *bytes.Buffer
+	limit int
 }
 
+// responseLogLimit 响应超过这个大小时不记录到日志, 也不再缓存, 避免SSE等长连接的响应一直占用内存
+// 脱敏需要解析完整的响应, 所以先按这个大小缓存, 脱敏后再按 app.log.max_body_size 截断
+const responseLogLimit = 10 * 1024
+
 // 重写Write方法
 func (w bodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
+	if w.body.Len() <= w.limit {
+		w.body.Write(b)
+	}
 	return w.ResponseWriter.Write(b)
 }
 
-// LoggerMiddleware 记录请求信息和响应信息的日志
+// Unwrap 让http.ResponseController能取到底层的ResponseWriter, 比如SSE连接取消写超时
+func (w bodyLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// LoggerMiddleware 记录请求信息和响应信息的日志, 请求头、请求体和响应体按 app.log 的配置脱敏
 func LoggerMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 记录请求信息日志（前置）
@@ -54,35 +95,36 @@ func LoggerMiddleware() gin.HandlerFunc {
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
 		}
 		start := time.Now()
-		writeAccessLog(c, "access_start", time.Since(start), requestBody, nil)
+		requestLogging := logger.RedactBody(c.ContentType(), requestBody)
+		writeAccessLog(c, "access_start", time.Since(start), requestLogging, nil)
 
 		// 初始化响应记录器
-		blw := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
+		blw := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer, limit: responseLogLimit}
 		c.Writer = blw
 
 		c.Next() //跳出中间件，执行其他中间件以及路由处理函数，最后执行下面的代码
 
 		// 记录响应信息
 		var responseLogging string
-		if c.Writer.Size() > 10*1024 { // 响应大于10KB 不记录
+		if c.Writer.Size() > responseLogLimit { // 响应大于10KB 不记录
 			responseLogging = "Response data size is too Large to log"
 		} else {
-			responseLogging = blw.body.String()
+			responseLogging = logger.RedactBody(blw.Header().Get("Content-Type"), blw.body.Bytes())
 		}
-		writeAccessLog(c, "access_end", time.Since(start), requestBody, responseLogging)
+		writeAccessLog(c, "access_end", time.Since(start), requestLogging, responseLogging)
 	}
 }
 
-func writeAccessLog(c *gin.Context, accessType string, cost time.Duration, body []byte, response interface{}) {
+func writeAccessLog(c *gin.Context, accessType string, cost time.Duration, body string, response interface{}) {
 	req := c.Request
 	logger.NewLogger(c).Info("AccessLog",
 		"type", accessType,
 		"ip", c.ClientIP(),
-		//"token", req.Header.Get("token"),
 		"method", req.Method,
 		"path", req.URL.Path,
-		"query", req.URL.RawQuery,
-		"body", string(body),
+		"query", logger.RedactBody("application/x-www-form-urlencoded", []byte(req.URL.RawQuery)),
+		"header", logger.RedactHeader(req.Header),
+		"body", body,
 		"reply", response,
 		"time(ms)", int64(cost/time.Millisecond),
 	)
@@ -103,7 +145,10 @@ func RecoveryMiddleware() gin.HandlerFunc {
 					}
 				}
 
-				httpRequest, _ := httputil.DumpRequest(c.Request, false)
+				// 只复制请求头用于输出, 不影响后续处理
+				dumpRequest := c.Request.Clone(c.Request.Context())
+				dumpRequest.Header = logger.RedactedHeaderClone(c.Request.Header)
+				httpRequest, _ := httputil.DumpRequest(dumpRequest, false)
 				if brokenPipe {
 					// 若是连接中断导致的错误，只要记录路径、错误信息以及请求信息即可，不需要记录堆栈信息直接终止处理
 					logger.NewLogger(c).Error("http request broken pipe", "path", c.Request.URL.Path, "error", err, "request", string(httpRequest))
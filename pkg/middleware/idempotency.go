@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/Cospk/go-mall/internal/dal/cache"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/idempotency"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed" // 重放的响应带上这个响应头, 方便客户端和排查问题时区分
+	idempotencyKeyMaxLength   = 128
+	idempotencyLockRetryAfter = 1 // 第一个请求还在处理时, 建议客户端等待的秒数
+)
+
+// IdempotencyMiddleware 让带Idempotency-Key请求头的请求只处理一次, 挂在AuthMiddleware之后的写接口上
+// 第一个请求加锁处理并保存状态码和响应体, 之后相同幂等键的请求直接重放保存的响应;
+// 幂等键用于方法、路径或请求体不同的请求时返回ErrIdempotencyKeyMismatch, 第一个请求还在处理时返回ErrIdempotencyKeyInUse。
+// 幂等键按用户隔离, 没有登录的请求按客户端IP隔离。5xx响应和超过大小限制的响应不保存, 客户端重试时重新处理。
+// Redis不可用时不做幂等控制直接处理请求, 重复提交由业务自身的校验兜底
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > idempotencyKeyMaxLength {
+			resp.NewResponse(c).Error(errcode.ErrParams.WithCause(errors.New("Idempotency-Key过长")))
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			resp.NewResponse(c).Error(errcode.ErrParams.WithCause(err))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+
+		storeKey := idempotencyScope(c) + ":" + key
+		fingerprint := requestFingerprint(c.Request, body)
+		store := cache.Idempotency()
+		token, record, err := store.Acquire(c, storeKey, fingerprint)
+		if err != nil {
+			logger.NewLogger(c).Warn("IdempotencyUnavailable", "key", storeKey, "err", err)
+			c.Next()
+			return
+		}
+		if token == "" {
+			replayIdempotentResponse(c, record, fingerprint)
+			return
+		}
+
+		maxResponseSize := config.AppConfig().Idempotency.MaxResponseSize
+		blw := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer, limit: maxResponseSize}
+		c.Writer = blw
+		// 客户端断开后请求的ctx会被取消, 保存响应和释放锁不能因此失败
+		ctx := context.WithoutCancel(c)
+		saved := false
+		defer func() {
+			// 没有保存响应(包括处理过程中panic)时释放幂等键, 让客户端可以重试
+			if !saved {
+				if err := store.Release(ctx, storeKey, token); err != nil {
+					logger.NewLogger(c).Error("IdempotencyReleaseError", "key", storeKey, "err", err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := blw.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		if blw.Size() > maxResponseSize {
+			logger.NewLogger(c).Warn("IdempotencyResponseTooLarge", "key", storeKey, "size", blw.Size())
+			return
+		}
+		err = store.Complete(ctx, storeKey, token, status, blw.Header().Get("Content-Type"), blw.body.Bytes())
+		if err != nil {
+			// ErrLockLost说明处理时间超过了锁的超时时间, 锁已经被其他请求抢占
+			logger.NewLogger(c).Error("IdempotencyCompleteError", "key", storeKey, "err", err)
+			return
+		}
+		saved = true
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, record *idempotency.Record, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		resp.NewResponse(c).Error(errcode.ErrIdempotencyKeyMismatch)
+		c.Abort()
+		return
+	}
+	if !record.Completed() {
+		c.Header("Retry-After", strconv.Itoa(idempotencyLockRetryAfter))
+		resp.NewResponse(c).Error(errcode.ErrIdempotencyKeyInUse)
+		c.Abort()
+		return
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(record.Status, record.ContentType, record.Body)
+	c.Abort()
+}
+
+// idempotencyScope 幂等键的隔离范围, 避免不同用户碰巧使用相同的幂等键时拿到别人的响应
+func idempotencyScope(c *gin.Context) string {
+	if userId := c.GetInt64("userId"); userId > 0 {
+		return "user:" + strconv.FormatInt(userId, 10)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// requestFingerprint 请求指纹, 由方法、路径、查询参数和请求体计算
+func requestFingerprint(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.Path + "?" + req.URL.RawQuery + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/Cospk/go-mall/internal/logic/service"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+)
+
+// MerchantMiddleware 商家鉴权中间件, 需要放在AuthMiddleware之后使用
+// 用户名下有营业中的店铺才能访问商家后台, 店铺ID写入上下文的merchantId, 后续接口只能操作本店铺的数据
+func MerchantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		merchantId, err := service.NewMerchantSvc(c).OwnerMerchantId(c.GetInt64("userId"))
+		if err != nil {
+			var appErr *errcode.AppError
+			switch {
+			case errors.Is(err, errcode.ErrMerchantNotFound):
+				// 没有开通店铺的用户
+				resp.NewResponse(c).Error(errcode.ErrForbid)
+			case errors.As(err, &appErr) && appErr.Code() > 0:
+				// 店铺已被关闭等
+				resp.NewResponse(c).Error(appErr)
+			default:
+				resp.NewResponse(c).Error(errcode.ErrServer.WithCause(err))
+			}
+			c.Abort()
+			return
+		}
+		c.Set("merchantId", merchantId)
+		c.Next()
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"github.com/Cospk/go-mall/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// MetricsMiddleware 按路由和状态码统计接口的请求数和耗时(注意: 放在RecoveryMiddleware前面, 才能统计到panic的请求)
+// 路由取注册时的路径模板, 避免路径参数让指标数量无限增长, 没有匹配到路由的请求记为unmatched
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
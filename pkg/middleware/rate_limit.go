@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"github.com/Cospk/go-mall/internal/dal/cache"
+	"github.com/Cospk/go-mall/pkg/config"
+	"github.com/Cospk/go-mall/pkg/errcode"
+	"github.com/Cospk/go-mall/pkg/ratelimit"
+	"github.com/Cospk/go-mall/pkg/resp"
+	"github.com/gin-gonic/gin"
+	"math"
+	"strconv"
+	"time"
+)
+
+// RateLimitMiddleware 按配置文件 app.rate_limit.rules 中名为ruleName的规则限流
+// 规则每次请求时读取, 修改配置后热加载生效; 限流关闭或规则不存在时直接放行
+// 按user限流时要挂在AuthMiddleware之后才能取到用户ID
+func RateLimitMiddleware(ruleName string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rateLimitConfig := config.AppConfig().RateLimit
+		rule, ok := rateLimitConfig.Rules[ruleName]
+		if !rateLimitConfig.Enabled || !ok {
+			c.Next()
+			return
+		}
+
+		key := ruleName + ":" + rateLimitKey(c, rule.Key)
+		result := cache.RateLimiter().Allow(c, key, ratelimit.Rule{Limit: rule.Limit, Period: rule.Period})
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			resp.NewResponse(c).Error(errcode.ErrTooManyRequests)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitKey 取限流维度的值, 取不到用户ID或API Key不是配置过的Key时按客户端IP限流
+func rateLimitKey(c *gin.Context, dimension string) string {
+	switch dimension {
+	case "user":
+		if userId := c.GetInt64("userId"); userId > 0 {
+			return "user:" + strconv.FormatInt(userId, 10)
+		}
+	case "api_key":
+		if client := apiKeyClient(c.GetHeader("X-Api-Key")); client != "" {
+			return "api_key:" + client
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// apiKeyClient 返回API Key对应的调用方名称, 不是配置过的Key时返回空
+// 限流的key用调用方名称, 随便填的Key不会各自得到一个新的令牌桶, Key本身也不会写进Redis
+func apiKeyClient(apiKey string) string {
+	if apiKey == "" {
+		return ""
+	}
+	for client, key := range config.AppConfig().RateLimit.ApiKeys {
+		if subtle.ConstantTimeCompare([]byte(apiKey), []byte(key)) == 1 {
+			return client
+		}
+	}
+	return ""
+}
+
+// ceilSeconds 响应头中的时间按秒向上取整, 客户端按秒等待后一定能拿到令牌
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,186 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/pkg/logger"
+	"github.com/redis/go-redis/v9"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 基于Redis令牌桶的分布式限流
+//
+//	{name}:{key}  HASH  tokens 桶里剩余的令牌数, ts 上次补充令牌的时间(毫秒)
+//
+// 令牌按 Limit/Period 的速度匀速补充, 桶最多存 Limit 个令牌, 允许短时间内突发 Limit 个请求。
+// 时间取Redis服务器的时间, 多个实例的时钟不一致也不影响限流。
+// Redis出错时切换到进程内的令牌桶继续限流, 冷却时间内不再访问Redis, 避免每个请求都等Redis超时;
+// 本地限流只统计本实例的请求, 多实例部署时实际放行的请求会多于配置的限额。
+
+// allowScript 补充令牌后尝试取一个令牌, 返回 {是否放行, 剩余令牌数, 需要等待的毫秒数, 桶装满需要的毫秒数}
+var allowScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed, retry = 0, 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate) + 1000)
+return {allowed, math.floor(tokens), retry, math.ceil((burst - tokens) / rate)}
+`)
+
+// Rule 限流规则, Period内最多放行Limit个请求
+type Rule struct {
+	Limit  int
+	Period time.Duration
+}
+
+// rate 每毫秒补充的令牌数
+func (r Rule) rate() float64 {
+	return float64(r.Limit) / float64(r.Period.Milliseconds())
+}
+
+// Result 限流结果, 用于设置 X-RateLimit-* 和 Retry-After 响应头
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // 被限流时多久后可以重试
+	ResetAfter time.Duration // 多久后令牌桶恢复到满
+}
+
+type Limiter struct {
+	client redis.UniversalClient
+	name   string
+	config *Config
+	local  *localLimiter
+	// fallbackUntil Redis出错后在这个时间(纳秒)之前只使用本地限流
+	fallbackUntil atomic.Int64
+}
+
+// Config 限流器的配置参数, 通过Option设置
+type Config struct {
+	fallbackCooldown time.Duration // Redis出错后多久内只使用本地限流
+}
+
+type Option func(c *Config)
+
+func WithFallbackCooldown(d time.Duration) Option {
+	return func(c *Config) {
+		if d > 0 {
+			c.fallbackCooldown = d
+		}
+	}
+}
+
+// New 创建限流器, name作为Redis键名前缀
+func New(client redis.UniversalClient, name string, opts ...Option) *Limiter {
+	config := &Config{
+		fallbackCooldown: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return &Limiter{
+		client: client,
+		name:   name,
+		config: config,
+		local:  newLocalLimiter(),
+	}
+}
+
+// Allow 按规则判断key的这次请求是否放行, Limit或Period不大于0时不限流
+func (l *Limiter) Allow(ctx context.Context, key string, rule Rule) *Result {
+	if rule.Limit <= 0 || rule.Period <= 0 {
+		return &Result{Allowed: true}
+	}
+	key = l.name + ":" + key
+	if time.Now().UnixNano() < l.fallbackUntil.Load() {
+		return l.local.allow(key, rule, time.Now())
+	}
+	values, err := allowScript.Run(ctx, l.client, []string{key}, rule.rate(), rule.Limit).Int64Slice()
+	if ctx.Err() != nil {
+		// 请求已经取消, 不是Redis的问题
+		return l.local.allow(key, rule, time.Now())
+	}
+	if err != nil || len(values) != 4 {
+		l.fallbackUntil.Store(time.Now().Add(l.config.fallbackCooldown).UnixNano())
+		logger.NewLogger(ctx).Warn("RateLimitFallbackToLocal", "limiter", l.name, "cooldown", l.config.fallbackCooldown, "err", err)
+		return l.local.allow(key, rule, time.Now())
+	}
+	return &Result{
+		Allowed:    values[0] == 1,
+		Limit:      rule.Limit,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}
+}
+
+// localLimiter 进程内的令牌桶, 算法和Redis脚本一致
+type localLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	ts     time.Time
+	period time.Duration
+}
+
+// sweepInterval 多久清理一次已经装满的令牌桶, 装满的桶和不存在的桶效果一样
+const sweepInterval = time.Minute
+
+func newLocalLimiter() *localLimiter {
+	return &localLimiter{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+// allow 按now补充令牌后尝试取一个令牌
+func (l *localLimiter) allow(key string, rule Rule, now time.Time) *Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) > sweepInterval {
+		l.sweep(now)
+	}
+	rate, burst := rule.rate(), float64(rule.Limit)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, ts: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+float64(now.Sub(b.ts))/float64(time.Millisecond)*rate)
+	b.ts, b.period = now, rule.Period
+	result := &Result{Limit: rule.Limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1-b.tokens)/rate)) * time.Millisecond
+	}
+	result.Remaining = int(b.tokens)
+	result.ResetAfter = time.Duration(math.Ceil((burst-b.tokens)/rate)) * time.Millisecond
+	return result
+}
+
+func (l *localLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.ts) > b.period {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLocalLimiterAllow(t *testing.T) {
+	type step struct {
+		at            time.Duration // 距离第一次请求的时间
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}
+	tests := []struct {
+		name  string
+		rule  Rule
+		steps []step
+	}{
+		{
+			name: "令牌用完后限流, 按速度补充",
+			rule: Rule{Limit: 1, Period: 1024 * time.Millisecond},
+			steps: []step{
+				{at: 0, wantAllowed: true},
+				{at: 0, wantRetry: 1024 * time.Millisecond},
+				{at: 512 * time.Millisecond, wantRetry: 512 * time.Millisecond},
+				{at: 1024 * time.Millisecond, wantAllowed: true},
+			},
+		},
+		{
+			name: "不足1毫秒的间隔也补充令牌",
+			rule: Rule{Limit: 1, Period: time.Millisecond},
+			steps: []step{
+				{at: 0, wantAllowed: true},
+				{at: 500 * time.Microsecond, wantRetry: time.Millisecond},
+				{at: time.Millisecond, wantAllowed: true},
+			},
+		},
+		{
+			name: "桶里最多存Limit个令牌",
+			rule: Rule{Limit: 2, Period: 1024 * time.Millisecond},
+			steps: []step{
+				{at: 0, wantAllowed: true, wantRemaining: 1},
+				{at: 10 * time.Second, wantAllowed: true, wantRemaining: 1},
+				{at: 10 * time.Second, wantAllowed: true},
+				{at: 10 * time.Second, wantRetry: 512 * time.Millisecond},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newLocalLimiter()
+			start := time.Now()
+			for i, s := range tt.steps {
+				result := limiter.allow("user:1", tt.rule, start.Add(s.at))
+				if result.Allowed != s.wantAllowed || result.Remaining != s.wantRemaining || result.RetryAfter != s.wantRetry {
+					t.Errorf("step %d: allowed, remaining, retry = %v, %d, %v, want %v, %d, %v",
+						i, result.Allowed, result.Remaining, result.RetryAfter, s.wantAllowed, s.wantRemaining, s.wantRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalLimiterKeys(t *testing.T) {
+	limiter := newLocalLimiter()
+	rule := Rule{Limit: 1, Period: time.Second}
+	now := time.Now()
+	if !limiter.allow("user:1", rule, now).Allowed {
+		t.Fatal("first request of user:1 should be allowed")
+	}
+	if limiter.allow("user:1", rule, now).Allowed {
+		t.Fatal("second request of user:1 should be limited")
+	}
+	if !limiter.allow("user:2", rule, now).Allowed {
+		t.Fatal("user:2 should not share the bucket of user:1")
+	}
+}
+
+func TestLimiterAllowWithoutRule(t *testing.T) {
+	limiter := New(nil, "test")
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{"没有配置限额", Rule{Period: time.Second}},
+		{"没有配置周期", Rule{Limit: 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !limiter.Allow(context.Background(), "user:1", tt.rule).Allowed {
+				t.Errorf("Allow() should not limit without a valid rule")
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/Cospk/go-mall/pkg/config"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
+	"os"
+	"sync"
+)
+
+// fileClient 把OTLP数据按JSON格式写到文件或标准输出, 每次导出的一批span写一行
+// 实现otlptrace.Client, span到OTLP格式的转换由otlptrace完成
+type fileClient struct {
+	mu     sync.Mutex
+	writer io.Writer
+	closer io.Closer
+}
+
+// newFileClient exporter为none时返回nil, 不导出链路数据
+func newFileClient(exporter, filePath string) *fileClient {
+	switch exporter {
+	case "stdout":
+		return &fileClient{writer: os.Stdout}
+	case "file":
+		// 和日志一样按大小切割, 避免链路文件无限增长
+		writer := &lumberjack.Logger{
+			Filename: filePath,
+			MaxSize:  config.AppConfig().Log.FileMaxSize,
+			MaxAge:   config.AppConfig().Log.BackUpFileMaxAge,
+			Compress: false,
+		}
+		return &fileClient{writer: writer, closer: writer}
+	default:
+		return nil
+	}
+}
+
+func (c *fileClient) Start(ctx context.Context) error {
+	return nil
+}
+
+func (c *fileClient) Stop(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closer != nil {
+		return c.closer.Close()
+	}
+	return nil
+}
+
+func (c *fileClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	data, err := marshalOTLP(&collectortrace.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.writer.Write(append(data, '\n'))
+	return err
+}
+
+// idFields OTLP JSON中按16进制输出的ID字段
+var idFields = map[string]struct{}{"traceId": {}, "spanId": {}, "parentSpanId": {}}
+
+// marshalOTLP 按OTLP JSON格式编码: 枚举输出为数字, traceId、spanId输出为16进制而不是protojson默认的base64
+func marshalOTLP(request *collectortrace.ExportTraceServiceRequest) ([]byte, error) {
+	data, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err = json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	hexIds(value)
+	return json.Marshal(value)
+}
+
+func hexIds(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if encoded, ok := field.(string); ok {
+				if _, isId := idFields[key]; isId {
+					if id, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+						v[key] = hex.EncodeToString(id)
+					}
+				}
+				continue
+			}
+			hexIds(field)
+		}
+	case []interface{}:
+		for _, item := range v {
+			hexIds(item)
+		}
+	}
+}
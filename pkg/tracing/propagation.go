@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+const (
+	legacyTraceHeader = "Trace-Id"
+	legacySpanHeader  = "Span-Id"
+)
+
+// legacyPropagator 兼容旧的Trace-Id/Span-Id请求头
+// 旧的ID是不定长的16进制串, 左侧补0转成W3C要求的长度, 两个请求头都有效时才作为上级span; 同时有traceparent时以traceparent为准
+type legacyPropagator struct{}
+
+func (legacyPropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return
+	}
+	carrier.Set(legacyTraceHeader, spanContext.TraceID().String())
+	carrier.Set(legacySpanHeader, spanContext.SpanID().String())
+}
+
+func (legacyPropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	traceId, err := trace.TraceIDFromHex(padHex(carrier.Get(legacyTraceHeader), 32))
+	if err != nil {
+		return ctx
+	}
+	spanId, err := trace.SpanIDFromHex(padHex(carrier.Get(legacySpanHeader), 16))
+	if err != nil {
+		return ctx
+	}
+	// 旧请求头没有采样标记, 由本服务的采样比例决定是否采样
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceId,
+		SpanID:  spanId,
+		Remote:  true,
+	}))
+}
+
+func (legacyPropagator) Fields() []string {
+	return []string{legacyTraceHeader, legacySpanHeader}
+}
+
+func padHex(value string, length int) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || len(value) > length {
+		return value
+	}
+	return strings.Repeat("0", length-len(value)) + value
+}
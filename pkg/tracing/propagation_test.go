@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+func TestPadHex(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		length int
+		want   string
+	}{
+		{"左侧补0", "abc", 8, "00000abc"},
+		{"长度正好", "0123456789abcdef", 16, "0123456789abcdef"},
+		{"转成小写并去掉空白", " ABC ", 4, "0abc"},
+		{"空值不补", "", 16, ""},
+		{"超长的原样返回", "123456789", 8, "123456789"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := padHex(tt.value, tt.length); got != tt.want {
+				t.Errorf("padHex(%q, %d) = %q, want %q", tt.value, tt.length, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLegacyPropagatorExtract(t *testing.T) {
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		wantValid   bool
+		wantTraceId string
+		wantSpanId  string
+	}{
+		{
+			name:        "不定长的旧ID",
+			headers:     map[string]string{legacyTraceHeader: "1a2b3c", legacySpanHeader: "ff"},
+			wantValid:   true,
+			wantTraceId: "000000000000000000000000001a2b3c",
+			wantSpanId:  "00000000000000ff",
+		},
+		{
+			name:      "只有Trace-Id",
+			headers:   map[string]string{legacyTraceHeader: "1a2b3c"},
+			wantValid: false,
+		},
+		{
+			name:      "不是16进制",
+			headers:   map[string]string{legacyTraceHeader: "xyz", legacySpanHeader: "ff"},
+			wantValid: false,
+		},
+		{
+			name:      "ID超长",
+			headers:   map[string]string{legacyTraceHeader: "1a2b3c", legacySpanHeader: "0123456789abcdef0"},
+			wantValid: false,
+		},
+		{
+			name:      "全0的ID无效",
+			headers:   map[string]string{legacyTraceHeader: "0", legacySpanHeader: "1"},
+			wantValid: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := legacyPropagator{}.Extract(context.Background(), propagation.MapCarrier(tt.headers))
+			spanContext := trace.SpanContextFromContext(ctx)
+			if spanContext.IsValid() != tt.wantValid {
+				t.Fatalf("span context valid = %v, want %v", spanContext.IsValid(), tt.wantValid)
+			}
+			if !tt.wantValid {
+				return
+			}
+			if got := spanContext.TraceID().String(); got != tt.wantTraceId {
+				t.Errorf("trace id = %s, want %s", got, tt.wantTraceId)
+			}
+			if got := spanContext.SpanID().String(); got != tt.wantSpanId {
+				t.Errorf("span id = %s, want %s", got, tt.wantSpanId)
+			}
+			if !spanContext.IsRemote() {
+				t.Errorf("span context should be remote")
+			}
+		})
+	}
+}
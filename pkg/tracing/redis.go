@@ -0,0 +1,62 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook 为处于请求链路中的Redis命令创建span, 通过client.AddHook添加
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := StartChild(ctx, "redis "+cmd.Name(), redisSpanOptions(cmd.Name())...)
+		if !span.IsRecording() {
+			return next(ctx, cmd)
+		}
+		defer span.End()
+		err := next(ctx, cmd)
+		recordRedisError(span, err)
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := StartChild(ctx, "redis pipeline", redisSpanOptions("pipeline")...)
+		if !span.IsRecording() {
+			return next(ctx, cmds)
+		}
+		defer span.End()
+		span.SetAttributes(attribute.Int("db.redis.num_cmd", len(cmds)))
+		err := next(ctx, cmds)
+		recordRedisError(span, err)
+		return err
+	}
+}
+
+func redisSpanOptions(operation string) []trace.SpanStartOption {
+	return []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", operation),
+		),
+	}
+}
+
+// recordRedisError 键不存在(redis.Nil)不算失败
+func recordRedisError(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
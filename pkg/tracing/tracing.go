@@ -0,0 +1,105 @@
+package tracing
+
+import (
+	"context"
+	"github.com/Cospk/go-mall/pkg/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"log"
+)
+
+// 基于OpenTelemetry的链路追踪
+//
+//	服务端 TraceMiddleware 为每个请求创建span, 上游通过W3C traceparent或旧的Trace-Id/Span-Id请求头传入链路
+//	数据库 GormLogger.Trace 按SQL的开始时间补记span
+//	Redis  RedisHook 记录每条命令
+//	外部调用 httptool.Request 创建span并通过traceparent和旧请求头传给下游
+//
+// 链路数据按OTLP JSON格式写到文件或标准输出, 每批一行, 可以直接用OpenTelemetry Collector的otlpjsonfile接收器导入。
+// 导出方式为none时仍然生成链路ID和SpanID, 日志里的traceid照常可用
+
+// SpanKey span在gin.Context中的键名
+// 没有开启ContextWithFallback时gin.Context取不到Request.Context里的span, 中间件把span同时放在Keys里
+const SpanKey = "Otel-Span"
+
+const instrumentationName = "github.com/Cospk/go-mall"
+
+var (
+	provider *sdktrace.TracerProvider
+	tracer   = otel.Tracer(instrumentationName)
+)
+
+// InitTracer 按配置创建TracerProvider, 需要在InitLogger之后调用
+func InitTracer() {
+	traceConfig := config.AppConfig().Trace
+	ratioSampler := sdktrace.TraceIDRatioBased(traceConfig.SampleRatio)
+	opts := []sdktrace.TracerProviderOption{
+		// 上游已经采样的链路跟随上游; 上游没有采样标记(比如旧请求头)时按本服务的比例采样
+		sdktrace.WithSampler(sdktrace.ParentBased(ratioSampler,
+			sdktrace.WithRemoteParentNotSampled(ratioSampler),
+		)),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", config.AppConfig().Name),
+			attribute.String("deployment.environment", config.AppConfig().Env),
+		)),
+	}
+	if client := newFileClient(traceConfig.Exporter, traceConfig.FilePath); client != nil {
+		exporter, err := otlptrace.New(context.Background(), client)
+		if err != nil {
+			log.Fatalf("初始化链路导出失败: %v", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	provider = sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(legacyPropagator{}, propagation.TraceContext{}))
+	tracer = provider.Tracer(instrumentationName)
+}
+
+// Shutdown 导出缓冲中的span并关闭导出, 服务退出时在请求和后台任务结束后调用
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
+
+// Start 创建span, ctx是gin.Context时从Keys中取上级span
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return tracer.Start(parentContext(ctx), name, opts...)
+}
+
+// StartChild 只在ctx已经处于一条链路中时创建span, 否则返回不记录的span
+// 后台轮询这类没有上级span的数据库、Redis操作不单独生成链路, 避免产生大量无意义的链路
+func StartChild(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	ctx = parentContext(ctx)
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return tracer.Start(ctx, name, opts...)
+}
+
+// Extract 从请求头中解析上游传入的链路, 优先使用traceparent
+func Extract(ctx context.Context, header propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, header)
+}
+
+// Inject 把ctx中的链路写入请求头, 同时写traceparent和旧的Trace-Id/Span-Id, 兼容还没有升级的下游服务
+func Inject(ctx context.Context, header propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(parentContext(ctx), header)
+}
+
+func parentContext(ctx context.Context) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	if span, ok := ctx.Value(SpanKey).(trace.Span); ok {
+		return trace.ContextWithSpan(ctx, span)
+	}
+	return ctx
+}
@@ -7,7 +7,8 @@ import (
 	"time"
 )
 
-func CopyStruct(src, dest interface{}) error {
+// CopyStruct 把src的字段复制到dest, 调用方式同copier: 目标在前, 来源在后
+func CopyStruct(dest, src interface{}) error {
 	err := copier.CopyWithOption(dest, src, copier.Option{
 		IgnoreEmpty: true,
 		DeepCopy:    true,
@@ -5,7 +5,12 @@ import (
 	"context"
 	"github.com/Cospk/go-mall/pkg/errcode"
 	"github.com/Cospk/go-mall/pkg/logger"
-	"github.com/Cospk/go-mall/pkg/utils"
+	"github.com/Cospk/go-mall/pkg/metrics"
+	"github.com/Cospk/go-mall/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"io/ioutil"
 	"net/http"
 	"time"
@@ -21,23 +26,33 @@ func Request(method string, url string, options ...Option) (httpStatusCode int,
 			return
 		}
 	}
-	log := logger.NewLogger(reqOpts.ctx) //创建日志记录器
+	// 外部调用作为当前链路的子span, 下游服务通过请求头接上这条链路
+	ctx, span := tracing.Start(reqOpts.ctx, "HTTP "+method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.request.method", method)))
+	defer span.End()
+	log := logger.NewLogger(ctx) //创建日志记录器
 	defer func() {
 		// 结束部分
+		if httpStatusCode > 0 {
+			span.SetAttributes(attribute.Int("http.response.status_code", httpStatusCode))
+		}
 		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
 			log.Error("HTTP请求失败日志", "method", method, "url", url, "body", reqOpts.data, "reply", respBody, "err", err)
 		}
 	}()
 
 	// 创建请求对象
-	req, err := http.NewRequestWithContext(reqOpts.ctx, method, url, bytes.NewReader(reqOpts.data))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(reqOpts.data))
 	if err != nil {
 		return
 	}
 	defer req.Body.Close()
+	span.SetAttributes(attribute.String("server.address", req.URL.Host), attribute.String("url.path", req.URL.Path))
 
 	// 在header添加追踪信息，把内部服务的日志关联起来
-	setTraceHeaders(req, reqOpts.ctx)
+	setTraceHeaders(req, ctx)
 	for k, v := range reqOpts.headers {
 		req.Header.Set(k, v)
 	}
@@ -46,8 +61,10 @@ func Request(method string, url string, options ...Option) (httpStatusCode int,
 	client := &http.Client{Timeout: reqOpts.timeout}
 	resp, err := client.Do(req)
 	if err != nil {
+		metrics.ObserveClientRequest(req.URL.Host, method, 0, time.Since(start))
 		return
 	}
+	metrics.ObserveClientRequest(req.URL.Host, method, resp.StatusCode, time.Since(start))
 	defer resp.Body.Close()
 
 	dur := time.Since(start).Milliseconds()
@@ -69,10 +86,9 @@ func Request(method string, url string, options ...Option) (httpStatusCode int,
 	return
 }
 
+// setTraceHeaders 写入W3C traceparent, 同时保留旧的Trace-Id/Span-Id请求头
 func setTraceHeaders(req *http.Request, ctx context.Context) {
-	traceId, spanId, _ := utils.GetTraceInfoFromCtx(ctx)
-	req.Header.Set("Trace-Id", traceId)
-	req.Header.Set("Span-Id", spanId)
+	tracing.Inject(ctx, propagation.HeaderCarrier(req.Header))
 }
 
 // Get 发起GET请求
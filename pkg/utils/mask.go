@@ -65,3 +65,15 @@ func MaskRealName(realName string) string {
 	}
 	return realName
 }
+
+// MaskNickname 昵称只保留首尾字符, 如 张三丰 ---> 张***丰, 空昵称返回"匿名用户"
+func MaskNickname(nickname string) string {
+	runes := []rune(nickname)
+	if len(runes) == 0 {
+		return "匿名用户"
+	}
+	if len(runes) == 1 {
+		return string(runes) + "***"
+	}
+	return string(runes[0]) + "***" + string(runes[len(runes)-1])
+}
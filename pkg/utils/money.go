@@ -0,0 +1,43 @@
+package utils
+
+import "sort"
+
+// AllocateByWeight 把total(分)按weights的比例分摊, 分摊结果之和严格等于total
+// 先按比例向下取整, 剩下的零头按小数部分从大到小逐分补齐, 小数部分相同时补给靠前的, 保证结果确定
+func AllocateByWeight(total int64, weights []int64) []int64 {
+	shares := make([]int64, len(weights))
+	var weightSum int64
+	for _, weight := range weights {
+		weightSum += weight
+	}
+	if total == 0 || weightSum == 0 {
+		return shares
+	}
+	remainders := make([]int64, len(weights))
+	var allocated int64
+	for i, weight := range weights {
+		shares[i] = total * weight / weightSum
+		remainders[i] = total * weight % weightSum
+		allocated += shares[i]
+	}
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for i := 0; allocated < total; i++ {
+		shares[order[i%len(order)]]++
+		allocated++
+	}
+	return shares
+}
+
+// RoundDiv 非负整数除法, 结果四舍五入, 用于金额按比例计算时统一取整规则
+func RoundDiv(dividend, divisor int64) int64 {
+	if divisor == 0 {
+		return 0
+	}
+	return (dividend*2 + divisor) / (divisor * 2)
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAllocateByWeight(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []int64
+		want    []int64
+	}{
+		{"金额为0", 0, []int64{1, 2}, []int64{0, 0}},
+		{"权重都为0", 100, []int64{0, 0}, []int64{0, 0}},
+		{"没有权重", 100, []int64{}, []int64{}},
+		{"整除", 500, []int64{6000, 4000}, []int64{300, 200}},
+		{"零头补给小数部分大的", 10, []int64{1, 2}, []int64{3, 7}},
+		{"小数部分相同时补给靠前的", 100, []int64{1, 1, 1}, []int64{34, 33, 33}},
+		{"权重为0的不分摊", 5, []int64{0, 3, 7}, []int64{0, 2, 3}},
+		{"金额小于份数", 2, []int64{1, 1, 1}, []int64{1, 1, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AllocateByWeight(tt.total, tt.weights)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AllocateByWeight(%d, %v) = %v, want %v", tt.total, tt.weights, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundDiv(t *testing.T) {
+	tests := []struct {
+		name     string
+		dividend int64
+		divisor  int64
+		want     int64
+	}{
+		{"整除", 9, 3, 3},
+		{"小于一半舍去", 7, 3, 2},
+		{"大于一半进位", 8, 3, 3},
+		{"正好一半进位", 5, 2, 3},
+		{"被除数为0", 0, 5, 0},
+		{"除数为0", 5, 0, 0},
+		{"结果小于1", 1, 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundDiv(tt.dividend, tt.divisor); got != tt.want {
+				t.Errorf("RoundDiv(%d, %d) = %d, want %d", tt.dividend, tt.divisor, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	cryptorand "crypto/rand"
+	"math/big"
 	"math/rand"
 	"time"
 )
@@ -18,6 +20,8 @@ const (
 	Alphabetic   Charset = "abcdefghijklmnopqrstuvwxyz" + "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	Numeric      Charset = "0123456789"
 	Hex          Charset = Numeric + "abcdef"
+	// Readable 去掉了容易混淆的 0/O、1/I/L, 用于需要用户手工输入的卡密
+	Readable Charset = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
 )
 
 var (
@@ -58,3 +62,17 @@ func RandNumStr(len uint8) string {
 }
 
 var rnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// SecureString 用crypto/rand生成随机字符串, 用于卡密等不能被猜测的场景
+func SecureString(length uint8, charset Charset) string {
+	b := make([]byte, length)
+	max := big.NewInt(int64(len(charset)))
+	for i := range b {
+		n, err := cryptorand.Int(cryptorand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b[i] = charset[n.Int64()]
+	}
+	return string(b)
+}
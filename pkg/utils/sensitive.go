@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SensitiveFilter 基于前缀树的敏感词过滤器, 匹配时忽略大小写, 也能识别中间夹了空格、标点的敏感词, 如"加 微-信"
+// 构建后只读, 可以在多个协程中并发使用
+type SensitiveFilter struct {
+	root *sensitiveNode
+}
+
+type sensitiveNode struct {
+	children map[rune]*sensitiveNode
+	end      bool // 是否是一个敏感词的结尾
+}
+
+func NewSensitiveFilter(words []string) *SensitiveFilter {
+	filter := &SensitiveFilter{root: &sensitiveNode{children: make(map[rune]*sensitiveNode)}}
+	for _, word := range words {
+		node := filter.root
+		for _, r := range strings.ToLower(word) {
+			if isSensitiveNoise(r) {
+				continue
+			}
+			child, ok := node.children[r]
+			if !ok {
+				child = &sensitiveNode{children: make(map[rune]*sensitiveNode)}
+				node.children[r] = child
+			}
+			node = child
+		}
+		if node != filter.root {
+			node.end = true
+		}
+	}
+	return filter
+}
+
+// Replace 把文本中的敏感词(包括夹在中间的空格、标点)替换成*, 返回替换后的文本和是否命中了敏感词
+// 同一位置有多个敏感词时按最长的匹配替换
+func (f *SensitiveFilter) Replace(text string) (string, bool) {
+	runes := []rune(text)
+	found := false
+	for i := 0; i < len(runes); {
+		node, matchEnd := f.root, -1
+		for j := i; j < len(runes); j++ {
+			r := unicode.ToLower(runes[j])
+			if j > i && isSensitiveNoise(r) {
+				continue
+			}
+			child, ok := node.children[r]
+			if !ok {
+				break
+			}
+			node = child
+			if node.end {
+				matchEnd = j
+			}
+		}
+		if matchEnd < 0 {
+			i++
+			continue
+		}
+		for k := i; k <= matchEnd; k++ {
+			runes[k] = '*'
+		}
+		found = true
+		i = matchEnd + 1
+	}
+	if !found {
+		return text, false
+	}
+	return string(runes), true
+}
+
+// isSensitiveNoise 匹配敏感词时跳过的干扰字符
+func isSensitiveNoise(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// GenOrderNo 生成订单号: 14位时间 + 用户ID后4位 + 6位随机数
+// 带上用户ID是为了后期按用户分库分表时能从订单号直接定位到库表
+func GenOrderNo(userId int64) string {
+	return fmt.Sprintf("%s%04d%s", time.Now().Format("20060102150405"), userId%10000, RandNumStr(6))
+}
+
+// GenParentOrderNo 生成父订单号, 规则同订单号, 加M前缀区分
+func GenParentOrderNo(userId int64) string {
+	return "M" + GenOrderNo(userId)
+}
+
+// GenPaymentNo 生成支付单号, 规则同订单号, 加P前缀区分
+func GenPaymentNo(userId int64) string {
+	return "P" + GenOrderNo(userId)
+}
+
+// GenAfterSaleNo 生成售后单号, 规则同订单号, 加R前缀区分, 售后单号同时作为向支付渠道退款的退款单号
+func GenAfterSaleNo(userId int64) string {
+	return "R" + GenOrderNo(userId)
+}
+
+// GenSeckillTicket 生成抢购凭证, 规则同订单号, 加S前缀区分
+func GenSeckillTicket(userId int64) string {
+	return "S" + GenOrderNo(userId)
+}
+
+// GenLedgerTxNo 生成记账交易号, 规则同订单号, 加L前缀区分
+func GenLedgerTxNo(userId int64) string {
+	return "L" + GenOrderNo(userId)
+}
+
+// GenGiftCardNo 生成礼品卡号: G + 14位时间 + 8位随机数, 礼品卡按批次发行, 随机位比订单号长以减少同批次内的重复
+func GenGiftCardNo() string {
+	return "G" + time.Now().Format("20060102150405") + RandNumStr(8)
+}
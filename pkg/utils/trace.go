@@ -7,7 +7,6 @@ import (
 	"math/rand"
 	"net"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 )
@@ -24,11 +23,14 @@ var (
 )
 
 // GenerateSpanId 生成spanId，思路：ip（空间唯一）与时间戳（时间唯一）异或运算+随机数
+// 请求链路的spanId已经改由OpenTelemetry生成, 这里保留给需要独立ID的场景; addr支持IPv4和IPv6, 可以带端口
 func GenerateSpanId(addr string) string {
-	strAddr := strings.Split(addr, ":")
-	ip := strAddr[0]
-	// 获取IP数值
-	ipVal, _ := ipv4ToUint32(ip)
+	ip := addr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		ip = host
+	}
+	// 获取IP数值, IPv6取前后8字节异或
+	ipVal, _ := ipToUint64(ip)
 
 	//获取时间戳
 	times := uint64(time.Now().UnixNano())
@@ -39,7 +41,7 @@ func GenerateSpanId(addr string) string {
 	randLock.Unlock()
 
 	// 组合成spanId (将ip和时间戳进行异或运算，既保证唯一性又保证ip信息安全。然后左移保留低32位后与随机数进行或运算)
-	spanId := ((times ^ uint64(ipVal)) << 32) | random
+	spanId := ((times ^ ipVal) << 32) | random
 
 	return strconv.FormatUint(spanId, 16)
 }
@@ -59,14 +61,6 @@ func ipToUint64(ipStr string) (uint64, error) {
 	return lower64 ^ upper64, nil
 }
 
-func ipv4ToUint32(ip string) (uint32, error) {
-	ipAddr, err := net.ResolveIPAddr("ip", ip)
-	if err != nil {
-		return 0, err
-	}
-	return binary.BigEndian.Uint32(ipAddr.IP.To4()), nil
-}
-
 // GetTraceInfoFromCtx 从ctx中获取trace信息
 func GetTraceInfoFromCtx(ctx context.Context) (traceId, spanId, pSpanId string) {
 	if ctx.Value("Trace-Id") != nil {